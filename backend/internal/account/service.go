@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log"
 	"mime/multipart"
+	"strings"
 	"time"
 
+	"upvista-community-backend/internal/mailer"
 	"upvista-community-backend/internal/models"
 	"upvista-community-backend/internal/repository"
 	"upvista-community-backend/internal/utils"
@@ -15,12 +17,24 @@ import (
 	"github.com/google/uuid"
 )
 
+// TwoFactorVerifier verifies a user's second factor (TOTP or recovery code)
+type TwoFactorVerifier interface {
+	VerifySecondFactor(ctx context.Context, user *models.User, code string) error
+}
+
+// SessionRevoker immediately invalidates the access tokens of a session
+type SessionRevoker interface {
+	RevokeSession(sessionID uuid.UUID)
+}
+
 // AccountService handles account management business logic
 type AccountService struct {
-	userRepo    repository.UserRepository
-	sessionRepo repository.SessionRepository
-	emailSvc    *utils.EmailService
-	storageSvc  *utils.StorageService
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+	emailSvc       *utils.EmailService
+	storageSvc     *utils.StorageService
+	twoFactor      TwoFactorVerifier // Optional: enforces 2FA on sensitive actions
+	sessionRevoker SessionRevoker    // Optional: kills access tokens of deleted sessions
 }
 
 // NewAccountService creates a new account service
@@ -33,6 +47,39 @@ func NewAccountService(userRepo repository.UserRepository, sessionRepo repositor
 	}
 }
 
+// SetTwoFactorVerifier sets the verifier used to enforce 2FA on sensitive account actions
+func (s *AccountService) SetTwoFactorVerifier(verifier TwoFactorVerifier) {
+	s.twoFactor = verifier
+}
+
+// SetSessionRevoker sets the revoker used to invalidate access tokens when sessions are deleted
+func (s *AccountService) SetSessionRevoker(revoker SessionRevoker) {
+	s.sessionRevoker = revoker
+}
+
+// revokeSessions invalidates the access tokens of deleted sessions
+// Without a revoker they still stop working once they expire, since the refresh token is gone
+func (s *AccountService) revokeSessions(sessionIDs ...uuid.UUID) {
+	if s.sessionRevoker == nil {
+		return
+	}
+	for _, id := range sessionIDs {
+		s.sessionRevoker.RevokeSession(id)
+	}
+}
+
+// verifySecondFactor requires a valid 2FA code for users who have 2FA enabled
+func (s *AccountService) verifySecondFactor(ctx context.Context, user *models.User, code string) error {
+	if !user.TwoFactorEnabled {
+		return nil
+	}
+	if s.twoFactor == nil {
+		// Fail closed: never skip 2FA because the verifier wasn't wired up
+		return errors.ErrTwoFactorRequired
+	}
+	return s.twoFactor.VerifySecondFactor(ctx, user, code)
+}
+
 // GetProfile retrieves the user's profile
 func (s *AccountService) GetProfile(ctx context.Context, userID uuid.UUID) (*models.User, error) {
 	user, err := s.userRepo.GetUserByID(ctx, userID)
@@ -70,6 +117,10 @@ func (s *AccountService) UpdateProfile(ctx context.Context, userID uuid.UUID, re
 		updates["profile_picture"] = *req.ProfilePicture
 	}
 
+	if req.Language != nil {
+		updates["language"] = *req.Language
+	}
+
 	// If no fields to update, return current user
 	if len(updates) == 0 {
 		return user.ToSafeUser(), nil
@@ -112,6 +163,11 @@ func (s *AccountService) ChangePassword(ctx context.Context, userID uuid.UUID, r
 		return errors.NewAppError(401, "Current password is incorrect")
 	}
 
+	// Require second factor if enabled
+	if err := s.verifySecondFactor(ctx, user, req.TwoFactorCode); err != nil {
+		return err
+	}
+
 	// Check new password matches confirmation
 	if req.NewPassword != req.ConfirmPassword {
 		return errors.NewAppError(400, "New password and confirmation do not match")
@@ -134,7 +190,7 @@ func (s *AccountService) ChangePassword(ctx context.Context, userID uuid.UUID, r
 	}
 
 	// Send notification email asynchronously
-	go s.emailSvc.SendPasswordChangedEmail(user.Email, user.DisplayName)
+	go s.emailSvc.SendPasswordChangedEmail(user.Email, user.DisplayName, user.Language)
 
 	return nil
 }
@@ -159,13 +215,18 @@ func (s *AccountService) DeleteAccount(ctx context.Context, userID uuid.UUID, re
 		}
 	}
 
+	// Require second factor if enabled
+	if err := s.verifySecondFactor(ctx, user, req.TwoFactorCode); err != nil {
+		return err
+	}
+
 	// Verify confirmation text
 	if req.Confirmation != "DELETE MY ACCOUNT" {
 		return errors.NewAppError(400, "Confirmation text must be exactly 'DELETE MY ACCOUNT'")
 	}
 
 	// Send notification email before deletion
-	go s.emailSvc.SendAccountDeletedEmail(user.Email, user.DisplayName)
+	go s.emailSvc.SendAccountDeletedEmail(user.Email, user.DisplayName, user.Language)
 
 	// Delete the user
 	if err := s.userRepo.DeleteUser(ctx, userID); err != nil {
@@ -195,6 +256,11 @@ func (s *AccountService) ChangeEmail(ctx context.Context, userID uuid.UUID, req
 		}
 	}
 
+	// Require second factor if enabled
+	if err := s.verifySecondFactor(ctx, user, req.TwoFactorCode); err != nil {
+		return err
+	}
+
 	// Normalize new email
 	newEmail := utils.NormalizeEmail(req.NewEmail)
 
@@ -222,10 +288,10 @@ func (s *AccountService) ChangeEmail(ctx context.Context, userID uuid.UUID, req
 	}
 
 	// Send verification email to NEW email address
-	go s.emailSvc.SendEmailChangeVerificationEmail(newEmail, code)
+	go s.emailSvc.SendEmailChangeVerificationEmail(newEmail, code, user.Language)
 
 	// Send notification to OLD email address (security best practice)
-	go s.emailSvc.SendEmailChangeNotificationToOldEmail(user.Email, user.DisplayName, newEmail)
+	go s.emailSvc.SendEmailChangeNotificationToOldEmail(user.Email, user.DisplayName, newEmail, user.Language)
 
 	return nil
 }
@@ -299,7 +365,7 @@ func (s *AccountService) ChangeUsername(ctx context.Context, userID uuid.UUID, r
 	}
 
 	// Send notification email
-	go s.emailSvc.SendUsernameChangedEmail(user.Email, user.DisplayName, oldUsername, newUsername)
+	go s.emailSvc.SendUsernameChangedEmail(user.Email, user.DisplayName, oldUsername, newUsername, user.Language)
 
 	return nil
 }
@@ -371,16 +437,34 @@ func (s *AccountService) DeleteSession(ctx context.Context, userID, sessionID uu
 		return err
 	}
 
+	// Log that device out immediately rather than when its access token expires
+	s.revokeSessions(sessionID)
+
 	return nil
 }
 
 // LogoutAllDevices logs out from all devices except current (optional)
 func (s *AccountService) LogoutAllDevices(ctx context.Context, userID uuid.UUID, currentSessionID *uuid.UUID) error {
+	// Collect the sessions first so their access tokens can be revoked
+	sessions, err := s.sessionRepo.GetSessionsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	// Delete all sessions for the user, optionally except the current one
 	if err := s.sessionRepo.DeleteAllUserSessions(ctx, userID, currentSessionID); err != nil {
 		return err
 	}
 
+	revoked := make([]uuid.UUID, 0, len(sessions))
+	for _, session := range sessions {
+		if currentSessionID != nil && session.ID == *currentSessionID {
+			continue
+		}
+		revoked = append(revoked, session.ID)
+	}
+	s.revokeSessions(revoked...)
+
 	return nil
 }
 
@@ -388,7 +472,7 @@ func (s *AccountService) LogoutAllDevices(ctx context.Context, userID uuid.UUID,
 
 func validateUpdateProfile(req *models.UpdateProfileRequest) error {
 	// At least one field must be provided
-	if req.DisplayName == nil && req.Age == nil && req.ProfilePicture == nil {
+	if req.DisplayName == nil && req.Age == nil && req.ProfilePicture == nil && req.Language == nil {
 		return errors.NewAppError(400, "At least one field must be provided for update")
 	}
 
@@ -410,6 +494,11 @@ func validateUpdateProfile(req *models.UpdateProfileRequest) error {
 
 	// Profile picture validation (URL format) is handled by validator tag
 
+	// Validate language
+	if req.Language != nil && !mailer.IsSupportedLanguage(*req.Language) {
+		return errors.NewAppError(400, "Language must be one of: "+strings.Join(mailer.SupportedLanguages, ", "))
+	}
+
 	return nil
 }
 
@@ -1,15 +1,13 @@
 package auth
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"upvista-community-backend/internal/config"
+	"upvista-community-backend/internal/mailer"
 	"upvista-community-backend/internal/models"
-	"upvista-community-backend/internal/repository"
 	"upvista-community-backend/internal/utils"
 	"upvista-community-backend/pkg/errors"
 
@@ -26,7 +24,7 @@ type AuthHandlers struct {
 	googleOAuth   *GoogleOAuthService
 	githubOAuth   *GitHubOAuthService
 	linkedinOAuth *LinkedInOAuthService
-	sessionRepo   repository.SessionRepository
+	passkeySvc    *PasskeyService
 }
 
 // NewAuthHandlers creates new authentication handlers
@@ -38,7 +36,7 @@ func NewAuthHandlers(
 	googleOAuth *GoogleOAuthService,
 	githubOAuth *GitHubOAuthService,
 	linkedinOAuth *LinkedInOAuthService,
-	sessionRepo repository.SessionRepository,
+	passkeySvc *PasskeyService,
 ) *AuthHandlers {
 	return &AuthHandlers{
 		authSvc:       authSvc,
@@ -48,30 +46,23 @@ func NewAuthHandlers(
 		googleOAuth:   googleOAuth,
 		githubOAuth:   githubOAuth,
 		linkedinOAuth: linkedinOAuth,
-		sessionRepo:   sessionRepo,
+		passkeySvc:    passkeySvc,
 	}
 }
 
-// Helper function to create session from login
-func (h *AuthHandlers) createSession(ctx context.Context, userID uuid.UUID, token string, c *gin.Context) error {
-	// Hash the token for storage
-	tokenHash := utils.HashToken(token)
-
-	// Extract device info from request
-	userAgent := c.GetHeader("User-Agent")
-	ipAddress := c.ClientIP()
-
-	// Create session
-	session := &models.UserSession{
-		UserID:    userID,
-		TokenHash: tokenHash,
-		UserAgent: &userAgent,
-		IPAddress: &ipAddress,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour), // 30 days - same as JWT
-		CreatedAt: time.Now(),
+// createSession starts a session for a completed login and fills in its access and refresh tokens
+func (h *AuthHandlers) createSession(c *gin.Context, response *models.AuthResponse) error {
+	tokens, err := h.authSvc.StartSession(c.Request.Context(), response.User, c.GetHeader("User-Agent"), c.ClientIP())
+	if err != nil {
+		return err
 	}
 
-	return h.sessionRepo.CreateSession(ctx, session)
+	response.Token = tokens.Token
+	response.ExpiresAt = tokens.ExpiresAt
+	response.RefreshToken = tokens.RefreshToken
+	response.RefreshExpiresAt = &tokens.RefreshExpiresAt
+
+	return nil
 }
 
 // RegisterHandler handles user registration
@@ -86,6 +77,12 @@ func (h *AuthHandlers) RegisterHandler(c *gin.Context) {
 		return
 	}
 
+	// Default the email language to the browser's
+	if req.Language == nil {
+		language := mailer.MatchLanguage(c.GetHeader("Accept-Language"))
+		req.Language = &language
+	}
+
 	response, err := h.authSvc.RegisterUser(c.Request.Context(), &req)
 	if err != nil {
 		appErr := errors.GetAppError(err)
@@ -97,6 +94,19 @@ func (h *AuthHandlers) RegisterHandler(c *gin.Context) {
 		return
 	}
 
+	// Create session when the email was already verified during signup
+	if response.IssueSession {
+		if err := h.createSession(c, response); err != nil {
+			appErr := errors.GetAppError(err)
+			c.JSON(appErr.Code, gin.H{
+				"success": false,
+				"message": appErr.Message,
+				"error":   appErr.Details,
+			})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -124,8 +134,16 @@ func (h *AuthHandlers) VerifyEmailHandler(c *gin.Context) {
 	}
 
 	// Create session after successful email verification (login)
-	if response.User != nil && response.Token != "" {
-		go h.createSession(c.Request.Context(), response.User.ID, response.Token, c)
+	if response.IssueSession {
+		if err := h.createSession(c, response); err != nil {
+			appErr := errors.GetAppError(err)
+			c.JSON(appErr.Code, gin.H{
+				"success": false,
+				"message": appErr.Message,
+				"error":   appErr.Details,
+			})
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -155,8 +173,55 @@ func (h *AuthHandlers) LoginHandler(c *gin.Context) {
 	}
 
 	// Create session to track this login
-	if response.User != nil && response.Token != "" {
-		go h.createSession(c.Request.Context(), response.User.ID, response.Token, c)
+	if response.IssueSession {
+		if err := h.createSession(c, response); err != nil {
+			appErr := errors.GetAppError(err)
+			c.JSON(appErr.Code, gin.H{
+				"success": false,
+				"message": appErr.Message,
+				"error":   appErr.Details,
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyTwoFactorLoginHandler completes a login that requires a second factor
+func (h *AuthHandlers) VerifyTwoFactorLoginHandler(c *gin.Context) {
+	var req models.VerifyTwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authSvc.VerifyTwoFactorLogin(c.Request.Context(), &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	// Create session to track this login
+	if response.IssueSession {
+		if err := h.createSession(c, response); err != nil {
+			appErr := errors.GetAppError(err)
+			c.JSON(appErr.Code, gin.H{
+				"success": false,
+				"message": appErr.Message,
+				"error":   appErr.Details,
+			})
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -248,27 +313,19 @@ func (h *AuthHandlers) MeHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// RefreshHandler handles token refresh
+// RefreshHandler exchanges a refresh token for a new access token and rotated refresh token
 func (h *AuthHandlers) RefreshHandler(c *gin.Context) {
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "User not authenticated",
-		})
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "Invalid user ID",
+			"message": "Invalid request data",
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	response, err := h.authSvc.RefreshToken(c.Request.Context(), userID)
+	response, err := h.authSvc.RefreshSession(c.Request.Context(), req.RefreshToken)
 	if err != nil {
 		appErr := errors.GetAppError(err)
 		c.JSON(appErr.Code, gin.H{
@@ -323,12 +380,6 @@ func (h *AuthHandlers) LogoutHandler(c *gin.Context) {
 		return
 	}
 
-	// Delete session from database
-	if token != "" {
-		tokenHash := utils.HashToken(token)
-		go h.sessionRepo.DeleteSessionByTokenHash(c.Request.Context(), tokenHash)
-	}
-
 	c.JSON(http.StatusOK, response)
 }
 
@@ -374,9 +425,29 @@ func (h *AuthHandlers) GoogleExchangeHandler(c *gin.Context) {
 		return
 	}
 
+	// Accounts with 2FA must complete the second step before a session is created
+	response, err = h.authSvc.RequireSecondFactor(c.Request.Context(), response)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
 	// Create session for OAuth login
-	if response.User != nil && response.Token != "" {
-		go h.createSession(c.Request.Context(), response.User.ID, response.Token, c)
+	if response.IssueSession {
+		if err := h.createSession(c, response); err != nil {
+			appErr := errors.GetAppError(err)
+			c.JSON(appErr.Code, gin.H{
+				"success": false,
+				"message": appErr.Message,
+				"error":   appErr.Details,
+			})
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -442,9 +513,29 @@ func (h *AuthHandlers) GitHubExchangeHandler(c *gin.Context) {
 		return
 	}
 
+	// Accounts with 2FA must complete the second step before a session is created
+	response, err = h.authSvc.RequireSecondFactor(c.Request.Context(), response)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
 	// Create session for OAuth login
-	if response.User != nil && response.Token != "" {
-		go h.createSession(c.Request.Context(), response.User.ID, response.Token, c)
+	if response.IssueSession {
+		if err := h.createSession(c, response); err != nil {
+			appErr := errors.GetAppError(err)
+			c.JSON(appErr.Code, gin.H{
+				"success": false,
+				"message": appErr.Message,
+				"error":   appErr.Details,
+			})
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -507,9 +598,29 @@ func (h *AuthHandlers) LinkedInExchangeHandler(c *gin.Context) {
 		return
 	}
 
+	// Accounts with 2FA must complete the second step before a session is created
+	response, err = h.authSvc.RequireSecondFactor(c.Request.Context(), response)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
 	// Create session for OAuth login
-	if response.User != nil && response.Token != "" {
-		go h.createSession(c.Request.Context(), response.User.ID, response.Token, c)
+	if response.IssueSession {
+		if err := h.createSession(c, response); err != nil {
+			appErr := errors.GetAppError(err)
+			c.JSON(appErr.Code, gin.H{
+				"success": false,
+				"message": appErr.Message,
+				"error":   appErr.Details,
+			})
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -546,7 +657,7 @@ func (h *AuthHandlers) SendSignupOTPHandler(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authSvc.SendOTPForSignup(c.Request.Context(), req.Email)
+	response, err := h.authSvc.SendOTPForSignup(c.Request.Context(), req.Email, mailer.MatchLanguage(c.GetHeader("Accept-Language")))
 	if err != nil {
 		appErr := errors.GetAppError(err)
 		c.JSON(appErr.Code, gin.H{
@@ -702,7 +813,10 @@ func (h *AuthHandlers) OAuthCompleteProfileHandler(c *gin.Context) {
 		ProfilePicture: req.ProfilePicture,
 	}
 
-	response, err := h.authSvc.CompleteOAuthProfile(c.Request.Context(), userID, &serviceReq)
+	// The refreshed access token stays bound to the current session
+	sessionID, _ := uuid.Parse(c.GetString("session_id"))
+
+	response, err := h.authSvc.CompleteOAuthProfile(c.Request.Context(), userID, sessionID, &serviceReq)
 	if err != nil {
 		appErr := errors.GetAppError(err)
 		c.JSON(appErr.Code, gin.H{
@@ -716,32 +830,479 @@ func (h *AuthHandlers) OAuthCompleteProfileHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// SetupRoutes sets up authentication routes with rate limiting
-func (h *AuthHandlers) SetupRoutes(r *gin.RouterGroup) {
-	// Parse rate limit window duration
-	window, err := time.ParseDuration(h.config.RateLimit.Window)
+// TwoFactorStatusHandler returns the current user's 2FA status
+func (h *AuthHandlers) TwoFactorStatusHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.authSvc.GetTwoFactorStatus(c.Request.Context(), userID)
 	if err != nil {
-		// Default to 1 minute if parsing fails
-		window = time.Minute
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
 	}
 
-	auth := r.Group("/auth")
+	c.JSON(http.StatusOK, response)
+}
+
+// TwoFactorSetupHandler starts 2FA enrollment and returns the provisioning URI
+func (h *AuthHandlers) TwoFactorSetupHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.authSvc.SetupTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// TwoFactorEnableHandler confirms 2FA enrollment and returns recovery codes
+func (h *AuthHandlers) TwoFactorEnableHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.EnableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authSvc.EnableTwoFactor(c.Request.Context(), userID, &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// TwoFactorDisableHandler turns off 2FA
+func (h *AuthHandlers) TwoFactorDisableHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authSvc.DisableTwoFactor(c.Request.Context(), userID, &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// TwoFactorRecoveryCodesHandler regenerates recovery codes
+func (h *AuthHandlers) TwoFactorRecoveryCodesHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RegenerateRecoveryCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authSvc.RegenerateRecoveryCodes(c.Request.Context(), userID, &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PasskeyLoginBeginHandler starts a passkey login and returns the WebAuthn request options
+func (h *AuthHandlers) PasskeyLoginBeginHandler(c *gin.Context) {
+	response, err := h.passkeySvc.BeginLogin(c.Request.Context())
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PasskeyLoginFinishHandler verifies a passkey assertion and logs the user in
+func (h *AuthHandlers) PasskeyLoginFinishHandler(c *gin.Context) {
+	var req models.FinishPasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.passkeySvc.FinishLogin(c.Request.Context(), &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	// Create session to track this login
+	if response.IssueSession {
+		if err := h.createSession(c, response); err != nil {
+			appErr := errors.GetAppError(err)
+			c.JSON(appErr.Code, gin.H{
+				"success": false,
+				"message": appErr.Message,
+				"error":   appErr.Details,
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PasskeyTwoFactorBeginHandler starts a passkey assertion for the second step of a 2FA login
+func (h *AuthHandlers) PasskeyTwoFactorBeginHandler(c *gin.Context) {
+	var req models.BeginPasskeyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.passkeySvc.BeginTwoFactor(c.Request.Context(), &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PasskeyTwoFactorFinishHandler completes a 2FA login with a passkey
+func (h *AuthHandlers) PasskeyTwoFactorFinishHandler(c *gin.Context) {
+	var req models.FinishPasskeyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.passkeySvc.FinishTwoFactor(c.Request.Context(), &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	// Create session to track this login
+	if response.IssueSession {
+		if err := h.createSession(c, response); err != nil {
+			appErr := errors.GetAppError(err)
+			c.JSON(appErr.Code, gin.H{
+				"success": false,
+				"message": appErr.Message,
+				"error":   appErr.Details,
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PasskeyRegisterBeginHandler starts registering a new passkey for the current user
+func (h *AuthHandlers) PasskeyRegisterBeginHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.BeginPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.passkeySvc.BeginRegistration(c.Request.Context(), userID, &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PasskeyRegisterFinishHandler verifies the authenticator response and stores the new passkey
+func (h *AuthHandlers) PasskeyRegisterFinishHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.FinishPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.passkeySvc.FinishRegistration(c.Request.Context(), userID, &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListPasskeysHandler returns the current user's passkeys
+func (h *AuthHandlers) ListPasskeysHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.passkeySvc.ListPasskeys(c.Request.Context(), userID)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RenamePasskeyHandler renames one of the current user's passkeys
+func (h *AuthHandlers) RenamePasskeyHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	passkeyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid passkey ID",
+		})
+		return
+	}
+
+	var req models.RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.passkeySvc.RenamePasskey(c.Request.Context(), userID, passkeyID, &req)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeletePasskeyHandler removes one of the current user's passkeys
+func (h *AuthHandlers) DeletePasskeyHandler(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	passkeyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid passkey ID",
+		})
+		return
+	}
+
+	response, err := h.passkeySvc.DeletePasskey(c.Request.Context(), userID, passkeyID)
+	if err != nil {
+		appErr := errors.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{
+			"success": false,
+			"message": appErr.Message,
+			"error":   appErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// currentUserID extracts the authenticated user ID, writing an error response if missing
+func (h *AuthHandlers) currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "User not authenticated",
+		})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid user ID",
+		})
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// SetupRoutes sets up authentication routes with rate limiting
+func (h *AuthHandlers) SetupRoutes(r *gin.RouterGroup) {
+	policies := NewRateLimitPolicies(h.config)
+
+	// Every auth route counts against the general quota (per IP, the group runs before JWT validation)
+	auth := r.Group("/auth", RateLimitMiddleware(policies.API, h.rateLimiter))
 	{
 		// Public routes (no authentication required, but rate limited)
 		auth.POST("/register",
-			RateLimitMiddleware(h.config.RateLimit.Register, window, h.rateLimiter),
+			RateLimitMiddleware(policies.Register, h.rateLimiter),
 			h.RegisterHandler)
 		auth.POST("/verify-email", h.VerifyEmailHandler)
 		auth.POST("/login",
-			RateLimitMiddleware(h.config.RateLimit.Login, window, h.rateLimiter),
+			RateLimitMiddleware(policies.Login, h.rateLimiter),
 			h.LoginHandler)
-		auth.POST("/forgot-password", h.ForgotPasswordHandler)
+		auth.POST("/2fa/verify",
+			RateLimitMiddleware(policies.Login, h.rateLimiter),
+			h.VerifyTwoFactorLoginHandler)
+		auth.POST("/2fa/passkey/begin",
+			RateLimitMiddleware(policies.Login, h.rateLimiter),
+			h.PasskeyTwoFactorBeginHandler)
+		auth.POST("/2fa/passkey/finish",
+			RateLimitMiddleware(policies.Login, h.rateLimiter),
+			h.PasskeyTwoFactorFinishHandler)
+		auth.POST("/passkeys/login/begin",
+			RateLimitMiddleware(policies.Login, h.rateLimiter),
+			h.PasskeyLoginBeginHandler)
+		auth.POST("/passkeys/login/finish",
+			RateLimitMiddleware(policies.Login, h.rateLimiter),
+			h.PasskeyLoginFinishHandler)
+		auth.POST("/refresh", h.RefreshHandler)
+		auth.POST("/forgot-password",
+			RateLimitMiddleware(policies.Reset, h.rateLimiter),
+			h.ForgotPasswordHandler)
 		auth.POST("/reset-password",
-			RateLimitMiddleware(h.config.RateLimit.Reset, window, h.rateLimiter),
+			RateLimitMiddleware(policies.Reset, h.rateLimiter),
 			h.ResetPasswordHandler)
 
 		// Send OTP for signup (before registration)
-		auth.POST("/send-signup-otp", h.SendSignupOTPHandler)
+		auth.POST("/send-signup-otp",
+			RateLimitMiddleware(policies.Reset, h.rateLimiter),
+			h.SendSignupOTPHandler)
 
 		// Verify OTP for signup (before registration)
 		auth.POST("/verify-signup-otp", h.VerifySignupOTPHandler)
@@ -762,8 +1323,35 @@ func (h *AuthHandlers) SetupRoutes(r *gin.RouterGroup) {
 
 		// Protected routes (authentication required)
 		auth.GET("/me", JWTAuthMiddleware(h.jwtSvc), h.MeHandler)
-		auth.POST("/refresh", JWTAuthMiddleware(h.jwtSvc), h.RefreshHandler)
 		auth.POST("/logout", JWTAuthMiddleware(h.jwtSvc), h.LogoutHandler)
 		auth.POST("/oauth/complete-profile", JWTAuthMiddleware(h.jwtSvc), h.OAuthCompleteProfileHandler)
+
+		// Two-factor authentication management (authentication required)
+		twoFactor := auth.Group("/2fa", JWTAuthMiddleware(h.jwtSvc))
+		{
+			twoFactor.GET("/status", h.TwoFactorStatusHandler)
+			twoFactor.POST("/setup", h.TwoFactorSetupHandler)
+			twoFactor.POST("/enable",
+				RateLimitMiddleware(policies.Login, h.rateLimiter),
+				h.TwoFactorEnableHandler)
+			twoFactor.POST("/disable",
+				RateLimitMiddleware(policies.Login, h.rateLimiter),
+				h.TwoFactorDisableHandler)
+			twoFactor.POST("/recovery-codes",
+				RateLimitMiddleware(policies.Login, h.rateLimiter),
+				h.TwoFactorRecoveryCodesHandler)
+		}
+
+		// Passkey management for account settings (authentication required)
+		passkeys := auth.Group("/passkeys", JWTAuthMiddleware(h.jwtSvc))
+		{
+			passkeys.GET("", h.ListPasskeysHandler)
+			passkeys.POST("/register/begin",
+				RateLimitMiddleware(policies.Login, h.rateLimiter),
+				h.PasskeyRegisterBeginHandler)
+			passkeys.POST("/register/finish", h.PasskeyRegisterFinishHandler)
+			passkeys.PATCH("/:id", h.RenamePasskeyHandler)
+			passkeys.DELETE("/:id", h.DeletePasskeyHandler)
+		}
 	}
 }
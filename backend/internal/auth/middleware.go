@@ -1,20 +1,17 @@
 package auth
 
 import (
-	"log"
 	"net/http"
 	"strings"
-	"time"
 
-	"upvista-community-backend/internal/models"
 	"upvista-community-backend/internal/utils"
 	"upvista-community-backend/pkg/errors"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
-// JWTAuthMiddleware validates JWT tokens
+// JWTAuthMiddleware validates JWT access tokens
+// Access tokens are short-lived; clients renew them with their refresh token at /auth/refresh
 func JWTAuthMiddleware(jwtSvc *utils.JWTService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
@@ -56,28 +53,7 @@ func JWTAuthMiddleware(jwtSvc *utils.JWTService) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_username", claims.Username)
-
-		// Sliding Window Token Refresh
-		// If token is more than halfway to expiry (15+ days old), issue a new one
-		timeUntilExpiry := time.Until(claims.ExpiresAt.Time)
-		halfLife := 15 * 24 * time.Hour // Half of 30 days
-
-		if timeUntilExpiry < halfLife {
-			// Token is past halfway - issue a fresh 30-day token
-			userID, _ := uuid.Parse(claims.UserID)
-			user := &models.User{
-				ID:       userID,
-				Email:    claims.Email,
-				Username: claims.Username,
-			}
-
-			newToken, err := jwtSvc.GenerateToken(user)
-			if err == nil {
-				// Send new token in response header for frontend to update
-				c.Header("X-New-Token", newToken)
-				log.Printf("[Auth] Token refreshed for user %s (was expiring in %v, refreshed to 30 days)", claims.UserID, timeUntilExpiry)
-			}
-		}
+		c.Set("session_id", claims.SessionID)
 
 		// Continue to next handler
 		c.Next()
@@ -111,6 +87,29 @@ func OptionalJWTAuthMiddleware(jwtSvc *utils.JWTService) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_username", claims.Username)
+		c.Set("session_id", claims.SessionID)
+
+		c.Next()
+	}
+}
+
+// AdminMiddleware only lets the listed users through, it must run after JWTAuthMiddleware
+func AdminMiddleware(adminUserIDs []string) gin.HandlerFunc {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[strings.ToLower(id)] = true
+	}
+
+	return func(c *gin.Context) {
+		userID, err := GetCurrentUserID(c)
+		if err != nil || !admins[strings.ToLower(userID)] {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "Admin access required",
+			})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -24,7 +24,6 @@ import (
 type GitHubOAuthService struct {
 	config   *oauth2.Config
 	userRepo repository.UserRepository
-	jwtSvc   *utils.JWTService
 }
 
 // GitHubUserInfo represents user information from GitHub
@@ -46,7 +45,7 @@ type GitHubEmailInfo struct {
 }
 
 // NewGitHubOAuthService creates a new GitHub OAuth service
-func NewGitHubOAuthService(cfg *config.GitHubConfig, userRepo repository.UserRepository, jwtSvc *utils.JWTService) *GitHubOAuthService {
+func NewGitHubOAuthService(cfg *config.GitHubConfig, userRepo repository.UserRepository) *GitHubOAuthService {
 	return &GitHubOAuthService{
 		config: &oauth2.Config{
 			ClientID:     cfg.ClientID,
@@ -56,7 +55,6 @@ func NewGitHubOAuthService(cfg *config.GitHubConfig, userRepo repository.UserRep
 			Endpoint:     github.Endpoint,
 		},
 		userRepo: userRepo,
-		jwtSvc:   jwtSvc,
 	}
 }
 
@@ -201,21 +199,13 @@ func (s *GitHubOAuthService) HandleCallback(ctx context.Context, code string) (*
 		return nil, errors.ErrUserInactive
 	}
 
-	// Generate JWT token
-	jwtToken, err := s.jwtSvc.GenerateToken(user)
-	if err != nil {
-		log.Printf("[GitHubOAuth] Failed to generate token: %v", err)
-		return nil, errors.ErrInternalServer
-	}
-
 	// Update last login
 	s.userRepo.UpdateLastLogin(ctx, user.ID)
 
 	return &models.AuthResponse{
-		Success:   true,
-		Message:   "GitHub authentication successful",
-		Token:     jwtToken,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour),
-		User:      user.ToSafeUser(),
+		Success:      true,
+		Message:      "GitHub authentication successful",
+		User:         user.ToSafeUser(),
+		IssueSession: true,
 	}, nil
 }
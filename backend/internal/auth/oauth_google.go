@@ -23,7 +23,6 @@ import (
 type GoogleOAuthService struct {
 	config   *oauth2.Config
 	userRepo repository.UserRepository
-	jwtSvc   *utils.JWTService
 }
 
 // GoogleUserInfo represents user information from Google
@@ -38,7 +37,7 @@ type GoogleUserInfo struct {
 }
 
 // NewGoogleOAuthService creates a new Google OAuth service
-func NewGoogleOAuthService(cfg *config.GoogleConfig, userRepo repository.UserRepository, jwtSvc *utils.JWTService) *GoogleOAuthService {
+func NewGoogleOAuthService(cfg *config.GoogleConfig, userRepo repository.UserRepository) *GoogleOAuthService {
 	return &GoogleOAuthService{
 		config: &oauth2.Config{
 			ClientID:     cfg.ClientID,
@@ -51,7 +50,6 @@ func NewGoogleOAuthService(cfg *config.GoogleConfig, userRepo repository.UserRep
 			Endpoint: google.Endpoint,
 		},
 		userRepo: userRepo,
-		jwtSvc:   jwtSvc,
 	}
 }
 
@@ -158,21 +156,13 @@ func (s *GoogleOAuthService) HandleCallback(ctx context.Context, code string) (*
 		return nil, errors.ErrUserInactive
 	}
 
-	// Generate JWT token
-	jwtToken, err := s.jwtSvc.GenerateToken(user)
-	if err != nil {
-		log.Printf("[GoogleOAuth] Failed to generate token: %v", err)
-		return nil, errors.ErrInternalServer
-	}
-
 	// Update last login
 	s.userRepo.UpdateLastLogin(ctx, user.ID)
 
 	return &models.AuthResponse{
-		Success:   true,
-		Message:   "Google authentication successful",
-		Token:     jwtToken,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour),
-		User:      user.ToSafeUser(),
+		Success:      true,
+		Message:      "Google authentication successful",
+		User:         user.ToSafeUser(),
+		IssueSession: true,
 	}, nil
 }
@@ -22,7 +22,6 @@ import (
 type LinkedInOAuthService struct {
 	config   *oauth2.Config
 	userRepo repository.UserRepository
-	jwtSvc   *utils.JWTService
 }
 
 // LinkedInUserInfo represents user information from LinkedIn
@@ -37,7 +36,7 @@ type LinkedInUserInfo struct {
 }
 
 // NewLinkedInOAuthService creates a new LinkedIn OAuth service
-func NewLinkedInOAuthService(cfg *config.LinkedInConfig, userRepo repository.UserRepository, jwtSvc *utils.JWTService) *LinkedInOAuthService {
+func NewLinkedInOAuthService(cfg *config.LinkedInConfig, userRepo repository.UserRepository) *LinkedInOAuthService {
 	return &LinkedInOAuthService{
 		config: &oauth2.Config{
 			ClientID:     cfg.ClientID,
@@ -50,7 +49,6 @@ func NewLinkedInOAuthService(cfg *config.LinkedInConfig, userRepo repository.Use
 			},
 		},
 		userRepo: userRepo,
-		jwtSvc:   jwtSvc,
 	}
 }
 
@@ -165,21 +163,13 @@ func (s *LinkedInOAuthService) HandleCallback(ctx context.Context, code string)
 		return nil, errors.ErrUserInactive
 	}
 
-	// Generate JWT token
-	jwtToken, err := s.jwtSvc.GenerateToken(user)
-	if err != nil {
-		log.Printf("[LinkedInOAuth] Failed to generate token: %v", err)
-		return nil, errors.ErrInternalServer
-	}
-
 	// Update last login
 	s.userRepo.UpdateLastLogin(ctx, user.ID)
 
 	return &models.AuthResponse{
-		Success:   true,
-		Message:   "LinkedIn authentication successful",
-		Token:     jwtToken,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour),
-		User:      user.ToSafeUser(),
+		Success:      true,
+		Message:      "LinkedIn authentication successful",
+		User:         user.ToSafeUser(),
+		IssueSession: true,
 	}, nil
 }
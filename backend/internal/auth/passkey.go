@@ -0,0 +1,556 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"upvista-community-backend/internal/config"
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/repository"
+	"upvista-community-backend/pkg/errors"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+const (
+	// passkeyCeremonyTTL is how long the browser has to complete a registration or login ceremony
+	passkeyCeremonyTTL = 5 * time.Minute
+
+	// maxPasskeysPerUser limits how many credentials a single account can register
+	maxPasskeysPerUser = 10
+
+	// Ceremony kinds stored alongside the WebAuthn session data
+	passkeyCeremonyRegistration = "registration"
+	passkeyCeremonyLogin        = "login"
+	passkeyCeremonyTwoFactor    = "two_factor"
+)
+
+// PasskeyService handles WebAuthn (passkey) registration and authentication
+type PasskeyService struct {
+	webAuthn    *webauthn.WebAuthn
+	authSvc     *AuthService
+	passkeyRepo repository.PasskeyRepository
+}
+
+// passkeyCeremony is the server-side state of an in-progress WebAuthn ceremony (stored in Redis)
+type passkeyCeremony struct {
+	Kind    string               `json:"kind"`
+	UserID  string               `json:"user_id,omitempty"`
+	Session webauthn.SessionData `json:"session"`
+}
+
+// passkeyUser adapts a user and their passkeys to the webauthn.User interface
+type passkeyUser struct {
+	user     *models.User
+	passkeys []*models.Passkey
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if u.user.DisplayName != "" {
+		return u.user.DisplayName
+	}
+	return u.user.Username
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.passkeys))
+	for _, p := range u.passkeys {
+		id, err := base64.RawURLEncoding.DecodeString(p.CredentialID)
+		if err != nil {
+			continue
+		}
+
+		transports := make([]protocol.AuthenticatorTransport, len(p.Transports))
+		for i, t := range p.Transports {
+			transports[i] = protocol.AuthenticatorTransport(t)
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       p.PublicKey,
+			AttestationType: p.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: p.BackupEligible,
+				BackupState:    p.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:       p.AAGUID,
+				SignCount:    p.SignCount,
+				CloneWarning: p.CloneWarning,
+			},
+		})
+	}
+	return credentials
+}
+
+// passkeyFor returns the stored passkey matching a raw credential ID
+func (u *passkeyUser) passkeyFor(credentialID []byte) *models.Passkey {
+	encoded := base64.RawURLEncoding.EncodeToString(credentialID)
+	for _, p := range u.passkeys {
+		if p.CredentialID == encoded {
+			return p
+		}
+	}
+	return nil
+}
+
+// NewPasskeyService creates a new passkey service
+// Ceremony state is kept in the auth service's Redis client, so passkeys are unavailable without Redis
+func NewPasskeyService(cfg *config.Config, authSvc *AuthService, passkeyRepo repository.PasskeyRepository) (*PasskeyService, error) {
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthn.RPID,
+		RPDisplayName: cfg.WebAuthn.RPDisplayName,
+		RPOrigins:     cfg.GetWebAuthnOrigins(),
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: passkeyCeremonyTTL,
+			},
+			Registration: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: passkeyCeremonyTTL,
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure WebAuthn: %w", err)
+	}
+
+	return &PasskeyService{
+		webAuthn:    wa,
+		authSvc:     authSvc,
+		passkeyRepo: passkeyRepo,
+	}, nil
+}
+
+// BeginRegistration starts registering a new passkey for the current user
+// Users with 2FA enabled must confirm with a code, since a passkey is a new way to sign in
+func (s *PasskeyService) BeginRegistration(ctx context.Context, userID uuid.UUID, req *models.BeginPasskeyRegistrationRequest) (*models.PasskeyCeremonyResponse, error) {
+	pu, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authSvc.VerifySecondFactor(ctx, pu.user, req.TwoFactorCode); err != nil {
+		return nil, err
+	}
+
+	if len(pu.passkeys) >= maxPasskeysPerUser {
+		return nil, errors.ErrPasskeyLimitReached
+	}
+
+	// Exclude existing credentials so the same authenticator isn't registered twice
+	excluded := webauthn.Credentials(pu.WebAuthnCredentials()).CredentialDescriptors()
+
+	options, session, err := s.webAuthn.BeginRegistration(pu, webauthn.WithExclusions(excluded))
+	if err != nil {
+		log.Printf("[Passkey] BeginRegistration failed for user %s: %v", userID, err)
+		return nil, errors.ErrInternalServer
+	}
+
+	ceremonyID, err := s.saveCeremony(ctx, &passkeyCeremony{
+		Kind:    passkeyCeremonyRegistration,
+		UserID:  userID.String(),
+		Session: *session,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PasskeyCeremonyResponse{
+		Success:    true,
+		CeremonyID: ceremonyID,
+		Options:    options,
+	}, nil
+}
+
+// FinishRegistration verifies the authenticator's attestation and stores the new passkey
+func (s *PasskeyService) FinishRegistration(ctx context.Context, userID uuid.UUID, req *models.FinishPasskeyRegistrationRequest) (*models.PasskeyResponse, error) {
+	ceremony, err := s.consumeCeremony(ctx, req.CeremonyID, passkeyCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	if ceremony.UserID != userID.String() {
+		return nil, errors.ErrPasskeyCeremonyExpired
+	}
+
+	pu, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid passkey response", err.Error())
+	}
+
+	credential, err := s.webAuthn.CreateCredential(pu, ceremony.Session, parsed)
+	if err != nil {
+		log.Printf("[Passkey] Registration verification failed for user %s: %v", userID, err)
+		return nil, errors.ErrPasskeyVerification
+	}
+
+	transports := make([]string, len(credential.Transport))
+	for i, t := range credential.Transport {
+		transports[i] = string(t)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = fmt.Sprintf("Passkey %d", len(pu.passkeys)+1)
+	}
+
+	passkey := &models.Passkey{
+		UserID:          userID,
+		CredentialID:    base64.RawURLEncoding.EncodeToString(credential.ID),
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            name,
+	}
+
+	if err := s.passkeyRepo.CreatePasskey(ctx, passkey); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Passkey] Passkey registered for user %s", userID)
+
+	return &models.PasskeyResponse{
+		Success: true,
+		Message: "Passkey added",
+		Passkey: passkey,
+	}, nil
+}
+
+// BeginLogin starts a usernameless passkey login (the browser offers the user's discoverable credentials)
+func (s *PasskeyService) BeginLogin(ctx context.Context) (*models.PasskeyCeremonyResponse, error) {
+	options, session, err := s.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		log.Printf("[Passkey] BeginDiscoverableLogin failed: %v", err)
+		return nil, errors.ErrInternalServer
+	}
+
+	ceremonyID, err := s.saveCeremony(ctx, &passkeyCeremony{
+		Kind:    passkeyCeremonyLogin,
+		Session: *session,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PasskeyCeremonyResponse{
+		Success:    true,
+		CeremonyID: ceremonyID,
+		Options:    options,
+	}, nil
+}
+
+// FinishLogin verifies a passkey assertion and signs the user in
+// A user-verified passkey is itself multi-factor, so no additional 2FA step is required
+func (s *PasskeyService) FinishLogin(ctx context.Context, req *models.FinishPasskeyLoginRequest) (*models.AuthResponse, error) {
+	ceremony, err := s.consumeCeremony(ctx, req.CeremonyID, passkeyCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid passkey response", err.Error())
+	}
+
+	var pu *passkeyUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		pu, err = s.loadUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return pu, nil
+	}
+
+	_, credential, err := s.webAuthn.ValidatePasskeyLogin(handler, ceremony.Session, parsed)
+	if err != nil {
+		log.Printf("[Passkey] Login verification failed: %v", err)
+		return nil, errors.ErrPasskeyVerification
+	}
+
+	if !pu.user.IsActive {
+		return nil, errors.ErrUserInactive
+	}
+
+	if err := s.recordUsage(ctx, pu, credential); err != nil {
+		return nil, err
+	}
+
+	return s.authSvc.completeLogin(ctx, pu.user)
+}
+
+// BeginTwoFactor starts a passkey assertion for the second step of a password login
+func (s *PasskeyService) BeginTwoFactor(ctx context.Context, req *models.BeginPasskeyTwoFactorRequest) (*models.PasskeyCeremonyResponse, error) {
+	pu, err := s.loadChallengeUser(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pu.passkeys) == 0 {
+		return nil, errors.ErrPasskeyNotFound
+	}
+
+	options, session, err := s.webAuthn.BeginLogin(pu)
+	if err != nil {
+		log.Printf("[Passkey] BeginLogin failed for user %s: %v", pu.user.ID, err)
+		return nil, errors.ErrInternalServer
+	}
+
+	ceremonyID, err := s.saveCeremony(ctx, &passkeyCeremony{
+		Kind:    passkeyCeremonyTwoFactor,
+		UserID:  pu.user.ID.String(),
+		Session: *session,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PasskeyCeremonyResponse{
+		Success:    true,
+		CeremonyID: ceremonyID,
+		Options:    options,
+	}, nil
+}
+
+// FinishTwoFactor completes a 2FA login with a passkey assertion instead of a TOTP or recovery code
+func (s *PasskeyService) FinishTwoFactor(ctx context.Context, req *models.FinishPasskeyTwoFactorRequest) (*models.AuthResponse, error) {
+	pu, err := s.loadChallengeUser(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	ceremony, err := s.consumeCeremony(ctx, req.CeremonyID, passkeyCeremonyTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	if ceremony.UserID != pu.user.ID.String() {
+		return nil, errors.ErrPasskeyCeremonyExpired
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid passkey response", err.Error())
+	}
+
+	credential, err := s.webAuthn.ValidateLogin(pu, ceremony.Session, parsed)
+	if err != nil {
+		log.Printf("[Passkey] Second factor verification failed for user %s: %v", pu.user.ID, err)
+		return nil, errors.ErrPasskeyVerification
+	}
+
+	if err := s.recordUsage(ctx, pu, credential); err != nil {
+		return nil, err
+	}
+
+	// Challenge tokens are single-use
+	s.authSvc.revokeChallengeToken(req.ChallengeToken)
+
+	return s.authSvc.completeLogin(ctx, pu.user)
+}
+
+// ListPasskeys returns all passkeys registered by the user
+func (s *PasskeyService) ListPasskeys(ctx context.Context, userID uuid.UUID) (*models.PasskeysResponse, error) {
+	passkeys, err := s.passkeyRepo.GetPasskeysByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PasskeysResponse{
+		Success:  true,
+		Passkeys: passkeys,
+	}, nil
+}
+
+// RenamePasskey changes the display name of one of the user's passkeys
+func (s *PasskeyService) RenamePasskey(ctx context.Context, userID, passkeyID uuid.UUID, req *models.RenamePasskeyRequest) (*models.PasskeyResponse, error) {
+	passkey, err := s.getOwnedPasskey(ctx, userID, passkeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.NewAppError(400, "Name is required")
+	}
+
+	if err := s.passkeyRepo.RenamePasskey(ctx, passkeyID, userID, name); err != nil {
+		return nil, err
+	}
+	passkey.Name = name
+
+	return &models.PasskeyResponse{
+		Success: true,
+		Message: "Passkey renamed",
+		Passkey: passkey,
+	}, nil
+}
+
+// DeletePasskey removes one of the user's passkeys
+func (s *PasskeyService) DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) (*models.MessageResponse, error) {
+	if _, err := s.getOwnedPasskey(ctx, userID, passkeyID); err != nil {
+		return nil, err
+	}
+
+	if err := s.passkeyRepo.DeletePasskey(ctx, passkeyID, userID); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Passkey] Passkey %s removed for user %s", passkeyID, userID)
+
+	return &models.MessageResponse{
+		Success: true,
+		Message: "Passkey removed",
+	}, nil
+}
+
+// getOwnedPasskey loads a passkey and checks it belongs to the user
+func (s *PasskeyService) getOwnedPasskey(ctx context.Context, userID, passkeyID uuid.UUID) (*models.Passkey, error) {
+	passkey, err := s.passkeyRepo.GetPasskeyByID(ctx, passkeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if passkey.UserID != userID {
+		return nil, errors.ErrPasskeyNotFound
+	}
+
+	return passkey, nil
+}
+
+// loadUser loads a user together with their registered passkeys
+func (s *PasskeyService) loadUser(ctx context.Context, userID uuid.UUID) (*passkeyUser, error) {
+	user, err := s.authSvc.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := s.passkeyRepo.GetPasskeysByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &passkeyUser{user: user, passkeys: passkeys}, nil
+}
+
+// loadChallengeUser resolves the user behind a 2FA challenge token
+func (s *PasskeyService) loadChallengeUser(ctx context.Context, challengeToken string) (*passkeyUser, error) {
+	claims, err := s.authSvc.jwtSvc.ValidateChallengeToken(challengeToken, twoFactorChallengePurpose)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	pu, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	if !pu.user.IsActive {
+		return nil, errors.ErrUserInactive
+	}
+
+	return pu, nil
+}
+
+// recordUsage persists the updated signature counter and flags after a successful assertion
+func (s *PasskeyService) recordUsage(ctx context.Context, pu *passkeyUser, credential *webauthn.Credential) error {
+	passkey := pu.passkeyFor(credential.ID)
+	if passkey == nil {
+		return errors.ErrPasskeyVerification
+	}
+
+	if credential.Authenticator.CloneWarning {
+		log.Printf("[Passkey] Signature counter regression for passkey %s (user %s), possible cloned authenticator", passkey.ID, pu.user.ID)
+	}
+
+	return s.passkeyRepo.UpdatePasskeyUsage(ctx, passkey.ID, credential.Authenticator.SignCount, credential.Authenticator.CloneWarning, credential.Flags.BackupState)
+}
+
+// saveCeremony stores ceremony state in Redis and returns its ID
+func (s *PasskeyService) saveCeremony(ctx context.Context, ceremony *passkeyCeremony) (string, error) {
+	if s.authSvc.redis == nil {
+		return "", errors.ErrPasskeyServiceUnavailable
+	}
+
+	data, err := json.Marshal(ceremony)
+	if err != nil {
+		return "", errors.ErrInternalServer
+	}
+
+	ceremonyID := uuid.New().String()
+	key := fmt.Sprintf("passkey_ceremony:%s", ceremonyID)
+	if err := s.authSvc.redis.Set(ctx, key, data, passkeyCeremonyTTL).Err(); err != nil {
+		log.Printf("[Passkey] Failed to store ceremony in Redis: %v", err)
+		return "", errors.ErrInternalServer
+	}
+
+	return ceremonyID, nil
+}
+
+// consumeCeremony loads and deletes ceremony state so each challenge can only be answered once
+func (s *PasskeyService) consumeCeremony(ctx context.Context, ceremonyID, kind string) (*passkeyCeremony, error) {
+	if s.authSvc.redis == nil {
+		return nil, errors.ErrPasskeyServiceUnavailable
+	}
+
+	// GETDEL reads and deletes in one step, concurrent finish requests can't both get the ceremony
+	key := fmt.Sprintf("passkey_ceremony:%s", ceremonyID)
+	data, err := s.authSvc.redis.GetDel(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, errors.ErrPasskeyCeremonyExpired // Expired or already used
+	}
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	var ceremony passkeyCeremony
+	if err := json.Unmarshal(data, &ceremony); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	if ceremony.Kind != kind {
+		return nil, errors.ErrPasskeyCeremonyExpired
+	}
+
+	return &ceremony, nil
+}
@@ -1,45 +1,81 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"upvista-community-backend/internal/config"
 	"upvista-community-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-// RateLimitMiddleware creates a middleware that enforces rate limiting per IP address
-// with forgiveness mechanism for legitimate errors
-func RateLimitMiddleware(limit int, window time.Duration, rateLimiter *utils.RateLimiter) gin.HandlerFunc {
+// RateLimitPolicies holds the configured rate limit policy for each route group
+type RateLimitPolicies struct {
+	Login    utils.RateLimitPolicy // Login, 2FA and passkey ceremonies
+	Register utils.RateLimitPolicy
+	Reset    utils.RateLimitPolicy // Password reset
+	API      utils.RateLimitPolicy // Every other endpoint
+	Write    utils.RateLimitPolicy // Creating posts, comments and messages
+	Search   utils.RateLimitPolicy
+	Upload   utils.RateLimitPolicy // Media uploads (expensive, separate quota)
+}
+
+// NewRateLimitPolicies builds the rate limit policies from configuration
+func NewRateLimitPolicies(cfg *config.Config) *RateLimitPolicies {
+	window := cfg.GetRateLimitWindow()
+	rl := cfg.RateLimit
+
+	return &RateLimitPolicies{
+		Login:    utils.RateLimitPolicy{Name: "login", Limit: rl.Login, Burst: rl.Forgiveness, Window: window},
+		Register: utils.RateLimitPolicy{Name: "register", Limit: rl.Register, Burst: rl.Forgiveness, Window: window},
+		Reset:    utils.RateLimitPolicy{Name: "reset", Limit: rl.Reset, Burst: rl.Forgiveness, Window: window},
+		API:      utils.RateLimitPolicy{Name: "api", Limit: rl.API, Burst: rl.Burst, Window: window},
+		Write:    utils.RateLimitPolicy{Name: "write", Limit: rl.Write, Burst: rl.Burst, Window: window},
+		Search:   utils.RateLimitPolicy{Name: "search", Limit: rl.Search, Burst: rl.Burst, Window: window},
+		Upload:   utils.RateLimitPolicy{Name: "upload", Limit: rl.Upload, Burst: rl.Forgiveness, Window: cfg.GetUploadRateLimitWindow()},
+	}
+}
+
+// RateLimitMiddleware creates a middleware that enforces a rate limit policy
+// Requests are counted per authenticated user, falling back to the IP address for anonymous requests,
+// so it should run after the JWT middleware on protected routes
+// A policy with a non-positive limit is disabled
+func RateLimitMiddleware(policy utils.RateLimitPolicy, rateLimiter *utils.RateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Extract IP address from request
-		forwardedFor := c.GetHeader("X-Forwarded-For")
-		remoteAddr := c.RemoteIP()
-		ip := utils.ExtractIP(remoteAddr, forwardedFor)
+		if policy.Limit <= 0 {
+			c.Next()
+			return
+		}
 
 		// Check if request is allowed
-		allowed, remaining, resetTime := rateLimiter.Allow(ip, limit, window)
+		allowed, remaining, resetTime := rateLimiter.AllowPolicy(rateLimitKey(c), policy)
 
-		// Set rate limit headers (RFC 6585)
-		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		resetSeconds := int(time.Until(resetTime).Seconds())
+		if resetSeconds < 0 {
+			resetSeconds = 0
+		}
+
+		// Standard RateLimit headers (IETF draft) - reset is in seconds from now
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d;name=%q", policy.Limit, int(policy.Window.Seconds()), policy.Burst, policy.Name))
+
+		// Legacy headers (RFC 6585 era) - reset is a Unix timestamp
+		c.Header("X-RateLimit-Limit", strconv.Itoa(policy.Limit))
 		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
 		c.Header("X-RateLimit-Reset", strconv.FormatInt(resetTime.Unix(), 10))
 
 		if !allowed {
-			// Calculate retry-after in seconds
-			retryAfter := int(time.Until(resetTime).Seconds())
-			if retryAfter < 0 {
-				retryAfter = 0
-			}
-
-			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.Header("Retry-After", strconv.Itoa(resetSeconds))
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"success":     false,
 				"message":     "Too many requests. Please try again later.",
 				"error":       "Rate limit exceeded",
-				"retry_after": retryAfter,
+				"retry_after": resetSeconds,
 			})
 			c.Abort()
 			return
@@ -49,3 +85,15 @@ func RateLimitMiddleware(limit int, window time.Duration, rateLimiter *utils.Rat
 		c.Next()
 	}
 }
+
+// rateLimitKey identifies the client a request is counted against
+func rateLimitKey(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+
+	// Extract IP address from request
+	forwardedFor := c.GetHeader("X-Forwarded-For")
+	remoteAddr := c.RemoteIP()
+	return "ip:" + utils.ExtractIP(remoteAddr, forwardedFor)
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
+	"upvista-community-backend/internal/mailer"
 	"upvista-community-backend/internal/models"
 	"upvista-community-backend/internal/repository"
 	"upvista-community-backend/internal/utils"
@@ -20,26 +22,38 @@ import (
 
 // AuthService handles authentication business logic
 type AuthService struct {
-	userRepo  repository.UserRepository
-	emailSvc  *utils.EmailService
-	jwtSvc    *utils.JWTService
-	blacklist *utils.TokenBlacklist
-	redis     *redis.Client // Optional: for OTP caching
+	userRepo      repository.UserRepository
+	sessionRepo   repository.SessionRepository
+	emailSvc      *utils.EmailService
+	jwtSvc        *utils.JWTService
+	blacklist     *utils.TokenBlacklist
+	refreshExpiry time.Duration // Lifetime of a session's refresh token (extended on every rotation)
+	redis         *redis.Client // Optional: for OTP caching
+
+	// Codes tried per 2FA challenge, in memory when Redis isn't available
+	challengeAttemptsMu sync.Mutex
+	challengeAttempts   map[string]challengeAttempts
 }
 
 // NewAuthService creates a new authentication service
 func NewAuthService(
 	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
 	emailSvc *utils.EmailService,
 	jwtSvc *utils.JWTService,
 	blacklist *utils.TokenBlacklist,
+	refreshExpiry time.Duration,
 ) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		emailSvc:  emailSvc,
-		jwtSvc:    jwtSvc,
-		blacklist: blacklist,
-		redis:     nil, // Will be set if Redis is available
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		emailSvc:      emailSvc,
+		jwtSvc:        jwtSvc,
+		blacklist:     blacklist,
+		refreshExpiry: refreshExpiry,
+		redis:         nil, // Will be set if Redis is available
+
+		challengeAttempts: make(map[string]challengeAttempts),
 	}
 }
 
@@ -130,6 +144,7 @@ func (s *AuthService) RegisterUser(ctx context.Context, req *models.RegisterRequ
 			}
 			return nil
 		}(),
+		Language:  mailer.DefaultLanguage,
 		IsActive:  true,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
@@ -148,39 +163,35 @@ func (s *AuthService) RegisterUser(ctx context.Context, req *models.RegisterRequ
 	if req.ProfilePicture != nil {
 		user.ProfilePicture = req.ProfilePicture
 	}
+	if req.Language != nil {
+		user.Language = mailer.NormalizeLanguage(*req.Language)
+	}
 
 	// Save user to database
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
 		return nil, err
 	}
 
-	// If email is already verified (OTP was pre-verified), generate token immediately
+	// If email is already verified (OTP was pre-verified), log the user in immediately
 	if isEmailVerified {
-		// Generate JWT token
-		token, err := s.jwtSvc.GenerateToken(user)
-		if err != nil {
-			log.Printf("[AuthService] RegisterUser - Token generation failed: %v", err)
-		} else {
-			// Update last login
-			s.userRepo.UpdateLastLogin(ctx, user.ID)
-
-			// Send welcome email
-			go s.emailSvc.SendWelcomeEmail(user.Email, user.DisplayName)
-
-			return &models.AuthResponse{
-				Success:   true,
-				Message:   "Registration and email verification successful",
-				Token:     token,
-				ExpiresAt: time.Now().Add(30 * 24 * time.Hour),
-				User:      user.ToSafeUser(),
-				UserID:    user.ID.String(),
-			}, nil
-		}
+		// Update last login
+		s.userRepo.UpdateLastLogin(ctx, user.ID)
+
+		// Send welcome email
+		go s.emailSvc.SendWelcomeEmail(user.Email, user.DisplayName, user.Language)
+
+		return &models.AuthResponse{
+			Success:      true,
+			Message:      "Registration and email verification successful",
+			User:         user.ToSafeUser(),
+			UserID:       user.ID.String(),
+			IssueSession: true,
+		}, nil
 	}
 
 	// Send verification email (if code wasn't provided or verification failed)
 	if !isEmailVerified && verificationCode != "" {
-		if err := s.emailSvc.SendVerificationEmail(email, verificationCode); err != nil {
+		if err := s.emailSvc.SendVerificationEmail(email, verificationCode, user.Language); err != nil {
 			// Log error but don't fail registration
 			log.Printf("[AuthService] Failed to send verification email: %v", err)
 		}
@@ -217,25 +228,17 @@ func (s *AuthService) VerifyEmail(ctx context.Context, req *models.VerifyEmailRe
 		return nil, err
 	}
 
-	// Generate JWT token
-	token, err := s.jwtSvc.GenerateToken(user)
-	if err != nil {
-		log.Printf("[AuthService] VerifyEmail - Token generation failed: %v", err)
-		return nil, errors.ErrInternalServer
-	}
-
 	// Update last login
 	s.userRepo.UpdateLastLogin(ctx, user.ID)
 
 	// Send welcome email
-	go s.emailSvc.SendWelcomeEmail(user.Email, user.DisplayName)
+	go s.emailSvc.SendWelcomeEmail(user.Email, user.DisplayName, user.Language)
 
 	return &models.AuthResponse{
-		Success:   true,
-		Message:   "Email verified successfully",
-		Token:     token,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour), // 30 days
-		User:      user.ToSafeUser(),
+		Success:      true,
+		Message:      "Email verified successfully",
+		User:         user.ToSafeUser(),
+		IssueSession: true,
 	}, nil
 }
 
@@ -270,22 +273,12 @@ func (s *AuthService) LoginUser(ctx context.Context, req *models.LoginRequest) (
 		return nil, errors.ErrEmailNotVerified
 	}
 
-	// Generate JWT token
-	token, err := s.jwtSvc.GenerateToken(user)
-	if err != nil {
-		return nil, errors.ErrInternalServer
+	// Password is correct - ask for the second factor before issuing a token
+	if user.TwoFactorEnabled {
+		return s.twoFactorChallenge(user)
 	}
 
-	// Update last login
-	s.userRepo.UpdateLastLogin(ctx, user.ID)
-
-	return &models.AuthResponse{
-		Success:   true,
-		Message:   "Login successful",
-		Token:     token,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour), // 30 days
-		User:      user.ToSafeUser(),
-	}, nil
+	return s.completeLogin(ctx, user)
 }
 
 // ForgotPassword handles password reset request
@@ -299,7 +292,7 @@ func (s *AuthService) ForgotPassword(ctx context.Context, req *models.ForgotPass
 	email := utils.NormalizeEmail(req.Email)
 
 	// Check if user exists
-	_, err := s.userRepo.GetUserByEmail(ctx, email)
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
 		// Don't reveal if user exists or not for security
 		return &models.MessageResponse{
@@ -323,7 +316,7 @@ func (s *AuthService) ForgotPassword(ctx context.Context, req *models.ForgotPass
 	}
 
 	// Send reset email
-	if err := s.emailSvc.SendPasswordResetEmail(email, resetToken); err != nil {
+	if err := s.emailSvc.SendPasswordResetEmail(email, resetToken, user.Language); err != nil {
 		// Log error but don't fail the request
 		log.Printf("[AuthService] Failed to send password reset email: %v", err)
 	}
@@ -371,36 +364,9 @@ func (s *AuthService) GetCurrentUser(ctx context.Context, userID uuid.UUID) (*mo
 	}, nil
 }
 
-// RefreshToken handles token refresh
-func (s *AuthService) RefreshToken(ctx context.Context, userID uuid.UUID) (*models.TokenResponse, error) {
-	// Get user
-	user, err := s.userRepo.GetUserByID(ctx, userID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Check if user is active
-	if !user.IsActive {
-		return nil, errors.ErrUserInactive
-	}
-
-	// Generate new token
-	token, err := s.jwtSvc.GenerateToken(user)
-	if err != nil {
-		return nil, errors.ErrInternalServer
-	}
-
-	return &models.TokenResponse{
-		Success:   true,
-		Message:   "Token refreshed successfully",
-		Token:     token,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour), // 30 days
-	}, nil
-}
-
-// LogoutUser handles user logout by blacklisting the token
+// LogoutUser handles user logout by ending the token's session
 func (s *AuthService) LogoutUser(ctx context.Context, userID uuid.UUID, token string) (*models.MessageResponse, error) {
-	// Validate the token first to get expiry
+	// Validate the token first to find its session
 	claims, err := s.jwtSvc.ValidateToken(token)
 	if err != nil {
 		// Even if token is invalid/expired, we return success
@@ -411,10 +377,9 @@ func (s *AuthService) LogoutUser(ctx context.Context, userID uuid.UUID, token st
 		}, nil
 	}
 
-	// Add token to blacklist until it expires
-	// The blacklist service will automatically clean it up
-	if s.blacklist != nil {
-		s.blacklist.Add(token, claims.ExpiresAt.Time)
+	// Delete the session (its refresh token stops working) and revoke its access tokens
+	if sessionID, err := uuid.Parse(claims.SessionID); err == nil {
+		s.endSession(ctx, sessionID)
 	}
 
 	return &models.MessageResponse{
@@ -430,7 +395,8 @@ func (s *AuthService) CheckUsernameExists(ctx context.Context, username string)
 
 // SendOTPForSignup sends an OTP to email for signup flow (before registration)
 // This allows users to verify their email before completing registration
-func (s *AuthService) SendOTPForSignup(ctx context.Context, email string) (*models.MessageResponse, error) {
+// The language comes from the browser since there is no account yet
+func (s *AuthService) SendOTPForSignup(ctx context.Context, email, language string) (*models.MessageResponse, error) {
 	// Normalize email
 	normalizedEmail := utils.NormalizeEmail(email)
 
@@ -456,7 +422,7 @@ func (s *AuthService) SendOTPForSignup(ctx context.Context, email string) (*mode
 	}
 
 	// Send verification email
-	if err := s.emailSvc.SendVerificationEmail(normalizedEmail, verificationCode); err != nil {
+	if err := s.emailSvc.SendVerificationEmail(normalizedEmail, verificationCode, language); err != nil {
 		return nil, errors.ErrEmailSendError
 	}
 
@@ -531,7 +497,7 @@ func (s *AuthService) ConsumeSignupOTP(ctx context.Context, email, code string)
 }
 
 // CompleteOAuthProfile completes OAuth user profile with additional required information
-func (s *AuthService) CompleteOAuthProfile(ctx context.Context, userID uuid.UUID, req *struct {
+func (s *AuthService) CompleteOAuthProfile(ctx context.Context, userID, sessionID uuid.UUID, req *struct {
 	Password       string
 	Username       string
 	DisplayName    string
@@ -591,8 +557,8 @@ func (s *AuthService) CompleteOAuthProfile(ctx context.Context, userID uuid.UUID
 		return nil, err
 	}
 
-	// Generate new JWT token with updated user info
-	token, err := s.jwtSvc.GenerateToken(user)
+	// Generate new access token with updated user info for the current session
+	token, expiresAt, err := s.jwtSvc.GenerateToken(user, sessionID)
 	if err != nil {
 		return nil, errors.ErrInternalServer
 	}
@@ -601,7 +567,7 @@ func (s *AuthService) CompleteOAuthProfile(ctx context.Context, userID uuid.UUID
 		Success:   true,
 		Message:   "Profile completed successfully",
 		Token:     token,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour),
+		ExpiresAt: expiresAt,
 		User:      user.ToSafeUser(),
 	}, nil
 }
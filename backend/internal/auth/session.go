@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/utils"
+	"upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// StartSession creates a session for a fully authenticated user and issues its first token pair
+// The access token is short-lived; the opaque refresh token is stored hashed on the session
+func (s *AuthService) StartSession(ctx context.Context, user *models.User, userAgent, ipAddress string) (*models.TokenResponse, error) {
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	now := time.Now()
+	session := &models.UserSession{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		UserAgent: &userAgent,
+		IPAddress: &ipAddress,
+		ExpiresAt: now.Add(s.refreshExpiry),
+		CreatedAt: now,
+	}
+
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	if session.ID == uuid.Nil {
+		log.Printf("[AuthService] Session created for user %s without an ID", user.ID)
+		return nil, errors.ErrInternalServer
+	}
+
+	token, expiresAt, err := s.jwtSvc.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	return &models.TokenResponse{
+		Success:          true,
+		Message:          "Session created",
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// RefreshSession exchanges a refresh token for a new token pair, rotating the refresh token
+// Presenting any refresh token the session was already rotated away from revokes the whole session (reuse detection)
+func (s *AuthService) RefreshSession(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	tokenHash := utils.HashToken(refreshToken)
+
+	session, err := s.sessionRepo.GetSessionByTokenHash(ctx, tokenHash)
+	if err != nil {
+		// Not the current token - check whether it is a replay of an old one
+		if reused, lookupErr := s.sessionRepo.GetSessionByRetiredTokenHash(ctx, tokenHash); lookupErr == nil {
+			log.Printf("[AuthService] Refresh token reuse detected for session %s (user %s), revoking session", reused.ID, reused.UserID)
+			s.endSession(ctx, reused.ID)
+			return nil, errors.ErrRefreshTokenReused
+		}
+		return nil, errors.ErrInvalidToken
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		s.endSession(ctx, session.ID)
+		return nil, errors.ErrTokenExpired
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	if !user.IsActive {
+		s.endSession(ctx, session.ID)
+		return nil, errors.ErrUserInactive
+	}
+
+	newRefreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	// Retired before rotating, so it is recognized as reused even by a request that loses the race below
+	if err := s.sessionRepo.RetireSessionToken(ctx, session.ID, tokenHash); err != nil {
+		return nil, err
+	}
+
+	refreshExpiresAt := time.Now().Add(s.refreshExpiry)
+	rotated, err := s.sessionRepo.RotateSessionToken(ctx, session.ID, tokenHash, utils.HashToken(newRefreshToken), refreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Another request rotated this token first - the same token was used twice
+	if !rotated {
+		log.Printf("[AuthService] Concurrent refresh token reuse for session %s (user %s), revoking session", session.ID, session.UserID)
+		s.endSession(ctx, session.ID)
+		return nil, errors.ErrRefreshTokenReused
+	}
+
+	token, expiresAt, err := s.jwtSvc.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	return &models.TokenResponse{
+		Success:          true,
+		Message:          "Token refreshed successfully",
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     newRefreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// RevokeSession immediately invalidates all access tokens issued for a session
+// Callers are expected to delete the session as well so its refresh token stops working
+func (s *AuthService) RevokeSession(sessionID uuid.UUID) {
+	if s.blacklist == nil {
+		return
+	}
+	// Access tokens for the session can't outlive their own expiry
+	s.blacklist.RevokeSession(sessionID.String(), time.Now().Add(s.jwtSvc.Expiry()))
+}
+
+// endSession deletes a session and revokes its access tokens
+func (s *AuthService) endSession(ctx context.Context, sessionID uuid.UUID) {
+	if err := s.sessionRepo.DeleteSession(ctx, sessionID); err != nil {
+		log.Printf("[AuthService] Failed to delete session %s: %v", sessionID, err)
+	}
+	s.RevokeSession(sessionID)
+}
@@ -0,0 +1,423 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/utils"
+	"upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// twoFactorIssuer is the account issuer shown in authenticator apps
+	twoFactorIssuer = "Asteria"
+
+	// twoFactorChallengePurpose marks JWTs that may only be exchanged for a session after a 2FA code
+	twoFactorChallengePurpose = "2fa_challenge"
+
+	// twoFactorChallengeTTL is how long the user has to enter their code after the password step
+	twoFactorChallengeTTL = 5 * time.Minute
+
+	// recoveryCodeAttempts is how many times using a recovery code is retried when other codes are used at the same time
+	recoveryCodeAttempts = 3
+
+	// maxChallengeCodeAttempts is how many codes can be tried against one 2FA challenge before it is revoked
+	maxChallengeCodeAttempts = 5
+)
+
+// challengeAttempts counts the codes tried against a 2FA challenge until it expires
+type challengeAttempts struct {
+	count     int
+	expiresAt time.Time
+}
+
+// GetTwoFactorStatus returns whether 2FA is enabled and how many recovery codes remain
+func (s *AuthService) GetTwoFactorStatus(ctx context.Context, userID uuid.UUID) (*models.TwoFactorStatusResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorStatusResponse{
+		Success:                true,
+		Enabled:                user.TwoFactorEnabled,
+		EnabledAt:              user.TwoFactorEnabledAt,
+		RecoveryCodesRemaining: len(user.TwoFactorRecoveryCodes),
+	}, nil
+}
+
+// SetupTwoFactor starts enrollment by generating a new TOTP secret and provisioning URI
+// 2FA is not active until EnableTwoFactor verifies a code generated from this secret
+func (s *AuthService) SetupTwoFactor(ctx context.Context, userID uuid.UUID) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, errors.ErrTwoFactorAlreadyOn
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	if err := s.userRepo.SetTwoFactorSecret(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Success:         true,
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(secret, twoFactorIssuer, user.Email),
+	}, nil
+}
+
+// EnableTwoFactor confirms enrollment with a code from the authenticator app and issues recovery codes
+func (s *AuthService) EnableTwoFactor(ctx context.Context, userID uuid.UUID, req *models.EnableTwoFactorRequest) (*models.TwoFactorRecoveryCodesResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, errors.ErrTwoFactorAlreadyOn
+	}
+
+	if user.TwoFactorSecret == nil || *user.TwoFactorSecret == "" {
+		return nil, errors.ErrTwoFactorSetupRequired
+	}
+
+	step, ok := utils.ValidateTOTPCode(*user.TwoFactorSecret, req.Code, user.TwoFactorLastStep)
+	if !ok {
+		return nil, errors.ErrInvalidTwoFactorCode
+	}
+
+	// The enrollment code can't be used again to log in
+	used, err := s.userRepo.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, errors.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	if err := s.userRepo.EnableTwoFactor(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[AuthService] Two-factor authentication enabled for user %s", userID)
+
+	return &models.TwoFactorRecoveryCodesResponse{
+		Success:       true,
+		Message:       "Two-factor authentication enabled. Store these recovery codes somewhere safe.",
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// DisableTwoFactor turns off 2FA after re-verifying the password and a second factor
+func (s *AuthService) DisableTwoFactor(ctx context.Context, userID uuid.UUID, req *models.DisableTwoFactorRequest) (*models.MessageResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TwoFactorEnabled {
+		return nil, errors.ErrTwoFactorNotEnabled
+	}
+
+	// Verify password (OAuth-only accounts may not have one)
+	if user.PasswordHash != "" {
+		if req.Password == "" {
+			return nil, errors.NewAppError(400, "Password is required")
+		}
+		if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+			return nil, errors.NewAppError(401, "Password is incorrect")
+		}
+	}
+
+	if err := s.VerifySecondFactor(ctx, user, req.Code); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.DisableTwoFactor(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[AuthService] Two-factor authentication disabled for user %s", userID)
+
+	// Alert the account owner (security best practice)
+	go s.emailSvc.SendTwoFactorDisabledEmail(user.Email, user.DisplayName, user.Language)
+
+	return &models.MessageResponse{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	}, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes, invalidating the previous set
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req *models.RegenerateRecoveryCodesRequest) (*models.TwoFactorRecoveryCodesResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TwoFactorEnabled {
+		return nil, errors.ErrTwoFactorNotEnabled
+	}
+
+	if err := s.VerifySecondFactor(ctx, user, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	if err := s.userRepo.UpdateRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorRecoveryCodesResponse{
+		Success:       true,
+		Message:       "New recovery codes generated. Previous codes no longer work.",
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// VerifySecondFactor checks a TOTP or recovery code for a user with 2FA enabled
+// Both are single-use: a TOTP code is rejected once it or a later one was accepted, recovery codes are removed once consumed.
+// Users without 2FA always pass.
+func (s *AuthService) VerifySecondFactor(ctx context.Context, user *models.User, code string) error {
+	if !user.TwoFactorEnabled {
+		return nil
+	}
+
+	if code == "" {
+		return errors.ErrTwoFactorRequired
+	}
+
+	// Authenticator app code, claimed so concurrent logins can't both use it
+	if user.TwoFactorSecret != nil {
+		if step, ok := utils.ValidateTOTPCode(*user.TwoFactorSecret, code, user.TwoFactorLastStep); ok {
+			used, err := s.userRepo.UseTOTPStep(ctx, user.ID, step)
+			if err != nil {
+				return err
+			}
+			if !used {
+				return errors.ErrInvalidTwoFactorCode
+			}
+			user.TwoFactorLastStep = step
+			return nil
+		}
+	}
+
+	// Recovery code (single use)
+	// The codes are only replaced if no other code was used since they were read, then they are read again
+	codeHash := utils.HashRecoveryCode(code)
+	for attempt := 0; attempt < recoveryCodeAttempts; attempt++ {
+		i := indexOfHash(user.TwoFactorRecoveryCodes, codeHash)
+		if i < 0 {
+			return errors.ErrInvalidTwoFactorCode
+		}
+
+		remaining := make([]string, 0, len(user.TwoFactorRecoveryCodes)-1)
+		remaining = append(remaining, user.TwoFactorRecoveryCodes[:i]...)
+		remaining = append(remaining, user.TwoFactorRecoveryCodes[i+1:]...)
+
+		used, err := s.userRepo.UseRecoveryCode(ctx, user.ID, user.TwoFactorRecoveryCodes, remaining)
+		if err != nil {
+			return err
+		}
+		if used {
+			user.TwoFactorRecoveryCodes = remaining
+			log.Printf("[AuthService] Recovery code used for user %s (%d remaining)", user.ID, len(remaining))
+			return nil
+		}
+
+		current, err := s.userRepo.GetUserByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		user.TwoFactorRecoveryCodes = current.TwoFactorRecoveryCodes
+	}
+
+	return errors.ErrInvalidTwoFactorCode
+}
+
+// indexOfHash returns the position of a recovery code hash, -1 if it isn't there
+func indexOfHash(hashes []string, hash string) int {
+	for i, stored := range hashes {
+		if stored == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// VerifyTwoFactorLogin completes a login that was paused for a second factor
+func (s *AuthService) VerifyTwoFactorLogin(ctx context.Context, req *models.VerifyTwoFactorLoginRequest) (*models.AuthResponse, error) {
+	claims, err := s.jwtSvc.ValidateChallengeToken(req.ChallengeToken, twoFactorChallengePurpose)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	if !user.IsActive {
+		return nil, errors.ErrUserInactive
+	}
+
+	// Counted before checking, so concurrent guesses can't get past the limit
+	attempts, err := s.countChallengeAttempt(ctx, req.ChallengeToken, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if attempts > maxChallengeCodeAttempts {
+		s.revokeChallengeToken(req.ChallengeToken)
+		return nil, errors.ErrTooManyTwoFactorCodes
+	}
+
+	if err := s.VerifySecondFactor(ctx, user, req.Code); err != nil {
+		if attempts == maxChallengeCodeAttempts {
+			s.revokeChallengeToken(req.ChallengeToken)
+			log.Printf("[AuthService] 2FA challenge for user %s revoked after %d invalid codes", user.ID, attempts)
+		}
+		return nil, err
+	}
+
+	// Challenge tokens are single-use
+	s.revokeChallengeToken(req.ChallengeToken)
+
+	return s.completeLogin(ctx, user)
+}
+
+// completeLogin marks a login as successful once every required factor has been verified
+// The handler creates the session and issues the tokens
+func (s *AuthService) completeLogin(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	s.userRepo.UpdateLastLogin(ctx, user.ID)
+
+	return &models.AuthResponse{
+		Success:      true,
+		Message:      "Login successful",
+		User:         user.ToSafeUser(),
+		IssueSession: true,
+	}, nil
+}
+
+// countChallengeAttempt records a code tried against a 2FA challenge and returns how many were tried so far
+// Counted in Redis when available so every replica shares the count, in memory otherwise
+func (s *AuthService) countChallengeAttempt(ctx context.Context, challengeToken string, expiresAt time.Time) (int, error) {
+	hash := sha256.Sum256([]byte(challengeToken))
+	key := "2fa_attempts:" + hex.EncodeToString(hash[:])
+
+	if s.redis != nil {
+		count, err := s.redis.Incr(ctx, key).Result()
+		if err != nil {
+			log.Printf("[AuthService] Failed to count 2FA attempt: %v", err)
+			return 0, errors.ErrInternalServer
+		}
+		if count == 1 {
+			s.redis.ExpireAt(ctx, key, expiresAt)
+		}
+		return int(count), nil
+	}
+
+	s.challengeAttemptsMu.Lock()
+	defer s.challengeAttemptsMu.Unlock()
+
+	now := time.Now()
+	for k, attempts := range s.challengeAttempts {
+		if now.After(attempts.expiresAt) {
+			delete(s.challengeAttempts, k)
+		}
+	}
+
+	attempts := s.challengeAttempts[key]
+	attempts.count++
+	attempts.expiresAt = expiresAt
+	s.challengeAttempts[key] = attempts
+	return attempts.count, nil
+}
+
+// revokeChallengeToken blacklists a 2FA challenge token once it has been exchanged
+func (s *AuthService) revokeChallengeToken(challengeToken string) {
+	if s.blacklist == nil {
+		return
+	}
+	if claims, err := s.jwtSvc.ValidateChallengeToken(challengeToken, twoFactorChallengePurpose); err == nil {
+		s.blacklist.Add(challengeToken, claims.ExpiresAt.Time)
+	}
+}
+
+// RequireSecondFactor replaces a completed login response with a 2FA challenge when the user has 2FA enabled
+// Used for login paths (e.g. OAuth) that authenticate the user before this service sees them
+func (s *AuthService) RequireSecondFactor(ctx context.Context, response *models.AuthResponse) (*models.AuthResponse, error) {
+	if response == nil || response.User == nil || !response.IssueSession {
+		return response, nil
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, response.User.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TwoFactorEnabled {
+		return response, nil
+	}
+
+	return s.twoFactorChallenge(user)
+}
+
+// twoFactorChallenge builds the response for the first step of a 2FA login
+func (s *AuthService) twoFactorChallenge(user *models.User) (*models.AuthResponse, error) {
+	challengeToken, err := s.jwtSvc.GenerateChallengeToken(user, twoFactorChallengePurpose, twoFactorChallengeTTL)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	return &models.AuthResponse{
+		Success:           true,
+		Message:           "Two-factor authentication required",
+		RequiresTwoFactor: true,
+		ChallengeToken:    challengeToken,
+		ExpiresAt:         time.Now().Add(twoFactorChallengeTTL),
+		UserID:            user.ID.String(),
+	}, nil
+}
+
+// generateRecoveryCodes returns plaintext recovery codes and their storage hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashRecoveryCode(code)
+	}
+
+	return codes, hashes, nil
+}
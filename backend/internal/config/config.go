@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -21,6 +22,10 @@ type Config struct {
 	LinkedIn  LinkedInConfig  `mapstructure:"linkedin"`
 	Storage   StorageConfig   `mapstructure:"storage"`
 	Redis     RedisConfig     `mapstructure:"redis"`
+	WebAuthn  WebAuthnConfig  `mapstructure:"webauthn"`
+	Push      PushConfig      `mapstructure:"push"`
+
+	LinkPreview LinkPreviewConfig `mapstructure:"link_preview"`
 }
 
 type DatabaseConfig struct {
@@ -33,6 +38,36 @@ type StorageConfig struct {
 	BucketName       string `mapstructure:"bucket_name"`
 	MaxFileSize      int64  `mapstructure:"max_file_size"`
 	AllowedFileTypes string `mapstructure:"allowed_file_types"`
+
+	Driver          string `mapstructure:"driver"`            // supabase, local or s3
+	PrivateBuckets  string `mapstructure:"private_buckets"`   // Comma-separated buckets only reachable through signed URLs
+	SignedURLExpiry string `mapstructure:"signed_url_expiry"` // How long signed URLs stay valid
+	LocalDir        string `mapstructure:"local_dir"`         // Root directory of the local driver
+	PublicURL       string `mapstructure:"public_url"`        // Base URL objects are served from (local driver, or a CDN in front of S3)
+
+	S3Endpoint        string `mapstructure:"s3_endpoint"` // e.g. https://s3.amazonaws.com or http://localhost:9000 for MinIO
+	S3Region          string `mapstructure:"s3_region"`
+	S3Bucket          string `mapstructure:"s3_bucket"` // Every storage bucket is a key prefix in this S3 bucket
+	S3AccessKeyID     string `mapstructure:"s3_access_key_id"`
+	S3SecretAccessKey string `mapstructure:"s3_secret_access_key"`
+	S3ForcePathStyle  bool   `mapstructure:"s3_force_path_style"` // Required by MinIO
+
+	UploadChunkSize   int64  `mapstructure:"upload_chunk_size"`   // Largest chunk a resumable upload accepts per request
+	UploadSessionTTL  string `mapstructure:"upload_session_ttl"`  // Resumable uploads without progress for this long are deleted
+	UploadPartsBucket string `mapstructure:"upload_parts_bucket"` // Private bucket holding chunks until an upload is completed
+
+	ImageAVIFEncoder string `mapstructure:"image_avif_encoder"` // Path of avifenc, image variants are also stored as AVIF when set
+
+	FFmpegPath       string `mapstructure:"ffmpeg_path"` // Video and audio processing is disabled if ffmpeg can't be found
+	FFprobePath      string `mapstructure:"ffprobe_path"`
+	MediaWorkers     int    `mapstructure:"media_workers"`      // Concurrent video and audio processing jobs
+	MediaMaxAttempts int    `mapstructure:"media_max_attempts"` // Attempts before a processing job is marked failed
+	MediaGCGrace     string `mapstructure:"media_gc_grace"`     // Media unreferenced for this long is deleted
+
+	ClamdAddress       string `mapstructure:"clamd_address"`         // unix:///path/to/clamd.sock or tcp://host:3310, malware scanning is disabled when empty
+	ClamdTimeout       string `mapstructure:"clamd_timeout"`         // How long one scan may take
+	UploadScanFailOpen bool   `mapstructure:"upload_scan_fail_open"` // Accept uploads unscanned while the scanner is unreachable
+	QuarantineBucket   string `mapstructure:"quarantine_bucket"`     // Private bucket holding rejected uploads for review
 }
 
 type JWTConfig struct {
@@ -49,6 +84,14 @@ type EmailConfig struct {
 	FromName    string `mapstructure:"from_name"`
 	FromEmail   string `mapstructure:"from_email"`
 	FrontendURL string `mapstructure:"frontend_url"`
+
+	Transport     string `mapstructure:"transport"`      // smtp, file, sendgrid, postmark or resend
+	FileDir       string `mapstructure:"file_dir"`       // Where the file transport writes .eml files (development)
+	APIKey        string `mapstructure:"api_key"`        // API key for the HTTP providers
+	WebhookSecret string `mapstructure:"webhook_secret"` // Token providers send with bounce/complaint webhooks
+	Workers       int    `mapstructure:"workers"`        // Concurrent outbox sends
+	MaxAttempts   int    `mapstructure:"max_attempts"`   // Sends before an email is marked failed
+	DevRoutes     bool   `mapstructure:"dev_routes"`     // Mount the outbox routes for admins, off unless explicitly enabled
 }
 
 type ServerConfig struct {
@@ -56,14 +99,23 @@ type ServerConfig struct {
 	GinMode            string `mapstructure:"gin_mode"`
 	CORSAllowedOrigins string `mapstructure:"cors_allowed_origins"`
 	DataProvider       string `mapstructure:"data_provider"`
+	ClusterMode        bool   `mapstructure:"cluster_mode"`    // Share websocket fan-out, presence and ACK state through Redis
+	EventRetention     string `mapstructure:"event_retention"` // How long websocket events are kept for replay on reconnect
+	AdminUserIDs       string `mapstructure:"admin_user_ids"`  // Comma-separated IDs of users allowed on admin routes
 }
 
 type RateLimitConfig struct {
-	Login       int    `mapstructure:"login"`
-	Register    int    `mapstructure:"register"`
-	Reset       int    `mapstructure:"reset"`
-	Window      string `mapstructure:"window"`
-	Forgiveness int    `mapstructure:"forgiveness"`
+	Login        int    `mapstructure:"login"`
+	Register     int    `mapstructure:"register"`
+	Reset        int    `mapstructure:"reset"`
+	Window       string `mapstructure:"window"`
+	Forgiveness  int    `mapstructure:"forgiveness"`
+	API          int    `mapstructure:"api"`           // General quota per user (or IP) for all other endpoints
+	Write        int    `mapstructure:"write"`         // Creating posts, comments and messages
+	Search       int    `mapstructure:"search"`        // Search endpoints
+	Burst        int    `mapstructure:"burst"`         // Burst allowance for the api, write and search quotas
+	Upload       int    `mapstructure:"upload"`        // Media uploads per upload window
+	UploadWindow string `mapstructure:"upload_window"` // Uploads are expensive, so they get a longer window
 }
 
 type GoogleConfig struct {
@@ -91,6 +143,26 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db"`
 }
 
+type WebAuthnConfig struct {
+	RPID          string `mapstructure:"rp_id"`           // Relying party ID (registrable domain, e.g. "example.com")
+	RPDisplayName string `mapstructure:"rp_display_name"` // Shown by the browser/authenticator during ceremonies
+	RPOrigins     string `mapstructure:"rp_origins"`      // Comma-separated list of allowed origins
+}
+
+type PushConfig struct {
+	VAPIDPublicKey  string `mapstructure:"vapid_public_key"`  // Base64url uncompressed P-256 public key, handed to browsers
+	VAPIDPrivateKey string `mapstructure:"vapid_private_key"` // Base64url P-256 private key (32 bytes)
+	VAPIDSubject    string `mapstructure:"vapid_subject"`     // Contact for push services ("mailto:" or "https:" URL)
+}
+
+type LinkPreviewConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Timeout   string `mapstructure:"timeout"`    // Longest a post or message waits for its previews
+	CacheTTL  string `mapstructure:"cache_ttl"`  // How long fetched previews are reused
+	MaxLinks  int    `mapstructure:"max_links"`  // Links previewed per post or message
+	UserAgent string `mapstructure:"user_agent"` // Sent when fetching pages, some sites only serve Open Graph tags to known crawlers
+}
+
 // LoadConfig loads configuration from environment variables and .env file
 func LoadConfig() (*Config, error) {
 	// Load .env file if it exists
@@ -101,8 +173,8 @@ func LoadConfig() (*Config, error) {
 	// Set default values
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.gin_mode", "debug")
-	viper.SetDefault("jwt.expiry", "720h")         // 30 days (720 hours)
-	viper.SetDefault("jwt.refresh_expiry", "720h") // Same as expiry for sliding window
+	viper.SetDefault("jwt.expiry", "15m")          // Access token lifetime
+	viper.SetDefault("jwt.refresh_expiry", "720h") // Refresh token lifetime (30 days, extended on every rotation)
 	viper.SetDefault("email.host", "smtp.gmail.com")
 	viper.SetDefault("email.port", 587)
 	viper.SetDefault("rate_limit.login", 5)
@@ -110,14 +182,44 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("rate_limit.reset", 3)
 	viper.SetDefault("rate_limit.window", "1m")
 	viper.SetDefault("rate_limit.forgiveness", 2)
+	viper.SetDefault("rate_limit.api", 300)
+	viper.SetDefault("rate_limit.write", 30)
+	viper.SetDefault("rate_limit.search", 60)
+	viper.SetDefault("rate_limit.burst", 20)
+	viper.SetDefault("rate_limit.upload", 30)
+	viper.SetDefault("rate_limit.upload_window", "1h")
+	viper.SetDefault("server.event_retention", "24h")
 	viper.SetDefault("email.frontend_url", "http://localhost:3001")
 	viper.SetDefault("storage.bucket_name", "profile-pictures")
 	viper.SetDefault("storage.max_file_size", 5242880) // 5MB
 	viper.SetDefault("storage.allowed_file_types", "image/jpeg,image/png,image/gif,image/webp")
+	viper.SetDefault("storage.driver", "supabase")
+	viper.SetDefault("storage.private_buckets", "chat-attachments")
+	viper.SetDefault("storage.signed_url_expiry", "1h")
+	viper.SetDefault("storage.local_dir", "uploads")
+	viper.SetDefault("storage.s3_region", "us-east-1")
+	viper.SetDefault("storage.s3_force_path_style", true)
+	viper.SetDefault("storage.upload_chunk_size", 8388608) // 8MB
+	viper.SetDefault("storage.upload_session_ttl", "24h")
+	viper.SetDefault("storage.upload_parts_bucket", "upload-parts")
+	viper.SetDefault("storage.ffmpeg_path", "ffmpeg")
+	viper.SetDefault("storage.ffprobe_path", "ffprobe")
+	viper.SetDefault("storage.media_workers", 1)
+	viper.SetDefault("storage.media_max_attempts", 3)
+	viper.SetDefault("storage.media_gc_grace", "720h")
+	viper.SetDefault("storage.clamd_timeout", "30s")
+	viper.SetDefault("storage.quarantine_bucket", "quarantine")
 	viper.SetDefault("redis.host", "localhost")
 	viper.SetDefault("redis.port", "6379")
 	viper.SetDefault("redis.password", "")
 	viper.SetDefault("redis.db", 0)
+	viper.SetDefault("webauthn.rp_id", "localhost")
+	viper.SetDefault("webauthn.rp_display_name", "Asteria")
+	viper.SetDefault("link_preview.enabled", true)
+	viper.SetDefault("link_preview.timeout", "3s")
+	viper.SetDefault("link_preview.cache_ttl", "24h")
+	viper.SetDefault("link_preview.max_links", 3)
+	viper.SetDefault("link_preview.user_agent", "Mozilla/5.0 (compatible; UpVistaBot/1.0; link previews)")
 
 	// Set environment variable prefix
 	viper.SetEnvPrefix("")
@@ -137,14 +239,30 @@ func LoadConfig() (*Config, error) {
 	viper.BindEnv("email.from_name", "SMTP_FROM_NAME")
 	viper.BindEnv("email.from_email", "SMTP_FROM_EMAIL")
 	viper.BindEnv("email.frontend_url", "FRONTEND_URL")
+	viper.BindEnv("email.transport", "EMAIL_TRANSPORT")
+	viper.BindEnv("email.file_dir", "EMAIL_FILE_DIR")
+	viper.BindEnv("email.api_key", "EMAIL_API_KEY")
+	viper.BindEnv("email.webhook_secret", "EMAIL_WEBHOOK_SECRET")
+	viper.BindEnv("email.workers", "EMAIL_WORKERS")
+	viper.BindEnv("email.max_attempts", "EMAIL_MAX_ATTEMPTS")
+	viper.BindEnv("email.dev_routes", "EMAIL_DEV_ROUTES")
 	viper.BindEnv("server.port", "PORT")
 	viper.BindEnv("server.gin_mode", "GIN_MODE")
 	viper.BindEnv("server.cors_allowed_origins", "CORS_ALLOWED_ORIGINS")
+	viper.BindEnv("server.cluster_mode", "CLUSTER_MODE")
+	viper.BindEnv("server.event_retention", "WS_EVENT_RETENTION")
+	viper.BindEnv("server.admin_user_ids", "ADMIN_USER_IDS")
 	viper.BindEnv("rate_limit.login", "RATE_LIMIT_LOGIN")
 	viper.BindEnv("rate_limit.register", "RATE_LIMIT_REGISTER")
 	viper.BindEnv("rate_limit.reset", "RATE_LIMIT_RESET")
 	viper.BindEnv("rate_limit.window", "RATE_LIMIT_WINDOW")
 	viper.BindEnv("rate_limit.forgiveness", "RATE_LIMIT_FORGIVENESS")
+	viper.BindEnv("rate_limit.api", "RATE_LIMIT_API")
+	viper.BindEnv("rate_limit.write", "RATE_LIMIT_WRITE")
+	viper.BindEnv("rate_limit.search", "RATE_LIMIT_SEARCH")
+	viper.BindEnv("rate_limit.burst", "RATE_LIMIT_BURST")
+	viper.BindEnv("rate_limit.upload", "RATE_LIMIT_UPLOAD")
+	viper.BindEnv("rate_limit.upload_window", "RATE_LIMIT_UPLOAD_WINDOW")
 	viper.BindEnv("google.client_id", "GOOGLE_CLIENT_ID")
 	viper.BindEnv("google.client_secret", "GOOGLE_CLIENT_SECRET")
 	viper.BindEnv("google.redirect_url", "GOOGLE_REDIRECT_URL")
@@ -157,10 +275,45 @@ func LoadConfig() (*Config, error) {
 	viper.BindEnv("storage.bucket_name", "STORAGE_BUCKET_NAME")
 	viper.BindEnv("storage.max_file_size", "STORAGE_MAX_FILE_SIZE")
 	viper.BindEnv("storage.allowed_file_types", "STORAGE_ALLOWED_FILE_TYPES")
+	viper.BindEnv("storage.driver", "STORAGE_DRIVER")
+	viper.BindEnv("storage.private_buckets", "STORAGE_PRIVATE_BUCKETS")
+	viper.BindEnv("storage.signed_url_expiry", "STORAGE_SIGNED_URL_EXPIRY")
+	viper.BindEnv("storage.local_dir", "STORAGE_LOCAL_DIR")
+	viper.BindEnv("storage.public_url", "STORAGE_PUBLIC_URL")
+	viper.BindEnv("storage.s3_endpoint", "S3_ENDPOINT")
+	viper.BindEnv("storage.s3_region", "S3_REGION")
+	viper.BindEnv("storage.s3_bucket", "S3_BUCKET")
+	viper.BindEnv("storage.s3_access_key_id", "S3_ACCESS_KEY_ID")
+	viper.BindEnv("storage.s3_secret_access_key", "S3_SECRET_ACCESS_KEY")
+	viper.BindEnv("storage.s3_force_path_style", "S3_FORCE_PATH_STYLE")
+	viper.BindEnv("storage.upload_chunk_size", "UPLOAD_CHUNK_SIZE")
+	viper.BindEnv("storage.upload_session_ttl", "UPLOAD_SESSION_TTL")
+	viper.BindEnv("storage.upload_parts_bucket", "UPLOAD_PARTS_BUCKET")
+	viper.BindEnv("storage.image_avif_encoder", "IMAGE_AVIF_ENCODER")
+	viper.BindEnv("storage.ffmpeg_path", "FFMPEG_PATH")
+	viper.BindEnv("storage.ffprobe_path", "FFPROBE_PATH")
+	viper.BindEnv("storage.media_workers", "MEDIA_WORKERS")
+	viper.BindEnv("storage.media_max_attempts", "MEDIA_MAX_ATTEMPTS")
+	viper.BindEnv("storage.media_gc_grace", "MEDIA_GC_GRACE")
+	viper.BindEnv("storage.clamd_address", "CLAMD_ADDRESS")
+	viper.BindEnv("storage.clamd_timeout", "CLAMD_TIMEOUT")
+	viper.BindEnv("storage.upload_scan_fail_open", "UPLOAD_SCAN_FAIL_OPEN")
+	viper.BindEnv("storage.quarantine_bucket", "QUARANTINE_BUCKET")
 	viper.BindEnv("redis.host", "REDIS_HOST")
 	viper.BindEnv("redis.port", "REDIS_PORT")
 	viper.BindEnv("redis.password", "REDIS_PASSWORD")
 	viper.BindEnv("redis.db", "REDIS_DB")
+	viper.BindEnv("webauthn.rp_id", "WEBAUTHN_RP_ID")
+	viper.BindEnv("webauthn.rp_display_name", "WEBAUTHN_RP_DISPLAY_NAME")
+	viper.BindEnv("webauthn.rp_origins", "WEBAUTHN_RP_ORIGINS")
+	viper.BindEnv("push.vapid_public_key", "VAPID_PUBLIC_KEY")
+	viper.BindEnv("push.vapid_private_key", "VAPID_PRIVATE_KEY")
+	viper.BindEnv("push.vapid_subject", "VAPID_SUBJECT")
+	viper.BindEnv("link_preview.enabled", "LINK_PREVIEW_ENABLED")
+	viper.BindEnv("link_preview.timeout", "LINK_PREVIEW_TIMEOUT")
+	viper.BindEnv("link_preview.cache_ttl", "LINK_PREVIEW_CACHE_TTL")
+	viper.BindEnv("link_preview.max_links", "LINK_PREVIEW_MAX_LINKS")
+	viper.BindEnv("link_preview.user_agent", "LINK_PREVIEW_USER_AGENT")
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
@@ -182,8 +335,21 @@ func validateConfig(config *Config) error {
 		"SUPABASE_ANON_KEY":         config.Database.SupabaseAnonKey,
 		"SUPABASE_SERVICE_ROLE_KEY": config.Database.SupabaseServiceKey,
 		"JWT_SECRET":                config.JWT.Secret,
-		"SMTP_USERNAME":             config.Email.Username,
-		"SMTP_PASSWORD":             config.Email.Password,
+	}
+
+	// SMTP credentials are only needed when sending through SMTP
+	switch config.GetEmailTransport() {
+	case "smtp":
+		requiredFields["SMTP_USERNAME"] = config.Email.Username
+		requiredFields["SMTP_PASSWORD"] = config.Email.Password
+	case "sendgrid", "postmark", "resend":
+		requiredFields["EMAIL_API_KEY"] = config.Email.APIKey
+	case "file":
+	default:
+		return &ConfigError{
+			Field: "EMAIL_TRANSPORT",
+			Msg:   "must be one of smtp, file, sendgrid, postmark or resend",
+		}
 	}
 
 	for field, value := range requiredFields {
@@ -195,6 +361,38 @@ func validateConfig(config *Config) error {
 		}
 	}
 
+	// S3 needs an endpoint, a bucket and credentials
+	switch config.GetStorageDriver() {
+	case "supabase", "local":
+	case "s3":
+		s3Fields := map[string]string{
+			"S3_ENDPOINT":          config.Storage.S3Endpoint,
+			"S3_BUCKET":            config.Storage.S3Bucket,
+			"S3_ACCESS_KEY_ID":     config.Storage.S3AccessKeyID,
+			"S3_SECRET_ACCESS_KEY": config.Storage.S3SecretAccessKey,
+		}
+		for field, value := range s3Fields {
+			if value == "" {
+				return &ConfigError{
+					Field: field,
+					Msg:   "required when STORAGE_DRIVER is s3",
+				}
+			}
+		}
+	default:
+		return &ConfigError{
+			Field: "STORAGE_DRIVER",
+			Msg:   "must be one of supabase, local or s3",
+		}
+	}
+
+	if config.Storage.UploadChunkSize <= 0 {
+		return &ConfigError{
+			Field: "UPLOAD_CHUNK_SIZE",
+			Msg:   "must be a positive number of bytes",
+		}
+	}
+
 	// Validate JWT secret length
 	if len(config.JWT.Secret) < 32 {
 		return &ConfigError{
@@ -214,6 +412,175 @@ func (c *Config) GetCORSOrigins() []string {
 	return strings.Split(c.Server.CORSAllowedOrigins, ",")
 }
 
+// GetAccessTokenExpiry returns the access token lifetime, defaulting to 15 minutes
+func (c *Config) GetAccessTokenExpiry() time.Duration {
+	if d, err := time.ParseDuration(c.JWT.Expiry); err == nil && d > 0 {
+		return d
+	}
+	return 15 * time.Minute
+}
+
+// GetRefreshTokenExpiry returns the refresh token lifetime, defaulting to 30 days
+func (c *Config) GetRefreshTokenExpiry() time.Duration {
+	if d, err := time.ParseDuration(c.JWT.RefreshExpiry); err == nil && d > 0 {
+		return d
+	}
+	return 30 * 24 * time.Hour
+}
+
+// GetRateLimitWindow returns the window for the rate limit quotas, defaulting to 1 minute
+func (c *Config) GetRateLimitWindow() time.Duration {
+	if d, err := time.ParseDuration(c.RateLimit.Window); err == nil && d > 0 {
+		return d
+	}
+	return time.Minute
+}
+
+// GetUploadRateLimitWindow returns the window for the media upload quota, defaulting to 1 hour
+func (c *Config) GetUploadRateLimitWindow() time.Duration {
+	if d, err := time.ParseDuration(c.RateLimit.UploadWindow); err == nil && d > 0 {
+		return d
+	}
+	return time.Hour
+}
+
+// GetEventRetention returns how long websocket events are kept for replay, defaulting to 24 hours
+func (c *Config) GetEventRetention() time.Duration {
+	if d, err := time.ParseDuration(c.Server.EventRetention); err == nil && d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// GetAdminUserIDs returns the IDs of users allowed on admin routes
+func (c *Config) GetAdminUserIDs() []string {
+	var ids []string
+	for _, id := range strings.Split(c.Server.AdminUserIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// GetWebAuthnOrigins returns the origins allowed to perform WebAuthn ceremonies
+// Falls back to the frontend URL when no origins are configured
+func (c *Config) GetWebAuthnOrigins() []string {
+	if c.WebAuthn.RPOrigins == "" {
+		return []string{c.Email.FrontendURL}
+	}
+
+	origins := strings.Split(c.WebAuthn.RPOrigins, ",")
+	for i := range origins {
+		origins[i] = strings.TrimSpace(origins[i])
+	}
+	return origins
+}
+
+// PushEnabled reports whether Web Push is configured with a VAPID key pair
+func (c *Config) PushEnabled() bool {
+	return c.Push.VAPIDPublicKey != "" && c.Push.VAPIDPrivateKey != ""
+}
+
+// GetVAPIDSubject returns the VAPID contact, defaulting to the frontend URL
+func (c *Config) GetVAPIDSubject() string {
+	if c.Push.VAPIDSubject != "" {
+		return c.Push.VAPIDSubject
+	}
+	return c.Email.FrontendURL
+}
+
+// GetEmailTransport returns the normalized email transport name, defaulting to smtp
+func (c *Config) GetEmailTransport() string {
+	if transport := strings.ToLower(strings.TrimSpace(c.Email.Transport)); transport != "" {
+		return transport
+	}
+	return "smtp"
+}
+
+// GetStorageDriver returns the normalized storage driver name, defaulting to supabase
+func (c *Config) GetStorageDriver() string {
+	if driver := strings.ToLower(strings.TrimSpace(c.Storage.Driver)); driver != "" {
+		return driver
+	}
+	return "supabase"
+}
+
+// GetPrivateBuckets returns the storage buckets only reachable through signed URLs
+// The resumable upload parts bucket and the quarantine bucket are always private
+func (c *Config) GetPrivateBuckets() []string {
+	var buckets []string
+	for _, bucket := range strings.Split(c.Storage.PrivateBuckets, ",") {
+		if bucket = strings.TrimSpace(bucket); bucket != "" && bucket != c.Storage.UploadPartsBucket && bucket != c.Storage.QuarantineBucket {
+			buckets = append(buckets, bucket)
+		}
+	}
+	if c.Storage.UploadPartsBucket != "" {
+		buckets = append(buckets, c.Storage.UploadPartsBucket)
+	}
+	if c.Storage.QuarantineBucket != "" && c.Storage.QuarantineBucket != c.Storage.UploadPartsBucket {
+		buckets = append(buckets, c.Storage.QuarantineBucket)
+	}
+	return buckets
+}
+
+// GetSignedURLExpiry returns how long signed storage URLs stay valid, defaulting to 1 hour
+func (c *Config) GetSignedURLExpiry() time.Duration {
+	if d, err := time.ParseDuration(c.Storage.SignedURLExpiry); err == nil && d > 0 {
+		return d
+	}
+	return time.Hour
+}
+
+// GetUploadSessionTTL returns how long a resumable upload survives without progress, defaulting to 24 hours
+func (c *Config) GetUploadSessionTTL() time.Duration {
+	if d, err := time.ParseDuration(c.Storage.UploadSessionTTL); err == nil && d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// GetMediaGCGrace returns how long media stays stored without any post, message or event using it,
+// defaulting to 30 days so deleted posts keep their media while they can still be viewed
+func (c *Config) GetMediaGCGrace() time.Duration {
+	if d, err := time.ParseDuration(c.Storage.MediaGCGrace); err == nil && d > 0 {
+		return d
+	}
+	return 30 * 24 * time.Hour
+}
+
+// GetClamdTimeout returns how long a malware scan may take, defaulting to 30 seconds
+func (c *Config) GetClamdTimeout() time.Duration {
+	if d, err := time.ParseDuration(c.Storage.ClamdTimeout); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// GetLinkPreviewTimeout returns how long creating a post or message waits for link previews, defaulting to 3 seconds
+func (c *Config) GetLinkPreviewTimeout() time.Duration {
+	if d, err := time.ParseDuration(c.LinkPreview.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return 3 * time.Second
+}
+
+// GetLinkPreviewCacheTTL returns how long fetched link previews are reused, defaulting to 24 hours
+func (c *Config) GetLinkPreviewCacheTTL() time.Duration {
+	if d, err := time.ParseDuration(c.LinkPreview.CacheTTL); err == nil && d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// GetStoragePublicURL returns the base URL the local driver serves objects from, defaulting to this server
+func (c *Config) GetStoragePublicURL() string {
+	if c.Storage.PublicURL != "" {
+		return strings.TrimRight(c.Storage.PublicURL, "/")
+	}
+	return "http://localhost:" + c.Server.Port
+}
+
 // ConfigError represents a configuration error
 type ConfigError struct {
 	Field string
@@ -15,8 +15,14 @@ import (
 
 // Service handles course business logic
 type Service struct {
-	courseRepo repository.CourseRepository
-	userRepo   repository.UserRepository
+	courseRepo          repository.CourseRepository
+	userRepo            repository.UserRepository
+	notificationService NotificationService // Optional, set after initialization
+}
+
+// NotificationService interface for creating notifications (avoid circular dependency)
+type NotificationService interface {
+	CreateCourseEnrollmentNotification(ctx context.Context, studentID, creatorID, courseID uuid.UUID, courseTitle, courseSlug string) error
 }
 
 // NewService creates a new course service
@@ -30,6 +36,11 @@ func NewService(
 	}
 }
 
+// SetNotificationService enables notifying course creators about enrollments
+func (s *Service) SetNotificationService(notificationService NotificationService) {
+	s.notificationService = notificationService
+}
+
 // CreateCourse creates a new course
 func (s *Service) CreateCourse(ctx context.Context, req *models.CreateCourseRequest, creatorID uuid.UUID) (*models.Course, error) {
 	// Generate slug from title
@@ -175,6 +186,12 @@ func (s *Service) EnrollInCourse(ctx context.Context, courseID, userID uuid.UUID
 		return nil, fmt.Errorf("failed to enroll: %w", err)
 	}
 
+	if s.notificationService != nil {
+		if err := s.notificationService.CreateCourseEnrollmentNotification(ctx, userID, course.CreatorID, course.ID, course.Title, course.Slug); err != nil {
+			log.Printf("[Courses] Failed to notify creator about enrollment: %v", err)
+		}
+	}
+
 	return enrollment, nil
 }
 
@@ -1,11 +1,12 @@
 package events
 
 import (
-	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
 
+	"upvista-community-backend/internal/media"
 	"upvista-community-backend/internal/models"
 	"upvista-community-backend/internal/utils"
 	"upvista-community-backend/pkg/errors"
@@ -18,13 +19,15 @@ import (
 type Handlers struct {
 	service    *Service
 	storageSvc *utils.StorageService
+	mediaSvc   *media.Service
 }
 
 // NewHandlers creates new event handlers
-func NewHandlers(service *Service, storageSvc *utils.StorageService) *Handlers {
+func NewHandlers(service *Service, storageSvc *utils.StorageService, mediaSvc *media.Service) *Handlers {
 	return &Handlers{
 		service:    service,
 		storageSvc: storageSvc,
+		mediaSvc:   mediaSvc,
 	}
 }
 
@@ -471,6 +474,9 @@ func (h *Handlers) GetCategories(c *gin.Context) {
 	})
 }
 
+// Same limit as resumable event cover uploads
+const maxCoverImageSize = 20 * 1024 * 1024
+
 // UploadEventCoverImage handles POST /api/v1/events/upload-cover-image
 func (h *Handlers) UploadEventCoverImage(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -502,9 +508,25 @@ func (h *Handlers) UploadEventCoverImage(c *gin.Context) {
 	}
 	defer file.Close()
 
-	// Upload to Supabase Storage (event-covers bucket)
-	fileName := fmt.Sprintf("events/%s/%s_%s", uid.String(), uuid.New().String(), header.Filename)
-	uploadedURL, err := h.storageSvc.UploadFile(c.Request.Context(), "event-covers", fileName, file, header.Header.Get("Content-Type"))
+	if header.Size > maxCoverImageSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Cover image too large (max 20MB)",
+		})
+		return
+	}
+
+	fileData, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to read file",
+		})
+		return
+	}
+
+	// Render the size variants and upload them (event-covers bucket), a cover used before is reused
+	info, err := h.mediaSvc.StoreImage(c.Request.Context(), uid, "event-covers", fileData, header.Header.Get("Content-Type"), media.QualityStandard)
 	if err != nil {
 		log.Printf("[Events] Failed to upload cover image: %v", err)
 		appErr := errors.GetAppError(err)
@@ -517,7 +539,8 @@ func (h *Handlers) UploadEventCoverImage(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"url":     uploadedURL,
+		"url":     info.URL,
+		"media":   info,
 		"message": "Cover image uploaded successfully",
 	})
 }
@@ -11,6 +11,7 @@ import (
 	"upvista-community-backend/internal/models"
 	"upvista-community-backend/internal/repository"
 	"upvista-community-backend/internal/utils"
+	"upvista-community-backend/internal/websocket"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -21,6 +22,22 @@ type Service struct {
 	eventRepo repository.EventRepository
 	userRepo  repository.UserRepository
 	emailSvc  *utils.EmailService
+	wsManager *websocket.Manager // Optional, live updates for "event:<id>" topics
+
+	notificationService NotificationService // Optional, set after initialization
+	mediaReferences     MediaReferences     // Optional, keeps cover images of events from being collected
+}
+
+// MediaReferences records which events use uploaded cover images, files nothing uses are deleted
+type MediaReferences interface {
+	Attach(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID, urls ...string) error
+	Detach(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID) error
+}
+
+// NotificationService interface for creating notifications (avoid circular dependency)
+type NotificationService interface {
+	CreateEventApprovedNotification(ctx context.Context, creatorID, eventID uuid.UUID, eventTitle string) error
+	CreateEventRejectedNotification(ctx context.Context, creatorID, eventID uuid.UUID, eventTitle, reason string) error
 }
 
 // NewService creates a new event service
@@ -36,6 +53,21 @@ func NewService(
 	}
 }
 
+// SetWebSocketManager enables live updates to subscribers of "event:<id>" topics
+func (s *Service) SetWebSocketManager(wsManager *websocket.Manager) {
+	s.wsManager = wsManager
+}
+
+// SetNotificationService enables in-app notifications of approval decisions
+func (s *Service) SetNotificationService(notificationService NotificationService) {
+	s.notificationService = notificationService
+}
+
+// SetMediaReferences records the cover image of events, and releases it when it is replaced or the event deleted
+func (s *Service) SetMediaReferences(mediaReferences MediaReferences) {
+	s.mediaReferences = mediaReferences
+}
+
 // ============================================
 // EVENT CREATION & APPROVAL
 // ============================================
@@ -123,6 +155,8 @@ func (s *Service) CreateEvent(ctx context.Context, req *models.CreateEventReques
 	}
 	log.Printf("[Events] Event created successfully: ID=%s", event.ID)
 
+	s.attachCover(ctx, event)
+
 	// If approval required, create approval request AFTER event is created
 	if requiresApproval {
 		approvalToken, err := generateApprovalToken()
@@ -218,15 +252,40 @@ func (s *Service) ApproveEvent(ctx context.Context, token string, adminID uuid.U
 		return fmt.Errorf("failed to update event: %w", err)
 	}
 
+	s.broadcastEventUpdated(event)
+
 	// Send notification email to creator
 	statusPtr := &req.Status
 	if err := s.sendApprovalDecisionEmail(ctx, event, statusPtr, req.RejectionReason); err != nil {
 		log.Printf("Warning: failed to send approval decision email: %v", err)
 	}
 
+	s.notifyApprovalDecision(ctx, event)
+
 	return nil
 }
 
+// notifyApprovalDecision tells the event's creator in-app whether it was approved
+func (s *Service) notifyApprovalDecision(ctx context.Context, event *models.Event) {
+	if s.notificationService == nil {
+		return
+	}
+
+	var err error
+	if event.Status == "approved" {
+		err = s.notificationService.CreateEventApprovedNotification(ctx, event.CreatorID, event.ID, event.Title)
+	} else {
+		reason := ""
+		if event.RejectionReason != nil {
+			reason = *event.RejectionReason
+		}
+		err = s.notificationService.CreateEventRejectedNotification(ctx, event.CreatorID, event.ID, event.Title, reason)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to send approval decision notification: %v", err)
+	}
+}
+
 // ============================================
 // EVENT APPLICATION & TICKET GENERATION
 // ============================================
@@ -339,6 +398,8 @@ func (s *Service) ApplyToEvent(ctx context.Context, eventID uuid.UUID, userID uu
 		return nil, fmt.Errorf("failed to create application: %w", err)
 	}
 
+	s.broadcastAttendance(ctx, eventID)
+
 	// Send ticket email
 	if email != nil {
 		if err := s.sendTicketEmail(ctx, event, application, *email); err != nil {
@@ -423,6 +484,33 @@ func getCurrencyOrDefault(currency string) string {
 	return currency
 }
 
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// attachCover records the cover image an event uses, failures only leave it unprotected from collection
+func (s *Service) attachCover(ctx context.Context, event *models.Event) {
+	if s.mediaReferences == nil || event.CoverImageURL == nil {
+		return
+	}
+	if err := s.mediaReferences.Attach(ctx, models.MediaOwnerEvent, event.ID, *event.CoverImageURL); err != nil {
+		log.Printf("[Events] Failed to record cover image of event %s: %v", event.ID, err)
+	}
+}
+
+// detachCover releases the cover image of an event
+func (s *Service) detachCover(ctx context.Context, eventID uuid.UUID) {
+	if s.mediaReferences == nil {
+		return
+	}
+	if err := s.mediaReferences.Detach(ctx, models.MediaOwnerEvent, eventID); err != nil {
+		log.Printf("[Events] Failed to release cover image of event %s: %v", eventID, err)
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -634,6 +722,8 @@ func (s *Service) UpdateEvent(ctx context.Context, eventID uuid.UUID, userID uui
 		return nil, fmt.Errorf("unauthorized: you can only update your own events")
 	}
 
+	coverChanged := stringValue(event.CoverImageURL) != stringValue(req.CoverImageURL)
+
 	// Update fields
 	event.Title = req.Title
 	event.Description = req.Description
@@ -676,6 +766,13 @@ func (s *Service) UpdateEvent(ctx context.Context, eventID uuid.UUID, userID uui
 		return nil, fmt.Errorf("failed to update event: %w", err)
 	}
 
+	if coverChanged {
+		s.detachCover(ctx, event.ID)
+		s.attachCover(ctx, event)
+	}
+
+	s.broadcastEventUpdated(event)
+
 	return event, nil
 }
 
@@ -692,7 +789,18 @@ func (s *Service) DeleteEvent(ctx context.Context, eventID uuid.UUID, userID uui
 		return fmt.Errorf("unauthorized: you can only delete your own events")
 	}
 
-	return s.eventRepo.DeleteEvent(ctx, eventID)
+	if err := s.eventRepo.DeleteEvent(ctx, eventID); err != nil {
+		return err
+	}
+
+	s.detachCover(ctx, eventID)
+
+	s.broadcastToEvent(eventID, map[string]interface{}{
+		"type":     "event_deleted",
+		"event_id": eventID,
+	})
+
+	return nil
 }
 
 // GetApplication gets user's application to an event
@@ -731,3 +839,78 @@ func (s *Service) GetCategories(ctx context.Context) ([]*models.EventCategory, e
 func (s *Service) IncrementEventViews(ctx context.Context, eventID uuid.UUID) error {
 	return s.eventRepo.IncrementEventViews(ctx, eventID)
 }
+
+// ============================================
+// REALTIME TOPICS
+// ============================================
+
+// AuthorizeEventTopic allows subscribing to "event:<id>" when the user can see the event
+// Approved public events are open to everyone, otherwise only the creator and applicants qualify
+func (s *Service) AuthorizeEventTopic(ctx context.Context, userID uuid.UUID, id string) error {
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid event ID")
+	}
+
+	event, err := s.eventRepo.GetEventByID(ctx, eventID, &userID)
+	if err != nil {
+		return fmt.Errorf("event not found: %w", err)
+	}
+
+	if event.CreatorID == userID {
+		return nil
+	}
+	if event.IsPublic && (event.Status == "approved" || event.Status == "completed") {
+		return nil
+	}
+
+	application, _ := s.eventRepo.GetApplicationByEventAndUser(ctx, eventID, userID)
+	if application == nil {
+		return fmt.Errorf("unauthorized: event is not visible to this user")
+	}
+
+	return nil
+}
+
+// broadcastToEvent sends an update to everyone viewing an event
+func (s *Service) broadcastToEvent(eventID uuid.UUID, update map[string]interface{}) {
+	if s.wsManager == nil {
+		return
+	}
+
+	topic := "event:" + eventID.String()
+	update["topic"] = topic
+	s.wsManager.BroadcastToTopicWithData(topic, update)
+}
+
+func (s *Service) broadcastEventUpdated(event *models.Event) {
+	// Drop fields specific to the user who made the change
+	shared := *event
+	shared.HasApplied = false
+	shared.Application = nil
+
+	s.broadcastToEvent(event.ID, map[string]interface{}{
+		"type":  "event_updated",
+		"event": &shared,
+	})
+}
+
+// broadcastAttendance sends the updated application counts for live attendee counters
+func (s *Service) broadcastAttendance(ctx context.Context, eventID uuid.UUID) {
+	if s.wsManager == nil {
+		return
+	}
+
+	stats, err := s.eventRepo.GetEventStats(ctx, eventID)
+	if err != nil {
+		log.Printf("Warning: failed to load event stats for live update: %v", err)
+		return
+	}
+
+	s.broadcastToEvent(eventID, map[string]interface{}{
+		"type":                  "event_attendance",
+		"event_id":              eventID,
+		"total_applications":    stats.TotalApplications,
+		"approved_applications": stats.ApprovedApplications,
+	})
+}
@@ -0,0 +1,193 @@
+package inspect
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Limits of archive inspection
+const (
+	maxArchiveEntries    = 10000
+	maxArchiveSize       = 1 << 30  // Total uncompressed bytes
+	maxCompressionRatio  = 100      // Uncompressed to compressed, beyond this an entry is a zip bomb
+	minRatioCheckSize    = 1 << 20  // Small entries (text compresses well) aren't held to the ratio
+	maxArchiveDepth      = 3        // Archives nested deeper are refused
+	maxNestedArchiveSize = 64 << 20 // Nested archives are read into memory to be inspected
+)
+
+// Content types of ZIP files
+var zipBasedTypes = map[string]bool{
+	typeZip: true, typeJavaArchive: true, typeAndroidPackage: true, "application/epub+zip": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+	"application/vnd.oasis.opendocument.text":                                   true,
+	"application/vnd.oasis.opendocument.spreadsheet":                            true,
+	"application/vnd.oasis.opendocument.presentation":                           true,
+}
+
+func isZipBased(contentType string) bool {
+	return zipBasedTypes[contentType]
+}
+
+// refineZip tells the formats built on ZIP apart by their entries: Office and OpenDocument files,
+// EPUB books, Java and Android packages. Other archives stay application/zip.
+func refineZip(zr *zip.Reader) string {
+	var contentTypes, javaManifest, javaClasses, androidManifest, dex bool
+	var office string
+	for _, f := range zr.File {
+		switch name := f.Name; {
+		case name == "mimetype":
+			// OpenDocument and EPUB store their type, uncompressed, as the first entry
+			if declared := readMimetype(f); zipBasedTypes[declared] && !strings.HasPrefix(declared, "application/java") {
+				return declared
+			}
+		case name == "[Content_Types].xml":
+			contentTypes = true
+		case name == "META-INF/MANIFEST.MF":
+			javaManifest = true
+		case strings.HasSuffix(name, ".class"):
+			javaClasses = true
+		case name == "AndroidManifest.xml":
+			androidManifest = true
+		case name == "classes.dex":
+			dex = true
+		case strings.HasPrefix(name, "word/") && office == "":
+			office = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		case strings.HasPrefix(name, "xl/") && office == "":
+			office = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		case strings.HasPrefix(name, "ppt/") && office == "":
+			office = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+		}
+	}
+
+	switch {
+	case androidManifest && dex:
+		return typeAndroidPackage
+	case javaManifest && javaClasses:
+		return typeJavaArchive
+	case contentTypes && office != "":
+		return office
+	}
+	return typeZip
+}
+
+func readMimetype(f *zip.File) string {
+	rc, err := f.Open()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(rc, 100))
+	return strings.TrimSpace(string(data))
+}
+
+// inspectZip looks into every entry of an archive, and into nested archives down to maxArchiveDepth.
+// Path traversal, links, zip bombs and programs are suspicious; encrypted entries and archives that
+// can't be opened are refused. Archive formats other than ZIP are only accepted when scanned.
+func inspectZip(zr *zip.Reader, depth int, scanned bool) *finding {
+	if len(zr.File) > maxArchiveEntries {
+		return suspicious("archive has %d entries", len(zr.File))
+	}
+
+	var total uint64
+	for _, f := range zr.File {
+		name := strings.ReplaceAll(f.Name, "\\", "/")
+		if unsafeEntryName(name) {
+			return suspicious("archive entry %q points outside the archive", f.Name)
+		}
+		if f.Mode()&os.ModeSymlink != 0 {
+			return suspicious("archive entry %q is a symbolic link", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if f.Flags&0x1 != 0 {
+			return refused("Password-protected archives can't be checked and are not allowed")
+		}
+
+		total += f.UncompressedSize64
+		if total > maxArchiveSize {
+			return suspicious("archive expands to more than %d bytes", maxArchiveSize)
+		}
+		if f.UncompressedSize64 > minRatioCheckSize && f.UncompressedSize64 > maxCompressionRatio*max(f.CompressedSize64, 1) {
+			return suspicious("archive entry %q expands %d bytes to %d (zip bomb)", f.Name, f.CompressedSize64, f.UncompressedSize64)
+		}
+		if ext := strings.ToLower(filepath.Ext(name)); executableExtensions[ext] {
+			return suspicious("archive contains a program: %q", f.Name)
+		}
+
+		if result := inspectEntry(f, depth, scanned); result != nil {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// inspectEntry sniffs an archive entry, and inspects it when it is an archive itself
+func inspectEntry(f *zip.File, depth int, scanned bool) *finding {
+	rc, err := f.Open()
+	if err != nil {
+		return refused("Archive is damaged and can't be checked")
+	}
+	defer rc.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(rc, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return refused("Archive is damaged and can't be checked")
+	}
+	head = head[:n]
+
+	detected := Detect(head)
+	switch {
+	case isExecutable(detected):
+		return suspicious("archive entry %q is a program (%s)", f.Name, detected)
+	case opaqueTypes[detected] && detected != typeOctetStream && !scanned:
+		return refused("Archive contains a %s archive that can't be checked", Extension(detected))
+	case detected != typeZip:
+		return nil
+	}
+
+	if depth >= maxArchiveDepth {
+		return suspicious("archives nested more than %d deep", maxArchiveDepth)
+	}
+	if f.UncompressedSize64 > maxNestedArchiveSize {
+		return refused("Archive contains an archive too large to be checked")
+	}
+
+	// Sizes in the headers can lie, reading stops past the limit and the nested reader fails on a short entry
+	data, err := io.ReadAll(io.LimitReader(io.MultiReader(bytes.NewReader(head), rc), maxNestedArchiveSize+1))
+	if err != nil || len(data) > maxNestedArchiveSize {
+		return refused("Archive is damaged and can't be checked")
+	}
+
+	nested, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil && !errors.Is(err, zip.ErrInsecurePath) { // Entry names are checked by inspectZip
+		return refused("Archive contains a damaged archive")
+	}
+	if nestedType := refineZip(nested); isExecutable(nestedType) {
+		return suspicious("archive entry %q is a program (%s)", f.Name, nestedType)
+	}
+	return inspectZip(nested, depth+1, scanned)
+}
+
+// unsafeEntryName reports whether extracting an entry would write outside the destination directory
+func unsafeEntryName(name string) bool {
+	if strings.HasPrefix(name, "/") || strings.ContainsRune(name, 0) || (len(name) >= 2 && name[1] == ':') {
+		return true
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package inspect
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Scanner checks files for malware
+// ClamdScanner talks to ClamAV, other engines plug in by implementing Scan
+type Scanner interface {
+	// Scan reads the file to the end and returns the name of the threat found, "" when the file is clean
+	// An error means the file couldn't be scanned
+	Scan(ctx context.Context, file io.Reader) (threat string, err error)
+}
+
+// clamdChunkSize is how much of a file is sent per INSTREAM chunk
+const clamdChunkSize = 64 * 1024
+
+// ClamdScanner scans files with a clamd daemon, streaming them over its socket (INSTREAM)
+// clamd refuses streams larger than its StreamMaxLength (25MB by default), raise it to the largest upload
+type ClamdScanner struct {
+	network string // unix or tcp
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner creates a scanner for the clamd at address: unix:///path/to/clamd.sock, tcp://host:port,
+// a socket path or host:port
+func NewClamdScanner(address string, timeout time.Duration) (*ClamdScanner, error) {
+	network, addr := "tcp", address
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, addr = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		addr = strings.TrimPrefix(address, "tcp://")
+	case strings.HasPrefix(address, "/"):
+		network = "unix"
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("invalid clamd address: %q", address)
+	}
+
+	return &ClamdScanner{network: network, address: addr, timeout: timeout}, nil
+}
+
+// Ping checks that clamd is reachable
+func (c *ClamdScanner) Ping(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return fmt.Errorf("failed to ping clamd: %w", err)
+	}
+	reply, err := readReply(conn)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected clamd reply: %q", reply)
+	}
+	return nil
+}
+
+// Scan streams a file to clamd and returns the signature it matched, "" when clean
+func (c *ClamdScanner) Scan(ctx context.Context, file io.Reader) (string, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", fmt.Errorf("failed to start clamd scan: %w", err)
+	}
+
+	// Each chunk is prefixed with its length, a zero length ends the stream
+	w := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	buf := make([]byte, clamdChunkSize)
+	var length [4]byte
+	for {
+		n, readErr := file.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(length[:], uint32(n))
+			w.Write(length[:])
+			if _, err := w.Write(buf[:n]); err != nil {
+				// clamd closes the connection when the stream is over its limit, its reply says so
+				return "", c.replyError(conn, err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return "", fmt.Errorf("failed to read file: %w", readErr)
+		}
+	}
+	binary.BigEndian.PutUint32(length[:], 0)
+	w.Write(length[:])
+	if err := w.Flush(); err != nil {
+		return "", c.replyError(conn, err)
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return "", err
+	}
+	return parseScanReply(reply)
+}
+
+func (c *ClamdScanner) dial(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+
+	deadline := time.Now().Add(c.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	return conn, nil
+}
+
+// replyError returns clamd's explanation for a failed write when it sent one
+func (c *ClamdScanner) replyError(conn net.Conn, writeErr error) error {
+	if reply, err := readReply(conn); err == nil && reply != "" {
+		return fmt.Errorf("clamd: %s", reply)
+	}
+	return fmt.Errorf("failed to send file to clamd: %w", writeErr)
+}
+
+// readReply reads a NUL-terminated reply (z-prefixed commands)
+func readReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && !(errors.Is(err, io.EOF) && len(reply) > 0) {
+		return "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return strings.TrimSpace(string(bytes.TrimRight(reply, "\x00"))), nil
+}
+
+// parseScanReply turns "stream: OK" and "stream: <signature> FOUND" into a threat name
+func parseScanReply(reply string) (string, error) {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("clamd: %s", reply)
+	}
+}
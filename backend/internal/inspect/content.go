@@ -0,0 +1,127 @@
+package inspect
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// marker is a byte sequence betraying a payload hidden in a file of another format
+type marker struct {
+	data []byte
+	what string
+}
+
+// Markup and scripts a browser or PHP would run if the file were served as such, matched case-insensitively
+var scriptMarkers = []marker{
+	{[]byte("<script"), "a script"},
+	{[]byte("javascript:"), "a script"},
+	{[]byte("<?php"), "PHP code"},
+	{[]byte("<html"), "an HTML page"},
+	{[]byte("<!doctype html"), "an HTML page"},
+	{[]byte("<iframe"), "an HTML page"},
+}
+
+// The DOS stub of every Windows program, matched case-insensitively
+var programMarker = marker{[]byte("this program cannot be run in dos mode"), "a Windows program"}
+
+// A ZIP archive is readable wherever it sits in a file as long as its end record is there too
+var (
+	zipEntryMarker = []byte("PK\x03\x04")
+	zipEndMarker   = []byte("PK\x05\x06")
+	pdfMarker      = []byte("%PDF-") // Readers accept a PDF header anywhere in the first kilobyte
+)
+
+// maxMarkerLen is how many bytes chunks overlap so markers split between chunks are found
+const maxMarkerLen = 38
+
+// hiddenPayloadChecks is what is looked for in a file of a given type
+type hiddenPayloadChecks struct {
+	scripts bool // Markup and scripts: media and PDFs have no reason to contain any
+	zip     bool // An embedded ZIP archive (GIFAR-style polyglots, JARs and PHARs glued to images)
+	pdf     bool // An embedded PDF document
+	program bool // An embedded Windows program
+}
+
+func checksFor(contentType string) hiddenPayloadChecks {
+	media := strings.HasPrefix(contentType, "image/") || strings.HasPrefix(contentType, "video/") || strings.HasPrefix(contentType, "audio/")
+	switch {
+	case media:
+		return hiddenPayloadChecks{scripts: true, zip: true, pdf: true, program: true}
+	case contentType == "application/pdf":
+		return hiddenPayloadChecks{scripts: true, zip: true, program: true}
+	case isZipBased(contentType) || contentType == typeText:
+		// Archive entries are inspected one by one, plain text is stored and served as text
+		return hiddenPayloadChecks{}
+	}
+	return hiddenPayloadChecks{program: true}
+}
+
+// scanContent reads a file to the end, returning its SHA-256 (hex), its size, and a finding if it hides
+// a payload that doesn't belong in its format (a polyglot)
+func scanContent(r io.Reader, contentType string) (string, int64, *finding, error) {
+	checks := checksFor(contentType)
+	digest := sha256.New()
+
+	var (
+		size                int64
+		found               *finding
+		sawZipEntry, sawEnd bool
+		tail                []byte
+	)
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			digest.Write(buf[:n])
+			size += int64(n)
+
+			if found == nil && checks != (hiddenPayloadChecks{}) {
+				window := append(tail, buf[:n]...)
+				found = checks.match(window, &sawZipEntry, &sawEnd)
+				tail = append([]byte(nil), window[max(len(window)-maxMarkerLen+1, 0):]...)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, nil, err
+		}
+	}
+
+	if found == nil && sawZipEntry && sawEnd {
+		found = suspicious("%s file hides a ZIP archive", contentType)
+	}
+	return hex.EncodeToString(digest.Sum(nil)), size, found, nil
+}
+
+// match looks for hidden payloads in a window of the file, ZIP records are only remembered:
+// the archive is only readable when both its entries and its end record are present
+func (c hiddenPayloadChecks) match(window []byte, sawZipEntry, sawEnd *bool) *finding {
+	if c.zip {
+		*sawZipEntry = *sawZipEntry || bytes.Contains(window, zipEntryMarker)
+		*sawEnd = *sawEnd || bytes.Contains(window, zipEndMarker)
+	}
+	if c.pdf && bytes.Contains(window, pdfMarker) {
+		return suspicious("file hides a PDF document")
+	}
+	if !c.scripts && !c.program {
+		return nil
+	}
+
+	lower := asciiLower(window)
+	if c.program && bytes.Contains(lower, programMarker.data) {
+		return suspicious("file hides %s", programMarker.what)
+	}
+	if c.scripts {
+		for _, m := range scriptMarkers {
+			if bytes.Contains(lower, m.data) {
+				return suspicious("file hides %s", m.what)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package inspect
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"upvista-community-backend/internal/config"
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/repository"
+	"upvista-community-backend/internal/storage"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// Inspector checks uploads before they are stored or linked to posts and messages: the content must be
+// what the client says it is and allowed where it is going, must not hide a second format (polyglots),
+// archives must be safe to extract, and the malware scanner, when one is configured, must find nothing.
+// Suspicious files are moved to the quarantine bucket and recorded for review; files that are merely
+// not allowed are refused.
+type Inspector struct {
+	driver           storage.Storage
+	repo             repository.QuarantineRepository
+	quarantineBucket string
+	scanner          Scanner // nil when no malware scanner is configured
+	failOpen         bool    // Accept files unscanned while the scanner is unreachable
+}
+
+// NewInspector creates an upload inspector
+func NewInspector(driver storage.Storage, repo repository.QuarantineRepository, cfg *config.Config) *Inspector {
+	return &Inspector{
+		driver:           driver,
+		repo:             repo,
+		quarantineBucket: cfg.Storage.QuarantineBucket,
+		failOpen:         cfg.Storage.UploadScanFailOpen,
+	}
+}
+
+// SetScanner scans every upload for malware
+// Archive formats this package can't look into (RAR, 7z, tarballs) and unknown binaries are only accepted with a scanner
+func (i *Inspector) SetScanner(scanner Scanner) {
+	i.scanner = scanner
+}
+
+// Upload describes a file to inspect
+type Upload struct {
+	UserID      uuid.UUID
+	Kind        models.MediaKind // What the file must be: an image, video, audio, or any allowed file
+	Bucket      string           // Where the file is stored
+	Filename    string           // As sent by the client, only its extension is checked
+	ContentType string           // As declared by the client, may be empty
+}
+
+// Result is what inspection found out about an accepted file
+type Result struct {
+	ContentType string // Sniffed from the content, what the file is stored and served as
+	SHA256      string // Hex-encoded
+	Size        int64
+}
+
+// Extension returns the extension the file should be stored with, fallback when its type has no single one
+func (r *Result) Extension(fallback string) string {
+	if ext := Extension(r.ContentType); ext != "" {
+		return ext
+	}
+	return fallback
+}
+
+// finding is why a file is refused
+type finding struct {
+	reason     string
+	suspicious bool // Quarantined rather than just refused
+}
+
+// suspicious is a finding that sends the file to quarantine, the reason is recorded but not shown to the uploader
+func suspicious(format string, args ...interface{}) *finding {
+	return &finding{reason: fmt.Sprintf(format, args...), suspicious: true}
+}
+
+// refused is a finding that turns the file down, the reason is shown to the uploader
+func refused(format string, args ...interface{}) *finding {
+	return &finding{reason: fmt.Sprintf(format, args...)}
+}
+
+// Inspect checks a file and rewinds it, returning its sniffed type and hash
+// Refused files fail with a 415, quarantined ones with apperr.ErrFileQuarantined
+func (i *Inspector) Inspect(ctx context.Context, upload Upload, file io.ReadSeeker) (*Result, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if n == 0 {
+		return nil, apperr.NewAppError(http.StatusBadRequest, "File is empty")
+	}
+	result := &Result{ContentType: Detect(head[:n])}
+
+	var archive *finding
+	if result.ContentType == typeZip {
+		result.ContentType, archive, err = i.inspectArchive(file, upload.Kind)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+	digest, size, hidden, err := scanContent(file, result.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	result.SHA256, result.Size = digest, size
+
+	if found := i.evaluate(upload, result.ContentType, hidden, archive); found != nil {
+		return nil, i.reject(upload, file, result, found, nil)
+	}
+
+	if i.scanner != nil {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind file: %w", err)
+		}
+
+		threat, err := i.scanner.Scan(ctx, file)
+		switch {
+		case err != nil && !i.failOpen:
+			log.Printf("[Inspect] Malware scan of upload from user %s failed: %v", upload.UserID, err)
+			return nil, apperr.ErrFileScanUnavailable
+		case err != nil:
+			log.Printf("[Inspect] Warning: malware scan of upload from user %s failed, accepting it unscanned: %v", upload.UserID, err)
+		case threat != "":
+			return nil, i.reject(upload, file, result, suspicious("malware scanner found %s", threat), &threat)
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+	return result, nil
+}
+
+// InspectObject checks a file already written to upload.Bucket (a completed resumable upload)
+// A refused file is deleted, a suspicious one moved to quarantine
+func (i *Inspector) InspectObject(ctx context.Context, upload Upload, key string) (*Result, error) {
+	object, err := i.driver.Get(ctx, upload.Bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s/%s: %w", upload.Bucket, key, err)
+	}
+	defer object.Close()
+
+	// Archives are read at random and the file is read more than once, a local copy is needed anyway
+	tmp, err := os.CreateTemp("", "upvista-inspect-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, object); err != nil {
+		return nil, fmt.Errorf("failed to read %s/%s: %w", upload.Bucket, key, err)
+	}
+
+	result, err := i.Inspect(ctx, upload, tmp)
+	if IsRejected(err) {
+		if deleteErr := i.driver.Delete(context.Background(), upload.Bucket, key); deleteErr != nil {
+			log.Printf("[Inspect] Failed to delete rejected upload %s/%s: %v", upload.Bucket, key, deleteErr)
+		}
+	}
+	return result, err
+}
+
+// IsRejected reports whether an Inspect error means the file was refused or quarantined,
+// rather than that it couldn't be inspected (and can be retried)
+func IsRejected(err error) bool {
+	var appErr *apperr.AppError
+	if !errors.As(err, &appErr) {
+		return false
+	}
+	return appErr == apperr.ErrFileQuarantined || appErr.Code == http.StatusUnsupportedMediaType
+}
+
+// inspectArchive tells ZIP-based formats apart and inspects the archive's entries
+func (i *Inspector) inspectArchive(file io.ReadSeeker, kind models.MediaKind) (string, *finding, error) {
+	readerAt, ok := file.(io.ReaderAt)
+	if !ok {
+		// Only archives sent as plain files are inspected, anything else is refused by type anyway
+		return typeZip, nil, nil
+	}
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	zr, err := zip.NewReader(readerAt, size)
+	if err != nil && !errors.Is(err, zip.ErrInsecurePath) { // Entry names are checked by inspectZip
+		return typeZip, refused("Archive is damaged and can't be checked"), nil
+	}
+
+	contentType := refineZip(zr)
+	if kind != models.MediaKindFile {
+		return contentType, nil, nil
+	}
+	return contentType, inspectZip(zr, 1, i.scanner != nil), nil
+}
+
+// evaluate decides whether a file is accepted: suspicious findings first, so a disguised program is
+// quarantined rather than just refused
+func (i *Inspector) evaluate(upload Upload, detected string, hidden, archive *finding) *finding {
+	declared := normalizeType(upload.ContentType)
+	dangerous := isExecutable(detected) || isActiveContent(detected)
+
+	switch {
+	case dangerous && !compatible(declared, detected):
+		return suspicious("%s file sent as %s", detected, declared)
+	case hidden != nil:
+		return hidden
+	case archive != nil && archive.suspicious:
+		return archive
+	case dangerous:
+		return refused("%s files are not allowed", detected)
+	case blockedExtension(upload.Filename) != "":
+		return refused("%s files are not allowed", blockedExtension(upload.Filename))
+	case !compatible(declared, detected):
+		return refused("File content (%s) doesn't match its type (%s)", detected, declared)
+	case !allowed(upload.Kind, detected, i.scanner != nil):
+		return refused("File type %s is not allowed here", detected)
+	}
+	return archive
+}
+
+// reject refuses a file, or moves it to quarantine when it is suspicious
+func (i *Inspector) reject(upload Upload, file io.ReadSeeker, result *Result, found *finding, threat *string) error {
+	if !found.suspicious {
+		log.Printf("[Inspect] Refused upload from user %s (%s, declared %q): %s", upload.UserID, result.ContentType, upload.ContentType, found.reason)
+		return apperr.NewAppError(http.StatusUnsupportedMediaType, found.reason)
+	}
+
+	log.Printf("[Inspect] Quarantining upload from user %s (%s, declared %q, sha256 %s): %s",
+		upload.UserID, result.ContentType, upload.ContentType, result.SHA256, found.reason)
+	// Detached from the request: the uploader disconnecting must not keep the file out of quarantine
+	i.quarantine(context.Background(), upload, file, result, found.reason, threat)
+	return apperr.ErrFileQuarantined
+}
+
+// quarantine copies a file to the quarantine bucket and records it for review
+func (i *Inspector) quarantine(ctx context.Context, upload Upload, file io.ReadSeeker, result *Result, reason string, threat *string) {
+	if i.quarantineBucket == "" {
+		return
+	}
+
+	// Stored as opaque data, so nothing ever serves it as what it claims to be
+	key := fmt.Sprintf("%s/%s.bin", upload.UserID, uuid.New().String())
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		log.Printf("[Inspect] Failed to quarantine upload from user %s: %v", upload.UserID, err)
+		return
+	}
+	if err := i.driver.Put(ctx, i.quarantineBucket, key, file, result.Size, typeOctetStream); err != nil {
+		log.Printf("[Inspect] Failed to quarantine upload from user %s: %v", upload.UserID, err)
+		return
+	}
+
+	record := &models.QuarantinedFile{
+		UserID:       upload.UserID,
+		Bucket:       i.quarantineBucket,
+		ObjectKey:    key,
+		TargetBucket: upload.Bucket,
+		Filename:     upload.Filename,
+		DeclaredType: upload.ContentType,
+		DetectedType: result.ContentType,
+		Size:         result.Size,
+		SHA256:       result.SHA256,
+		Reason:       reason,
+		Threat:       threat,
+	}
+	if err := i.repo.CreateQuarantinedFile(ctx, record); err != nil {
+		log.Printf("[Inspect] Failed to record quarantined file %s/%s: %v", i.quarantineBucket, key, err)
+	}
+}
+
+// Matches reports whether content sniffed as detected can be what the client declared
+func Matches(declared, detected string) bool {
+	return compatible(normalizeType(declared), detected)
+}
+
+// Allowed reports whether content sniffed as detected is accepted for a kind of upload, without a malware scanner
+func Allowed(kind models.MediaKind, detected string) bool {
+	return allowed(kind, detected, false)
+}
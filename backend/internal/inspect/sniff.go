@@ -0,0 +1,396 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"upvista-community-backend/internal/models"
+)
+
+// sniffLen is how much of the start of a file Detect looks at
+const sniffLen = 8192
+
+// Content types Detect reports that mean the file must never be served to a browser or run
+const (
+	typeWindowsExecutable = "application/x-msdownload"
+	typeELF               = "application/x-executable"
+	typeMachO             = "application/x-mach-binary"
+	typeShellScript       = "text/x-shellscript"
+	typeJavaArchive       = "application/java-archive"
+	typeAndroidPackage    = "application/vnd.android.package-archive"
+	typeHTML              = "text/html"
+	typeSVG               = "image/svg+xml"
+	typeXML               = "text/xml"
+	typeOctetStream       = "application/octet-stream"
+	typeZip               = "application/zip"
+	typeOLE               = "application/x-ole-storage" // Legacy Office documents (doc, xls, ppt), also MSI installers
+	typeText              = "text/plain"
+)
+
+// Detect returns the content type of a file from its first bytes (magic numbers), never from its name
+// or declared type. Files nothing matches are application/octet-stream.
+// ZIP-based formats (Office documents, JARs) are reported as application/zip, refineZip tells them apart.
+func Detect(head []byte) string {
+	switch {
+	case isPE(head):
+		return typeWindowsExecutable
+	case bytes.HasPrefix(head, []byte("\x7fELF")):
+		return typeELF
+	case hasAnyPrefix(head, "\xfe\xed\xfa\xce", "\xfe\xed\xfa\xcf", "\xce\xfa\xed\xfe", "\xcf\xfa\xed\xfe", "\xca\xfe\xba\xbe"):
+		return typeMachO // 0xCAFEBABE is also a Java class file, neither is allowed
+	case bytes.HasPrefix(head, []byte("#!AMR")):
+		return "audio/amr"
+	case bytes.HasPrefix(head, []byte("#!")):
+		return typeShellScript
+
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case hasAnyPrefix(head, "GIF87a", "GIF89a"):
+		return "image/gif"
+	case isRIFF(head, "WEBP"):
+		return "image/webp"
+	case hasAnyPrefix(head, "II*\x00", "MM\x00*"):
+		return "image/tiff"
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		return detectISOBMFF(head)
+
+	case bytes.HasPrefix(head, []byte("\x1a\x45\xdf\xa3")):
+		// Matroska, WebM declares its DocType in the EBML header
+		if bytes.Contains(head[:min(len(head), 64)], []byte("webm")) {
+			return "video/webm"
+		}
+		return "video/x-matroska"
+	case bytes.HasPrefix(head, []byte("OggS")):
+		if bytes.Contains(head, []byte("\x80theora")) {
+			return "video/ogg"
+		}
+		return "audio/ogg"
+	case isRIFF(head, "WAVE"):
+		return "audio/wav"
+	case isRIFF(head, "AVI "):
+		return "video/x-msvideo"
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return "audio/mpeg"
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return "audio/flac"
+	case len(head) >= 2 && head[0] == 0xff && head[1] != 0xff && head[1]&0xe0 == 0xe0:
+		// MPEG audio frame sync, layer bits 00 are AAC in ADTS
+		if head[1]&0x06 == 0 {
+			return "audio/aac"
+		}
+		return "audio/mpeg"
+
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return "application/pdf"
+	case bytes.HasPrefix(head, []byte("{\\rtf")):
+		return "application/rtf"
+	case hasAnyPrefix(head, "PK\x03\x04", "PK\x05\x06"):
+		return typeZip
+	case bytes.HasPrefix(head, []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1")):
+		return typeOLE
+	case bytes.HasPrefix(head, []byte("Rar!\x1a\x07")):
+		return "application/vnd.rar"
+	case bytes.HasPrefix(head, []byte("7z\xbc\xaf\x27\x1c")):
+		return "application/x-7z-compressed"
+	case bytes.HasPrefix(head, []byte("\x1f\x8b")):
+		return "application/gzip"
+	case bytes.HasPrefix(head, []byte("BZh")):
+		return "application/x-bzip2"
+	case bytes.HasPrefix(head, []byte("\xfd7zXZ\x00")):
+		return "application/x-xz"
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return "application/x-tar"
+	}
+
+	// Text, markup and the formats above this doesn't know about
+	detected, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	switch detected {
+	case typeText:
+		if looksLikeMarkup(head) {
+			return typeHTML
+		}
+	case typeXML:
+		if bytes.Contains(bytes.ToLower(head), []byte("<svg")) {
+			return typeSVG
+		}
+	}
+	return detected
+}
+
+// isPE reports whether head is a Windows executable: the MZ stub and, when it fits in head, the PE header it points to
+func isPE(head []byte) bool {
+	if !bytes.HasPrefix(head, []byte("MZ")) {
+		return false
+	}
+	if len(head) >= 0x40 {
+		offset := int(binary.LittleEndian.Uint32(head[0x3c:]))
+		if offset >= 0x40 && offset+4 <= len(head) {
+			return string(head[offset:offset+4]) == "PE\x00\x00"
+		}
+	}
+	// DOS executables and stubs pointing past head: binary, unlike a text file starting with "MZ"
+	detected, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return detected == typeOctetStream
+}
+
+func isRIFF(head []byte, format string) bool {
+	return len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == format
+}
+
+func hasAnyPrefix(head []byte, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(head, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectISOBMFF tells MP4, QuickTime, 3GP, M4A, HEIC and AVIF apart by the brands of their ftyp box
+func detectISOBMFF(head []byte) string {
+	boxSize := int(binary.BigEndian.Uint32(head))
+	if boxSize < 16 || boxSize > len(head) {
+		boxSize = min(len(head), 64)
+	}
+	major := string(head[8:12])
+	brands := string(head[16:max(boxSize, 16)])
+
+	switch {
+	case major == "avif" || major == "avis" || strings.Contains(brands, "avif"):
+		return "image/avif"
+	case major == "heic" || major == "heix" || major == "heim" || major == "heis" || major == "mif1" || major == "msf1":
+		return "image/heic"
+	case major == "M4A " || major == "M4B " || major == "M4P ":
+		return "audio/mp4"
+	case major == "qt  ":
+		return "video/quicktime"
+	case strings.HasPrefix(major, "3g2"):
+		return "video/3gpp2"
+	case strings.HasPrefix(major, "3gp"):
+		return "video/3gpp"
+	default:
+		return "video/mp4"
+	}
+}
+
+// looksLikeMarkup catches HTML that http.DetectContentType misses because it doesn't start with a known tag.
+// Like browsers, only text starting with markup counts, a note mentioning a tag stays text.
+func looksLikeMarkup(head []byte) bool {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n\f")
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		return false
+	}
+	lower := asciiLower(trimmed)
+	for _, tag := range [][]byte{[]byte("<html"), []byte("<script"), []byte("<body"), []byte("<iframe"), []byte("<svg")} {
+		if bytes.Contains(lower, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// asciiLower lowercases ASCII letters only, so binary data keeps its length and offsets
+func asciiLower(data []byte) []byte {
+	lower := make([]byte, len(data))
+	for i, c := range data {
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	return lower
+}
+
+// isExecutable reports whether a content type is a program
+func isExecutable(contentType string) bool {
+	switch contentType {
+	case typeWindowsExecutable, typeELF, typeMachO, typeShellScript, typeJavaArchive, typeAndroidPackage:
+		return true
+	}
+	return false
+}
+
+// isActiveContent reports whether a browser runs scripts in a content type, so it can't be served from our storage
+func isActiveContent(contentType string) bool {
+	switch contentType {
+	case typeHTML, typeSVG, typeXML, "application/xhtml+xml", "application/xml", "text/javascript", "application/javascript":
+		return true
+	}
+	return false
+}
+
+// Extensions of programs and scripts, refused at the top level and inside archives
+var executableExtensions = map[string]bool{
+	".exe": true, ".dll": true, ".scr": true, ".com": true, ".pif": true, ".msi": true, ".msp": true, ".cpl": true,
+	".bat": true, ".cmd": true, ".ps1": true, ".psm1": true, ".vbs": true, ".vbe": true, ".js": true, ".jse": true,
+	".mjs": true, ".wsf": true, ".wsh": true, ".hta": true, ".lnk": true, ".reg": true, ".msc": true, ".gadget": true,
+	".jar": true, ".apk": true, ".app": true, ".sh": true, ".elf": true, ".dmg": true, ".pkg": true, ".deb": true, ".rpm": true,
+}
+
+// Extensions browsers render as active content, refused at the top level: the local storage driver serves
+// objects with the content type of their extension. Inside archives they are harmless (Office documents are XML).
+var activeExtensions = map[string]bool{
+	".html": true, ".htm": true, ".xhtml": true, ".shtml": true, ".svg": true, ".svgz": true, ".xml": true,
+	".php": true, ".phtml": true, ".phar": true, ".asp": true, ".aspx": true, ".jsp": true,
+}
+
+// blockedExtension returns the extension of filename if files with it are never accepted, "" otherwise
+func blockedExtension(filename string) string {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSpace(filename)))
+	if executableExtensions[ext] || activeExtensions[ext] {
+		return ext
+	}
+	return ""
+}
+
+// normalizeType lowercases a declared content type, drops its parameters and maps aliases to the type Detect reports
+func normalizeType(contentType string) string {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	switch contentType {
+	case "image/jpg", "image/pjpeg":
+		return "image/jpeg"
+	case "audio/mp3", "audio/x-mp3", "audio/mpeg3", "audio/x-mpeg":
+		return "audio/mpeg"
+	case "audio/x-wav", "audio/wave", "audio/vnd.wave":
+		return "audio/wav"
+	case "audio/x-m4a", "audio/m4a", "audio/aac-adts":
+		return "audio/mp4"
+	case "audio/x-flac":
+		return "audio/flac"
+	case "audio/opus", "application/ogg":
+		return "audio/ogg"
+	case "video/x-m4v":
+		return "video/mp4"
+	case "application/x-zip-compressed", "application/x-zip", "multipart/x-zip":
+		return typeZip
+	case "application/x-rar-compressed", "application/x-rar":
+		return "application/vnd.rar"
+	case "application/x-gzip":
+		return "application/gzip"
+	case "application/msword", "application/vnd.ms-excel", "application/vnd.ms-powerpoint", "application/vnd.ms-outlook":
+		return typeOLE
+	case "application/x-msdos-program", "application/x-dosexec", "application/vnd.microsoft.portable-executable", "application/exe":
+		return typeWindowsExecutable
+	case "application/x-sh", "application/x-shellscript":
+		return typeShellScript
+	}
+	return contentType
+}
+
+// containerFamily groups types that are the same container: a WebM recording is declared as audio or video
+func containerFamily(contentType string) string {
+	switch contentType {
+	case "video/mp4", "audio/mp4", "video/quicktime", "video/3gpp", "video/3gpp2":
+		return "mp4"
+	case "video/webm", "audio/webm", "video/x-matroska", "audio/x-matroska":
+		return "matroska"
+	case "audio/ogg", "video/ogg":
+		return "ogg"
+	}
+	return contentType
+}
+
+// compatible reports whether a file detected as detected can be what the client declared
+// No declared type (or application/octet-stream) means the client doesn't know, any content fits
+func compatible(declared, detected string) bool {
+	switch {
+	case declared == "" || declared == typeOctetStream || declared == detected:
+		return true
+	case containerFamily(declared) == containerFamily(detected):
+		return true
+	case declared == typeZip:
+		return isZipBased(detected) // Office documents, ODF and EPUB are ZIP files
+	case strings.HasPrefix(declared, "text/") && detected == typeText:
+		return !isActiveContent(declared) // CSV, Markdown and other plain text
+	}
+	return false
+}
+
+// Content types accepted per kind of upload, images are limited to what the image processor decodes
+var allowedTypes = map[models.MediaKind]map[string]bool{
+	models.MediaKindImage: {
+		"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true, "image/tiff": true, "image/bmp": true,
+	},
+	models.MediaKindVideo: {
+		"video/mp4": true, "video/quicktime": true, "video/webm": true, "video/x-matroska": true,
+		"video/3gpp": true, "video/3gpp2": true, "video/ogg": true, "video/x-msvideo": true,
+	},
+	models.MediaKindAudio: {
+		"audio/mpeg": true, "audio/mp4": true, "audio/ogg": true, "audio/wav": true, "audio/flac": true, "audio/aac": true, "audio/amr": true,
+		"video/webm": true, "video/mp4": true, // Browser recordings, audio-only containers sniff as video
+	},
+}
+
+// Documents accepted as plain file attachments besides images, video and audio
+var allowedDocumentTypes = map[string]bool{
+	"application/pdf": true, "application/rtf": true, typeText: true, typeOLE: true, typeZip: true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+	"application/vnd.oasis.opendocument.text":                                   true,
+	"application/vnd.oasis.opendocument.spreadsheet":                            true,
+	"application/vnd.oasis.opendocument.presentation":                           true,
+	"application/epub+zip": true,
+	// Images the processor can't render are still fine as attachments
+	"image/avif": true,
+	"image/heic": true,
+}
+
+// Formats this package can't look into: accepted as attachments only when a malware scanner checks them
+var opaqueTypes = map[string]bool{
+	typeOctetStream: true, "application/vnd.rar": true, "application/x-7z-compressed": true, "application/gzip": true,
+	"application/x-bzip2": true, "application/x-xz": true, "application/x-tar": true,
+}
+
+// allowed reports whether a detected content type is accepted for a kind of upload
+func allowed(kind models.MediaKind, detected string, scanned bool) bool {
+	if types, ok := allowedTypes[kind]; ok {
+		return types[detected]
+	}
+	if isExecutable(detected) || isActiveContent(detected) {
+		return false
+	}
+
+	// Plain files: any media or document, opaque formats only when scanned
+	for _, types := range allowedTypes {
+		if types[detected] {
+			return true
+		}
+	}
+	return allowedDocumentTypes[detected] || (scanned && opaqueTypes[detected])
+}
+
+// Extension returns the file extension (with the dot) files of a content type are stored with,
+// "" when the type has no single extension (plain text, unknown binaries)
+func Extension(contentType string) string {
+	return extensions[contentType]
+}
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif", "image/webp": ".webp", "image/tiff": ".tiff",
+	"image/bmp": ".bmp", "image/avif": ".avif", "image/heic": ".heic",
+	"video/mp4": ".mp4", "video/quicktime": ".mov", "video/webm": ".webm", "video/x-matroska": ".mkv",
+	"video/3gpp": ".3gp", "video/3gpp2": ".3g2", "video/ogg": ".ogv", "video/x-msvideo": ".avi",
+	"audio/mpeg": ".mp3", "audio/mp4": ".m4a", "audio/ogg": ".ogg", "audio/wav": ".wav", "audio/flac": ".flac",
+	"audio/aac": ".aac", "audio/amr": ".amr",
+	"application/pdf": ".pdf", "application/rtf": ".rtf", typeZip: ".zip",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   ".docx",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/vnd.oasis.opendocument.text":                                   ".odt",
+	"application/vnd.oasis.opendocument.spreadsheet":                            ".ods",
+	"application/vnd.oasis.opendocument.presentation":                           ".odp",
+	"application/epub+zip":        ".epub",
+	"application/vnd.rar":         ".rar",
+	"application/x-7z-compressed": ".7z",
+	"application/gzip":            ".gz",
+	"application/x-bzip2":         ".bz2",
+	"application/x-xz":            ".xz",
+	"application/x-tar":           ".tar",
+}
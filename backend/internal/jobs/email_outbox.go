@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// How often the outbox is checked for due emails and retries, and how many are sent per batch
+const (
+	emailOutboxInterval  = 15 * time.Second
+	emailOutboxBatchSize = 50
+)
+
+// EmailDispatcher sends queued emails (implemented by mailer.Outbox)
+type EmailDispatcher interface {
+	// DispatchDue returns how many emails were actually claimed and sent (or retried)
+	DispatchDue(ctx context.Context, limit int) (int, error)
+	ReleaseStale(ctx context.Context) (int, error)
+	// Wakeups signals when new emails are queued
+	Wakeups() <-chan struct{}
+}
+
+// EmailOutboxJob sends queued emails and retries failed ones
+type EmailOutboxJob struct {
+	dispatcher EmailDispatcher
+}
+
+// NewEmailOutboxJob creates a new email outbox job
+func NewEmailOutboxJob(dispatcher EmailDispatcher) *EmailOutboxJob {
+	return &EmailOutboxJob{
+		dispatcher: dispatcher,
+	}
+}
+
+// Run requeues emails abandoned by crashed workers, then sends every due email
+func (j *EmailOutboxJob) Run(ctx context.Context) error {
+	released, err := j.dispatcher.ReleaseStale(ctx)
+	if err != nil {
+		log.Printf("[EmailOutboxJob] Failed to release stale emails: %v", err)
+	} else if released > 0 {
+		log.Printf("[EmailOutboxJob] Requeued %d emails stuck in sending", released)
+	}
+
+	return j.dispatch(ctx)
+}
+
+// dispatch sends due emails until a batch comes back short
+// Emails that weren't claimed make the batch short, so failing claims wait for the next run
+func (j *EmailOutboxJob) dispatch(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		count, err := j.dispatcher.DispatchDue(ctx, emailOutboxBatchSize)
+		if err != nil {
+			log.Printf("[EmailOutboxJob] Failed to dispatch emails: %v", err)
+			return err
+		}
+
+		// A full batch means more may be due
+		if count < emailOutboxBatchSize {
+			return nil
+		}
+	}
+}
+
+// Start runs the job every 15 seconds, and right away when emails are queued
+func (j *EmailOutboxJob) Start(ctx context.Context) {
+	log.Println("[EmailOutboxJob] Email outbox job started (runs every 15 seconds and on new emails)")
+
+	// Send whatever was queued before a restart
+	if err := j.Run(ctx); err != nil {
+		log.Printf("[EmailOutboxJob] Run failed: %v", err)
+	}
+
+	ticker := time.NewTicker(emailOutboxInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				log.Printf("[EmailOutboxJob] Run failed: %v", err)
+			}
+
+		case <-j.dispatcher.Wakeups():
+			if err := j.dispatch(ctx); err != nil {
+				log.Printf("[EmailOutboxJob] Dispatch failed: %v", err)
+			}
+
+		case <-ctx.Done():
+			log.Println("[EmailOutboxJob] Stopping job")
+			return
+		}
+	}
+}
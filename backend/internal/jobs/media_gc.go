@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// How often unreferenced media is deleted, and how many assets per batch
+const (
+	mediaGCInterval  = time.Hour
+	mediaGCBatchSize = 100
+)
+
+// MediaCollector deletes media no post, comment, message or event uses anymore (implemented by media.Service)
+type MediaCollector interface {
+	CollectOrphans(ctx context.Context, limit int) (int, error)
+}
+
+// MediaGCJob deletes stored files once nothing has referenced them for the grace period
+type MediaGCJob struct {
+	collector MediaCollector
+}
+
+// NewMediaGCJob creates a new media garbage collection job
+func NewMediaGCJob(collector MediaCollector) *MediaGCJob {
+	return &MediaGCJob{
+		collector: collector,
+	}
+}
+
+// Run deletes orphaned media until a batch comes back short
+func (j *MediaGCJob) Run(ctx context.Context) error {
+	total := 0
+	for {
+		count, err := j.collector.CollectOrphans(ctx, mediaGCBatchSize)
+		if err != nil {
+			log.Printf("[MediaGCJob] Collection failed: %v", err)
+			return err
+		}
+
+		total += count
+		if count < mediaGCBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("[MediaGCJob] Deleted %d unreferenced media assets", total)
+	}
+	return nil
+}
+
+// Start runs the job every hour
+func (j *MediaGCJob) Start(ctx context.Context) {
+	log.Println("[MediaGCJob] Media garbage collection job started (runs every hour)")
+
+	ticker := time.NewTicker(mediaGCInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				log.Printf("[MediaGCJob] Run failed: %v", err)
+			}
+		case <-ctx.Done():
+			log.Println("[MediaGCJob] Media garbage collection job stopped")
+			return
+		}
+	}
+}
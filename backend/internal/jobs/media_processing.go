@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// How often the queue is checked for due media jobs and retries, and how many are picked up per batch
+// Jobs run ffmpeg for up to minutes each, so batches are small
+const (
+	mediaProcessingInterval  = 30 * time.Second
+	mediaProcessingBatchSize = 10
+)
+
+// MediaProcessor processes queued video and audio (implemented by media.Service)
+type MediaProcessor interface {
+	ProcessDue(ctx context.Context, limit int) (int, error)
+	ReleaseStale(ctx context.Context) (int, error)
+	// Wakeups signals when media is queued
+	Wakeups() <-chan struct{}
+}
+
+// MediaProcessingJob probes, thumbnails and transcodes uploaded video and audio
+type MediaProcessingJob struct {
+	processor MediaProcessor
+}
+
+// NewMediaProcessingJob creates a new media processing job
+func NewMediaProcessingJob(processor MediaProcessor) *MediaProcessingJob {
+	return &MediaProcessingJob{
+		processor: processor,
+	}
+}
+
+// Run requeues jobs abandoned by crashed workers, then processes every due job
+func (j *MediaProcessingJob) Run(ctx context.Context) error {
+	released, err := j.processor.ReleaseStale(ctx)
+	if err != nil {
+		log.Printf("[MediaProcessingJob] Failed to release stale jobs: %v", err)
+	} else if released > 0 {
+		log.Printf("[MediaProcessingJob] Requeued %d jobs stuck in processing", released)
+	}
+
+	return j.process(ctx)
+}
+
+// process handles due jobs until a batch comes back short
+func (j *MediaProcessingJob) process(ctx context.Context) error {
+	for {
+		count, err := j.processor.ProcessDue(ctx, mediaProcessingBatchSize)
+		if err != nil {
+			log.Printf("[MediaProcessingJob] Failed to process media: %v", err)
+			return err
+		}
+
+		// A full batch means more may be due
+		if count < mediaProcessingBatchSize {
+			return nil
+		}
+	}
+}
+
+// Start runs the job every 30 seconds, and right away when media is queued
+func (j *MediaProcessingJob) Start(ctx context.Context) {
+	log.Println("[MediaProcessingJob] Media processing job started (runs every 30 seconds and on new uploads)")
+
+	// Process whatever was queued before a restart
+	if err := j.Run(ctx); err != nil {
+		log.Printf("[MediaProcessingJob] Run failed: %v", err)
+	}
+
+	ticker := time.NewTicker(mediaProcessingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				log.Printf("[MediaProcessingJob] Run failed: %v", err)
+			}
+
+		case <-j.processor.Wakeups():
+			if err := j.process(ctx); err != nil {
+				log.Printf("[MediaProcessingJob] Process failed: %v", err)
+			}
+
+		case <-ctx.Done():
+			log.Println("[MediaProcessingJob] Stopping job")
+			return
+		}
+	}
+}
@@ -10,7 +10,8 @@ import (
 
 // NotificationCleanupJob handles automatic deletion of expired notifications
 type NotificationCleanupJob struct {
-	repo repository.NotificationRepository
+	repo     repository.NotificationRepository
+	pushRepo repository.PushSubscriptionRepository
 }
 
 // NewNotificationCleanupJob creates a new cleanup job
@@ -20,6 +21,11 @@ func NewNotificationCleanupJob(repo repository.NotificationRepository) *Notifica
 	}
 }
 
+// SetPushSubscriptionRepository enables pruning of expired Web Push subscriptions
+func (j *NotificationCleanupJob) SetPushSubscriptionRepository(pushRepo repository.PushSubscriptionRepository) {
+	j.pushRepo = pushRepo
+}
+
 // Run executes the cleanup job
 func (j *NotificationCleanupJob) Run(ctx context.Context) error {
 	log.Println("[CleanupJob] Starting notification cleanup...")
@@ -36,6 +42,17 @@ func (j *NotificationCleanupJob) Run(ctx context.Context) error {
 		log.Println("[CleanupJob] Cleanup complete: no expired notifications found")
 	}
 
+	if j.pushRepo != nil {
+		pruned, err := j.pushRepo.DeleteExpiredSubscriptions(ctx)
+		if err != nil {
+			log.Printf("[CleanupJob] Push subscription cleanup failed: %v", err)
+			return err
+		}
+		if pruned > 0 {
+			log.Printf("[CleanupJob] Pruned %d expired push subscriptions", pruned)
+		}
+	}
+
 	return nil
 }
 
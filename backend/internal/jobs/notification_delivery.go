@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// How often held notifications are checked, and how many are released per batch
+const (
+	notificationDeliveryInterval  = time.Minute
+	notificationDeliveryBatchSize = 100
+)
+
+// HeldNotificationReleaser delivers notifications held back by quiet hours or a snooze
+// (implemented by notifications.NotificationService)
+type HeldNotificationReleaser interface {
+	ReleaseHeldNotifications(ctx context.Context, limit int) (int, error)
+}
+
+// NotificationDeliveryJob releases held email and push notifications when quiet hours or snoozes end
+type NotificationDeliveryJob struct {
+	releaser HeldNotificationReleaser
+}
+
+// NewNotificationDeliveryJob creates a new deferred delivery job
+func NewNotificationDeliveryJob(releaser HeldNotificationReleaser) *NotificationDeliveryJob {
+	return &NotificationDeliveryJob{
+		releaser: releaser,
+	}
+}
+
+// Run releases every held notification that is due
+func (j *NotificationDeliveryJob) Run(ctx context.Context) error {
+	for {
+		count, err := j.releaser.ReleaseHeldNotifications(ctx, notificationDeliveryBatchSize)
+		if err != nil {
+			log.Printf("[DeliveryJob] Failed to release held notifications: %v", err)
+			return err
+		}
+
+		if count > 0 {
+			log.Printf("[DeliveryJob] Released %d held notifications", count)
+		}
+
+		// A full batch means more may be due
+		if count < notificationDeliveryBatchSize {
+			return nil
+		}
+	}
+}
+
+// Start runs the job every minute
+func (j *NotificationDeliveryJob) Start(ctx context.Context) {
+	log.Println("[DeliveryJob] Deferred delivery job started (runs every minute)")
+
+	ticker := time.NewTicker(notificationDeliveryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				log.Printf("[DeliveryJob] Run failed: %v", err)
+			}
+
+		case <-ctx.Done():
+			log.Println("[DeliveryJob] Stopping job")
+			return
+		}
+	}
+}
@@ -42,97 +42,133 @@ func (j *NotificationDigestJob) RunWeekly(ctx context.Context) error {
 	return j.sendDigests(ctx, models.EmailFrequencyWeekly)
 }
 
-// sendDigests sends digest emails to all users with the specified frequency
-func (j *NotificationDigestJob) sendDigests(ctx context.Context, frequency models.EmailFrequency) error {
-	// Get all users who want this frequency
-	// For now, we'll fetch unread notifications and group by user
-	// In a production system, you'd query the preferences table to get users with this frequency
+// Digests go out at this hour in each user's timezone
+const digestHour = 9
+
+// Maximum notifications listed in one digest
+const digestMaxNotifications = 50
 
+// sendDigests sends digest emails to all users with the specified frequency who are due one
+func (j *NotificationDigestJob) sendDigests(ctx context.Context, frequency models.EmailFrequency) error {
 	log.Printf("[DigestJob] Processing %s digests...", frequency)
 
-	// This is a simplified implementation
-	// In production, you'd:
-	// 1. Query notification_preferences for users with this frequency
-	// 2. For each user, get their unread notifications since last digest
-	// 3. Send email with grouped notifications
-	// 4. Mark digest as sent (add digest_sent_at to preferences or metadata)
+	prefsList, err := j.notificationRepo.GetPreferencesByFrequency(ctx, frequency)
+	if err != nil {
+		log.Printf("[DigestJob] Failed to get %s digest users: %v", frequency, err)
+		return err
+	}
+
+	now := time.Now()
+	sent := 0
+	for _, prefs := range prefsList {
+		if !digestDue(prefs, frequency, now) {
+			continue
+		}
+
+		if err := j.sendDigest(ctx, prefs, frequency, now); err != nil {
+			log.Printf("[DigestJob] Failed to send %s digest to user %s: %v", frequency, prefs.UserID, err)
+			continue
+		}
+		sent++
+	}
 
-	log.Printf("[DigestJob] %s digest processing complete (implementation simplified for MVP)", frequency)
+	log.Printf("[DigestJob] %s digest processing complete: %d users", frequency, sent)
 	return nil
 }
 
-// StartDaily begins the daily digest job (runs at 9:00 AM daily)
-func (j *NotificationDigestJob) StartDaily(ctx context.Context) {
-	log.Println("[DigestJob] Daily digest job started (runs daily at 9:00 AM)")
-
-	// Calculate time until next 9 AM
-	now := time.Now()
-	next9AM := time.Date(now.Year(), now.Month(), now.Day(), 9, 0, 0, 0, now.Location())
-	if now.After(next9AM) {
-		next9AM = next9AM.Add(24 * time.Hour)
+// sendDigest emails a user the unread notifications since their last digest
+func (j *NotificationDigestJob) sendDigest(ctx context.Context, prefs *models.NotificationPreferences, frequency models.EmailFrequency, now time.Time) error {
+	since := now.Add(-digestPeriod(frequency))
+	if prefs.LastDigestAt != nil {
+		since = *prefs.LastDigestAt
 	}
 
-	// Wait until first run
-	time.Sleep(time.Until(next9AM))
-
-	// Run immediately
-	if err := j.RunDaily(ctx); err != nil {
-		log.Printf("[DigestJob] Daily initial run failed: %v", err)
+	unread, err := j.notificationRepo.GetUnreadSince(ctx, prefs.UserID, since, digestMaxNotifications)
+	if err != nil {
+		return err
 	}
 
-	// Then run every 24 hours
-	ticker := time.NewTicker(24 * time.Hour)
-	defer ticker.Stop()
+	// Only the notifications the user wants by email
+	notifications := make([]*models.Notification, 0, len(unread))
+	for _, notification := range unread {
+		if prefs.ChannelsFor(notification.Type, notification.Category).Email {
+			notifications = append(notifications, notification)
+		}
+	}
 
-	for {
-		select {
-		case <-ticker.C:
-			if err := j.RunDaily(ctx); err != nil {
-				log.Printf("[DigestJob] Daily scheduled run failed: %v", err)
-			}
-		case <-ctx.Done():
-			log.Println("[DigestJob] Daily digest job stopped")
-			return
+	if len(notifications) > 0 {
+		user, err := j.userRepo.GetUserByID(ctx, prefs.UserID)
+		if err != nil {
+			return err
+		}
+		if err := j.emailSvc.SendDigestEmail(ctx, user, notifications, frequency); err != nil {
+			return err
 		}
 	}
+
+	return j.notificationRepo.MarkDigestSent(ctx, prefs.UserID, now)
 }
 
-// StartWeekly begins the weekly digest job (runs Monday 9:00 AM)
-func (j *NotificationDigestJob) StartWeekly(ctx context.Context) {
-	log.Println("[DigestJob] Weekly digest job started (runs Monday at 9:00 AM)")
+// digestDue reports whether a user should get their digest now
+// Digests are sent from 9:00 in the user's timezone, once quiet hours or a snooze are over
+func digestDue(prefs *models.NotificationPreferences, frequency models.EmailFrequency, now time.Time) bool {
+	if prefs.ShouldHold(now) {
+		return false
+	}
 
-	// Calculate time until next Monday 9 AM
-	now := time.Now()
-	daysUntilMonday := (int(time.Monday) - int(now.Weekday()) + 7) % 7
-	if daysUntilMonday == 0 && now.Hour() >= 9 {
-		daysUntilMonday = 7 // Next Monday if already past 9 AM today
+	local := now.In(prefs.Location())
+	if local.Hour() < digestHour {
+		return false
+	}
+
+	if prefs.LastDigestAt == nil {
+		return frequency == models.EmailFrequencyDaily || local.Weekday() == time.Monday
 	}
+	last := prefs.LastDigestAt.In(prefs.Location())
+	sinceLast := now.Sub(*prefs.LastDigestAt)
 
-	nextMonday9AM := time.Date(
-		now.Year(), now.Month(), now.Day()+daysUntilMonday,
-		9, 0, 0, 0, now.Location(),
-	)
+	if frequency == models.EmailFrequencyDaily {
+		// Once per local calendar day
+		return last.Year() != local.Year() || last.YearDay() != local.YearDay()
+	}
 
-	// Wait until first run
-	time.Sleep(time.Until(nextMonday9AM))
+	// Weekly digests go out on Monday, or later in the week if Monday was missed
+	return (local.Weekday() == time.Monday && sinceLast >= 24*time.Hour) || sinceLast >= 7*24*time.Hour
+}
 
-	// Run immediately
-	if err := j.RunWeekly(ctx); err != nil {
-		log.Printf("[DigestJob] Weekly initial run failed: %v", err)
+// digestPeriod is how far back a user's first digest looks
+func digestPeriod(frequency models.EmailFrequency) time.Duration {
+	if frequency == models.EmailFrequencyWeekly {
+		return 7 * 24 * time.Hour
 	}
+	return 24 * time.Hour
+}
 
-	// Then run every 7 days
-	ticker := time.NewTicker(7 * 24 * time.Hour)
-	defer ticker.Stop()
+// Start checks for due digests at the top of every hour
+// Each user gets theirs at 9:00 in their own timezone
+func (j *NotificationDigestJob) Start(ctx context.Context) {
+	log.Println("[DigestJob] Digest job started (runs hourly, sends at 9:00 in each user's timezone)")
+
+	// Wait until the next full hour
+	now := time.Now()
+	timer := time.NewTimer(now.Truncate(time.Hour).Add(time.Hour).Sub(now))
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
+			if err := j.RunDaily(ctx); err != nil {
+				log.Printf("[DigestJob] Daily run failed: %v", err)
+			}
 			if err := j.RunWeekly(ctx); err != nil {
-				log.Printf("[DigestJob] Weekly scheduled run failed: %v", err)
+				log.Printf("[DigestJob] Weekly run failed: %v", err)
 			}
+
+			now := time.Now()
+			timer.Reset(now.Truncate(time.Hour).Add(time.Hour).Sub(now))
+
 		case <-ctx.Done():
-			log.Println("[DigestJob] Weekly digest job stopped")
+			log.Println("[DigestJob] Digest job stopped")
 			return
 		}
 	}
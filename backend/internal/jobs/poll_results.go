@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// How often ended polls are checked, and how many are announced per run
+const (
+	pollResultsInterval  = 5 * time.Minute
+	pollResultsBatchSize = 50
+)
+
+// PollResultsNotifier announces the results of ended polls (implemented by posts.Service)
+type PollResultsNotifier interface {
+	NotifyEndedPolls(ctx context.Context, limit int) (int, error)
+}
+
+// PollResultsJob notifies authors and voters when their polls end
+type PollResultsJob struct {
+	notifier PollResultsNotifier
+}
+
+// NewPollResultsJob creates a new poll results job
+func NewPollResultsJob(notifier PollResultsNotifier) *PollResultsJob {
+	return &PollResultsJob{
+		notifier: notifier,
+	}
+}
+
+// Run announces the results of polls that ended since the last run
+func (j *PollResultsJob) Run(ctx context.Context) error {
+	for {
+		count, err := j.notifier.NotifyEndedPolls(ctx, pollResultsBatchSize)
+		if err != nil {
+			log.Printf("[PollResultsJob] Failed to notify poll results: %v", err)
+			return err
+		}
+
+		if count > 0 {
+			log.Printf("[PollResultsJob] Announced results of %d ended polls", count)
+		}
+
+		// A full batch means more polls may be waiting
+		if count < pollResultsBatchSize {
+			return nil
+		}
+	}
+}
+
+// Start runs the job every 5 minutes
+func (j *PollResultsJob) Start(ctx context.Context) {
+	log.Println("[PollResultsJob] Poll results job started (runs every 5 minutes)")
+
+	if err := j.Run(ctx); err != nil {
+		log.Printf("[PollResultsJob] Initial run failed: %v", err)
+	}
+
+	ticker := time.NewTicker(pollResultsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				log.Printf("[PollResultsJob] Run failed: %v", err)
+			}
+
+		case <-ctx.Done():
+			log.Println("[PollResultsJob] Stopping job")
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// How often due scheduled posts are looked for, and how many are published per batch
+const (
+	scheduledPostsInterval  = time.Minute
+	scheduledPostsBatchSize = 50
+)
+
+// ScheduledPostPublisher publishes scheduled posts whose time has come (implemented by posts.Service)
+type ScheduledPostPublisher interface {
+	PublishDuePosts(ctx context.Context, limit int) (int, error)
+}
+
+// ScheduledPostsJob publishes scheduled posts
+type ScheduledPostsJob struct {
+	publisher ScheduledPostPublisher
+}
+
+// NewScheduledPostsJob creates a new scheduled posts job
+func NewScheduledPostsJob(publisher ScheduledPostPublisher) *ScheduledPostsJob {
+	return &ScheduledPostsJob{
+		publisher: publisher,
+	}
+}
+
+// Run publishes every scheduled post that is due
+func (j *ScheduledPostsJob) Run(ctx context.Context) error {
+	for {
+		count, err := j.publisher.PublishDuePosts(ctx, scheduledPostsBatchSize)
+		if err != nil {
+			log.Printf("[ScheduledPostsJob] Failed to publish scheduled posts: %v", err)
+			return err
+		}
+
+		if count > 0 {
+			log.Printf("[ScheduledPostsJob] Published %d scheduled posts", count)
+		}
+
+		// A full batch means more posts may be due
+		if count < scheduledPostsBatchSize {
+			return nil
+		}
+	}
+}
+
+// Start runs the job every minute
+func (j *ScheduledPostsJob) Start(ctx context.Context) {
+	log.Println("[ScheduledPostsJob] Scheduled posts job started (runs every minute)")
+
+	if err := j.Run(ctx); err != nil {
+		log.Printf("[ScheduledPostsJob] Initial run failed: %v", err)
+	}
+
+	ticker := time.NewTicker(scheduledPostsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				log.Printf("[ScheduledPostsJob] Run failed: %v", err)
+			}
+
+		case <-ctx.Done():
+			log.Println("[ScheduledPostsJob] Stopping job")
+			return
+		}
+	}
+}
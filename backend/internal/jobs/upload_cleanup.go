@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// How often expired resumable uploads are deleted, and how many per batch
+const (
+	uploadCleanupInterval  = time.Hour
+	uploadCleanupBatchSize = 100
+)
+
+// UploadCleaner deletes expired upload sessions and their chunks (implemented by uploads.Service)
+type UploadCleaner interface {
+	CleanupExpired(ctx context.Context, limit int) (int, error)
+}
+
+// UploadCleanupJob deletes abandoned resumable uploads
+type UploadCleanupJob struct {
+	cleaner UploadCleaner
+}
+
+// NewUploadCleanupJob creates a new upload cleanup job
+func NewUploadCleanupJob(cleaner UploadCleaner) *UploadCleanupJob {
+	return &UploadCleanupJob{
+		cleaner: cleaner,
+	}
+}
+
+// Run deletes expired uploads until a batch comes back short
+func (j *UploadCleanupJob) Run(ctx context.Context) error {
+	total := 0
+	for {
+		count, err := j.cleaner.CleanupExpired(ctx, uploadCleanupBatchSize)
+		if err != nil {
+			log.Printf("[UploadCleanupJob] Cleanup failed: %v", err)
+			return err
+		}
+
+		total += count
+		if count < uploadCleanupBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("[UploadCleanupJob] Deleted %d expired uploads", total)
+	}
+	return nil
+}
+
+// Start runs the job every hour
+func (j *UploadCleanupJob) Start(ctx context.Context) {
+	log.Println("[UploadCleanupJob] Upload cleanup job started (runs every hour)")
+
+	ticker := time.NewTicker(uploadCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				log.Printf("[UploadCleanupJob] Run failed: %v", err)
+			}
+		case <-ctx.Done():
+			log.Println("[UploadCleanupJob] Upload cleanup job stopped")
+			return
+		}
+	}
+}
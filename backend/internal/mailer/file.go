@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileTransport writes emails to a local maildir instead of sending them (development)
+// Each email is a .eml file in <dir>/new that any mail client can open
+type FileTransport struct {
+	dir string
+}
+
+// NewFileTransport creates a file transport, creating the maildir if needed
+func NewFileTransport(dir string) (*FileTransport, error) {
+	if dir == "" {
+		dir = "tmp/emails"
+	}
+
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create maildir: %w", err)
+		}
+	}
+
+	return &FileTransport{
+		dir: dir,
+	}, nil
+}
+
+// Name returns "file"
+func (t *FileTransport) Name() string {
+	return "file"
+}
+
+// Send writes the email to the maildir, returning its Message-ID
+func (t *FileTransport) Send(ctx context.Context, envelope *Envelope) (string, error) {
+	message, err := envelope.mime()
+	if err != nil {
+		return "", Permanent(fmt.Errorf("failed to build email: %w", err))
+	}
+
+	// Written to tmp and renamed so readers never see a partial file
+	name := fmt.Sprintf("%d.%s.eml", time.Now().UnixNano(), envelope.ID)
+	tmpPath := filepath.Join(t.dir, "tmp", name)
+	if err := os.WriteFile(tmpPath, message, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(t.dir, "new", name)); err != nil {
+		return "", fmt.Errorf("failed to deliver email to maildir: %w", err)
+	}
+
+	return envelope.messageID(), nil
+}
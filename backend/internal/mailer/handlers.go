@@ -0,0 +1,174 @@
+package mailer
+
+import (
+	"net/http"
+	"strconv"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handlers serves email previews and the outbox for developers
+type Handlers struct {
+	renderer *Renderer
+	outbox   *Outbox
+}
+
+// NewHandlers creates new email preview handlers
+func NewHandlers(renderer *Renderer) *Handlers {
+	return &Handlers{
+		renderer: renderer,
+	}
+}
+
+// SetOutbox exposes the outbox so developers can check what was sent and why an email failed
+func (h *Handlers) SetOutbox(outbox *Outbox) {
+	h.outbox = outbox
+}
+
+// ListTemplates returns the templates and languages that can be previewed
+// GET /api/v1/dev/emails
+func (h *Handlers) ListTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"templates": h.renderer.Templates(),
+		"languages": SupportedLanguages,
+	})
+}
+
+// PreviewTemplate renders a template with sample data
+// GET /api/v1/dev/emails/:template?lang=es&format=html|text|json
+func (h *Handlers) PreviewTemplate(c *gin.Context) {
+	name := c.Param("template")
+	if !h.renderer.Has(name) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Email template not found",
+		})
+		return
+	}
+
+	language := c.DefaultQuery("lang", DefaultLanguage)
+	if !IsSupportedLanguage(language) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Unsupported language",
+		})
+		return
+	}
+
+	message, err := h.renderer.Render(name, language, h.renderer.SampleData(name))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to render email",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	switch c.DefaultQuery("format", "html") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(message.HTML))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(message.Subject+"\n\n"+message.Text))
+	case "json":
+		c.JSON(http.StatusOK, gin.H{
+			"success":  true,
+			"template": name,
+			"language": language,
+			"email":    message,
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Format must be html, text or json",
+		})
+	}
+}
+
+// ListOutbox returns the newest outbox emails with their delivery status
+// GET /api/v1/dev/emails/outbox?status=failed&to=user@example.com&limit=50
+func (h *Handlers) ListOutbox(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	emails, err := h.outbox.ListEmails(c.Request.Context(), models.EmailStatus(c.Query("status")), c.Query("to"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch outbox",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"emails":  emails,
+		"count":   len(emails),
+	})
+}
+
+// GetOutboxEmail returns an outbox email, including its rendered bodies until it is sent or given up on
+// GET /api/v1/dev/emails/outbox/:id
+func (h *Handlers) GetOutboxEmail(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid email ID",
+		})
+		return
+	}
+
+	email, err := h.outbox.GetEmail(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch email",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if email == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Email not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"email":   email,
+		"html":    email.HTMLBody,
+		"text":    email.TextBody,
+	})
+}
+
+// SetupRoutes registers the preview routes, the router must only let admins through
+func (h *Handlers) SetupRoutes(router *gin.RouterGroup) {
+	emails := router.Group("/dev/emails")
+	{
+		emails.GET("", h.ListTemplates)
+		emails.GET("/:template", h.PreviewTemplate)
+	}
+}
+
+// SetupOutboxRoutes registers the outbox routes, the router must only let admins through
+func (h *Handlers) SetupOutboxRoutes(router *gin.RouterGroup) {
+	if h.outbox == nil {
+		return
+	}
+
+	outbox := router.Group("/dev/emails/outbox")
+	{
+		outbox.GET("", h.ListOutbox)
+		outbox.GET("/:id", h.GetOutboxEmail)
+	}
+}
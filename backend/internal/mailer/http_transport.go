@@ -0,0 +1,223 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTP API endpoints of the supported providers
+const (
+	sendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+	postmarkEndpoint = "https://api.postmarkapp.com/email"
+	resendEndpoint   = "https://api.resend.com/emails"
+)
+
+// apiClient posts JSON to an email provider's HTTP API
+type apiClient struct {
+	endpoint string
+	headers  map[string]string
+	http     *http.Client
+}
+
+func newAPIClient(endpoint string, headers map[string]string) *apiClient {
+	return &apiClient{
+		endpoint: endpoint,
+		headers:  headers,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// post sends a request and decodes a JSON response into out (if not nil)
+// 429 and 5xx responses are retried, other 4xx responses mean the provider rejected the email
+func (c *apiClient) post(ctx context.Context, payload interface{}, out interface{}) (http.Header, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("failed to encode request: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("failed to create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		err := fmt.Errorf("HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+			return nil, err
+		case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+			// A bad API key is a configuration problem, retry once it's fixed
+			return nil, err
+		default:
+			return nil, Permanent(err)
+		}
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return resp.Header, nil
+}
+
+// =====================================================
+// SENDGRID
+// =====================================================
+
+// SendGridTransport sends emails through the SendGrid v3 API
+type SendGridTransport struct {
+	client *apiClient
+}
+
+// NewSendGridTransport creates a new SendGrid transport
+func NewSendGridTransport(apiKey string) *SendGridTransport {
+	return &SendGridTransport{
+		client: newAPIClient(sendGridEndpoint, map[string]string{
+			"Authorization": "Bearer " + apiKey,
+		}),
+	}
+}
+
+// Name returns "sendgrid"
+func (t *SendGridTransport) Name() string {
+	return "sendgrid"
+}
+
+// Send delivers an email, returning SendGrid's X-Message-Id
+func (t *SendGridTransport) Send(ctx context.Context, envelope *Envelope) (string, error) {
+	content := []map[string]string{}
+	if envelope.Text != "" {
+		content = append(content, map[string]string{"type": "text/plain", "value": envelope.Text})
+	}
+	content = append(content, map[string]string{"type": "text/html", "value": envelope.HTML})
+
+	payload := map[string]interface{}{
+		"personalizations": []map[string]interface{}{
+			{
+				"to": []map[string]string{{"email": envelope.To}},
+				// Echoed back in event webhooks
+				"custom_args": map[string]string{"outbox_id": envelope.ID},
+			},
+		},
+		"from":    map[string]string{"email": envelope.FromEmail, "name": envelope.FromName},
+		"subject": envelope.Subject,
+		"content": content,
+	}
+
+	headers, err := t.client.post(ctx, payload, nil)
+	if err != nil {
+		return "", fmt.Errorf("sendgrid: %w", err)
+	}
+
+	return headers.Get("X-Message-Id"), nil
+}
+
+// =====================================================
+// POSTMARK
+// =====================================================
+
+// PostmarkTransport sends emails through the Postmark API
+type PostmarkTransport struct {
+	client *apiClient
+}
+
+// NewPostmarkTransport creates a new Postmark transport
+func NewPostmarkTransport(serverToken string) *PostmarkTransport {
+	return &PostmarkTransport{
+		client: newAPIClient(postmarkEndpoint, map[string]string{
+			"X-Postmark-Server-Token": serverToken,
+		}),
+	}
+}
+
+// Name returns "postmark"
+func (t *PostmarkTransport) Name() string {
+	return "postmark"
+}
+
+// Send delivers an email, returning Postmark's MessageID
+func (t *PostmarkTransport) Send(ctx context.Context, envelope *Envelope) (string, error) {
+	payload := map[string]interface{}{
+		"From":          envelope.from(),
+		"To":            envelope.To,
+		"Subject":       envelope.Subject,
+		"HtmlBody":      envelope.HTML,
+		"TextBody":      envelope.Text,
+		"MessageStream": "outbound",
+		// Echoed back in bounce, delivery and spam complaint webhooks
+		"Metadata": map[string]string{"outbox_id": envelope.ID},
+	}
+
+	var result struct {
+		MessageID string `json:"MessageID"`
+	}
+	if _, err := t.client.post(ctx, payload, &result); err != nil {
+		return "", fmt.Errorf("postmark: %w", err)
+	}
+
+	return result.MessageID, nil
+}
+
+// =====================================================
+// RESEND
+// =====================================================
+
+// ResendTransport sends emails through the Resend API
+type ResendTransport struct {
+	client *apiClient
+}
+
+// NewResendTransport creates a new Resend transport
+func NewResendTransport(apiKey string) *ResendTransport {
+	return &ResendTransport{
+		client: newAPIClient(resendEndpoint, map[string]string{
+			"Authorization": "Bearer " + apiKey,
+		}),
+	}
+}
+
+// Name returns "resend"
+func (t *ResendTransport) Name() string {
+	return "resend"
+}
+
+// Send delivers an email, returning Resend's email ID
+func (t *ResendTransport) Send(ctx context.Context, envelope *Envelope) (string, error) {
+	payload := map[string]interface{}{
+		"from":    envelope.from(),
+		"to":      []string{envelope.To},
+		"subject": envelope.Subject,
+		"html":    envelope.HTML,
+		"text":    envelope.Text,
+		"tags":    []map[string]string{{"name": "outbox_id", "value": envelope.ID}},
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if _, err := t.client.post(ctx, payload, &result); err != nil {
+		return "", fmt.Errorf("resend: %w", err)
+	}
+
+	return result.ID, nil
+}
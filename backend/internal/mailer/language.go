@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when the recipient has no (supported) language preference
+const DefaultLanguage = "en"
+
+// SupportedLanguages lists the languages emails are translated into (one locales/<code>.json each)
+var SupportedLanguages = []string{"en", "es", "fr"}
+
+// IsSupportedLanguage checks if emails can be sent in a language
+func IsSupportedLanguage(language string) bool {
+	for _, supported := range SupportedLanguages {
+		if language == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeLanguage maps a language tag like "es-MX" to a supported language, or the default
+func NormalizeLanguage(language string) string {
+	base := strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	if IsSupportedLanguage(base) {
+		return base
+	}
+	return DefaultLanguage
+}
+
+// MatchLanguage picks the preferred supported language from an Accept-Language header
+func MatchLanguage(acceptLanguage string) string {
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		language := NormalizeLanguage(tag)
+		if language == DefaultLanguage && !strings.HasPrefix(strings.ToLower(strings.TrimSpace(tag)), DefaultLanguage) {
+			continue // Unsupported language
+		}
+		if q > bestQ {
+			best, bestQ = language, q
+		}
+	}
+	return best
+}
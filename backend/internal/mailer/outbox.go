@@ -0,0 +1,308 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"upvista-community-backend/internal/config"
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Retry schedule: 30s, 1m, 2m, 4m... capped at 6 hours
+const (
+	retryBaseDelay     = 30 * time.Second
+	retryMaxDelay      = 6 * time.Hour
+	defaultMaxAttempts = 8
+	defaultWorkers     = 2
+
+	// Emails claimed longer ago than this belong to a worker that died mid-send
+	staleLockTimeout = 10 * time.Minute
+	sendTimeout      = 60 * time.Second
+)
+
+// EventHook is called for every delivery event reported by the provider
+// email is nil when the event doesn't match an outbox email
+type EventHook func(ctx context.Context, event *models.EmailDeliveryEvent, email *models.OutboxEmail)
+
+// Outbox stores emails before sending them, so a slow or failing transport never blocks
+// a request and nothing is lost when it is down. Workers (jobs.EmailOutboxJob) send due emails
+// and retry failures with exponential backoff.
+type Outbox struct {
+	repo        repository.EmailOutboxRepository
+	transport   Transport
+	fromName    string
+	fromEmail   string
+	workers     int
+	maxAttempts int
+
+	hooksMu sync.RWMutex
+	hooks   []EventHook
+
+	wake chan struct{}
+}
+
+// NewOutbox creates a new email outbox
+func NewOutbox(repo repository.EmailOutboxRepository, transport Transport, cfg *config.EmailConfig) *Outbox {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	return &Outbox{
+		repo:        repo,
+		transport:   transport,
+		fromName:    cfg.FromName,
+		fromEmail:   cfg.FromEmail,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// OnEvent registers a hook for bounces, complaints and deliveries
+func (o *Outbox) OnEvent(hook EventHook) {
+	o.hooksMu.Lock()
+	defer o.hooksMu.Unlock()
+	o.hooks = append(o.hooks, hook)
+}
+
+// Wakeups signals when new emails are queued, so workers send them without waiting for the next tick
+func (o *Outbox) Wakeups() <-chan struct{} {
+	return o.wake
+}
+
+// Enqueue stores an email for delivery
+// Addresses on the suppression list are recorded as suppressed and never sent
+func (o *Outbox) Enqueue(ctx context.Context, to, subject, htmlBody, textBody, template string) (*models.OutboxEmail, error) {
+	email := &models.OutboxEmail{
+		ToEmail:  strings.TrimSpace(to),
+		Subject:  subject,
+		HTMLBody: htmlBody,
+		TextBody: textBody,
+		Status:   models.EmailStatusPending,
+	}
+	if template != "" {
+		email.Template = &template
+	}
+
+	suppressed, err := o.repo.IsSuppressed(ctx, email.ToEmail)
+	if err != nil {
+		// Better to try sending than to drop the email
+		log.Printf("[Outbox] Failed to check suppression list for %s: %v", email.ToEmail, err)
+	}
+	if suppressed {
+		// Never sent, so the bodies (possibly holding one-time links) aren't kept
+		reason := "address is on the suppression list"
+		email.Status = models.EmailStatusSuppressed
+		email.LastError = &reason
+		email.HTMLBody = ""
+		email.TextBody = ""
+	}
+
+	if err := o.repo.CreateEmail(ctx, email); err != nil {
+		return nil, fmt.Errorf("failed to queue email: %w", err)
+	}
+
+	if suppressed {
+		log.Printf("[Outbox] Email %s to %s suppressed", email.ID, email.ToEmail)
+		return email, nil
+	}
+
+	// Non-blocking, one pending signal is enough to start a dispatch
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+
+	return email, nil
+}
+
+// GetEmail returns an outbox email, nil if it doesn't exist
+func (o *Outbox) GetEmail(ctx context.Context, id uuid.UUID) (*models.OutboxEmail, error) {
+	return o.repo.GetEmailByID(ctx, id)
+}
+
+// ListEmails returns the newest outbox emails, optionally filtered by status and recipient
+func (o *Outbox) ListEmails(ctx context.Context, status models.EmailStatus, to string, limit int) ([]*models.OutboxEmail, error) {
+	return o.repo.ListEmails(ctx, status, to, limit)
+}
+
+// DispatchDue sends up to limit due emails concurrently, returning how many were claimed and handed to the transport
+// Emails another worker claimed first, or that couldn't be claimed, aren't counted
+func (o *Outbox) DispatchDue(ctx context.Context, limit int) (int, error) {
+	emails, err := o.repo.GetDueEmails(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch due emails: %w", err)
+	}
+	if len(emails) == 0 {
+		return 0, nil
+	}
+
+	queue := make(chan *models.OutboxEmail)
+	var wg sync.WaitGroup
+	var delivered atomic.Int64
+	for i := 0; i < o.workers && i < len(emails); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for email := range queue {
+				if o.deliver(ctx, email) {
+					delivered.Add(1)
+				}
+			}
+		}()
+	}
+
+	for _, email := range emails {
+		queue <- email
+	}
+	close(queue)
+	wg.Wait()
+
+	return int(delivered.Load()), nil
+}
+
+// ReleaseStale requeues emails whose worker died mid-send
+func (o *Outbox) ReleaseStale(ctx context.Context) (int, error) {
+	return o.repo.ReleaseStaleEmails(ctx, time.Now().Add(-staleLockTimeout))
+}
+
+// deliver claims an email and hands it to the transport, scheduling a retry when it fails
+// Returns false when the email couldn't be claimed
+func (o *Outbox) deliver(ctx context.Context, email *models.OutboxEmail) bool {
+	claimed, err := o.repo.ClaimEmail(ctx, email.ID)
+	if err != nil {
+		log.Printf("[Outbox] Failed to claim email %s: %v", email.ID, err)
+		return false
+	}
+	if !claimed {
+		return false // Another worker got it
+	}
+
+	attempts := email.Attempts + 1
+	envelope := &Envelope{
+		ID:        email.ID.String(),
+		FromName:  o.fromName,
+		FromEmail: o.fromEmail,
+		To:        email.ToEmail,
+		Subject:   email.Subject,
+		HTML:      email.HTMLBody,
+		Text:      email.TextBody,
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	providerMessageID, sendErr := o.transport.Send(sendCtx, envelope)
+	cancel()
+
+	if sendErr == nil {
+		if err := o.repo.MarkEmailSent(ctx, email.ID, attempts, o.transport.Name(), providerMessageID); err != nil {
+			log.Printf("[Outbox] Email %s sent but status update failed: %v", email.ID, err)
+		}
+		return true
+	}
+
+	if IsPermanent(sendErr) || attempts >= o.maxAttempts {
+		log.Printf("[Outbox] Email %s to %s failed after %d attempts: %v", email.ID, email.ToEmail, attempts, sendErr)
+		if err := o.repo.MarkEmailFailed(ctx, email.ID, attempts, sendErr.Error()); err != nil {
+			log.Printf("[Outbox] Failed to mark email %s failed: %v", email.ID, err)
+		}
+		return true
+	}
+
+	nextAttemptAt := time.Now().Add(retryDelay(attempts))
+	log.Printf("[Outbox] Email %s to %s failed (attempt %d/%d), retrying at %s: %v",
+		email.ID, email.ToEmail, attempts, o.maxAttempts, nextAttemptAt.Format(time.RFC3339), sendErr)
+	if err := o.repo.ScheduleRetry(ctx, email.ID, attempts, nextAttemptAt, sendErr.Error()); err != nil {
+		log.Printf("[Outbox] Failed to schedule retry for email %s: %v", email.ID, err)
+	}
+	return true
+}
+
+// retryDelay doubles the wait after every attempt, with up to 20% jitter so retries don't bunch up
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// HandleEvent records a delivery event reported by the provider
+// Hard bounces and complaints put the address on the suppression list
+func (o *Outbox) HandleEvent(ctx context.Context, event *models.EmailDeliveryEvent) error {
+	var email *models.OutboxEmail
+	var err error
+	if event.OutboxID != nil {
+		email, err = o.repo.GetEmailByID(ctx, *event.OutboxID)
+	} else if event.ProviderMessageID != "" {
+		email, err = o.repo.GetEmailByProviderMessageID(ctx, event.ProviderMessageID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find email: %w", err)
+	}
+
+	if event.Email == "" && email != nil {
+		event.Email = email.ToEmail
+	}
+
+	switch event.Type {
+	case models.EmailEventDelivered:
+		// Only sent emails move to delivered, a late event must not overwrite a bounce
+		if email != nil && email.Status == models.EmailStatusSent {
+			err = o.repo.UpdateEmailStatus(ctx, email.ID, models.EmailStatusDelivered, "")
+		}
+
+	case models.EmailEventBounced, models.EmailEventComplained:
+		status, reason := models.EmailStatusBounced, "bounced"
+		if event.Type == models.EmailEventComplained {
+			status, reason = models.EmailStatusComplained, "complained"
+		}
+
+		if email != nil {
+			if err = o.repo.UpdateEmailStatus(ctx, email.ID, status, event.Reason); err != nil {
+				break
+			}
+		}
+		if event.Email != "" {
+			err = o.repo.Suppress(ctx, event.Email, reason)
+			log.Printf("[Outbox] Suppressed %s (%s): %s", event.Email, reason, event.Reason)
+		}
+
+	case models.EmailEventSoftBounce:
+		// The provider keeps retrying these itself
+		log.Printf("[Outbox] Soft bounce for %s: %s", event.Email, event.Reason)
+
+	default:
+		return fmt.Errorf("unknown email event type: %s", event.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record email event: %w", err)
+	}
+
+	o.hooksMu.RLock()
+	hooks := o.hooks
+	o.hooksMu.RUnlock()
+
+	for _, hook := range hooks {
+		hook(ctx, event, email)
+	}
+
+	return nil
+}
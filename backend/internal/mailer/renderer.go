@@ -0,0 +1,233 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl locales/*.json
+var files embed.FS
+
+// layoutFile holds the shared HTML/text layout and partials every email is parsed with
+const layoutFile = "templates/layout.tmpl"
+
+// Message is a rendered email
+type Message struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// Link is a call-to-action button
+type Link struct {
+	URL   string
+	Label string
+}
+
+// Notice is a highlighted warning box
+type Notice struct {
+	Title string
+	Body  string
+}
+
+// emailTemplate is one email parsed for one language
+// Each file defines "subject", "html" and "text"; the layout wraps "html" and "text"
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// Renderer renders emails from the embedded templates in the recipient's language
+type Renderer struct {
+	baseURL   string
+	names     []string
+	templates map[string]map[string]*emailTemplate // language -> template name -> template
+}
+
+// NewRenderer parses all email templates for every supported language
+func NewRenderer(baseURL string) (*Renderer, error) {
+	catalogs, err := loadCatalogs()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := files.ReadDir("templates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email templates: %w", err)
+	}
+
+	r := &Renderer{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		templates: make(map[string]map[string]*emailTemplate, len(catalogs)),
+	}
+
+	for _, entry := range entries {
+		file := path.Join("templates", entry.Name())
+		if file == layoutFile {
+			continue
+		}
+		r.names = append(r.names, strings.TrimSuffix(entry.Name(), ".tmpl"))
+	}
+	sort.Strings(r.names)
+
+	for _, language := range SupportedLanguages {
+		funcs := r.funcs(language, catalogs)
+		r.templates[language] = make(map[string]*emailTemplate, len(r.names))
+
+		for _, name := range r.names {
+			file := path.Join("templates", name+".tmpl")
+
+			html, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).ParseFS(files, layoutFile, file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+			}
+			text, err := texttemplate.New(name).Funcs(texttemplate.FuncMap(funcs)).ParseFS(files, layoutFile, file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+			}
+
+			r.templates[language][name] = &emailTemplate{html: html, text: text}
+		}
+	}
+
+	return r, nil
+}
+
+// Templates returns the names of all email templates
+func (r *Renderer) Templates() []string {
+	return r.names
+}
+
+// Has checks if a template exists
+func (r *Renderer) Has(name string) bool {
+	_, ok := r.templates[DefaultLanguage][name]
+	return ok
+}
+
+// Render renders a template in the given language, falling back to the default language
+func (r *Renderer) Render(name, language string, data map[string]interface{}) (*Message, error) {
+	tmpl, ok := r.templates[NormalizeLanguage(language)][name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template: %s", name)
+	}
+
+	var subject, html, text bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout_html", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "layout_text", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    cleanText(text.String()),
+	}, nil
+}
+
+// funcs returns the template functions bound to one language
+func (r *Renderer) funcs(language string, messages catalogs) map[string]interface{} {
+	translate := func(key string, args ...interface{}) string {
+		return messages.translate(language, key, args...)
+	}
+
+	return map[string]interface{}{
+		// t looks up a translation, formatting args into it like fmt.Sprintf
+		"t": translate,
+		// tn picks the ".one" or ".other" form of a translation by count
+		"tn": func(key string, count int, args ...interface{}) string {
+			form := ".other"
+			if count == 1 {
+				form = ".one"
+			}
+			return translate(key+form, append([]interface{}{count}, args...)...)
+		},
+		// date formats a time with the language's layout
+		"date": func(t time.Time) string {
+			return t.Format(translate("format.datetime"))
+		},
+		"lang": func() string {
+			return language
+		},
+		"year": func() int {
+			return time.Now().Year()
+		},
+		"link": func(url, label string) Link {
+			return Link{URL: url, Label: label}
+		},
+		"notice": func(title, body string) Notice {
+			return Notice{Title: title, Body: body}
+		},
+	}
+}
+
+// cleanText trims the plain-text body and collapses runs of blank lines left by template actions
+func cleanText(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	out := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// catalog maps translation keys to messages for one language
+type catalog map[string]string
+
+// loadCatalogs reads the translation files of all supported languages
+func loadCatalogs() (catalogs, error) {
+	result := make(catalogs, len(SupportedLanguages))
+	for _, language := range SupportedLanguages {
+		data, err := files.ReadFile("locales/" + language + ".json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s translations: %w", language, err)
+		}
+
+		var messages catalog
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("failed to parse %s translations: %w", language, err)
+		}
+		result[language] = messages
+	}
+	return result, nil
+}
+
+// catalogs holds the translations of every supported language
+type catalogs map[string]catalog
+
+// translate looks up a key, falling back to the default language and then to the key itself
+func (c catalogs) translate(language, key string, args ...interface{}) string {
+	message, ok := c[language][key]
+	if !ok {
+		message, ok = c[DefaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
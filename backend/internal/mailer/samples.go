@@ -0,0 +1,68 @@
+package mailer
+
+import "time"
+
+// SampleData returns example data for previewing a template
+func (r *Renderer) SampleData(name string) map[string]interface{} {
+	now := time.Now()
+	manageURL := r.baseURL + "/settings"
+
+	switch name {
+	case "verification", "email_change_verification":
+		return map[string]interface{}{"Code": "482913"}
+
+	case "password_reset":
+		return map[string]interface{}{"ResetURL": r.baseURL + "/auth/reset-password?token=sample-reset-token"}
+
+	case "welcome":
+		return map[string]interface{}{"DisplayName": "Jordan Lee"}
+
+	case "password_changed", "two_factor_disabled":
+		return map[string]interface{}{"DisplayName": "Jordan Lee", "ChangedAt": now}
+
+	case "account_deleted":
+		return map[string]interface{}{"DisplayName": "Jordan Lee", "DeletedAt": now}
+
+	case "username_changed":
+		return map[string]interface{}{"DisplayName": "Jordan Lee", "OldUsername": "jordanlee", "NewUsername": "jordan"}
+
+	case "email_change_notice":
+		return map[string]interface{}{
+			"DisplayName":  "Jordan Lee",
+			"CurrentEmail": "jordan@example.com",
+			"NewEmail":     "jordan.lee@example.com",
+			"RequestedAt":  now,
+		}
+
+	case "follow", "connection_request", "connection_accepted", "collaboration_request", "collaboration_accepted":
+		return map[string]interface{}{
+			"ActorName": "Sam Rivera",
+			"ActionURL": r.baseURL + "/profile/samrivera",
+			"ManageURL": manageURL,
+		}
+
+	case "notification":
+		return map[string]interface{}{
+			"Title":     "Sam Rivera mentioned you in a post",
+			"Message":   "Great write-up, @jordan! Would love your thoughts on the follow-up.",
+			"ActionURL": r.baseURL + "/posts/sample",
+			"ManageURL": manageURL,
+		}
+
+	case "digest":
+		notifications := []map[string]interface{}{
+			{"Title": "Sam Rivera and 3 others liked your post", "Message": "Shipping the new editor today"},
+			{"Title": "Alex Kim replied to your comment", "Message": "Agreed, the second approach scales better."},
+			{"Title": "Your poll has ended"},
+		}
+		return map[string]interface{}{
+			"Period":           "daily",
+			"Count":            len(notifications),
+			"Notifications":    notifications,
+			"NotificationsURL": r.baseURL + "/notifications",
+			"ManageURL":        manageURL,
+		}
+	}
+
+	return map[string]interface{}{}
+}
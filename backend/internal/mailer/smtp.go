@@ -0,0 +1,125 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// How long a whole SMTP conversation may take when the context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// SMTPTransport sends emails through an SMTP server
+// Port 465 uses implicit TLS, other ports upgrade with STARTTLS when the server offers it
+type SMTPTransport struct {
+	host     string
+	port     int
+	username string
+	password string
+}
+
+// NewSMTPTransport creates a new SMTP transport
+func NewSMTPTransport(host string, port int, username, password string) *SMTPTransport {
+	return &SMTPTransport{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+	}
+}
+
+// Name returns "smtp"
+func (t *SMTPTransport) Name() string {
+	return "smtp"
+}
+
+// Send delivers an email, returning its Message-ID
+func (t *SMTPTransport) Send(ctx context.Context, envelope *Envelope) (string, error) {
+	message, err := envelope.mime()
+	if err != nil {
+		return "", Permanent(fmt.Errorf("failed to build email: %w", err))
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+
+	addr := net.JoinHostPort(t.host, strconv.Itoa(t.port))
+	dialer := &net.Dialer{Deadline: deadline}
+
+	var conn net.Conn
+	if t.port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: t.host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	// Bounds every read and write, a stalled server can't hold the worker
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return "", fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if t.port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+				return "", fmt.Errorf("failed to start TLS: %w", err)
+			}
+		}
+	}
+
+	if t.username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+				// Bad credentials are a configuration problem, retry once it's fixed
+				return "", fmt.Errorf("SMTP authentication failed: %w", err)
+			}
+		}
+	}
+
+	if err := client.Mail(envelope.FromEmail); err != nil {
+		return "", classifySMTPError("MAIL FROM", err)
+	}
+	if err := client.Rcpt(envelope.To); err != nil {
+		return "", classifySMTPError("RCPT TO", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return "", classifySMTPError("DATA", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return "", fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", classifySMTPError("DATA", err)
+	}
+
+	client.Quit()
+
+	return envelope.messageID(), nil
+}
+
+// classifySMTPError marks 5xx replies as permanent, 4xx replies (greylisting, full mailbox) are retried
+func classifySMTPError(command string, err error) error {
+	wrapped := fmt.Errorf("SMTP %s failed: %w", command, err)
+
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return Permanent(wrapped)
+	}
+	return wrapped
+}
@@ -0,0 +1,104 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"upvista-community-backend/internal/config"
+
+	"github.com/jordan-wright/email"
+)
+
+// Envelope is a rendered email ready to hand to a transport
+type Envelope struct {
+	ID        string // Outbox email ID, passed to providers so their webhooks can be matched back
+	FromName  string
+	FromEmail string
+	To        string
+	Subject   string
+	HTML      string
+	Text      string
+}
+
+// Transport delivers emails (SMTP, a local maildir or an HTTP API provider)
+type Transport interface {
+	// Name identifies the transport in the outbox ("smtp", "file", "sendgrid", ...)
+	Name() string
+	// Send delivers an email and returns the provider's message ID, if it has one
+	// Errors wrapped with Permanent are not retried
+	Send(ctx context.Context, envelope *Envelope) (string, error)
+}
+
+// PermanentError is a send failure that will fail again on retry (rejected recipient, invalid request)
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent marks an error as not worth retrying
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether a send error should not be retried
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// NewTransport creates the transport selected by EMAIL_TRANSPORT
+func NewTransport(cfg *config.EmailConfig) (Transport, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Transport)) {
+	case "", "smtp":
+		return NewSMTPTransport(cfg.Host, cfg.Port, cfg.Username, cfg.Password), nil
+	case "file":
+		return NewFileTransport(cfg.FileDir)
+	case "sendgrid":
+		return NewSendGridTransport(cfg.APIKey), nil
+	case "postmark":
+		return NewPostmarkTransport(cfg.APIKey), nil
+	case "resend":
+		return NewResendTransport(cfg.APIKey), nil
+	default:
+		return nil, fmt.Errorf("unsupported email transport: %s", cfg.Transport)
+	}
+}
+
+// messageID is the Message-ID header for an outbox email, so bounces quote something we can match
+func (e *Envelope) messageID() string {
+	domain := "localhost"
+	if at := strings.LastIndex(e.FromEmail, "@"); at >= 0 {
+		domain = e.FromEmail[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", e.ID, domain)
+}
+
+// from is the formatted From header
+func (e *Envelope) from() string {
+	if e.FromName == "" {
+		return e.FromEmail
+	}
+	return fmt.Sprintf("%s <%s>", e.FromName, e.FromEmail)
+}
+
+// mime builds the multipart/alternative message sent over SMTP or written to disk
+func (e *Envelope) mime() ([]byte, error) {
+	mail := email.NewEmail()
+	mail.From = e.from()
+	mail.To = []string{e.To}
+	mail.Subject = e.Subject
+	mail.HTML = []byte(e.HTML)
+	mail.Text = []byte(e.Text)
+	mail.Headers.Set("Message-Id", e.messageID())
+
+	return mail.Bytes()
+}
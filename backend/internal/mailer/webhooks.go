@@ -0,0 +1,264 @@
+package mailer
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Largest webhook body accepted (SendGrid batches up to a few hundred events)
+const maxWebhookBodySize = 1 << 20
+
+// WebhookHandlers receives delivery, bounce and complaint events from email providers
+type WebhookHandlers struct {
+	outbox *Outbox
+	secret string
+}
+
+// NewWebhookHandlers creates new email webhook handlers
+// secret is the EMAIL_WEBHOOK_SECRET providers send as ?token=, webhooks are refused when it is empty
+func NewWebhookHandlers(outbox *Outbox, secret string) *WebhookHandlers {
+	return &WebhookHandlers{
+		outbox: outbox,
+		secret: secret,
+	}
+}
+
+// HandleWebhook records the events in a provider webhook
+// POST /api/v1/webhooks/email/:provider?token=<EMAIL_WEBHOOK_SECRET>
+func (h *WebhookHandlers) HandleWebhook(c *gin.Context) {
+	if h.secret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Email webhooks are not configured",
+		})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Invalid webhook token",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Failed to read request body",
+		})
+		return
+	}
+
+	provider := c.Param("provider")
+	events, err := ParseWebhook(provider, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid webhook payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	for _, event := range events {
+		if err := h.outbox.HandleEvent(c.Request.Context(), event); err != nil {
+			// Acknowledged anyway, providers retry the whole batch on errors
+			log.Printf("[Outbox] Failed to handle %s %s event for %s: %v", provider, event.Type, event.Email, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook processed",
+		"events":  len(events),
+	})
+}
+
+// SetupRoutes registers the provider webhook route (public, authenticated by the webhook token)
+func (h *WebhookHandlers) SetupRoutes(router *gin.RouterGroup) {
+	router.POST("/webhooks/email/:provider", h.HandleWebhook)
+}
+
+// ParseWebhook turns a provider's webhook payload into delivery events
+// Events we don't act on (opens, clicks, processed) are skipped
+func ParseWebhook(provider string, body []byte) ([]*models.EmailDeliveryEvent, error) {
+	switch provider {
+	case "sendgrid":
+		return parseSendGridWebhook(body)
+	case "postmark":
+		return parsePostmarkWebhook(body)
+	case "resend":
+		return parseResendWebhook(body)
+	default:
+		return nil, fmt.Errorf("unsupported email provider: %s", provider)
+	}
+}
+
+// parseSendGridWebhook parses an Event Webhook batch
+// outbox_id is the custom arg set when sending
+func parseSendGridWebhook(body []byte) ([]*models.EmailDeliveryEvent, error) {
+	var payload []struct {
+		Event       string `json:"event"`
+		Email       string `json:"email"`
+		Type        string `json:"type"`
+		Reason      string `json:"reason"`
+		Response    string `json:"response"`
+		SGMessageID string `json:"sg_message_id"`
+		OutboxID    string `json:"outbox_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	events := make([]*models.EmailDeliveryEvent, 0, len(payload))
+	for _, item := range payload {
+		var eventType models.EmailEventType
+		switch item.Event {
+		case "delivered":
+			eventType = models.EmailEventDelivered
+		case "bounce":
+			// "blocked" bounces are temporary rejections, SendGrid retries them
+			eventType = models.EmailEventBounced
+			if item.Type == "blocked" {
+				eventType = models.EmailEventSoftBounce
+			}
+		case "deferred":
+			eventType = models.EmailEventSoftBounce
+		case "spamreport":
+			eventType = models.EmailEventComplained
+		default:
+			continue
+		}
+
+		reason := item.Reason
+		if reason == "" {
+			reason = item.Response
+		}
+
+		event := &models.EmailDeliveryEvent{
+			Type:   eventType,
+			Email:  item.Email,
+			Reason: reason,
+			// sg_message_id is X-Message-Id followed by a ".filter..." suffix
+			ProviderMessageID: strings.SplitN(item.SGMessageID, ".", 2)[0],
+		}
+		event.OutboxID = parseOutboxID(item.OutboxID)
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// parsePostmarkWebhook parses a Delivery, Bounce or SpamComplaint webhook
+func parsePostmarkWebhook(body []byte) ([]*models.EmailDeliveryEvent, error) {
+	var payload struct {
+		RecordType  string            `json:"RecordType"`
+		Type        string            `json:"Type"`
+		MessageID   string            `json:"MessageID"`
+		Email       string            `json:"Email"`
+		Recipient   string            `json:"Recipient"`
+		Description string            `json:"Description"`
+		Metadata    map[string]string `json:"Metadata"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	var eventType models.EmailEventType
+	switch payload.RecordType {
+	case "Delivery":
+		eventType = models.EmailEventDelivered
+	case "Bounce":
+		eventType = models.EmailEventSoftBounce
+		switch payload.Type {
+		case "HardBounce", "BadEmailAddress", "ManuallyDeactivated":
+			eventType = models.EmailEventBounced
+		case "SpamComplaint":
+			eventType = models.EmailEventComplained
+		}
+	case "SpamComplaint":
+		eventType = models.EmailEventComplained
+	default:
+		return nil, nil
+	}
+
+	email := payload.Email
+	if email == "" {
+		email = payload.Recipient
+	}
+
+	return []*models.EmailDeliveryEvent{{
+		Type:              eventType,
+		OutboxID:          parseOutboxID(payload.Metadata["outbox_id"]),
+		ProviderMessageID: payload.MessageID,
+		Email:             email,
+		Reason:            payload.Description,
+	}}, nil
+}
+
+// parseResendWebhook parses an email.* webhook, matched by Resend's email ID
+func parseResendWebhook(body []byte) ([]*models.EmailDeliveryEvent, error) {
+	var payload struct {
+		Type string `json:"type"`
+		Data struct {
+			EmailID string   `json:"email_id"`
+			To      []string `json:"to"`
+			Bounce  struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"bounce"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	var eventType models.EmailEventType
+	switch payload.Type {
+	case "email.delivered":
+		eventType = models.EmailEventDelivered
+	case "email.bounced":
+		eventType = models.EmailEventSoftBounce
+		if payload.Data.Bounce.Type == "Permanent" {
+			eventType = models.EmailEventBounced
+		}
+	case "email.delivery_delayed":
+		eventType = models.EmailEventSoftBounce
+	case "email.complained":
+		eventType = models.EmailEventComplained
+	default:
+		return nil, nil
+	}
+
+	var email string
+	if len(payload.Data.To) > 0 {
+		email = payload.Data.To[0]
+	}
+
+	return []*models.EmailDeliveryEvent{{
+		Type:              eventType,
+		ProviderMessageID: payload.Data.EmailID,
+		Email:             email,
+		Reason:            payload.Data.Bounce.Message,
+	}}, nil
+}
+
+func parseOutboxID(value string) *uuid.UUID {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
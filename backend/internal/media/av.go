@@ -0,0 +1,367 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"upvista-community-backend/internal/models"
+)
+
+// Video and audio are handled by ffmpeg and ffprobe, which have to be installed on the server
+const (
+	probeTimeout     = 30 * time.Second
+	thumbnailTimeout = 60 * time.Second
+	waveformTimeout  = 2 * time.Minute
+	transcodeTimeout = 15 * time.Minute
+
+	thumbnailMaxWidth = 640
+	videoMaxSize      = 1280 // Longest edge of transcoded video
+	videoCRF          = 23
+	videoAudioBitrate = "128k"
+	audioBitrate      = "96k"
+
+	waveformBars       = 64   // Peaks stored per audio file
+	waveformSampleRate = 8000 // Audio is downsampled to this before computing peaks
+	waveformWindow     = 80   // Samples per intermediate peak, 100 per second
+
+	// Uploads are only ever read from disk, playlists and references inside them (HLS in AVI, concat)
+	// must not make ffmpeg open other files or URLs
+	protocolWhitelist = "file,pipe"
+)
+
+// ErrAVToolsMissing is returned when ffmpeg or ffprobe can't be found
+var ErrAVToolsMissing = errors.New("ffmpeg and ffprobe are required for video and audio processing")
+
+// ErrUnsupportedContainer is returned when ffprobe detects a container uploads can't be
+var ErrUnsupportedContainer = errors.New("unsupported media container")
+
+// allowedFormats are the containers uploads are accepted in, as ffprobe names them
+// Playlist and reference demuxers (hls, concat, ...) are never accepted
+var allowedFormats = map[string]bool{
+	"mov,mp4,m4a,3gp,3g2,mj2": true,
+	"matroska,webm":           true,
+	"ogg":                     true,
+	"mp3":                     true,
+	"wav":                     true,
+	"flac":                    true,
+	"aac":                     true,
+	"amr":                     true,
+	"avi":                     true,
+}
+
+// Probe describes a video or audio file as reported by ffprobe
+type Probe struct {
+	Format     string  // Container, e.g. "mov,mp4,m4a,3gp,3g2,mj2", "matroska,webm" or "mp3"
+	Duration   float64 // Seconds
+	Width      int     // Display size, rotation applied
+	Height     int
+	VideoCodec string // e.g. h264, vp9, hevc, empty without a video stream
+	AudioCodec string // e.g. aac, opus, mp3, empty without an audio stream
+}
+
+// HasVideo reports whether the file has a video stream
+func (p *Probe) HasVideo() bool {
+	return p.VideoCodec != ""
+}
+
+// WebFriendly reports whether every browser can play the file as it is:
+// H.264 with AAC in MP4 for video, MP3 or AAC for audio
+func (p *Probe) WebFriendly(kind models.MediaKind) bool {
+	mp4 := strings.Contains(p.Format, "mp4")
+	if kind == models.MediaKindVideo {
+		return mp4 && p.VideoCodec == "h264" && (p.AudioCodec == "" || p.AudioCodec == "aac")
+	}
+	return !p.HasVideo() && (p.AudioCodec == "mp3" || (p.AudioCodec == "aac" && mp4))
+}
+
+// AVTools runs ffmpeg and ffprobe
+type AVTools struct {
+	ffmpeg  string
+	ffprobe string
+}
+
+// NewAVTools resolves the ffmpeg and ffprobe binaries, returns ErrAVToolsMissing if either can't be found
+func NewAVTools(ffmpegPath, ffprobePath string) (*AVTools, error) {
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+	if ffprobePath == "" {
+		ffprobePath = "ffprobe"
+	}
+
+	ffmpeg, err := exec.LookPath(ffmpegPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAVToolsMissing, err)
+	}
+	ffprobe, err := exec.LookPath(ffprobePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAVToolsMissing, err)
+	}
+
+	return &AVTools{ffmpeg: ffmpeg, ffprobe: ffprobe}, nil
+}
+
+// ffprobeOutput is the subset of `ffprobe -print_format json -show_format -show_streams` that is used
+type ffprobeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType    string            `json:"codec_type"`
+		CodecName    string            `json:"codec_name"`
+		Width        int               `json:"width"`
+		Height       int               `json:"height"`
+		Duration     string            `json:"duration"`
+		Tags         map[string]string `json:"tags"`
+		Disposition  map[string]int    `json:"disposition"`
+		SideDataList []struct {
+			Rotation float64 `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+}
+
+// Probe reads the container, duration, dimensions and codecs of a file
+// Containers that aren't allowed return ErrUnsupportedContainer
+func (t *AVTools) Probe(ctx context.Context, path string) (*Probe, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, t.ffprobe, "-v", "error", "-protocol_whitelist", protocolWhitelist,
+		"-print_format", "json", "-show_format", "-show_streams", path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var parsed ffprobeOutput
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	if !allowedFormats[parsed.Format.FormatName] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContainer, parsed.Format.FormatName)
+	}
+
+	probe := &Probe{Format: parsed.Format.FormatName}
+	probe.Duration, _ = strconv.ParseFloat(parsed.Format.Duration, 64)
+
+	for _, stream := range parsed.Streams {
+		switch stream.CodecType {
+		case "video":
+			// Cover art in audio files shows up as a single-frame video stream
+			if probe.VideoCodec != "" || stream.Disposition["attached_pic"] == 1 {
+				continue
+			}
+			probe.VideoCodec = stream.CodecName
+			probe.Width, probe.Height = stream.Width, stream.Height
+
+			rotation := 0.0
+			if rotate, ok := stream.Tags["rotate"]; ok {
+				rotation, _ = strconv.ParseFloat(rotate, 64)
+			}
+			for _, sideData := range stream.SideDataList {
+				if sideData.Rotation != 0 {
+					rotation = sideData.Rotation
+				}
+			}
+			if int(math.Abs(rotation))%180 == 90 {
+				probe.Width, probe.Height = probe.Height, probe.Width
+			}
+
+		case "audio":
+			if probe.AudioCodec == "" {
+				probe.AudioCodec = stream.CodecName
+			}
+		}
+
+		// Some containers (WebM from MediaRecorder) only have the duration on the stream, or none at all
+		if probe.Duration == 0 {
+			probe.Duration, _ = strconv.ParseFloat(stream.Duration, 64)
+		}
+	}
+
+	if probe.VideoCodec == "" && probe.AudioCodec == "" {
+		return nil, fmt.Errorf("no audio or video stream found")
+	}
+
+	return probe, nil
+}
+
+// Thumbnail grabs the frame at the given second as a JPEG, at most 640 pixels wide
+func (t *AVTools) Thumbnail(ctx context.Context, path string, at float64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, thumbnailTimeout)
+	defer cancel()
+
+	args := []string{
+		"-v", "error",
+		"-ss", strconv.FormatFloat(at, 'f', 3, 64),
+		"-i", path,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", thumbnailMaxWidth),
+		"-c:v", "mjpeg", "-q:v", "3",
+		"-f", "image2", "pipe:1",
+	}
+
+	data, err := t.run(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("ffmpeg returned no frame at %.3fs", at)
+	}
+	return data, nil
+}
+
+// Waveform returns the audio peaks of a file as 64 bars scaled to 0-100
+func (t *AVTools) Waveform(ctx context.Context, path string) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, waveformTimeout)
+	defer cancel()
+
+	// Decode to 8kHz mono 16-bit PCM on stdout, the peaks are read as it streams
+	cmd := exec.CommandContext(ctx, t.ffmpeg,
+		"-v", "error",
+		"-protocol_whitelist", protocolWhitelist,
+		"-i", path,
+		"-vn", "-ac", "1", "-ar", strconv.Itoa(waveformSampleRate),
+		"-f", "s16le", "-acodec", "pcm_s16le", "pipe:1",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	peaks, readErr := windowPeaks(bufio.NewReader(stdout))
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read audio samples: %w", readErr)
+	}
+
+	return waveformBarsFrom(peaks), nil
+}
+
+// windowPeaks returns the loudest sample of every window of 16-bit little-endian PCM
+func windowPeaks(r io.Reader) ([]int, error) {
+	var peaks []int
+	sample := make([]byte, 2)
+	peak, count := 0, 0
+	for {
+		if _, err := io.ReadFull(r, sample); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+
+		value := int(int16(binary.LittleEndian.Uint16(sample)))
+		if value < 0 {
+			value = -value
+		}
+		peak = max(peak, value)
+
+		count++
+		if count == waveformWindow {
+			peaks = append(peaks, peak)
+			peak, count = 0, 0
+		}
+	}
+	if count > 0 {
+		peaks = append(peaks, peak)
+	}
+	return peaks, nil
+}
+
+// waveformBarsFrom groups window peaks into bars and scales them so the loudest bar is 100
+func waveformBarsFrom(peaks []int) []int {
+	bars := make([]int, waveformBars)
+	if len(peaks) == 0 {
+		return bars
+	}
+
+	loudest := 0
+	for i := range bars {
+		start := i * len(peaks) / waveformBars
+		end := (i + 1) * len(peaks) / waveformBars
+		if end <= start {
+			end = start + 1 // Fewer peaks than bars, short clips repeat them
+		}
+		for _, peak := range peaks[start:min(end, len(peaks))] {
+			bars[i] = max(bars[i], peak)
+		}
+		loudest = max(loudest, bars[i])
+	}
+
+	if loudest == 0 {
+		return bars // Silence
+	}
+	for i := range bars {
+		bars[i] = bars[i] * 100 / loudest
+	}
+	return bars
+}
+
+// TranscodeVideo converts a video to H.264/AAC MP4 that fits in 1280x1280, ready for progressive playback
+func (t *AVTools) TranscodeVideo(ctx context.Context, input, output string) error {
+	ctx, cancel := context.WithTimeout(ctx, transcodeTimeout)
+	defer cancel()
+
+	scale := fmt.Sprintf("scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease:force_divisible_by=2", videoMaxSize, videoMaxSize)
+	_, err := t.run(ctx, []string{
+		"-v", "error", "-y",
+		"-i", input,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-vf", scale,
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", strconv.Itoa(videoCRF), "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", videoAudioBitrate,
+		"-movflags", "+faststart",
+		"-f", "mp4", output,
+	})
+	return err
+}
+
+// TranscodeAudio converts audio to MP3, which every browser plays (WebM/Opus doesn't work in older Safari)
+func (t *AVTools) TranscodeAudio(ctx context.Context, input, output string) error {
+	ctx, cancel := context.WithTimeout(ctx, transcodeTimeout)
+	defer cancel()
+
+	_, err := t.run(ctx, []string{
+		"-v", "error", "-y",
+		"-i", input,
+		"-vn",
+		"-c:a", "libmp3lame", "-b:a", audioBitrate,
+		"-f", "mp3", output,
+	})
+	return err
+}
+
+// run runs ffmpeg and returns what it wrote to stdout, only letting it open local files and pipes
+func (t *AVTools) run(ctx context.Context, args []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, t.ffmpeg, append([]string{"-protocol_whitelist", protocolWhitelist}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
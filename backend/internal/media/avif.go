@@ -0,0 +1,66 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// There is no pure Go AVIF encoder, AVIF variants are produced by avifenc (libavif 1.0+) when it is configured
+const (
+	avifQuality   = 60
+	avifQualityHD = 75
+	avifSpeed     = 8 // 0 (slowest, smallest) to 10
+	avifTimeout   = 30 * time.Second
+)
+
+// avifEncoder runs an external avifenc binary
+type avifEncoder struct {
+	path string
+}
+
+// encode writes the image to a temporary PNG and converts it with avifenc
+func (e *avifEncoder) encode(ctx context.Context, img image.Image, quality Quality) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "avif-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "in.png")
+	output := filepath.Join(dir, "out.avif")
+
+	f, err := os.Create(input)
+	if err != nil {
+		return nil, err
+	}
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(f, img); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	q := avifQuality
+	if quality == QualityHD {
+		q = avifQualityHD
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, avifTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.path, "--speed", strconv.Itoa(avifSpeed), "-q", strconv.Itoa(q), input, output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("avifenc failed: %w: %s", err, out)
+	}
+
+	return os.ReadFile(output)
+}
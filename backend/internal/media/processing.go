@@ -0,0 +1,370 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"upvista-community-backend/internal/models"
+)
+
+// Retry schedule: 30s, 1m, 2m, 4m... capped at 1 hour
+const (
+	processingRetryBaseDelay = 30 * time.Second
+	processingRetryMaxDelay  = time.Hour
+	defaultMediaWorkers      = 1
+	defaultMediaMaxAttempts  = 3
+
+	// Jobs claimed longer ago than this belong to a worker that died mid-job, longer than any transcode
+	processingStaleLockTimeout = 30 * time.Minute
+)
+
+// MediaListener is told when a video or audio file has been processed, or processing gave up
+type MediaListener interface {
+	MediaProcessed(ctx context.Context, info *models.MediaInfo)
+}
+
+// AddListener registers a listener for processed media (posts and messages update their copies)
+func (s *Service) AddListener(listener MediaListener) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Wakeups signals when media is queued, so workers process it without waiting for the next tick
+func (s *Service) Wakeups() <-chan struct{} {
+	return s.wake
+}
+
+// enqueue queues a recorded video or audio asset for processing
+// The file is usable right away, processing adds to it and is announced to listeners
+func (s *Service) enqueue(ctx context.Context, asset *models.MediaAsset) {
+	job := &models.MediaJob{AssetID: asset.ID}
+	if err := s.jobs.CreateJob(ctx, job); err != nil {
+		// The file still works, it just won't get processed
+		log.Printf("[Media] Failed to queue processing of %s: %v", asset.URL, err)
+		asset.Status = models.MediaStatusFailed
+		if err := s.repo.UpdateMedia(ctx, asset.ID, &asset.MediaInfo); err != nil {
+			log.Printf("[Media] Failed to mark media asset %s failed: %v", asset.ID, err)
+		}
+		return
+	}
+
+	// Non-blocking, one pending signal is enough to start processing
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ProcessDue processes up to limit due jobs concurrently, returning how many were picked up
+func (s *Service) ProcessDue(ctx context.Context, limit int) (int, error) {
+	if s.av == nil {
+		return 0, nil
+	}
+
+	jobs, err := s.jobs.GetDueJobs(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch due media jobs: %w", err)
+	}
+	if len(jobs) == 0 {
+		return 0, nil
+	}
+
+	queue := make(chan *models.MediaJob)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers && i < len(jobs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				s.runJob(ctx, job)
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	return len(jobs), nil
+}
+
+// ReleaseStale requeues jobs whose worker died mid-job
+func (s *Service) ReleaseStale(ctx context.Context) (int, error) {
+	if s.av == nil {
+		return 0, nil
+	}
+	return s.jobs.ReleaseStaleJobs(ctx, time.Now().Add(-processingStaleLockTimeout))
+}
+
+// runJob claims a job and processes its asset, scheduling a retry when it fails
+func (s *Service) runJob(ctx context.Context, job *models.MediaJob) {
+	claimed, err := s.jobs.ClaimJob(ctx, job.ID)
+	if err != nil {
+		log.Printf("[Media] Failed to claim media job %s: %v", job.ID, err)
+		return
+	}
+	if !claimed {
+		return // Another worker got it
+	}
+
+	attempts := job.Attempts + 1
+
+	asset, err := s.repo.GetAsset(ctx, job.AssetID)
+	if err == nil && asset == nil {
+		err = fmt.Errorf("media asset %s not found", job.AssetID)
+		attempts = s.maxAttempts // Nothing to retry
+	}
+
+	var info *models.MediaInfo
+	if err == nil {
+		info, err = s.process(ctx, asset)
+		if errors.Is(err, ErrUnsupportedContainer) {
+			attempts = s.maxAttempts // Probing again won't change the container
+		}
+	}
+
+	if err == nil {
+		if err := s.repo.UpdateMedia(ctx, asset.ID, info); err != nil {
+			// Retried like any failure, the outputs are overwritten next time
+			s.retryJob(ctx, job, attempts, fmt.Errorf("failed to save processed media: %w", err))
+			return
+		}
+		if err := s.jobs.MarkJobCompleted(ctx, job.ID, attempts); err != nil {
+			log.Printf("[Media] Media job %s done but status update failed: %v", job.ID, err)
+		}
+		s.notify(ctx, info)
+		return
+	}
+
+	if attempts < s.maxAttempts {
+		s.retryJob(ctx, job, attempts, err)
+		return
+	}
+
+	log.Printf("[Media] Media job %s failed after %d attempts: %v", job.ID, attempts, err)
+	if markErr := s.jobs.MarkJobFailed(ctx, job.ID, attempts, err.Error()); markErr != nil {
+		log.Printf("[Media] Failed to mark media job %s failed: %v", job.ID, markErr)
+	}
+	if asset == nil {
+		return
+	}
+
+	// The original upload stays usable, clients stop waiting for processing
+	asset.Status = models.MediaStatusFailed
+	if err := s.repo.UpdateMedia(ctx, asset.ID, &asset.MediaInfo); err != nil {
+		log.Printf("[Media] Failed to mark media asset %s failed: %v", asset.ID, err)
+	}
+	s.notify(ctx, &asset.MediaInfo)
+}
+
+func (s *Service) retryJob(ctx context.Context, job *models.MediaJob, attempts int, jobErr error) {
+	nextAttemptAt := time.Now().Add(processingRetryDelay(attempts))
+	log.Printf("[Media] Media job %s failed (attempt %d/%d), retrying at %s: %v",
+		job.ID, attempts, s.maxAttempts, nextAttemptAt.Format(time.RFC3339), jobErr)
+	if err := s.jobs.ScheduleRetry(ctx, job.ID, attempts, nextAttemptAt, jobErr.Error()); err != nil {
+		log.Printf("[Media] Failed to schedule retry for media job %s: %v", job.ID, err)
+	}
+}
+
+// processingRetryDelay doubles the wait after every attempt, with up to 20% jitter so retries don't bunch up
+func processingRetryDelay(attempts int) time.Duration {
+	delay := processingRetryBaseDelay
+	for i := 1; i < attempts && delay < processingRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > processingRetryMaxDelay {
+		delay = processingRetryMaxDelay
+	}
+
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// process downloads an asset, probes it and uploads its thumbnail and transcoded copy next to it
+// as <key>_thumb.jpg and <key>_web.mp4 (or .mp3), overwriting the outputs of an earlier attempt
+func (s *Service) process(ctx context.Context, asset *models.MediaAsset) (*models.MediaInfo, error) {
+	driver := s.storage.Driver()
+	bucket, key, ok := driver.ParseURL(asset.URL)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a stored file", asset.URL)
+	}
+	keyBase := strings.TrimSuffix(key, path.Ext(key))
+
+	dir, err := os.MkdirTemp("", "media-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source"+path.Ext(key))
+	if err := s.download(ctx, bucket, key, source); err != nil {
+		return nil, err
+	}
+
+	probe, err := s.av.Probe(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if asset.Kind == models.MediaKindVideo && !probe.HasVideo() {
+		return nil, fmt.Errorf("no video stream found")
+	}
+
+	info := asset.MediaInfo
+	info.Status = models.MediaStatusReady
+	info.Duration = probe.Duration
+	info.Width, info.Height = probe.Width, probe.Height
+	info.VideoCodec, info.AudioCodec = probe.VideoCodec, probe.AudioCodec
+	info.Variants = []models.MediaVariant{}
+
+	if asset.Kind == models.MediaKindVideo {
+		thumb, err := s.videoThumbnail(ctx, source, probe.Duration, bucket, keyBase+"_thumb.jpg")
+		if err != nil {
+			return nil, err
+		}
+		info.ThumbnailURL = thumb.URL
+		info.Blurhash = thumb.blurhash
+		info.Variants = append(info.Variants, thumb.MediaVariant)
+	} else {
+		info.Waveform, err = s.av.Waveform(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if probe.WebFriendly(asset.Kind) {
+		return &info, nil
+	}
+
+	format, contentType, transcode := "mp3", "audio/mpeg", s.av.TranscodeAudio
+	if asset.Kind == models.MediaKindVideo {
+		format, contentType, transcode = "mp4", "video/mp4", s.av.TranscodeVideo
+	}
+
+	output := filepath.Join(dir, "web."+format)
+	if err := transcode(ctx, source, output); err != nil {
+		return nil, err
+	}
+
+	web := models.MediaVariant{Name: "web", Format: format}
+	if asset.Kind == models.MediaKindVideo {
+		// Transcoding scales the video down, the variant gets its own size
+		transcoded, err := s.av.Probe(ctx, output)
+		if err != nil {
+			return nil, err
+		}
+		web.Width, web.Height = transcoded.Width, transcoded.Height
+	}
+
+	web.URL, web.Size, err = s.uploadFile(ctx, bucket, fmt.Sprintf("%s_web.%s", keyBase, format), output, contentType)
+	if err != nil {
+		return nil, err
+	}
+	info.Variants = append(info.Variants, web)
+
+	return &info, nil
+}
+
+// thumbnailVariant is an uploaded video thumbnail and its placeholder
+type thumbnailVariant struct {
+	models.MediaVariant
+	blurhash string
+}
+
+// videoThumbnail grabs a frame one second in (or halfway through shorter clips) and uploads it
+func (s *Service) videoThumbnail(ctx context.Context, source string, duration float64, bucket, key string) (*thumbnailVariant, error) {
+	at := 1.0
+	if duration > 0 && duration < 2 {
+		at = duration / 2
+	}
+
+	data, err := s.av.Thumbnail(ctx, source, at)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := s.storage.UploadFile(ctx, bucket, key, bytes.NewReader(data), "image/jpeg")
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload thumbnail: %w", err)
+	}
+
+	bounds := img.Bounds()
+	return &thumbnailVariant{
+		MediaVariant: models.MediaVariant{
+			Name:   "thumb",
+			Format: "jpeg",
+			URL:    url,
+			Width:  bounds.Dx(),
+			Height: bounds.Dy(),
+			Size:   int64(len(data)),
+		},
+		blurhash: placeholder(img),
+	}, nil
+}
+
+// download copies an object to a local file, ffmpeg needs to seek in its input
+func (s *Service) download(ctx context.Context, bucket, key, dest string) error {
+	body, err := s.storage.Driver().Get(ctx, bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed to download %s/%s: %w", bucket, key, err)
+	}
+	defer body.Close()
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to download %s/%s: %w", bucket, key, err)
+	}
+	return f.Close()
+}
+
+// uploadFile streams a local file to a bucket, returning its URL and size
+func (s *Service) uploadFile(ctx context.Context, bucket, key, src, contentType string) (string, int64, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+
+	url, err := s.storage.UploadFile(ctx, bucket, key, f, contentType)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return url, stat.Size(), nil
+}
+
+// notify hands processed media to every listener
+func (s *Service) notify(ctx context.Context, info *models.MediaInfo) {
+	s.listenersMu.RLock()
+	listeners := s.listeners
+	s.listenersMu.RUnlock()
+
+	for _, listener := range listeners {
+		listener.MediaProcessed(ctx, info)
+	}
+}
@@ -0,0 +1,223 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"log"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/buckket/go-blurhash"
+	"github.com/disintegration/imaging"
+
+	_ "golang.org/x/image/webp" // Accept WebP uploads
+)
+
+// Quality selects how large and how finely the large variant is encoded
+type Quality string
+
+const (
+	QualityStandard Quality = "standard"
+	QualityHD       Quality = "hd"
+)
+
+// variantSpec is one size every image is rendered at
+type variantSpec struct {
+	name string
+	size int  // Longest edge, or both edges for square crops
+	crop bool // Fill a size x size square instead of fitting inside it
+}
+
+// Images are never upscaled: small and medium are skipped when the source isn't larger than them,
+// large is always rendered and keeps the source size if it is smaller
+var variantSpecs = []variantSpec{
+	{name: "thumb", size: 200, crop: true},
+	{name: "small", size: 320},
+	{name: "medium", size: 800},
+	{name: "large", size: 1920},
+}
+
+const (
+	largeSizeHD     = 4096
+	jpegQuality     = 82
+	jpegQualityHD   = 90
+	blurhashWidth   = 32 // The placeholder is computed on a tiny copy, it only keeps a few colors anyway
+	blurhashXComp   = 4
+	blurhashYComp   = 3
+	maxSourcePixels = 50_000_000 // Decoding is refused above this, roughly 200MB of RGBA
+)
+
+// EncodedVariant is a rendered and encoded variant
+type EncodedVariant struct {
+	Name        string
+	Format      string // jpeg, webp or avif
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Processed is the result of processing an image
+type Processed struct {
+	Width    int // After orientation correction
+	Height   int
+	Blurhash string
+	HasAlpha bool
+	Variants []EncodedVariant // Ordered by size; for each size the primary format comes first
+}
+
+// Processor renders images into size variants
+// Opaque images are encoded as JPEG plus lossless WebP, images with transparency only as lossless WebP
+// so alpha is kept. When an AVIF encoder is configured every variant is also encoded as AVIF.
+type Processor struct {
+	avif *avifEncoder // Optional
+}
+
+// NewProcessor creates an image processor, avifEncoderPath may be empty to skip AVIF
+func NewProcessor(avifEncoderPath string) *Processor {
+	p := &Processor{}
+	if avifEncoderPath != "" {
+		p.avif = &avifEncoder{path: avifEncoderPath}
+	}
+	return p
+}
+
+// Process decodes an image, corrects its orientation and renders every variant
+// Re-encoding drops all metadata, EXIF included
+func (p *Processor) Process(ctx context.Context, data []byte, quality Quality) (*Processed, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if config.Width*config.Height > maxSourcePixels {
+		return nil, fmt.Errorf("image is too large: %dx%d", config.Width, config.Height)
+	}
+
+	img, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	result := &Processed{
+		Width:    bounds.Dx(),
+		Height:   bounds.Dy(),
+		HasAlpha: HasAlpha(img),
+	}
+
+	result.Blurhash = placeholder(img)
+
+	for _, spec := range variantSpecs {
+		size := spec.size
+		if spec.name == "large" && quality == QualityHD {
+			size = largeSizeHD
+		}
+
+		var rendered image.Image
+		if spec.crop {
+			edge := min(size, bounds.Dx(), bounds.Dy())
+			rendered = imaging.Fill(img, edge, edge, imaging.Center, imaging.Lanczos)
+		} else {
+			longest := max(bounds.Dx(), bounds.Dy())
+			switch {
+			case longest > size:
+				rendered = imaging.Fit(img, size, size, imaging.Lanczos)
+			case spec.name == "large":
+				rendered = img
+			default:
+				continue // The large variant covers the source size
+			}
+		}
+
+		variants, err := p.encode(ctx, spec.name, rendered, result.HasAlpha, quality)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, variants...)
+	}
+
+	return result, nil
+}
+
+// encode encodes a rendered variant in the primary format, as WebP if that isn't the primary format,
+// and as AVIF if available
+func (p *Processor) encode(ctx context.Context, name string, img image.Image, hasAlpha bool, quality Quality) ([]EncodedVariant, error) {
+	bounds := img.Bounds()
+	variant := EncodedVariant{
+		Name:   name,
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+	}
+
+	var webp bytes.Buffer
+	if err := EncodeWebP(&webp, img); err != nil {
+		return nil, err
+	}
+
+	var variants []EncodedVariant
+	if !hasAlpha {
+		q := jpegQuality
+		if quality == QualityHD {
+			q = jpegQualityHD
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: q}); err != nil {
+			return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+		variant.Format, variant.ContentType, variant.Data = "jpeg", "image/jpeg", buf.Bytes()
+		variants = append(variants, variant)
+	}
+	variant.Format, variant.ContentType, variant.Data = "webp", "image/webp", webp.Bytes()
+	variants = append(variants, variant)
+
+	if p.avif == nil {
+		return variants, nil
+	}
+
+	// AVIF is an extra, the image is still usable without it
+	avifData, err := p.avif.encode(ctx, img, quality)
+	if err != nil {
+		log.Printf("[Media] Failed to encode %s variant as AVIF: %v", name, err)
+		return variants, nil
+	}
+	variant.Format, variant.ContentType, variant.Data = "avif", "image/avif", avifData
+	return append(variants, variant), nil
+}
+
+// placeholder computes the blurhash of an image, empty if it fails
+func placeholder(img image.Image) string {
+	hash, err := blurhash.Encode(blurhashXComp, blurhashYComp, imaging.Resize(img, blurhashWidth, 0, imaging.Box))
+	if err != nil {
+		log.Printf("[Media] Failed to compute blurhash: %v", err)
+		return ""
+	}
+	return hash
+}
+
+// Decode decodes an image and rotates it upright according to its EXIF orientation
+func Decode(r io.Reader) (image.Image, error) {
+	img, err := imaging.Decode(r, imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// HasAlpha reports whether an image has transparent pixels
+func HasAlpha(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return !opaque.Opaque()
+	}
+	return true // Unknown image types are assumed to need alpha
+}
+
+// EncodeWebP encodes an image as lossless WebP, alpha included
+func EncodeWebP(w io.Writer, img image.Image) error {
+	if err := nativewebp.Encode(w, img, nil); err != nil {
+		return fmt.Errorf("failed to encode webp: %w", err)
+	}
+	return nil
+}
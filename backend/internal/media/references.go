@@ -0,0 +1,71 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Attach records that a post, comment, message or event uses the media among urls, so it isn't collected
+// URLs that weren't uploaded through the service (external links) are skipped
+func (s *Service) Attach(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID, urls ...string) error {
+	stored := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if u != "" {
+			stored = append(stored, u)
+		}
+	}
+	if len(stored) == 0 {
+		return nil
+	}
+
+	assets, err := s.repo.GetAssetsByURLs(ctx, stored)
+	if err != nil {
+		return err
+	}
+
+	refs := make([]*models.MediaReference, len(assets))
+	for i, asset := range assets {
+		refs[i] = &models.MediaReference{AssetID: asset.ID, OwnerType: ownerType, OwnerID: ownerID}
+	}
+	return s.refs.AddReferences(ctx, refs)
+}
+
+// Detach releases every media used by a post, comment, message or event
+// Files nothing else uses are deleted by CollectOrphans once the grace period has passed
+func (s *Service) Detach(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID) error {
+	return s.refs.RemoveReferences(ctx, ownerType, ownerID)
+}
+
+// CollectOrphans deletes up to limit assets that have been unreferenced for the grace period, with their files
+// Returns how many were deleted
+func (s *Service) CollectOrphans(ctx context.Context, limit int) (int, error) {
+	cutoff := time.Now().Add(-s.gcGrace)
+	assets, err := s.repo.GetOrphanedAssets(ctx, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch orphaned media: %w", err)
+	}
+
+	deleted := 0
+	for _, asset := range assets {
+		// The row goes first: once it is gone no upload can reuse the files
+		ok, err := s.repo.DeleteOrphanedAsset(ctx, asset.ID, cutoff)
+		if err != nil {
+			log.Printf("[Media] Failed to delete orphaned media asset %s: %v", asset.ID, err)
+			continue
+		}
+		if !ok {
+			continue // Referenced or reused since it was fetched
+		}
+
+		s.deleteFiles(ctx, asset.Files())
+		deleted++
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,368 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"upvista-community-backend/internal/config"
+	"upvista-community-backend/internal/inspect"
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/repository"
+	"upvista-community-backend/internal/utils"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// Service processes uploaded images into variants and stores them, processes uploaded video and audio
+// in the background, and stores every file once per bucket, deleting it once nothing uses it
+// Every upload is inspected first: its sniffed type is what it is stored as, unsafe files never reach a bucket
+type Service struct {
+	processor *Processor
+	repo      repository.MediaAssetRepository
+	jobs      repository.MediaJobRepository
+	refs      repository.MediaReferenceRepository
+	storage   *utils.StorageService
+	inspector *inspect.Inspector
+	gcGrace   time.Duration // Unreferenced assets are deleted after this long
+
+	av          *AVTools // nil when ffmpeg isn't installed, video and audio are then stored unprocessed
+	workers     int
+	maxAttempts int
+
+	listenersMu sync.RWMutex
+	listeners   []MediaListener
+
+	wake chan struct{}
+}
+
+// NewService creates a new media service
+func NewService(
+	repo repository.MediaAssetRepository,
+	jobRepo repository.MediaJobRepository,
+	refRepo repository.MediaReferenceRepository,
+	storageSvc *utils.StorageService,
+	inspector *inspect.Inspector,
+	cfg *config.Config,
+) *Service {
+	workers := cfg.Storage.MediaWorkers
+	if workers <= 0 {
+		workers = defaultMediaWorkers
+	}
+	maxAttempts := cfg.Storage.MediaMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMediaMaxAttempts
+	}
+
+	av, err := NewAVTools(cfg.Storage.FFmpegPath, cfg.Storage.FFprobePath)
+	if err != nil {
+		log.Printf("[Media] Warning: %v, video and audio will be stored unprocessed", err)
+	}
+
+	return &Service{
+		processor:   NewProcessor(cfg.Storage.ImageAVIFEncoder),
+		repo:        repo,
+		jobs:        jobRepo,
+		refs:        refRepo,
+		storage:     storageSvc,
+		inspector:   inspector,
+		gcGrace:     cfg.GetMediaGCGrace(),
+		av:          av,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// AV returns the ffmpeg tools, nil when video and audio processing is disabled
+func (s *Service) AV() *AVTools {
+	return s.av
+}
+
+// Inspect checks an upload that is stored without going through StoreImage or StoreFile
+func (s *Service) Inspect(ctx context.Context, upload inspect.Upload, file io.ReadSeeker) (*inspect.Result, error) {
+	return s.inspector.Inspect(ctx, upload, file)
+}
+
+// StoreImage renders an image into its variants and uploads them, unless the same image was already stored
+// in the bucket at this quality, in which case the stored variants are returned
+// contentType is the type declared by the client, the content must match it
+// The returned URL is the large variant in the primary format, callers store it like any other media URL
+func (s *Service) StoreImage(ctx context.Context, userID uuid.UUID, bucket string, data []byte, contentType string, quality Quality) (*models.MediaInfo, error) {
+	upload := inspect.Upload{UserID: userID, Kind: models.MediaKindImage, Bucket: bucket, ContentType: contentType}
+	inspected, err := s.inspector.Inspect(ctx, upload, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// Variants depend on the quality, so each quality of an image is its own asset
+	contentHash := fmt.Sprintf("%s-%s", inspected.SHA256, quality)
+	if existing := s.reuse(ctx, bucket, contentHash); existing != nil {
+		return &existing.MediaInfo, nil
+	}
+
+	processed, err := s.processor.Process(ctx, data, quality)
+	if err != nil {
+		log.Printf("[Media] Failed to process image: %v", err)
+		return nil, apperr.NewAppError(400, "Invalid or unsupported image")
+	}
+
+	info := &models.MediaInfo{
+		Kind:     models.MediaKindImage,
+		Status:   models.MediaStatusReady,
+		Width:    processed.Width,
+		Height:   processed.Height,
+		Blurhash: processed.Blurhash,
+		Variants: make([]models.MediaVariant, 0, len(processed.Variants)),
+	}
+
+	keyBase := contentKey(contentHash)
+	for _, variant := range processed.Variants {
+		key := fmt.Sprintf("%s_%s.%s", keyBase, variant.Name, variant.Format)
+		url, err := s.storage.UploadFile(ctx, bucket, key, bytes.NewReader(variant.Data), variant.ContentType)
+		if err != nil {
+			log.Printf("[Media] Failed to upload %s: %v", key, err)
+			s.deleteVariants(ctx, info.Variants)
+			return nil, err
+		}
+
+		info.Variants = append(info.Variants, models.MediaVariant{
+			Name:   variant.Name,
+			Format: variant.Format,
+			URL:    url,
+			Width:  variant.Width,
+			Height: variant.Height,
+			Size:   int64(len(variant.Data)),
+		})
+
+		// First large variant is the primary format
+		if variant.Name == "large" && info.URL == "" {
+			info.URL = url
+		}
+	}
+
+	// The variants are usable even if the record fails, posts and messages just won't get them attached
+	asset := &models.MediaAsset{
+		UserID:      userID,
+		Bucket:      bucket,
+		ContentHash: contentHash,
+		Size:        int64(len(data)),
+		MediaInfo:   *info,
+	}
+	recorded, err := s.record(ctx, asset)
+	if err != nil {
+		log.Printf("[Media] Failed to record media asset %s: %v", info.URL, err)
+		return info, nil
+	}
+
+	return &recorded.MediaInfo, nil
+}
+
+// StoreFile uploads a video, audio or other file under its content hash, unless the same file is already
+// stored in the bucket, in which case the stored one is returned
+// filename and contentType are as sent by the client, the file is stored with the type sniffed from its content.
+// Video and audio are queued for processing: duration, thumbnail, waveform and a transcoded copy are added
+// by a worker (jobs.MediaProcessingJob) and announced to listeners. Without ffmpeg they are recorded as ready, unprocessed.
+func (s *Service) StoreFile(ctx context.Context, userID uuid.UUID, kind models.MediaKind, bucket string, file io.ReadSeeker, filename, contentType string) (*models.MediaInfo, error) {
+	upload := inspect.Upload{UserID: userID, Kind: kind, Bucket: bucket, Filename: filename, ContentType: contentType}
+	inspected, err := s.inspector.Inspect(ctx, upload, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing := s.reuse(ctx, bucket, inspected.SHA256); existing != nil {
+		return &existing.MediaInfo, nil
+	}
+
+	key := contentKey(inspected.SHA256) + inspected.Extension(storedExtension(filename))
+	fileURL, err := s.storage.UploadFile(ctx, bucket, key, file, inspected.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.adopt(ctx, userID, kind, bucket, inspected.SHA256, inspected.Size, fileURL), nil
+}
+
+// storedExtension is the extension of an uploaded filename made safe for object keys, .bin if there is none
+func storedExtension(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	ext = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, strings.TrimPrefix(ext, "."))
+	if ext == "" || len(ext) > 10 {
+		return ".bin"
+	}
+	return "." + ext
+}
+
+// AdoptUpload records a file that was already written to the bucket (a completed resumable upload)
+// If the same file is already stored, the new copy is deleted and the stored one is returned
+func (s *Service) AdoptUpload(ctx context.Context, userID uuid.UUID, kind models.MediaKind, bucket, key, contentHash string, size int64) *models.MediaInfo {
+	fileURL := s.storage.Driver().URL(bucket, key)
+	if existing := s.reuse(ctx, bucket, contentHash); existing != nil {
+		s.deleteFiles(ctx, []string{fileURL})
+		return &existing.MediaInfo
+	}
+
+	return s.adopt(ctx, userID, kind, bucket, contentHash, size, fileURL)
+}
+
+// adopt records an uploaded file and queues video and audio for processing
+func (s *Service) adopt(ctx context.Context, userID uuid.UUID, kind models.MediaKind, bucket, contentHash string, size int64, fileURL string) *models.MediaInfo {
+	asset := &models.MediaAsset{
+		UserID:      userID,
+		Bucket:      bucket,
+		ContentHash: contentHash,
+		Size:        size,
+		MediaInfo: models.MediaInfo{
+			URL:      fileURL,
+			Kind:     kind,
+			Status:   models.MediaStatusReady,
+			Variants: []models.MediaVariant{},
+		},
+	}
+	process := s.av != nil && (kind == models.MediaKindVideo || kind == models.MediaKindAudio)
+	if process {
+		asset.Status = models.MediaStatusProcessing
+	}
+
+	recorded, err := s.record(ctx, asset)
+	if err != nil {
+		// The file is usable even if the record fails, it just won't be processed
+		log.Printf("[Media] Failed to record media asset %s: %v", fileURL, err)
+		asset.Status = models.MediaStatusReady
+		return &asset.MediaInfo
+	}
+	if recorded != asset || !process {
+		return &recorded.MediaInfo
+	}
+
+	s.enqueue(ctx, asset)
+	return &asset.MediaInfo
+}
+
+// reuse returns the asset already stored in the bucket with the content hash, nil if the file has to be stored
+// The grace period of an unreferenced asset restarts, so it isn't collected before the new upload is used
+func (s *Service) reuse(ctx context.Context, bucket, contentHash string) *models.MediaAsset {
+	asset, err := s.repo.GetAssetByHash(ctx, bucket, contentHash)
+	if err != nil {
+		// Storing a duplicate beats failing the upload
+		log.Printf("[Media] Failed to look up %s/%s: %v", bucket, contentHash, err)
+		return nil
+	}
+	if asset == nil {
+		return nil
+	}
+
+	touched, err := s.repo.TouchAsset(ctx, asset.ID)
+	if err != nil {
+		log.Printf("[Media] Failed to touch media asset %s: %v", asset.ID, err)
+		return nil
+	}
+	if touched {
+		return asset
+	}
+
+	// Not touched: either it is referenced, or it was collected in the meantime
+	asset, err = s.repo.GetAsset(ctx, asset.ID)
+	if err != nil {
+		log.Printf("[Media] Failed to get media asset: %v", err)
+		return nil
+	}
+	return asset
+}
+
+// record saves a newly stored asset. If an identical upload was recorded first, the new copy is deleted
+// and the recorded asset is returned instead.
+func (s *Service) record(ctx context.Context, asset *models.MediaAsset) (*models.MediaAsset, error) {
+	err := s.repo.CreateAsset(ctx, asset)
+	if !errors.Is(err, repository.ErrMediaAssetExists) {
+		return asset, err
+	}
+
+	existing := s.reuse(ctx, asset.Bucket, asset.ContentHash)
+	if existing == nil {
+		return nil, err
+	}
+	s.deleteFiles(ctx, asset.Files())
+	return existing, nil
+}
+
+// contentKey returns the key prefix of a stored file: media/<first two hash digits>/<hash>-<random suffix>
+// The suffix keeps an upload racing an identical one, or following the collection of an identical one,
+// from overwriting files the other asset still owns or is about to delete
+func contentKey(contentHash string) string {
+	return fmt.Sprintf("media/%s/%s-%s", contentHash[:2], contentHash, uuid.New().String()[:8])
+}
+
+// ResolveMedia returns the recorded media among urls, in urls order
+// URLs that weren't uploaded through the service (external links) are skipped
+func (s *Service) ResolveMedia(ctx context.Context, urls []string) ([]models.MediaInfo, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	assets, err := s.repo.GetAssetsByURLs(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+
+	byURL := make(map[string]models.MediaInfo, len(assets))
+	for _, asset := range assets {
+		byURL[asset.URL] = asset.MediaInfo
+	}
+
+	media := make([]models.MediaInfo, 0, len(assets))
+	for _, u := range urls {
+		if info, ok := byURL[u]; ok {
+			media = append(media, info)
+		}
+	}
+	return media, nil
+}
+
+// SignMedia returns a copy of info with every URL signed if it lives in a private bucket
+func (s *Service) SignMedia(ctx context.Context, info *models.MediaInfo) *models.MediaInfo {
+	if info == nil {
+		return nil
+	}
+
+	signed := *info
+	signed.URL = s.storage.SignURL(ctx, info.URL)
+	if info.ThumbnailURL != "" {
+		signed.ThumbnailURL = s.storage.SignURL(ctx, info.ThumbnailURL)
+	}
+	signed.Variants = make([]models.MediaVariant, len(info.Variants))
+	for i, variant := range info.Variants {
+		variant.URL = s.storage.SignURL(ctx, variant.URL)
+		signed.Variants[i] = variant
+	}
+	return &signed
+}
+
+func (s *Service) deleteVariants(ctx context.Context, variants []models.MediaVariant) {
+	urls := make([]string, len(variants))
+	for i, variant := range variants {
+		urls[i] = variant.URL
+	}
+	s.deleteFiles(ctx, urls)
+}
+
+func (s *Service) deleteFiles(ctx context.Context, urls []string) {
+	for _, u := range urls {
+		if err := s.storage.DeleteURL(ctx, u); err != nil {
+			log.Printf("[Media] Failed to delete %s: %v", u, err)
+		}
+	}
+}
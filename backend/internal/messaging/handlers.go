@@ -10,10 +10,11 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
+	"upvista-community-backend/internal/media"
 	"upvista-community-backend/internal/models"
 	"upvista-community-backend/internal/utils"
+	apperr "upvista-community-backend/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -61,6 +62,7 @@ type MessageHandlers struct {
 	service        *MessagingService
 	storageService *utils.StorageService
 	mediaOptimizer *MediaOptimizer
+	mediaService   *media.Service
 }
 
 // NewMessageHandlers creates new message handlers
@@ -68,11 +70,13 @@ func NewMessageHandlers(
 	service *MessagingService,
 	storageService *utils.StorageService,
 	mediaOptimizer *MediaOptimizer,
+	mediaService *media.Service,
 ) *MessageHandlers {
 	return &MessageHandlers{
 		service:        service,
 		storageService: storageService,
 		mediaOptimizer: mediaOptimizer,
+		mediaService:   mediaService,
 	}
 }
 
@@ -345,11 +349,11 @@ func (h *MessageHandlers) UploadImage(c *gin.Context) {
 
 	// Get quality parameter
 	quality := c.DefaultQuery("quality", "standard")
-	var optimizeQuality OptimizeImageQuality
+	var imageQuality media.Quality
 	if quality == "hd" {
-		optimizeQuality = QualityHD
+		imageQuality = media.QualityHD
 	} else {
-		optimizeQuality = QualityStandard
+		imageQuality = media.QualityStandard
 	}
 
 	// Get file from form
@@ -380,28 +384,26 @@ func (h *MessageHandlers) UploadImage(c *gin.Context) {
 		return
 	}
 
-	// Optimize image
-	optimizedData, newFormat, err := h.mediaOptimizer.OptimizeImageFromBytes(fileData, optimizeQuality)
+	// Render the size variants and upload them, an image sent before is reused
+	info, err := h.mediaService.StoreImage(c.Request.Context(), uid, "chat-attachments", fileData, header.Header.Get("Content-Type"), imageQuality)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to optimize image"})
-		return
-	}
-
-	// Upload to Supabase Storage
-	fileName := fmt.Sprintf("messages/%s/%s_%d.%s", uid.String(), uuid.New().String(), time.Now().Unix(), newFormat)
-	uploadedURL, err := h.storageService.UploadFile(c.Request.Context(), "chat-attachments", fileName, bytes.NewReader(optimizedData), "image/"+newFormat)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		log.Printf("[UploadImage] Failed to upload: %v", err)
+		appErr := apperr.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{"error": appErr.Message})
 		return
 	}
+	primary := info.Primary()
 
+	// Variant URLs are signed for the sender's preview, the message keeps the permanent ones
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"url":     uploadedURL,
-		"name":    header.Filename,
-		"size":    len(optimizedData),
-		"type":    "image/" + newFormat,
-		"format":  newFormat,
+		"success":    true,
+		"url":        info.URL,
+		"signed_url": h.storageService.SignURL(c.Request.Context(), info.URL),
+		"name":       header.Filename,
+		"size":       primary.Size,
+		"type":       "image/" + primary.Format,
+		"format":     primary.Format,
+		"media":      h.mediaService.SignMedia(c.Request.Context(), info),
 	})
 }
 
@@ -450,28 +452,26 @@ func (h *MessageHandlers) UploadAudio(c *gin.Context) {
 
 	log.Printf("[UploadAudio] Audio validation passed")
 
-	// For Phase 1, we accept WebM directly without conversion
-	// Audio conversion to MP3 can be added in Phase 2 with ffmpeg
-
-	// Upload to Supabase Storage
-	fileName := fmt.Sprintf("messages/%s/audio_%s_%d.webm", uid.String(), uuid.New().String(), time.Now().Unix())
-	log.Printf("[UploadAudio] Uploading to Supabase: bucket=chat-attachments, fileName=%s", fileName)
-
-	uploadedURL, err := h.storageService.UploadFile(c.Request.Context(), "chat-attachments", fileName, bytes.NewReader(fileData), "audio/webm")
+	// Stored under its content hash, a recording sent before is reused
+	// Duration, waveform and an MP3 copy are added in the background, announced with message_media_ready
+	info, err := h.mediaService.StoreFile(c.Request.Context(), uid, models.MediaKindAudio, "chat-attachments", bytes.NewReader(fileData), header.Filename, header.Header.Get("Content-Type"))
 	if err != nil {
-		log.Printf("[UploadAudio] Supabase upload failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to upload audio: %v", err)})
+		log.Printf("[UploadAudio] Upload failed: %v", err)
+		respondUploadError(c, err, "Failed to upload audio")
 		return
 	}
+	uploadedURL := info.URL
 
 	log.Printf("[UploadAudio] Upload successful: %s", uploadedURL)
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"url":     uploadedURL,
-		"name":    header.Filename,
-		"size":    len(fileData),
-		"type":    "audio/webm", // Client can still play it as WebM
+		"success":    true,
+		"url":        uploadedURL,
+		"signed_url": h.storageService.SignURL(c.Request.Context(), uploadedURL),
+		"name":       header.Filename,
+		"size":       len(fileData),
+		"type":       header.Header.Get("Content-Type"), // Checked against the content by ValidateAudio
+		"media":      h.mediaService.SignMedia(c.Request.Context(), info),
 	})
 }
 
@@ -500,39 +500,31 @@ func (h *MessageHandlers) UploadFile(c *gin.Context) {
 		return
 	}
 
-	// Read file
-	fileData, err := io.ReadAll(file)
-	if err != nil {
-		log.Printf("[UploadFile] Failed to read file: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
-		return
-	}
-
-	log.Printf("[UploadFile] File data read successfully: %d bytes", len(fileData))
-
 	// Sanitize filename - remove non-ASCII characters and spaces
 	sanitizedFilename := sanitizeFilename(header.Filename)
 
-	// Upload to Supabase Storage
-	fileName := fmt.Sprintf("messages/%s/file_%s_%s", uid.String(), uuid.New().String(), sanitizedFilename)
 	log.Printf("[UploadFile] Original filename: %s, Sanitized: %s", header.Filename, sanitizedFilename)
-	log.Printf("[UploadFile] Uploading to Supabase: bucket=chat-attachments, fileName=%s", fileName)
 
-	uploadedURL, err := h.storageService.UploadFile(c.Request.Context(), "chat-attachments", fileName, bytes.NewReader(fileData), header.Header.Get("Content-Type"))
+	// Streamed from the multipart file, never fully held in memory
+	// Sniffed, archives looked into and scanned for malware first, unsafe files are quarantined, never attached
+	// Stored under its content hash, a file sent before (by anyone) is reused
+	info, err := h.mediaService.StoreFile(c.Request.Context(), uid, models.MediaKindFile, "chat-attachments", file, sanitizedFilename, header.Header.Get("Content-Type"))
 	if err != nil {
-		log.Printf("[UploadFile] Supabase upload failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to upload file: %v", err)})
+		log.Printf("[UploadFile] Upload failed: %v", err)
+		respondUploadError(c, err, "Failed to upload file")
 		return
 	}
+	uploadedURL := info.URL
 
 	log.Printf("[UploadFile] Upload successful: %s", uploadedURL)
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"url":     uploadedURL,
-		"name":    header.Filename, // Return original filename for display
-		"size":    header.Size,
-		"type":    header.Header.Get("Content-Type"),
+		"success":    true,
+		"url":        uploadedURL,
+		"signed_url": h.storageService.SignURL(c.Request.Context(), uploadedURL),
+		"name":       header.Filename, // Return original filename for display
+		"size":       header.Size,
+		"type":       header.Header.Get("Content-Type"),
 	})
 }
 
@@ -562,58 +554,57 @@ func (h *MessageHandlers) UploadVideo(c *gin.Context) {
 		return
 	}
 
-	// Read video file
-	fileData, err := io.ReadAll(file)
-	if err != nil {
-		log.Printf("[UploadVideo] Failed to read file: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
-		return
-	}
-
-	log.Printf("[UploadVideo] File data read successfully: %d bytes", len(fileData))
-
 	// Sanitize filename
 	sanitizedFilename := sanitizeFilename(header.Filename)
 
-	// Upload video to Supabase Storage
-	videoFileName := fmt.Sprintf("messages/%s/video_%s_%s", uid.String(), uuid.New().String(), sanitizedFilename)
-	log.Printf("[UploadVideo] Uploading to Supabase: bucket=chat-attachments, fileName=%s", videoFileName)
-
-	uploadedURL, err := h.storageService.UploadFile(c.Request.Context(), "chat-attachments", videoFileName, bytes.NewReader(fileData), header.Header.Get("Content-Type"))
+	// Stored under its content hash, a video sent before is reused
+	// Thumbnail, duration, dimensions and an MP4 copy are added in the background, announced with message_media_ready
+	info, err := h.mediaService.StoreFile(c.Request.Context(), uid, models.MediaKindVideo, "chat-attachments", file, sanitizedFilename, header.Header.Get("Content-Type"))
 	if err != nil {
-		log.Printf("[UploadVideo] Supabase upload failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to upload video: %v", err)})
+		log.Printf("[UploadVideo] Upload failed: %v", err)
+		respondUploadError(c, err, "Failed to upload video")
 		return
 	}
+	uploadedURL := info.URL
 
 	log.Printf("[UploadVideo] Video upload successful: %s", uploadedURL)
 
-	// Get optional thumbnail from form (base64 or file)
+	// Optional thumbnail from the client, shown until processing finishes (or when it is disabled)
 	var thumbnailURL string
 	thumbnailFile, thumbnailHeader, err := c.Request.FormFile("thumbnail")
 	if err == nil {
 		defer thumbnailFile.Close()
 
-		// Read thumbnail
-		thumbnailData, err := io.ReadAll(thumbnailFile)
+		// Upload thumbnail, stored under its content hash like the video
+		thumbnail, err := h.mediaService.StoreFile(c.Request.Context(), uid, models.MediaKindFile, "chat-attachments", thumbnailFile, thumbnailHeader.Filename, thumbnailHeader.Header.Get("Content-Type"))
 		if err == nil {
-			// Upload thumbnail
-			thumbnailFileName := fmt.Sprintf("messages/%s/thumb_%s.jpg", uid.String(), uuid.New().String())
-			thumbnailURL, _ = h.storageService.UploadFile(c.Request.Context(), "chat-attachments", thumbnailFileName, bytes.NewReader(thumbnailData), thumbnailHeader.Header.Get("Content-Type"))
-			log.Printf("[UploadVideo] Thumbnail uploaded: %s", thumbnailURL)
+			thumbnailURL = thumbnail.URL
 		}
+		log.Printf("[UploadVideo] Thumbnail uploaded: %s", thumbnailURL)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":       true,
 		"url":           uploadedURL,
+		"signed_url":    h.storageService.SignURL(c.Request.Context(), uploadedURL),
 		"thumbnail_url": thumbnailURL,
 		"name":          header.Filename,
 		"size":          header.Size,
 		"type":          header.Header.Get("Content-Type"),
+		"media":         h.mediaService.SignMedia(c.Request.Context(), info),
 	})
 }
 
+// respondUploadError reports a file refused by inspection with its own status and message,
+// anything else as a failed upload
+func respondUploadError(c *gin.Context, err error, message string) {
+	if appErr, ok := err.(*apperr.AppError); ok {
+		c.JSON(appErr.Code, gin.H{"error": appErr.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%s: %v", message, err)})
+}
+
 // ============================================
 // REACTIONS
 // ============================================
@@ -2,10 +2,17 @@ package messaging
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"io"
+	"os"
+	"path/filepath"
+
+	"upvista-community-backend/internal/inspect"
+	"upvista-community-backend/internal/media"
+	"upvista-community-backend/internal/models"
 
 	"github.com/disintegration/imaging"
 )
@@ -20,6 +27,8 @@ type MediaOptimizer struct {
 	standardQuality   int
 	hdQuality         int
 	thumbnailSize     int
+
+	av *media.AVTools // Optional, audio conversion and duration need ffmpeg
 }
 
 // NewMediaOptimizer creates a new media optimizer with default settings
@@ -35,6 +44,11 @@ func NewMediaOptimizer() *MediaOptimizer {
 	}
 }
 
+// SetAVTools enables audio conversion and duration detection
+func (m *MediaOptimizer) SetAVTools(av *media.AVTools) {
+	m.av = av
+}
+
 // OptimizeImageQuality represents the quality level for image optimization
 type OptimizeImageQuality string
 
@@ -43,12 +57,14 @@ const (
 	QualityHD       OptimizeImageQuality = "hd"
 )
 
-// OptimizeImage resizes and compresses an image
+// OptimizeImage resizes and compresses an image into a single file
+// Orientation is corrected and metadata dropped. Images with transparency become lossless WebP, others JPEG.
+// Uploads go through media.Service, which renders all size variants; this is for one-off conversions.
 func (m *MediaOptimizer) OptimizeImage(reader io.Reader, quality OptimizeImageQuality) ([]byte, string, error) {
-	// Decode image
-	img, format, err := image.Decode(reader)
+	// Decode image, rotated upright
+	img, err := media.Decode(reader)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+		return nil, "", err
 	}
 
 	// Determine target dimensions and quality
@@ -78,33 +94,28 @@ func (m *MediaOptimizer) OptimizeImage(reader io.Reader, quality OptimizeImageQu
 		img = imaging.Sharpen(img, 0.5)
 	}
 
-	// Encode to JPEG (more efficient than PNG for photos)
+	// JPEG is smaller for photos but has no alpha channel
 	var buf bytes.Buffer
-	if format == "png" && quality == QualityStandard {
-		// For PNG, convert to JPEG to reduce size
-		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
-		format = "jpeg"
-	} else if format == "jpeg" || format == "jpg" {
-		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
-	} else {
-		// For other formats, encode as JPEG
-		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
-		format = "jpeg"
+	if media.HasAlpha(img) {
+		if err := media.EncodeWebP(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "webp", nil
 	}
 
-	if err != nil {
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
 		return nil, "", fmt.Errorf("failed to encode image: %w", err)
 	}
 
-	return buf.Bytes(), format, nil
+	return buf.Bytes(), "jpeg", nil
 }
 
 // GenerateThumbnail creates a small thumbnail for an image
 func (m *MediaOptimizer) GenerateThumbnail(reader io.Reader) ([]byte, error) {
-	// Decode image
-	img, _, err := image.Decode(reader)
+	// Decode image, rotated upright
+	img, err := media.Decode(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode image: %w", err)
+		return nil, err
 	}
 
 	// Create thumbnail (crop to square and resize)
@@ -147,36 +158,49 @@ func (m *MediaOptimizer) GetImageDimensions(data []byte) (int, int, error) {
 	return config.Width, config.Height, nil
 }
 
-// ConvertToWebP converts an image to WebP format (placeholder for future implementation)
-// Note: WebP encoding requires cgo and external libraries
-// For now, we use JPEG which has excellent compression
+// ConvertToWebP converts an image to lossless WebP, keeping transparency
 func (m *MediaOptimizer) ConvertToWebP(reader io.Reader) ([]byte, error) {
-	// TODO: Implement WebP conversion when cgo is available
-	// For now, fall back to JPEG optimization
-	data, _, err := m.OptimizeImage(reader, QualityStandard)
-	return data, err
+	img, err := media.Decode(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := media.EncodeWebP(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
 }
 
 // ============================================
-// AUDIO PROCESSING (PLACEHOLDER)
+// AUDIO PROCESSING
 // ============================================
 
-// ConvertAudio converts WebM/Opus audio to MP3
-// Note: This requires ffmpeg to be installed on the system
-// This is a placeholder that returns an error for now
+// ConvertAudio converts audio (WebM/Opus from the browser, OGG) to MP3
+// Uploads are converted in the background by media.Service, this is for one-off conversions
 func (m *MediaOptimizer) ConvertAudio(reader io.Reader) ([]byte, error) {
-	// TODO: Implement audio conversion using ffmpeg
-	// This requires executing external command:
-	// ffmpeg -i input.webm -codec:a libmp3lame -b:a 64k output.mp3
+	if m.av == nil {
+		return nil, media.ErrAVToolsMissing
+	}
+
+	dir, input, err := writeTempMedia(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "out.mp3")
+	if err := m.av.TranscodeAudio(context.Background(), input, output); err != nil {
+		return nil, err
+	}
 
-	// For Phase 1, we'll accept WebM format directly
-	// Audio conversion can be added in Phase 2
-	return nil, fmt.Errorf("audio conversion not yet implemented - WebM format accepted as-is")
+	return os.ReadFile(output)
 }
 
-// ValidateAudio checks if the data is valid audio
+// ValidateAudio checks if the data is valid audio: a supported declared type that the content,
+// sniffed from its magic bytes, actually is
 func (m *MediaOptimizer) ValidateAudio(data []byte, mimeType string) (bool, error) {
-	// Basic validation - check mime type and file header
 	if mimeType != "audio/webm" && mimeType != "audio/ogg" && mimeType != "audio/mp3" && mimeType != "audio/mpeg" {
 		return false, fmt.Errorf("unsupported audio format: %s", mimeType)
 	}
@@ -186,14 +210,55 @@ func (m *MediaOptimizer) ValidateAudio(data []byte, mimeType string) (bool, erro
 		return false, fmt.Errorf("audio file too small: %d bytes", len(data))
 	}
 
+	detected := inspect.Detect(data)
+	if !inspect.Allowed(models.MediaKindAudio, detected) || !inspect.Matches(mimeType, detected) {
+		return false, fmt.Errorf("file content (%s) is not %s audio", detected, mimeType)
+	}
+
 	return true, nil
 }
 
-// GetAudioDuration returns the duration of an audio file in seconds (placeholder)
+// GetAudioDuration returns the duration of an audio file in seconds
 func (m *MediaOptimizer) GetAudioDuration(data []byte) (float64, error) {
-	// TODO: Implement audio duration detection
-	// For now, return 0
-	return 0, fmt.Errorf("audio duration detection not yet implemented")
+	if m.av == nil {
+		return 0, media.ErrAVToolsMissing
+	}
+
+	dir, input, err := writeTempMedia(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	probe, err := m.av.Probe(context.Background(), input)
+	if err != nil {
+		return 0, err
+	}
+
+	return probe.Duration, nil
+}
+
+// writeTempMedia copies media to a temporary file for ffmpeg, the caller removes dir
+func writeTempMedia(reader io.Reader) (dir, path string, err error) {
+	dir, err = os.MkdirTemp("", "audio-*")
+	if err != nil {
+		return "", "", err
+	}
+
+	path = filepath.Join(dir, "in")
+	f, err := os.Create(path)
+	if err == nil {
+		_, err = io.Copy(f, reader)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", err
+	}
+
+	return dir, path, nil
 }
 
 // ============================================
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"upvista-community-backend/internal/cache"
@@ -19,6 +20,28 @@ type NotificationService interface {
 	CreateNotification(ctx context.Context, notification *models.Notification) error
 }
 
+// AttachmentSigner turns stored attachment URLs into signed, expiring URLs (chat attachments are private)
+type AttachmentSigner interface {
+	SignURL(ctx context.Context, url string) string
+}
+
+// MediaResolver looks up the variants, dimensions and processing status of uploaded media by URL
+type MediaResolver interface {
+	ResolveMedia(ctx context.Context, urls []string) ([]models.MediaInfo, error)
+}
+
+// MediaReferences records which messages use uploaded attachments, files nothing uses are deleted
+type MediaReferences interface {
+	Attach(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID, urls ...string) error
+	Detach(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID) error
+}
+
+// LinkPreviewer builds previews of the links in a text
+type LinkPreviewer interface {
+	Previews(ctx context.Context, text string) []models.LinkPreview
+	Refresh(ctx context.Context, text string, previous []models.LinkPreview) []models.LinkPreview
+}
+
 // MessagingService handles all messaging business logic
 type MessagingService struct {
 	repo         repository.MessageRepository
@@ -26,6 +49,10 @@ type MessagingService struct {
 	wsManager    *websocket.Manager
 	userRepo     repository.UserRepository
 	notifService NotificationService
+	signer       AttachmentSigner
+	media        MediaResolver
+	references   MediaReferences
+	previews     LinkPreviewer
 }
 
 // NewMessagingService creates a new messaging service
@@ -45,6 +72,29 @@ func NewMessagingService(
 	}
 }
 
+// SetAttachmentSigner enables signed attachment URLs in messages returned to clients
+// The database and the cache keep the permanent URLs
+func (s *MessagingService) SetAttachmentSigner(signer AttachmentSigner) {
+	s.signer = signer
+}
+
+// SetMediaResolver attaches the variants, dimensions and blurhash of uploaded images, and the processing
+// metadata of uploaded video and audio, to messages
+func (s *MessagingService) SetMediaResolver(media MediaResolver) {
+	s.media = media
+}
+
+// SetMediaReferences records the attachments of sent and forwarded messages, so the shared file is kept
+// as long as any message uses it, and releases them when the sender unsends the message
+func (s *MessagingService) SetMediaReferences(references MediaReferences) {
+	s.references = references
+}
+
+// SetLinkPreviewer attaches previews of the links in messages when they are sent or edited
+func (s *MessagingService) SetLinkPreviewer(previews LinkPreviewer) {
+	s.previews = previews
+}
+
 // ============================================
 // CONVERSATIONS
 // ============================================
@@ -209,11 +259,28 @@ func (s *MessagingService) SendMessage(ctx context.Context, conversationID, send
 		CreatedAt:      time.Now(),
 	}
 
+	// Media uploaded through /messages/upload-* comes with variants, or with metadata once processed
+	if s.media != nil && message.AttachmentURL != nil && hasMediaAttachment(message.MessageType) {
+		media, err := s.media.ResolveMedia(ctx, []string{*message.AttachmentURL})
+		if err != nil {
+			log.Printf("[Messaging] Failed to resolve attachment media: %v", err)
+		} else if len(media) > 0 {
+			message.Media = &media[0]
+			applyVideoMetadata(message)
+		}
+	}
+
+	if s.previews != nil && message.Content != "" {
+		message.LinkPreviews = s.previews.Previews(ctx, message.Content)
+	}
+
 	// Save to database
 	if err := s.repo.CreateMessage(ctx, message); err != nil {
 		return nil, fmt.Errorf("failed to create message: %w", err)
 	}
 
+	s.attachMedia(ctx, message)
+
 	// Update sender's last seen (they're active sending messages)
 	if s.cache != nil {
 		s.cache.SetUserOnline(ctx, senderID)
@@ -228,9 +295,9 @@ func (s *MessagingService) SendMessage(ctx context.Context, conversationID, send
 	}
 
 	// Send via WebSocket to recipient (IsMine = false for recipient)
-	messageCopy := *message
+	messageCopy := s.signMessage(ctx, message)
 	messageCopy.IsMine = false
-	go s.broadcastNewMessage(recipientID, &messageCopy)
+	go s.broadcastNewMessage(recipientID, messageCopy)
 
 	// Set IsMine = true for sender (HTTP response)
 	message.IsMine = true
@@ -248,7 +315,7 @@ func (s *MessagingService) SendMessage(ctx context.Context, conversationID, send
 	log.Printf("[Messaging] Message %s sent from %s to %s in conversation %s",
 		message.ID, senderID, recipientID, conversationID)
 
-	return message, nil
+	return s.signMessage(ctx, message), nil
 }
 
 // GetMessages retrieves messages for a conversation with caching
@@ -277,7 +344,7 @@ func (s *MessagingService) GetMessages(ctx context.Context, conversationID, user
 			// Mark as read in background
 			go s.markConversationAsRead(ctx, conversationID, userID)
 
-			return cached, nil
+			return s.signMessages(ctx, cached), nil
 		}
 	}
 
@@ -300,7 +367,7 @@ func (s *MessagingService) GetMessages(ctx context.Context, conversationID, user
 	// Mark as read in background
 	go s.markConversationAsRead(ctx, conversationID, userID)
 
-	return messages, nil
+	return s.signMessages(ctx, messages), nil
 }
 
 // MarkAsRead marks messages in a conversation as read
@@ -365,6 +432,11 @@ func (s *MessagingService) DeleteMessage(ctx context.Context, messageID, userID
 		return err
 	}
 
+	// Unsent attachments are released, before the cache is cleared so it can't keep them
+	if isSender {
+		s.detachMedia(ctx, message)
+	}
+
 	// Invalidate cache
 	s.cache.InvalidateConversationCache(ctx, message.ConversationID)
 
@@ -397,7 +469,7 @@ func (s *MessagingService) SearchMessages(ctx context.Context, userID uuid.UUID,
 		msg.IsMine = msg.SenderID == userID
 	}
 
-	return messages, nil
+	return s.signMessages(ctx, messages), nil
 }
 
 // ============================================
@@ -555,7 +627,7 @@ func (s *MessagingService) GetStarredMessages(ctx context.Context, userID uuid.U
 		msg.IsMine = msg.SenderID == userID
 	}
 
-	return messages, nil
+	return s.signMessages(ctx, messages), nil
 }
 
 // ============================================
@@ -901,7 +973,7 @@ func (s *MessagingService) GetPinnedMessages(ctx context.Context, conversationID
 		msg.IsMine = msg.SenderID == userID
 	}
 
-	return messages, nil
+	return s.signMessages(ctx, messages), nil
 }
 
 // ============================================
@@ -922,8 +994,14 @@ func (s *MessagingService) EditMessage(ctx context.Context, messageID, userID uu
 
 	conversationID := message.ConversationID
 
+	// Links removed from the text lose their card, new ones get one
+	linkPreviews := message.LinkPreviews
+	if s.previews != nil {
+		linkPreviews = s.previews.Refresh(ctx, newContent, message.LinkPreviews)
+	}
+
 	// Edit the message
-	if err := s.repo.EditMessage(ctx, messageID, newContent, userID); err != nil {
+	if err := s.repo.EditMessage(ctx, messageID, newContent, linkPreviews, userID); err != nil {
 		return err
 	}
 
@@ -1008,12 +1086,14 @@ func (s *MessagingService) ForwardMessage(ctx context.Context, messageID, toConv
 		return nil, fmt.Errorf("unauthorized: not part of target conversation")
 	}
 
-	// Forward the message
+	// Forward the message, the copy points at the same stored attachment
 	forwardedMsg, err := s.repo.ForwardMessage(ctx, messageID, toConversationID, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	s.attachMedia(ctx, forwardedMsg)
+
 	if s.cache != nil {
 		s.cache.InvalidateConversationCache(ctx, toConversationID)
 	}
@@ -1038,7 +1118,7 @@ func (s *MessagingService) ForwardMessage(ctx context.Context, messageID, toConv
 	}
 
 	// Broadcast new message
-	go s.broadcastNewMessage(otherUserID, forwardedMsg)
+	go s.broadcastNewMessage(otherUserID, s.signMessage(ctx, forwardedMsg))
 
 	// Check if recipient is online, if not send notification
 	if !s.wsManager.IsUserConnected(otherUserID) && s.notifService != nil {
@@ -1047,7 +1127,7 @@ func (s *MessagingService) ForwardMessage(ctx context.Context, messageID, toConv
 
 	log.Printf("[Messaging] Message %s forwarded to conversation %s by user %s", messageID, toConversationID, userID)
 	forwardedMsg.IsMine = true
-	return forwardedMsg, nil
+	return s.signMessage(ctx, forwardedMsg), nil
 }
 
 // ============================================
@@ -1076,7 +1156,7 @@ func (s *MessagingService) SearchConversationMessages(ctx context.Context, conve
 		msg.IsMine = msg.SenderID == userID
 	}
 
-	return messages, nil
+	return s.signMessages(ctx, messages), nil
 }
 
 // ============================================
@@ -1116,6 +1196,7 @@ func (s *MessagingService) broadcastMessageUnpinned(recipientID, conversationID,
 }
 
 func (s *MessagingService) broadcastMessageEdited(recipientID, conversationID uuid.UUID, message *models.Message) {
+	message = s.signMessage(context.Background(), message)
 	message.IsMine = false // For recipient
 	envelope := models.WSMessageEnvelope{
 		ID:             uuid.New().String(),
@@ -1131,3 +1212,169 @@ func (s *MessagingService) broadcastMessageEdited(recipientID, conversationID uu
 	s.wsManager.BroadcastToUserWithData(recipientID, envelope)
 	log.Printf("[Messaging] Broadcasted message edited to user %s", recipientID)
 }
+
+// hasMediaAttachment reports whether a message type carries an uploaded image, video or audio file
+func hasMediaAttachment(messageType models.MessageType) bool {
+	switch messageType {
+	case models.MessageTypeImage, models.MessageTypeVideo, models.MessageTypeAudio:
+		return true
+	}
+	return false
+}
+
+// applyVideoMetadata copies the thumbnail, duration and size of a processed video onto its message
+func applyVideoMetadata(message *models.Message) {
+	media := message.Media
+	if media == nil || media.Kind != models.MediaKindVideo || media.Status != models.MediaStatusReady {
+		return
+	}
+
+	if media.ThumbnailURL != "" {
+		thumbnailURL := media.ThumbnailURL
+		message.ThumbnailURL = &thumbnailURL
+	}
+	duration := int(math.Round(media.Duration))
+	width, height := media.Width, media.Height
+	message.VideoDuration = &duration
+	message.VideoWidth = &width
+	message.VideoHeight = &height
+}
+
+// MediaProcessed updates the messages carrying a video or audio file once background processing finishes,
+// and tells both participants so clients can swap in the thumbnail, duration or transcoded file
+func (s *MessagingService) MediaProcessed(ctx context.Context, info *models.MediaInfo) {
+	messages, err := s.repo.UpdateAttachmentMedia(ctx, info.URL, info)
+	if err != nil {
+		log.Printf("[Messaging] Failed to update messages with %s: %v", info.URL, err)
+		return
+	}
+
+	for _, message := range messages {
+		if s.cache != nil {
+			s.cache.InvalidateConversationCache(ctx, message.ConversationID)
+		}
+
+		conversation, err := s.repo.GetConversation(ctx, message.ConversationID)
+		if err != nil {
+			log.Printf("[Messaging] Failed to load conversation %s: %v", message.ConversationID, err)
+			continue
+		}
+
+		for _, userID := range []uuid.UUID{conversation.Participant1ID, conversation.Participant2ID} {
+			go s.broadcastMediaReady(userID, message)
+		}
+	}
+}
+
+func (s *MessagingService) broadcastMediaReady(userID uuid.UUID, message *models.Message) {
+	message = s.signMessage(context.Background(), message)
+	message.IsMine = message.SenderID == userID
+	envelope := models.WSMessageEnvelope{
+		ID:             uuid.New().String(),
+		Type:           models.WSMessageTypeMediaReady,
+		Channel:        "messaging",
+		ConversationID: &message.ConversationID,
+		Data: map[string]interface{}{
+			"message": message,
+		},
+		Timestamp: time.Now().Unix(),
+	}
+
+	s.wsManager.BroadcastToUserWithData(userID, envelope)
+	log.Printf("[Messaging] Broadcasted media ready for message %s to user %s", message.ID, userID)
+}
+
+// ============================================
+// ATTACHMENT URLS
+// ============================================
+
+// attachMedia records the attachment of a message, failures only leave it unprotected from collection
+func (s *MessagingService) attachMedia(ctx context.Context, message *models.Message) {
+	if s.references == nil || message.AttachmentURL == nil {
+		return
+	}
+
+	urls := []string{*message.AttachmentURL}
+	if message.ThumbnailURL != nil {
+		urls = append(urls, *message.ThumbnailURL)
+	}
+	if err := s.references.Attach(ctx, models.MediaOwnerMessage, message.ID, urls...); err != nil {
+		log.Printf("[Messaging] Failed to record attachment of message %s: %v", message.ID, err)
+	}
+}
+
+// detachMedia removes the attachment of an unsent message and releases it
+// Forwarded copies are messages of their own and keep the file
+func (s *MessagingService) detachMedia(ctx context.Context, message *models.Message) {
+	if s.references == nil || message.AttachmentURL == nil {
+		return
+	}
+
+	// The message must stop pointing at the file before it can be collected
+	if err := s.repo.ClearMessageAttachment(ctx, message.ID, message.SenderID); err != nil {
+		log.Printf("[Messaging] Failed to remove attachment of message %s: %v", message.ID, err)
+		return
+	}
+	if err := s.references.Detach(ctx, models.MediaOwnerMessage, message.ID); err != nil {
+		log.Printf("[Messaging] Failed to release attachment of message %s: %v", message.ID, err)
+	}
+}
+
+// signMessage returns a copy of a message with signed attachment URLs, the message itself is left untouched
+// (it may be cached or shared with a broadcast goroutine)
+func (s *MessagingService) signMessage(ctx context.Context, message *models.Message) *models.Message {
+	if message == nil {
+		return nil
+	}
+
+	signed := *message
+	if s.signer == nil {
+		return &signed
+	}
+
+	signed.AttachmentURL = s.signURL(ctx, message.AttachmentURL)
+	signed.ThumbnailURL = s.signURL(ctx, message.ThumbnailURL)
+	signed.Media = s.signMedia(ctx, message.Media)
+	signed.ReplyToMessage = s.signMessage(ctx, message.ReplyToMessage)
+	return &signed
+}
+
+// signMedia signs the URL of every variant and the video thumbnail
+func (s *MessagingService) signMedia(ctx context.Context, media *models.MediaInfo) *models.MediaInfo {
+	if media == nil {
+		return nil
+	}
+
+	signed := *media
+	signed.URL = s.signer.SignURL(ctx, media.URL)
+	if media.ThumbnailURL != "" {
+		signed.ThumbnailURL = s.signer.SignURL(ctx, media.ThumbnailURL)
+	}
+	signed.Variants = make([]models.MediaVariant, len(media.Variants))
+	for i, variant := range media.Variants {
+		variant.URL = s.signer.SignURL(ctx, variant.URL)
+		signed.Variants[i] = variant
+	}
+	return &signed
+}
+
+// signMessages signs the attachment URLs of a page of messages
+func (s *MessagingService) signMessages(ctx context.Context, messages []*models.Message) []*models.Message {
+	if s.signer == nil {
+		return messages
+	}
+
+	signed := make([]*models.Message, len(messages))
+	for i, message := range messages {
+		signed[i] = s.signMessage(ctx, message)
+	}
+	return signed
+}
+
+func (s *MessagingService) signURL(ctx context.Context, url *string) *string {
+	if url == nil || *url == "" {
+		return url
+	}
+	signed := s.signer.SignURL(ctx, *url)
+	return &signed
+}
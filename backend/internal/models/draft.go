@@ -0,0 +1,160 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DraftContent is what a draft holds: saved by autosave, kept in its revisions and turned into the post when published
+// Only sizes are checked while saving, a draft can be incomplete until it is published
+type DraftContent struct {
+	Content        string        `json:"content" binding:"max=125000"`
+	MediaURLs      []string      `json:"media_urls" binding:"max=10"`
+	MediaTypes     []string      `json:"media_types" binding:"max=10"`
+	Visibility     string        `json:"visibility" binding:"omitempty,oneof=public connections private"`
+	AllowsComments bool          `json:"allows_comments"`
+	AllowsSharing  bool          `json:"allows_sharing"`
+	IsNSFW         bool          `json:"is_nsfw"`
+	Poll           *DraftPoll    `json:"poll,omitempty"`    // For poll drafts
+	Article        *DraftArticle `json:"article,omitempty"` // For article drafts
+}
+
+// DraftPoll is the poll of a draft, created when the draft is published
+type DraftPoll struct {
+	Question              string   `json:"question" binding:"max=280"`
+	Options               []string `json:"options" binding:"max=4"`
+	DurationHours         int      `json:"duration_hours" binding:"min=0,max=168"`
+	AllowMultipleVotes    bool     `json:"allow_multiple_votes"`
+	ShowResultsBeforeVote bool     `json:"show_results_before_vote"`
+	AllowVoteChanges      bool     `json:"allow_vote_changes"`
+	AnonymousVotes        bool     `json:"anonymous_votes"`
+}
+
+// DraftArticle is the article of a draft, created (with its slug) when the draft is published
+type DraftArticle struct {
+	Title           string   `json:"title" binding:"max=100"`
+	Subtitle        string   `json:"subtitle,omitempty" binding:"max=150"`
+	ContentHTML     string   `json:"content_html"`
+	CoverImageURL   string   `json:"cover_image_url,omitempty"`
+	MetaTitle       string   `json:"meta_title,omitempty" binding:"max=60"`
+	MetaDescription string   `json:"meta_description,omitempty" binding:"max=160"`
+	Category        string   `json:"category,omitempty" binding:"max=50"`
+	Tags            []string `json:"tags,omitempty" binding:"max=5"`
+}
+
+// DraftRevision is a saved state of a draft
+// Autosaves within a few minutes of each other update the same revision
+type DraftRevision struct {
+	ID        uuid.UUID    `json:"id"`
+	PostID    uuid.UUID    `json:"post_id"`
+	Revision  int          `json:"revision"` // The draft revision this state was saved as
+	Content   DraftContent `json:"content"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// CreateDraftRequest is the request body for starting a draft
+type CreateDraftRequest struct {
+	PostType string `json:"post_type" binding:"required,oneof=post poll article"`
+	DraftContent
+}
+
+// SaveDraftRequest is the request body for autosaving a draft
+type SaveDraftRequest struct {
+	DraftContent
+
+	// The draft revision the edits were made on, saving over a newer one fails so another tab's edits aren't lost
+	Revision *int `json:"revision,omitempty"`
+}
+
+// PublishDraftRequest is the optional request body for publishing a draft
+type PublishDraftRequest struct {
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"` // Schedule the post instead of publishing it now
+}
+
+// DraftsResponse is the API response for a user's drafts
+type DraftsResponse struct {
+	Success bool   `json:"success"`
+	Drafts  []Post `json:"drafts"`
+	Total   int    `json:"total"`
+	Limit   int    `json:"limit"`
+	HasMore bool   `json:"has_more"`
+}
+
+// Draft errors
+var (
+	ErrNotDraft             = &AppError{Code: "NOT_DRAFT", Message: "Post isn't a draft"}
+	ErrDraftConflict        = &AppError{Code: "DRAFT_CONFLICT", Message: "Draft was saved from somewhere else, reload it before saving"}
+	ErrDraftContentRequired = &AppError{Code: "DRAFT_CONTENT_REQUIRED", Message: "Add some text before publishing"}
+)
+
+// NewDraftContent takes the content of a post created as a draft
+func NewDraftContent(req *CreatePostRequest) DraftContent {
+	content := DraftContent{
+		Content:        req.Content,
+		MediaURLs:      req.MediaURLs,
+		MediaTypes:     req.MediaTypes,
+		Visibility:     req.Visibility,
+		AllowsComments: req.AllowsComments,
+		AllowsSharing:  req.AllowsSharing,
+		IsNSFW:         req.IsNSFW,
+	}
+	if req.Poll != nil {
+		poll := DraftPoll(*req.Poll)
+		content.Poll = &poll
+	}
+	if req.Article != nil {
+		article := DraftArticle(*req.Article)
+		content.Article = &article
+	}
+	return content
+}
+
+// PublishRequest turns a draft into the request creating its post, checked as strictly as a new post
+func (d *DraftContent) PublishRequest(postType string) (*CreatePostRequest, error) {
+	req := &CreatePostRequest{
+		PostType:       postType,
+		Content:        d.Content,
+		MediaURLs:      d.MediaURLs,
+		MediaTypes:     d.MediaTypes,
+		Visibility:     d.Visibility,
+		AllowsComments: d.AllowsComments,
+		AllowsSharing:  d.AllowsSharing,
+		IsNSFW:         d.IsNSFW,
+	}
+
+	if strings.TrimSpace(req.Content) == "" {
+		return nil, ErrDraftContentRequired
+	}
+	if len(req.Content) > 125000 {
+		return nil, ErrContentTooLong
+	}
+
+	switch postType {
+	case "poll":
+		if d.Poll == nil || strings.TrimSpace(d.Poll.Question) == "" {
+			return nil, ErrPollDataRequired
+		}
+		poll := CreatePollRequest(*d.Poll)
+		if err := poll.Validate(); err != nil {
+			return nil, err
+		}
+		req.Poll = &poll
+	case "article":
+		if d.Article == nil {
+			return nil, ErrArticleDataRequired
+		}
+		article := CreateArticleRequest(*d.Article)
+		if err := article.Validate(); err != nil {
+			return nil, err
+		}
+		req.Article = &article
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// EMAIL OUTBOX
+// =====================================================
+
+// EmailStatus is the delivery status of an outbox email
+type EmailStatus string
+
+const (
+	EmailStatusPending    EmailStatus = "pending"    // Waiting for a worker (first attempt or retry)
+	EmailStatusSending    EmailStatus = "sending"    // Claimed by a worker
+	EmailStatusSent       EmailStatus = "sent"       // Accepted by the transport
+	EmailStatusDelivered  EmailStatus = "delivered"  // Provider reported delivery to the mailbox
+	EmailStatusFailed     EmailStatus = "failed"     // Permanent error or out of attempts
+	EmailStatusBounced    EmailStatus = "bounced"    // Provider reported a hard bounce
+	EmailStatusComplained EmailStatus = "complained" // Recipient marked the email as spam
+	EmailStatusSuppressed EmailStatus = "suppressed" // Not sent, the address bounced or complained before
+)
+
+// OutboxEmail is an email queued for delivery
+type OutboxEmail struct {
+	ID                uuid.UUID   `json:"id" db:"id"`
+	ToEmail           string      `json:"to_email" db:"to_email"`
+	Subject           string      `json:"subject" db:"subject"`
+	HTMLBody          string      `json:"-" db:"html_body"`
+	TextBody          string      `json:"-" db:"text_body"`
+	Template          *string     `json:"template,omitempty" db:"template"` // Email template name, nil for raw emails
+	Status            EmailStatus `json:"status" db:"status"`
+	Attempts          int         `json:"attempts" db:"attempts"`
+	NextAttemptAt     time.Time   `json:"next_attempt_at" db:"next_attempt_at"`
+	LockedAt          *time.Time  `json:"-" db:"locked_at"` // When a worker claimed it, to recover from crashed workers
+	LastError         *string     `json:"last_error,omitempty" db:"last_error"`
+	Transport         *string     `json:"transport,omitempty" db:"transport"`                     // Transport that accepted it
+	ProviderMessageID *string     `json:"provider_message_id,omitempty" db:"provider_message_id"` // Matches provider webhooks to the email
+	SentAt            *time.Time  `json:"sent_at,omitempty" db:"sent_at"`
+	CreatedAt         time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// EmailEventType is a delivery event reported by an email provider
+type EmailEventType string
+
+const (
+	EmailEventDelivered  EmailEventType = "delivered"
+	EmailEventBounced    EmailEventType = "bounced"      // Hard bounce, the address doesn't accept mail
+	EmailEventSoftBounce EmailEventType = "soft_bounced" // Temporary failure (mailbox full, greylisting)
+	EmailEventComplained EmailEventType = "complained"
+)
+
+// EmailDeliveryEvent is a provider webhook event, matched to an outbox email by ID or provider message ID
+type EmailDeliveryEvent struct {
+	Type              EmailEventType `json:"type"`
+	OutboxID          *uuid.UUID     `json:"outbox_id,omitempty"`
+	ProviderMessageID string         `json:"provider_message_id,omitempty"`
+	Email             string         `json:"email"`
+	Reason            string         `json:"reason,omitempty"`
+}
+
+// EmailSuppression is an address that no longer receives email
+type EmailSuppression struct {
+	Email     string    `json:"email" db:"email"`
+	Reason    string    `json:"reason" db:"reason"` // bounced or complained
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// LinkPreviewType is what a previewed link points to
+type LinkPreviewType string
+
+const (
+	LinkPreviewWebsite LinkPreviewType = "website"
+	LinkPreviewArticle LinkPreviewType = "article"
+	LinkPreviewVideo   LinkPreviewType = "video"
+	LinkPreviewAudio   LinkPreviewType = "audio"
+	LinkPreviewPhoto   LinkPreviewType = "photo" // The link is an image, or an oEmbed photo
+)
+
+// LinkPreview is the card shown for a link in a post or message, built from the page's Open Graph,
+// Twitter Card and oEmbed metadata when the post or message is created or edited
+// Image, icon and embed URLs point to the linked site, they are never fetched by the server
+type LinkPreview struct {
+	URL          string          `json:"url"`                     // As written in the post or message
+	CanonicalURL string          `json:"canonical_url,omitempty"` // The page's canonical URL, or where redirects ended
+	Type         LinkPreviewType `json:"type"`
+	Title        string          `json:"title,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	SiteName     string          `json:"site_name,omitempty"`
+	AuthorName   string          `json:"author_name,omitempty"`
+	IconURL      string          `json:"icon_url,omitempty"`
+
+	ImageURL    string `json:"image_url,omitempty"`
+	ImageWidth  int    `json:"image_width,omitempty"`
+	ImageHeight int    `json:"image_height,omitempty"`
+	ImageAlt    string `json:"image_alt,omitempty"`
+
+	// Player to show in an iframe (videos, audio), always https
+	EmbedURL    string `json:"embed_url,omitempty"`
+	EmbedWidth  int    `json:"embed_width,omitempty"`
+	EmbedHeight int    `json:"embed_height,omitempty"`
+
+	FetchedAt time.Time `json:"fetched_at"`
+}
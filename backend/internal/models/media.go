@@ -0,0 +1,164 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// MEDIA
+// =====================================================
+
+// MediaKind is the type of an uploaded media file
+type MediaKind string
+
+const (
+	MediaKindImage MediaKind = "image"
+	MediaKindVideo MediaKind = "video"
+	MediaKindAudio MediaKind = "audio"
+	MediaKindFile  MediaKind = "file" // Stored as uploaded: documents, client-made thumbnails, resumable uploads of other types
+)
+
+// MediaStatus says whether background processing of a video or audio file is done
+type MediaStatus string
+
+const (
+	MediaStatusProcessing MediaStatus = "processing" // Queued or being probed and transcoded
+	MediaStatusReady      MediaStatus = "ready"
+	MediaStatusFailed     MediaStatus = "failed" // The original file is still usable
+)
+
+// MediaVariant is one rendition of an uploaded image
+type MediaVariant struct {
+	Name   string `json:"name"`   // thumb, small, medium or large for images, web for transcoded video and audio
+	Format string `json:"format"` // jpeg, webp, avif, mp4 or mp3
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int64  `json:"size"` // Bytes
+}
+
+// MediaInfo describes an uploaded media file: its variants, dimensions and placeholders
+// URL is what media_urls and attachment_url hold: the large variant in the most compatible format for images,
+// the uploaded file for video and audio
+type MediaInfo struct {
+	URL      string         `json:"url"`
+	Kind     MediaKind      `json:"kind,omitempty"`
+	Status   MediaStatus    `json:"status,omitempty"`
+	Width    int            `json:"width"`
+	Height   int            `json:"height"`
+	Blurhash string         `json:"blurhash,omitempty"`
+	Variants []MediaVariant `json:"variants"`
+
+	// Video and audio, set once processed
+	Duration     float64 `json:"duration,omitempty"` // Seconds
+	ThumbnailURL string  `json:"thumbnail_url,omitempty"`
+	Waveform     []int   `json:"waveform,omitempty"` // Audio peaks scaled to 0-100
+	VideoCodec   string  `json:"video_codec,omitempty"`
+	AudioCodec   string  `json:"audio_codec,omitempty"`
+}
+
+// MediaAsset is an uploaded file as recorded in media_assets
+// Identical files are stored once per bucket: ContentHash identifies the content, and the asset is deleted
+// with its files once no post, message or event has referenced it for the grace period
+type MediaAsset struct {
+	ID                uuid.UUID  `json:"id" db:"id"`
+	UserID            uuid.UUID  `json:"user_id" db:"user_id"` // First uploader
+	Bucket            string     `json:"bucket" db:"bucket"`
+	ContentHash       string     `json:"content_hash,omitempty" db:"content_hash"` // SHA-256 (hex) of the upload, images add their quality
+	Size              int64      `json:"size" db:"size"`                           // Bytes uploaded
+	RefCount          int        `json:"ref_count" db:"ref_count"`                 // Maintained by media_references triggers
+	UnreferencedSince *time.Time `json:"unreferenced_since,omitempty" db:"unreferenced_since"`
+	MediaInfo                    // url, kind, status, dimensions, placeholders, variants
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Files returns the URLs of every stored object of the asset: the upload, its variants and thumbnail
+func (a *MediaAsset) Files() []string {
+	seen := make(map[string]bool)
+	var urls []string
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	add(a.URL)
+	for _, variant := range a.Variants {
+		add(variant.URL)
+	}
+	add(a.ThumbnailURL)
+	return urls
+}
+
+// MediaOwnerType is the kind of record that uses a media asset
+type MediaOwnerType string
+
+const (
+	MediaOwnerPost    MediaOwnerType = "post"
+	MediaOwnerComment MediaOwnerType = "comment"
+	MediaOwnerMessage MediaOwnerType = "message"
+	MediaOwnerEvent   MediaOwnerType = "event"
+)
+
+// MediaReference records that a post, message or event uses a media asset
+type MediaReference struct {
+	AssetID   uuid.UUID      `json:"asset_id" db:"asset_id"`
+	OwnerType MediaOwnerType `json:"owner_type" db:"owner_type"`
+	OwnerID   uuid.UUID      `json:"owner_id" db:"owner_id"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// MediaJobStatus is the state of a background media processing job
+type MediaJobStatus string
+
+const (
+	MediaJobStatusPending    MediaJobStatus = "pending"
+	MediaJobStatusProcessing MediaJobStatus = "processing"
+	MediaJobStatusCompleted  MediaJobStatus = "completed"
+	MediaJobStatusFailed     MediaJobStatus = "failed" // Gave up after the last attempt
+)
+
+// MediaJob probes, thumbnails and transcodes an uploaded video or audio file
+type MediaJob struct {
+	ID            uuid.UUID      `json:"id" db:"id"`
+	AssetID       uuid.UUID      `json:"asset_id" db:"asset_id"`
+	Status        MediaJobStatus `json:"status" db:"status"`
+	Attempts      int            `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time      `json:"next_attempt_at" db:"next_attempt_at"`
+	LockedAt      *time.Time     `json:"locked_at,omitempty" db:"locked_at"`
+	LastError     *string        `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// Primary returns the variant behind URL, nil if it isn't among the variants
+func (m *MediaInfo) Primary() *MediaVariant {
+	for i := range m.Variants {
+		if m.Variants[i].URL == m.URL {
+			return &m.Variants[i]
+		}
+	}
+	return nil
+}
+
+// QuarantinedFile is an upload that failed inspection (malware, a disguised or polyglot file, a dangerous archive)
+// The file is kept in the quarantine bucket for review and never linked to a post or message
+type QuarantinedFile struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	Bucket       string    `json:"bucket" db:"bucket"` // Quarantine bucket
+	ObjectKey    string    `json:"object_key" db:"object_key"`
+	TargetBucket string    `json:"target_bucket" db:"target_bucket"` // Where the upload was headed
+	Filename     string    `json:"filename" db:"filename"`
+	DeclaredType string    `json:"declared_type" db:"declared_type"` // Content-Type sent by the client
+	DetectedType string    `json:"detected_type" db:"detected_type"` // Content type sniffed from the file
+	Size         int64     `json:"size" db:"size"`
+	SHA256       string    `json:"sha256" db:"sha256"`
+	Reason       string    `json:"reason" db:"reason"`
+	Threat       *string   `json:"threat,omitempty" db:"threat"` // Signature name reported by the malware scanner
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -59,15 +60,17 @@ type Conversation struct {
 
 // Message represents a single message in a conversation
 type Message struct {
-	ID             uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	ConversationID uuid.UUID   `json:"conversation_id" gorm:"type:uuid;not null"`
-	SenderID       uuid.UUID   `json:"sender_id" gorm:"type:uuid;not null"`
-	Content        string      `json:"content" gorm:"not null"`
-	MessageType    MessageType `json:"message_type" gorm:"default:'text'"`
-	AttachmentURL  *string     `json:"attachment_url"`
-	AttachmentName *string     `json:"attachment_name"`
-	AttachmentSize *int        `json:"attachment_size"`
-	AttachmentType *string     `json:"attachment_type"`
+	ID             uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ConversationID uuid.UUID     `json:"conversation_id" gorm:"type:uuid;not null"`
+	SenderID       uuid.UUID     `json:"sender_id" gorm:"type:uuid;not null"`
+	Content        string        `json:"content" gorm:"not null"`
+	MessageType    MessageType   `json:"message_type" gorm:"default:'text'"`
+	AttachmentURL  *string       `json:"attachment_url"`
+	AttachmentName *string       `json:"attachment_name"`
+	AttachmentSize *int          `json:"attachment_size"`
+	AttachmentType *string       `json:"attachment_type"`
+	Media          *MediaInfo    `json:"media,omitempty"`         // Variants and dimensions of an image attachment
+	LinkPreviews   []LinkPreview `json:"link_previews,omitempty"` // Cards for the links in Content
 	// Video-specific fields
 	ThumbnailURL  *string        `json:"thumbnail_url,omitempty"`
 	VideoDuration *int           `json:"video_duration,omitempty"` // Duration in seconds
@@ -143,6 +146,7 @@ const (
 	WSMessageTypeMessageEdited    WSMessageType = "message_edited"
 	WSMessageTypeMessagePinned    WSMessageType = "message_pinned"
 	WSMessageTypeMessageUnpinned  WSMessageType = "message_unpinned"
+	WSMessageTypeMediaReady       WSMessageType = "message_media_ready" // Video or audio attachment finished processing
 	WSMessageTypeOnline           WSMessageType = "online"
 	WSMessageTypeOffline          WSMessageType = "offline"
 	WSMessageTypeACK              WSMessageType = "ack"
@@ -158,6 +162,76 @@ type WSMessageEnvelope struct {
 	Timestamp      int64         `json:"timestamp"`                 // Unix timestamp
 }
 
+// WSCommandType identifies a command sent by the client over the websocket
+type WSCommandType string
+
+const (
+	WSCommandSendMessage WSCommandType = "send_message"
+	WSCommandTyping      WSCommandType = "typing"
+	WSCommandMarkRead    WSCommandType = "mark_read"
+	WSCommandReact       WSCommandType = "react"
+	WSCommandAck         WSCommandType = "ack"
+	WSCommandSubscribe   WSCommandType = "subscribe"
+	WSCommandUnsubscribe WSCommandType = "unsubscribe"
+)
+
+// WSCommand is an inbound websocket frame; Data is decoded according to Type
+type WSCommand struct {
+	ID   string          `json:"id"` // Client-chosen request ID, echoed in the reply
+	Type WSCommandType   `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// WSSendMessageCommand sends a message in a conversation (same fields as the REST request)
+type WSSendMessageCommand struct {
+	ConversationID uuid.UUID `json:"conversation_id" binding:"required"`
+	MessageRequest
+}
+
+// WSTypingCommand starts or stops the typing indicator in a conversation
+type WSTypingCommand struct {
+	ConversationID uuid.UUID `json:"conversation_id" binding:"required"`
+	IsTyping       bool      `json:"is_typing"`
+	IsRecording    bool      `json:"is_recording"`
+}
+
+// WSMarkReadCommand marks all messages in a conversation as read
+type WSMarkReadCommand struct {
+	ConversationID uuid.UUID `json:"conversation_id" binding:"required"`
+}
+
+// WSReactCommand toggles an emoji reaction on a message
+type WSReactCommand struct {
+	MessageID uuid.UUID `json:"message_id" binding:"required"`
+	Emoji     string    `json:"emoji" binding:"required,max=32"`
+}
+
+// WSAckCommand acknowledges a message delivered with ACK tracking
+type WSAckCommand struct {
+	MessageID string `json:"message_id" binding:"required"`
+}
+
+// WSSubscribeCommand subscribes to (or unsubscribes from) a topic such as "conversation:<id>"
+type WSSubscribeCommand struct {
+	Topic string `json:"topic" binding:"required,max=128"`
+}
+
+// WSCommandReply answers a command; Type is "reply" on success and "error" on failure
+type WSCommandReply struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Command WSCommandType   `json:"command"`
+	Success bool            `json:"success"`
+	Data    interface{}     `json:"data,omitempty"`
+	Error   *WSCommandError `json:"error,omitempty"`
+}
+
+// WSCommandError describes why a command failed
+type WSCommandError struct {
+	Code    string `json:"code"` // invalid_request, unknown_command, forbidden, command_failed
+	Message string `json:"message"`
+}
+
 // PresenceInfo represents a user's online/offline status
 type PresenceInfo struct {
 	UserID   uuid.UUID  `json:"user_id"`
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -31,6 +32,19 @@ const (
 	NotificationPostLike    NotificationType = "post_like"
 	NotificationPostComment NotificationType = "post_comment"
 	NotificationPostMention NotificationType = "post_mention"
+	NotificationPostShare   NotificationType = "post_share"
+	NotificationPollEnded   NotificationType = "poll_ended"
+
+	// Comment notifications
+	NotificationCommentReply   NotificationType = "comment_reply"
+	NotificationCommentMention NotificationType = "comment_mention"
+
+	// Event notifications
+	NotificationEventApproved NotificationType = "event_approved"
+	NotificationEventRejected NotificationType = "event_rejected"
+
+	// Course notifications
+	NotificationCourseEnrollment NotificationType = "course_enrollment"
 
 	// Future: Project notifications
 	NotificationProjectInvite NotificationType = "project_invite"
@@ -57,9 +71,74 @@ const (
 	CategoryProjects    NotificationCategory = "projects"
 	CategoryCommunities NotificationCategory = "communities"
 	CategoryPayments    NotificationCategory = "payments"
+	CategoryEvents      NotificationCategory = "events"
+	CategoryLearning    NotificationCategory = "learning"
 	CategorySystem      NotificationCategory = "system"
 )
 
+// NotificationCategories lists every category (unread counts are reported for each)
+var NotificationCategories = []NotificationCategory{
+	CategorySocial,
+	CategoryMessages,
+	CategoryProjects,
+	CategoryCommunities,
+	CategoryPayments,
+	CategoryEvents,
+	CategoryLearning,
+	CategorySystem,
+}
+
+// IsValidCategory checks if a category name exists
+func IsValidCategory(category string) bool {
+	for _, known := range NotificationCategories {
+		if string(known) == category {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultEmailTypes is used for notification types the user hasn't configured
+// Keep in sync with the email_types column default
+var DefaultEmailTypes = map[NotificationType]bool{
+	NotificationFollow:                false,
+	NotificationFollowBack:            false,
+	NotificationConnectionRequest:     true,
+	NotificationConnectionAccepted:    true,
+	NotificationCollaborationRequest:  true,
+	NotificationCollaborationAccepted: true,
+	NotificationMessage:               false,
+	NotificationPostLike:              false,
+	NotificationPostComment:           true,
+	NotificationPostMention:           true,
+	NotificationPostShare:             false,
+	NotificationPollEnded:             false,
+	NotificationCommentReply:          true,
+	NotificationCommentMention:        true,
+	NotificationEventApproved:         true,
+	NotificationEventRejected:         true,
+	NotificationCourseEnrollment:      false,
+	NotificationProjectInvite:         true,
+	NotificationCommunityInvite:       true,
+	NotificationPaymentReceived:       true,
+}
+
+// NotificationChannel is a way of delivering a notification
+type NotificationChannel string
+
+const (
+	ChannelInApp NotificationChannel = "in_app"
+	ChannelEmail NotificationChannel = "email"
+	ChannelPush  NotificationChannel = "push"
+)
+
+// ChannelSelection picks the channels used for a category
+type ChannelSelection struct {
+	InApp bool `json:"in_app"`
+	Email bool `json:"email"`
+	Push  bool `json:"push"`
+}
+
 // EmailFrequency represents how often to send email digests
 type EmailFrequency string
 
@@ -92,10 +171,22 @@ type Notification struct {
 	ActionType   *string                `json:"action_type,omitempty" db:"action_type"`
 	ActionTaken  bool                   `json:"action_taken" db:"action_taken"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+	GroupKey     *string                `json:"group_key,omitempty" db:"group_key"` // Unread notifications with the same key collapse into one
+	ActorIDs     []uuid.UUID            `json:"actor_ids,omitempty" db:"actor_ids"` // Most recent actors of a group, newest first
+	ActorCount   int                    `json:"actor_count" db:"actor_count"`       // Distinct actors in the group
+	InApp        bool                   `json:"in_app" db:"in_app"`                 // False when only sent by email/push (hidden from the inbox)
 	CreatedAt    time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at" db:"updated_at"` // Last time an actor joined the group
 	ExpiresAt    time.Time              `json:"expires_at" db:"expires_at"`
+
+	// Channels held back by quiet hours or a snooze, delivered at DeliverAt
+	PendingChannels []NotificationChannel `json:"pending_channels,omitempty" db:"pending_channels"`
+	DeliverAt       *time.Time            `json:"deliver_at,omitempty" db:"deliver_at"`
 }
 
+// Maximum recent actors remembered per grouped notification
+const MaxGroupedActors = 10
+
 // NotificationPreferences represents user notification settings
 type NotificationPreferences struct {
 	UserID               uuid.UUID       `json:"user_id" db:"user_id"`
@@ -106,7 +197,28 @@ type NotificationPreferences struct {
 	PushEnabled          bool            `json:"push_enabled" db:"push_enabled"`
 	InlineActionsEnabled bool            `json:"inline_actions_enabled" db:"inline_actions_enabled"`
 	CategoriesEnabled    map[string]bool `json:"categories_enabled" db:"categories_enabled"`
-	UpdatedAt            time.Time       `json:"updated_at" db:"updated_at"`
+	QuietHoursEnabled    bool            `json:"quiet_hours_enabled" db:"quiet_hours_enabled"`
+	QuietHoursStart      string          `json:"quiet_hours_start" db:"quiet_hours_start"` // "HH:MM" in the user's timezone
+	QuietHoursEnd        string          `json:"quiet_hours_end" db:"quiet_hours_end"`     // "HH:MM", may be earlier than start (overnight)
+	Timezone             string          `json:"timezone" db:"timezone"`                   // IANA name, e.g. "Europe/Berlin" (UTC if empty)
+	// Channels per category, categories missing here use every channel that is enabled
+	CategoryChannels map[string]ChannelSelection `json:"category_channels" db:"category_channels"`
+	SnoozedUntil     *time.Time                  `json:"snoozed_until,omitempty" db:"snoozed_until"`   // Email and push are held until then
+	LastDigestAt     *time.Time                  `json:"last_digest_at,omitempty" db:"last_digest_at"` // Last daily/weekly digest sent
+	UpdatedAt        time.Time                   `json:"updated_at" db:"updated_at"`
+}
+
+// PushSubscription is a browser's Web Push endpoint for a user
+type PushSubscription struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Endpoint   string     `json:"endpoint" db:"endpoint"`
+	P256dh     string     `json:"-" db:"p256dh"` // Browser's ECDH public key (base64url)
+	Auth       string     `json:"-" db:"auth"`   // Browser's authentication secret (base64url)
+	UserAgent  string     `json:"user_agent,omitempty" db:"user_agent"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 }
 
 // =====================================================
@@ -127,7 +239,10 @@ type NotificationResponse struct {
 	ActionType   *string                `json:"action_type,omitempty"`
 	ActionTaken  bool                   `json:"action_taken"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	GroupKey     *string                `json:"group_key,omitempty"`
+	ActorCount   int                    `json:"actor_count"`
 	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
 }
 
 // NotificationActor represents the user who triggered the notification
@@ -181,6 +296,32 @@ type UpdateNotificationPreferencesRequest struct {
 	PushEnabled          *bool           `json:"push_enabled,omitempty"`
 	InlineActionsEnabled *bool           `json:"inline_actions_enabled,omitempty"`
 	CategoriesEnabled    map[string]bool `json:"categories_enabled,omitempty"`
+	QuietHoursEnabled    *bool           `json:"quiet_hours_enabled,omitempty"`
+	QuietHoursStart      *string         `json:"quiet_hours_start,omitempty" binding:"omitempty,datetime=15:04"`
+	QuietHoursEnd        *string         `json:"quiet_hours_end,omitempty" binding:"omitempty,datetime=15:04"`
+	Timezone             *string         `json:"timezone,omitempty" binding:"omitempty,timezone"`
+	// Replaces the channels of the listed categories, other categories are left as they are
+	CategoryChannels map[string]ChannelSelection `json:"category_channels,omitempty"`
+}
+
+// SnoozeNotificationsRequest holds email and push for a number of hours
+type SnoozeNotificationsRequest struct {
+	Hours int `json:"hours" binding:"required,min=1,max=168"`
+}
+
+// RegisterPushSubscriptionRequest is the browser's PushSubscription.toJSON() output
+type RegisterPushSubscriptionRequest struct {
+	Endpoint       string `json:"endpoint" binding:"required,url,startswith=https://"`
+	ExpirationTime *int64 `json:"expirationTime,omitempty"` // Unix ms, null when the subscription doesn't expire
+	Keys           struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+}
+
+// UnregisterPushSubscriptionRequest removes a browser's subscription
+type UnregisterPushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" binding:"required"`
 }
 
 // =====================================================
@@ -224,7 +365,17 @@ func (n *Notification) ToResponse() *NotificationResponse {
 		ActionType:   n.ActionType,
 		ActionTaken:  n.ActionTaken,
 		Metadata:     n.Metadata,
+		GroupKey:     n.GroupKey,
+		ActorCount:   n.ActorCount,
 		CreatedAt:    n.CreatedAt,
+		UpdatedAt:    n.UpdatedAt,
+	}
+
+	if resp.ActorCount == 0 && n.ActorID != nil {
+		resp.ActorCount = 1
+	}
+	if resp.UpdatedAt.IsZero() {
+		resp.UpdatedAt = n.CreatedAt
 	}
 
 	// Add actor if available
@@ -259,9 +410,20 @@ func GetCategoryForType(notifType NotificationType) NotificationCategory {
 
 	case NotificationPostLike,
 		NotificationPostComment,
-		NotificationPostMention:
+		NotificationPostMention,
+		NotificationPostShare,
+		NotificationPollEnded,
+		NotificationCommentReply,
+		NotificationCommentMention:
 		return CategorySocial
 
+	case NotificationEventApproved,
+		NotificationEventRejected:
+		return CategoryEvents
+
+	case NotificationCourseEnrollment:
+		return CategoryLearning
+
 	case NotificationProjectInvite,
 		NotificationProjectUpdate:
 		return CategoryProjects
@@ -297,8 +459,9 @@ func (p *NotificationPreferences) ShouldSendEmail(notifType NotificationType) bo
 		return enabled
 	}
 
-	// Default to false for unknown types
-	return false
+	// Fall back to the default for types added after the user saved preferences
+	// (false for unknown types)
+	return DefaultEmailTypes[notifType]
 }
 
 // IsCategoryEnabled checks if a category is enabled
@@ -314,3 +477,102 @@ func (p *NotificationPreferences) IsCategoryEnabled(category NotificationCategor
 	// Default to true for unknown categories
 	return true
 }
+
+// ChannelsFor returns the channels a notification is delivered on
+// The category selection is combined with the global switches and per-type email settings
+func (p *NotificationPreferences) ChannelsFor(notifType NotificationType, category NotificationCategory) ChannelSelection {
+	channels := ChannelSelection{
+		InApp: p.IsCategoryEnabled(category),
+		Email: true,
+		Push:  true,
+	}
+	if selection, exists := p.CategoryChannels[string(category)]; exists {
+		channels = selection
+		channels.InApp = channels.InApp && p.InAppEnabled
+	}
+
+	channels.Email = channels.Email && p.ShouldSendEmail(notifType)
+	channels.Push = channels.Push && p.PushEnabled
+	return channels
+}
+
+// IsSnoozed reports whether the user snoozed notifications past t
+func (p *NotificationPreferences) IsSnoozed(t time.Time) bool {
+	return p.SnoozedUntil != nil && t.Before(*p.SnoozedUntil)
+}
+
+// ShouldHold reports whether email and push should wait (quiet hours or snoozed)
+func (p *NotificationPreferences) ShouldHold(t time.Time) bool {
+	return p.IsSnoozed(t) || p.InQuietHours(t)
+}
+
+// NextDeliveryTime returns when notifications held at t can be delivered
+func (p *NotificationPreferences) NextDeliveryTime(t time.Time) time.Time {
+	// A snooze can end inside quiet hours and vice versa, so step until neither applies
+	for i := 0; i < 4 && p.ShouldHold(t); i++ {
+		if p.IsSnoozed(t) {
+			t = *p.SnoozedUntil
+			continue
+		}
+		t = p.quietHoursEnd(t)
+	}
+	return t
+}
+
+// Location returns the user's timezone (UTC if unset or unknown)
+func (p *NotificationPreferences) Location() *time.Location {
+	location, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// InQuietHours reports whether t falls inside the user's quiet hours
+func (p *NotificationPreferences) InQuietHours(t time.Time) bool {
+	if !p.QuietHoursEnabled {
+		return false
+	}
+
+	start, err := parseClock(p.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(p.QuietHoursEnd)
+	if err != nil || start == end {
+		return false
+	}
+
+	local := t.In(p.Location())
+	now := local.Hour()*60 + local.Minute()
+
+	if start < end {
+		return now >= start && now < end
+	}
+	// Window wraps past midnight (e.g. 22:00-07:00)
+	return now >= start || now < end
+}
+
+// quietHoursEnd returns the first end of quiet hours after t
+func (p *NotificationPreferences) quietHoursEnd(t time.Time) time.Time {
+	end, err := parseClock(p.QuietHoursEnd)
+	if err != nil {
+		return t
+	}
+
+	local := t.In(p.Location())
+	next := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, local.Location())
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, end/60, end%60, 0, 0, local.Location())
+	}
+	return next
+}
+
+// parseClock converts "HH:MM" to minutes after midnight
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", value, err)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Passkey represents a WebAuthn credential registered to a user
+type Passkey struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	CredentialID    string     `json:"-" db:"credential_id"` // base64url-encoded raw credential ID
+	PublicKey       []byte     `json:"-" db:"public_key"`    // COSE-encoded public key
+	AttestationType string     `json:"-" db:"attestation_type"`
+	AAGUID          []byte     `json:"-" db:"aaguid"`
+	SignCount       uint32     `json:"-" db:"sign_count"`
+	CloneWarning    bool       `json:"clone_warning" db:"clone_warning"`
+	Transports      []string   `json:"transports" db:"transports"`
+	BackupEligible  bool       `json:"backup_eligible" db:"backup_eligible"`
+	BackupState     bool       `json:"backup_state" db:"backup_state"` // True for synced passkeys (e.g. iCloud Keychain)
+	Name            string     `json:"name" db:"name"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+// PasskeyCeremonyResponse returns WebAuthn options for the browser to pass to navigator.credentials
+type PasskeyCeremonyResponse struct {
+	Success    bool        `json:"success"`
+	CeremonyID string      `json:"ceremony_id"` // Must be sent back with the finish request
+	Options    interface{} `json:"options"`     // PublicKeyCredentialCreationOptions or PublicKeyCredentialRequestOptions
+}
+
+// PasskeyResponse represents the response for a single passkey
+type PasskeyResponse struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message,omitempty"`
+	Passkey *Passkey `json:"passkey"`
+}
+
+// PasskeysResponse represents the response for listing a user's passkeys
+type PasskeysResponse struct {
+	Success  bool       `json:"success"`
+	Passkeys []*Passkey `json:"passkeys"`
+}
+
+// BeginPasskeyRegistrationRequest represents the request payload for starting passkey registration
+type BeginPasskeyRegistrationRequest struct {
+	TwoFactorCode string `json:"two_factor_code,omitempty"` // Required when 2FA is enabled
+}
+
+// FinishPasskeyRegistrationRequest represents the authenticator's attestation response
+type FinishPasskeyRegistrationRequest struct {
+	CeremonyID string          `json:"ceremony_id" binding:"required"`
+	Name       string          `json:"name" binding:"max=64"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// FinishPasskeyLoginRequest represents the authenticator's assertion response for a passkey login
+type FinishPasskeyLoginRequest struct {
+	CeremonyID string          `json:"ceremony_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// BeginPasskeyTwoFactorRequest starts a passkey assertion for the second step of a 2FA login
+type BeginPasskeyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// FinishPasskeyTwoFactorRequest completes the second step of a 2FA login with a passkey
+type FinishPasskeyTwoFactorRequest struct {
+	ChallengeToken string          `json:"challenge_token" binding:"required"`
+	CeremonyID     string          `json:"ceremony_id" binding:"required"`
+	Credential     json.RawMessage `json:"credential" binding:"required"`
+}
+
+// RenamePasskeyRequest represents the request payload for renaming a passkey
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=64"`
+}
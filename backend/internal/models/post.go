@@ -15,6 +15,8 @@ type Post struct {
 	Content        string         `json:"content"`
 	MediaURLs      pq.StringArray `json:"media_urls" db:"media_urls"`
 	MediaTypes     pq.StringArray `json:"media_types" db:"media_types"`
+	Media          []MediaInfo    `json:"media,omitempty" db:"media"`                 // Variants and dimensions of the images in MediaURLs
+	LinkPreviews   []LinkPreview  `json:"link_previews,omitempty" db:"link_previews"` // Cards for the links in Content
 	Visibility     string         `json:"visibility"`
 	AllowsComments bool           `json:"allows_comments"`
 	AllowsSharing  bool           `json:"allows_sharing"`
@@ -32,9 +34,14 @@ type Post struct {
 	IsNSFW     bool `json:"is_nsfw"`
 
 	// Status
-	IsPublished bool       `json:"is_published"`
-	IsDraft     bool       `json:"is_draft"`
-	PublishedAt *time.Time `json:"published_at"`
+	IsPublished  bool       `json:"is_published"`
+	IsDraft      bool       `json:"is_draft"`
+	PublishedAt  *time.Time `json:"published_at"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty" db:"scheduled_for"` // Set while the post waits to be published
+
+	// Drafts only
+	Draft         *DraftContent `json:"draft,omitempty" db:"draft"`                   // Everything saved, poll and article included
+	DraftRevision int           `json:"draft_revision,omitempty" db:"draft_revision"` // Incremented by every save
 
 	// Timestamps
 	CreatedAt time.Time  `json:"created_at"`
@@ -63,6 +70,9 @@ type CreatePostRequest struct {
 	IsDraft        bool     `json:"is_draft"`
 	IsNSFW         bool     `json:"is_nsfw"`
 
+	// Publish at this time (RFC 3339) instead of now
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+
 	// For polls
 	Poll *CreatePollRequest `json:"poll,omitempty"`
 
@@ -80,6 +90,28 @@ type UpdatePostRequest struct {
 	IsPinned       *bool   `json:"is_pinned,omitempty"`
 }
 
+// ReschedulePostRequest is the request body for moving a scheduled post
+type ReschedulePostRequest struct {
+	ScheduledFor time.Time `json:"scheduled_for" binding:"required"`
+}
+
+// How far ahead posts can be scheduled
+const (
+	MinScheduleLead  = time.Minute
+	MaxScheduleAhead = 365 * 24 * time.Hour
+)
+
+// ValidateSchedule checks that a post can be scheduled for a time
+func ValidateSchedule(at time.Time) error {
+	if at.Before(time.Now().Add(MinScheduleLead)) {
+		return ErrScheduleInPast
+	}
+	if at.After(time.Now().Add(MaxScheduleAhead)) {
+		return ErrScheduleTooFar
+	}
+	return nil
+}
+
 // PostResponse is the API response for a post
 type PostResponse struct {
 	Success bool   `json:"success"`
@@ -151,6 +183,16 @@ func (r *CreatePostRequest) Validate() error {
 		}
 	}
 
+	// Scheduled posts are published by themselves, drafts only by their author
+	if r.ScheduledFor != nil {
+		if r.IsDraft {
+			return ErrScheduledDraft
+		}
+		if err := ValidateSchedule(*r.ScheduledFor); err != nil {
+			return err
+		}
+	}
+
 	// Poll must have poll data
 	if r.PostType == "poll" && r.Poll == nil {
 		return ErrPollDataRequired
@@ -173,6 +215,11 @@ var (
 	ErrArticleDataRequired = &AppError{Code: "ARTICLE_DATA_REQUIRED", Message: "Article data is required for article posts"}
 	ErrPostNotFound        = &AppError{Code: "POST_NOT_FOUND", Message: "Post not found"}
 	ErrUnauthorized        = &AppError{Code: "UNAUTHORIZED", Message: "Not authorized to perform this action"}
+	ErrInvalidHashtag      = &AppError{Code: "INVALID_HASHTAG", Message: "Hashtags must be lowercase letters, digits or underscores"}
+	ErrScheduleInPast      = &AppError{Code: "SCHEDULE_IN_PAST", Message: "Scheduled time must be at least a minute from now"}
+	ErrScheduleTooFar      = &AppError{Code: "SCHEDULE_TOO_FAR", Message: "Posts can be scheduled up to a year ahead"}
+	ErrScheduledDraft      = &AppError{Code: "SCHEDULED_DRAFT", Message: "Drafts can't be scheduled"}
+	ErrPostNotScheduled    = &AppError{Code: "POST_NOT_SCHEDULED", Message: "Post isn't scheduled"}
 )
 
 // AppError represents a custom application error
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// RESUMABLE UPLOADS
+// =====================================================
+
+// UploadPurpose says where a completed upload is stored and which limits apply
+type UploadPurpose string
+
+const (
+	UploadPurposePostVideo    UploadPurpose = "post_video"    // Video attached to a post
+	UploadPurposeMessageVideo UploadPurpose = "message_video" // Video sent in a chat
+	UploadPurposeMessageFile  UploadPurpose = "message_file"  // Document sent in a chat
+	UploadPurposeEventCover   UploadPurpose = "event_cover"   // Cover image of an event
+)
+
+// UploadStatus is the state of a resumable upload session
+type UploadStatus string
+
+const (
+	UploadStatusUploading  UploadStatus = "uploading"  // Accepting chunks
+	UploadStatusFinalizing UploadStatus = "finalizing" // Chunks are being assembled into the final file
+	UploadStatusCompleted  UploadStatus = "completed"  // Stored, URL is set
+)
+
+// UploadSession is a resumable upload: chunks are stored as parts until the client completes it
+type UploadSession struct {
+	ID          uuid.UUID     `json:"id" db:"id"`
+	UserID      uuid.UUID     `json:"user_id" db:"user_id"`
+	Purpose     UploadPurpose `json:"purpose" db:"purpose"`
+	Filename    string        `json:"filename" db:"filename"`
+	ContentType string        `json:"content_type" db:"content_type"`
+	Size        int64         `json:"size" db:"size"`                   // Total length, declared up front
+	Offset      int64         `json:"offset" db:"upload_offset"`        // Bytes received so far
+	Checksum    *string       `json:"checksum,omitempty" db:"checksum"` // Expected SHA-256 of the whole file (hex)
+	Parts       []string      `json:"-" db:"parts"`                     // Keys of the stored chunks, in order
+	Bucket      string        `json:"-" db:"bucket"`                    // Destination of the completed file
+	ObjectKey   string        `json:"-" db:"object_key"`
+	Status      UploadStatus  `json:"status" db:"status"`
+	URL         *string       `json:"url,omitempty" db:"url"`     // Set once completed
+	ExpiresAt   time.Time     `json:"expires_at" db:"expires_at"` // Pushed back by every chunk
+	CompletedAt *time.Time    `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
+
+	// Computed fields, signed URL of completed uploads in private buckets
+	// and the processing status of completed videos
+	SignedURL string     `json:"signed_url,omitempty" db:"-"`
+	Media     *MediaInfo `json:"media,omitempty" db:"-"`
+}
+
+// CreateUploadRequest starts a resumable upload
+type CreateUploadRequest struct {
+	Purpose     UploadPurpose `json:"purpose" binding:"required"`
+	Filename    string        `json:"filename" binding:"required,max=255"`
+	ContentType string        `json:"content_type" binding:"required,max=255"`
+	Size        int64         `json:"size" binding:"required,min=1"`
+	Checksum    string        `json:"checksum,omitempty"` // Optional SHA-256 of the whole file (hex), verified on completion
+}
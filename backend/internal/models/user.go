@@ -24,11 +24,17 @@ type User struct {
 	PendingEmailCode           *string    `json:"-" db:"pending_email_code"`
 	PendingEmailExpiresAt      *time.Time `json:"-" db:"pending_email_expires_at"`
 	UsernameChangedAt          *time.Time `json:"-" db:"username_changed_at"`
+	TwoFactorEnabled           bool       `json:"two_factor_enabled" db:"two_factor_enabled"`
+	TwoFactorSecret            *string    `json:"-" db:"two_factor_secret"`
+	TwoFactorRecoveryCodes     []string   `json:"-" db:"two_factor_recovery_codes"` // SHA256 hashes of unused codes
+	TwoFactorEnabledAt         *time.Time `json:"two_factor_enabled_at,omitempty" db:"two_factor_enabled_at"`
+	TwoFactorLastStep          int64      `json:"-" db:"two_factor_last_step"` // TOTP time step of the last accepted code
 	GoogleID                   *string    `json:"-" db:"google_id"`
 	GitHubID                   *string    `json:"-" db:"github_id"`
 	LinkedInID                 *string    `json:"-" db:"linkedin_id"`
 	OAuthProvider              *string    `json:"oauth_provider,omitempty" db:"oauth_provider"`
 	ProfilePicture             *string    `json:"profile_picture,omitempty" db:"profile_picture"`
+	Language                   string     `json:"language" db:"language"` // Email language, see mailer.SupportedLanguages
 	IsActive                   bool       `json:"is_active" db:"is_active"`
 	LastLoginAt                *time.Time `json:"last_login_at" db:"last_login_at"`
 	CreatedAt                  time.Time  `json:"created_at" db:"created_at"`
@@ -53,16 +59,19 @@ type User struct {
 	SocialLinks     map[string]*string `json:"social_links,omitempty" db:"social_links"`
 }
 
-// UserSession represents a user session (optional for advanced session management)
+// UserSession represents a logged-in device
+// Each session is one refresh token family: the token rotates on every refresh but the session ID stays the same
 type UserSession struct {
-	ID         uuid.UUID `json:"id" db:"id"`
-	UserID     uuid.UUID `json:"user_id" db:"user_id"`
-	TokenHash  string    `json:"-" db:"token_hash"`
-	DeviceInfo *string   `json:"device_info" db:"device_info"`
-	IPAddress  *string   `json:"ip_address" db:"ip_address"`
-	UserAgent  *string   `json:"user_agent" db:"user_agent"`
-	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID                uuid.UUID  `json:"id" db:"id"`
+	UserID            uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash         string     `json:"-" db:"token_hash"`          // SHA256 of the current refresh token
+	PreviousTokenHash string     `json:"-" db:"previous_token_hash"` // SHA256 of the last rotated-out refresh token (reuse detection)
+	DeviceInfo        *string    `json:"device_info" db:"device_info"`
+	IPAddress         *string    `json:"ip_address" db:"ip_address"`
+	UserAgent         *string    `json:"user_agent" db:"user_agent"`
+	ExpiresAt         time.Time  `json:"expires_at" db:"expires_at"`
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
 }
 
 // RegisterRequest represents the request payload for user registration
@@ -75,6 +84,7 @@ type RegisterRequest struct {
 	Gender           *string `json:"gender,omitempty" validate:"omitempty,oneof=male female non-binary prefer-not-to-say custom"`
 	Bio              *string `json:"bio,omitempty" validate:"omitempty,max=200"`
 	ProfilePicture   *string `json:"profile_picture,omitempty" validate:"omitempty,url"`
+	Language         *string `json:"language,omitempty"`
 	VerificationCode *string `json:"verification_code,omitempty" validate:"omitempty,len=6"` // Optional: if provided, verify immediately
 }
 
@@ -103,7 +113,7 @@ type ResetPasswordRequest struct {
 
 // RefreshTokenRequest represents the request payload for token refresh
 type RefreshTokenRequest struct {
-	RefreshToken string `json:"refresh_token" validate:"required"`
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // AuthResponse represents the response for authentication operations
@@ -114,14 +124,27 @@ type AuthResponse struct {
 	ExpiresAt time.Time `json:"expires_at,omitempty"`
 	User      *User     `json:"user,omitempty"`
 	UserID    string    `json:"user_id,omitempty"`
+
+	// Opaque refresh token, exchanged at /auth/refresh for a new access token
+	RefreshToken     string     `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty"`
+
+	// Set by services once the user is fully authenticated; the handler then creates the session and issues tokens
+	IssueSession bool `json:"-"`
+
+	// Two-factor login challenge (set instead of Token when 2FA is enabled)
+	RequiresTwoFactor bool   `json:"requires_two_factor,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
 }
 
 // TokenResponse represents the response for token operations
 type TokenResponse struct {
-	Success   bool      `json:"success"`
-	Message   string    `json:"message"`
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
+	Success          bool      `json:"success"`
+	Message          string    `json:"message"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
 // UserResponse represents the response for user operations
@@ -138,17 +161,64 @@ type MessageResponse struct {
 
 // JWTClaims represents the JWT token claims
 type JWTClaims struct {
-	UserID   string `json:"user_id"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Purpose   string `json:"purpose,omitempty"` // Empty for access tokens, set for single-purpose tokens (e.g. 2FA challenge)
+	SessionID string `json:"sid,omitempty"`     // Session the access token belongs to (revoking the session revokes the token)
 	jwt.RegisteredClaims
 }
 
+// TwoFactorSetupResponse represents the response when starting 2FA enrollment
+type TwoFactorSetupResponse struct {
+	Success         bool   `json:"success"`
+	Secret          string `json:"secret"`           // Base32 secret for manual entry
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI to render as a QR code
+}
+
+// TwoFactorStatusResponse represents the current 2FA state of an account
+type TwoFactorStatusResponse struct {
+	Success                bool       `json:"success"`
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorRecoveryCodesResponse returns freshly generated recovery codes (shown once)
+type TwoFactorRecoveryCodesResponse struct {
+	Success       bool     `json:"success"`
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnableTwoFactorRequest represents the request payload for confirming 2FA enrollment
+type EnableTwoFactorRequest struct {
+	Code string `json:"code" validate:"required,len=6"`
+}
+
+// DisableTwoFactorRequest represents the request payload for turning off 2FA
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code" validate:"required"` // TOTP code or recovery code
+}
+
+// RegenerateRecoveryCodesRequest represents the request payload for issuing new recovery codes
+type RegenerateRecoveryCodesRequest struct {
+	Code string `json:"code" validate:"required"` // TOTP code or recovery code
+}
+
+// VerifyTwoFactorLoginRequest represents the second step of a 2FA login
+type VerifyTwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"` // TOTP code or recovery code
+}
+
 // UpdateProfileRequest represents the request payload for updating user profile
 type UpdateProfileRequest struct {
 	DisplayName    *string `json:"display_name,omitempty" validate:"omitempty,min=2,max=50"`
 	Age            *int    `json:"age,omitempty" validate:"omitempty,min=13,max=120"`
 	ProfilePicture *string `json:"profile_picture,omitempty" validate:"omitempty,url"`
+	Language       *string `json:"language,omitempty"`
 }
 
 // ChangePasswordRequest represents the request payload for changing password
@@ -156,18 +226,21 @@ type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" validate:"required"`
 	NewPassword     string `json:"new_password" validate:"required,min=8"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,min=8"`
+	TwoFactorCode   string `json:"two_factor_code,omitempty"` // Required when 2FA is enabled
 }
 
 // DeleteAccountRequest represents the request payload for account deletion
 type DeleteAccountRequest struct {
-	Password     string `json:"password" validate:"required"`
-	Confirmation string `json:"confirmation" validate:"required,eqfield=DELETE MY ACCOUNT"`
+	Password      string `json:"password" validate:"required"`
+	Confirmation  string `json:"confirmation" validate:"required,eqfield=DELETE MY ACCOUNT"`
+	TwoFactorCode string `json:"two_factor_code,omitempty"` // Required when 2FA is enabled
 }
 
 // ChangeEmailRequest represents the request payload for initiating email change
 type ChangeEmailRequest struct {
-	NewEmail string `json:"new_email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	NewEmail      string `json:"new_email" validate:"required,email"`
+	Password      string `json:"password" validate:"required"`
+	TwoFactorCode string `json:"two_factor_code,omitempty"` // Required when 2FA is enabled
 }
 
 // VerifyEmailChangeRequest represents the request payload for verifying new email
@@ -441,34 +514,37 @@ type UpdateEducationRequest struct {
 // ToUser converts User model to a safe version without sensitive data
 func (u *User) ToSafeUser() *User {
 	return &User{
-		ID:              u.ID,
-		Email:           u.Email,
-		Username:        u.Username,
-		DisplayName:     u.DisplayName,
-		Age:             u.Age,
-		IsEmailVerified: u.IsEmailVerified,
-		OAuthProvider:   u.OAuthProvider,
-		ProfilePicture:  u.ProfilePicture,
-		IsActive:        u.IsActive,
-		LastLoginAt:     u.LastLoginAt,
-		CreatedAt:       u.CreatedAt,
-		UpdatedAt:       u.UpdatedAt,
-		Bio:             u.Bio,
-		Location:        u.Location,
-		Gender:          u.Gender,
-		GenderCustom:    u.GenderCustom,
-		Website:         u.Website,
-		IsVerified:      u.IsVerified,
-		ProfilePrivacy:  u.ProfilePrivacy,
-		FieldVisibility: u.FieldVisibility,
-		StatVisibility:  u.StatVisibility,
-		Story:           u.Story,
-		Ambition:        u.Ambition,
-		PostsCount:      u.PostsCount,
-		ProjectsCount:   u.ProjectsCount,
-		FollowersCount:  u.FollowersCount,
-		FollowingCount:  u.FollowingCount,
-		SocialLinks:     u.SocialLinks,
+		ID:                 u.ID,
+		Email:              u.Email,
+		Username:           u.Username,
+		DisplayName:        u.DisplayName,
+		Age:                u.Age,
+		IsEmailVerified:    u.IsEmailVerified,
+		TwoFactorEnabled:   u.TwoFactorEnabled,
+		TwoFactorEnabledAt: u.TwoFactorEnabledAt,
+		OAuthProvider:      u.OAuthProvider,
+		ProfilePicture:     u.ProfilePicture,
+		Language:           u.Language,
+		IsActive:           u.IsActive,
+		LastLoginAt:        u.LastLoginAt,
+		CreatedAt:          u.CreatedAt,
+		UpdatedAt:          u.UpdatedAt,
+		Bio:                u.Bio,
+		Location:           u.Location,
+		Gender:             u.Gender,
+		GenderCustom:       u.GenderCustom,
+		Website:            u.Website,
+		IsVerified:         u.IsVerified,
+		ProfilePrivacy:     u.ProfilePrivacy,
+		FieldVisibility:    u.FieldVisibility,
+		StatVisibility:     u.StatVisibility,
+		Story:              u.Story,
+		Ambition:           u.Ambition,
+		PostsCount:         u.PostsCount,
+		ProjectsCount:      u.ProjectsCount,
+		FollowersCount:     u.FollowersCount,
+		FollowingCount:     u.FollowingCount,
+		SocialLinks:        u.SocialLinks,
 	}
 }
 
@@ -0,0 +1,180 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// SNOOZE
+// =====================================================
+
+// Snooze holds a user's email and push notifications for a number of hours
+func (s *NotificationService) Snooze(ctx context.Context, userID uuid.UUID, hours int) (time.Time, error) {
+	prefs := s.preferencesForUpdate(ctx, userID)
+
+	until := time.Now().Add(time.Duration(hours) * time.Hour)
+	prefs.SnoozedUntil = &until
+
+	if err := s.repo.UpsertPreferences(ctx, prefs); err != nil {
+		return time.Time{}, fmt.Errorf("failed to snooze notifications: %w", err)
+	}
+
+	s.rescheduleHeld(ctx, prefs)
+
+	log.Printf("[NotificationService] Notifications snoozed for user %s until %s", userID, until.Format(time.RFC3339))
+	return until, nil
+}
+
+// Unsnooze ends a snooze, held notifications are released unless quiet hours apply
+func (s *NotificationService) Unsnooze(ctx context.Context, userID uuid.UUID) error {
+	prefs := s.preferencesForUpdate(ctx, userID)
+	prefs.SnoozedUntil = nil
+
+	if err := s.repo.UpsertPreferences(ctx, prefs); err != nil {
+		return fmt.Errorf("failed to end snooze: %w", err)
+	}
+
+	s.rescheduleHeld(ctx, prefs)
+
+	log.Printf("[NotificationService] Snooze ended for user %s", userID)
+	return nil
+}
+
+// rescheduleHeld moves a user's held notifications to the next time they may be delivered
+func (s *NotificationService) rescheduleHeld(ctx context.Context, prefs *models.NotificationPreferences) {
+	deliverAt := prefs.NextDeliveryTime(time.Now())
+	if err := s.repo.RescheduleDeliveries(ctx, prefs.UserID, deliverAt); err != nil {
+		log.Printf("[NotificationService] Failed to reschedule held notifications for user %s: %v", prefs.UserID, err)
+	}
+}
+
+// =====================================================
+// DEFERRED DELIVERY
+// =====================================================
+
+// ReleaseHeldNotifications delivers email and push held back by quiet hours or a snooze
+// Several held notifications are sent as one summary push and one catch-up email per user
+func (s *NotificationService) ReleaseHeldNotifications(ctx context.Context, limit int) (int, error) {
+	now := time.Now()
+
+	due, err := s.repo.GetDueDeliveries(ctx, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get held notifications: %w", err)
+	}
+
+	// Group by user, keeping delivery order
+	var userIDs []uuid.UUID
+	byUser := make(map[uuid.UUID][]*models.Notification)
+	for _, notification := range due {
+		if _, seen := byUser[notification.UserID]; !seen {
+			userIDs = append(userIDs, notification.UserID)
+		}
+		byUser[notification.UserID] = append(byUser[notification.UserID], notification)
+	}
+
+	released := 0
+	for _, userID := range userIDs {
+		released += s.releaseForUser(ctx, userID, byUser[userID], now)
+	}
+
+	return released, nil
+}
+
+// releaseForUser delivers one user's due notifications on the channels they still want
+func (s *NotificationService) releaseForUser(ctx context.Context, userID uuid.UUID, held []*models.Notification, now time.Time) int {
+	prefs, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		log.Printf("[NotificationService] Failed to get preferences for user %s: %v", userID, err)
+		return 0
+	}
+
+	// The snooze may have been extended or quiet hours changed since these were held
+	if prefs.ShouldHold(now) {
+		s.rescheduleHeld(ctx, prefs)
+		return 0
+	}
+
+	var emails, pushes []*models.Notification
+	released := 0
+	for _, notification := range held {
+		claimed, err := s.repo.ClaimDelivery(ctx, notification.ID)
+		if err != nil {
+			log.Printf("[NotificationService] Failed to claim held notification %s: %v", notification.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		released++
+
+		// Already seen in the inbox, no need to interrupt the user with it
+		if notification.IsRead {
+			continue
+		}
+
+		channels := prefs.ChannelsFor(notification.Type, notification.Category)
+		if containsChannel(notification.PendingChannels, models.ChannelEmail) && channels.Email &&
+			prefs.EmailFrequency == models.EmailFrequencyInstant && s.emailSvc != nil {
+			emails = append(emails, notification)
+		}
+		if containsChannel(notification.PendingChannels, models.ChannelPush) && channels.Push && s.pushSvc != nil {
+			pushes = append(pushes, notification)
+		}
+	}
+
+	s.releaseEmails(ctx, userID, emails)
+	s.releasePushes(ctx, userID, pushes)
+
+	return released
+}
+
+// releaseEmails sends held emails, as one catch-up digest when there are several
+func (s *NotificationService) releaseEmails(ctx context.Context, userID uuid.UUID, held []*models.Notification) {
+	if len(held) == 0 {
+		return
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("[NotificationService] Failed to get user %s for held emails: %v", userID, err)
+		return
+	}
+
+	if len(held) == 1 {
+		err = s.emailSvc.SendNotificationEmail(ctx, user, held[0])
+	} else {
+		err = s.emailSvc.SendDigestEmail(ctx, user, held, models.EmailFrequencyInstant)
+	}
+	if err != nil {
+		log.Printf("[NotificationService] Failed to send held emails to %s: %v", user.Email, err)
+	}
+}
+
+// releasePushes sends a held push, or a single summary when there are several
+func (s *NotificationService) releasePushes(ctx context.Context, userID uuid.UUID, held []*models.Notification) {
+	switch len(held) {
+	case 0:
+		return
+	case 1:
+		s.pushSvc.SendNotification(ctx, held[0])
+	default:
+		s.pushSvc.SendNotification(ctx, s.factory.NewHeldSummaryNotification(userID, len(held)))
+	}
+}
+
+// containsChannel checks if a channel is in a list
+func containsChannel(channels []models.NotificationChannel, channel models.NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package notifications
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"upvista-community-backend/internal/models"
@@ -17,6 +18,34 @@ func NewNotificationFactory() *NotificationFactory {
 	return &NotificationFactory{}
 }
 
+// =====================================================
+// GROUPING
+// =====================================================
+
+// groupKey builds the aggregation key for notifications that collapse per target
+// ("post_like:<post id>") or per recipient when there is no target ("follow")
+func groupKey(notifType models.NotificationType, targetID *uuid.UUID) *string {
+	key := string(notifType)
+	if targetID != nil {
+		key += ":" + targetID.String()
+	}
+	return &key
+}
+
+// GroupedTitle rewrites a single-actor title for a group
+// e.g. "alice liked your post" with 23 others becomes "alice and 23 others liked your post"
+func (f *NotificationFactory) GroupedTitle(title, actorUsername string, others int) string {
+	if others <= 0 || actorUsername == "" || !strings.HasPrefix(title, actorUsername+" ") {
+		return title
+	}
+
+	rest := strings.TrimPrefix(title, actorUsername)
+	if others == 1 {
+		return fmt.Sprintf("%s and 1 other%s", actorUsername, rest)
+	}
+	return fmt.Sprintf("%s and %d others%s", actorUsername, others, rest)
+}
+
 // =====================================================
 // SOCIAL NOTIFICATIONS
 // =====================================================
@@ -38,6 +67,7 @@ func (f *NotificationFactory) NewFollowNotification(followerID, followedID uuid.
 		ActionType:   &actionType,
 		ActionTaken:  false,
 		Metadata:     map[string]interface{}{},
+		GroupKey:     groupKey(models.NotificationFollow, nil),
 		CreatedAt:    time.Now(),
 		ExpiresAt:    time.Now().AddDate(0, 0, 30), // 30 days
 	}
@@ -244,6 +274,7 @@ func (f *NotificationFactory) NewPostLikeNotification(likerID, postOwnerID, post
 		Metadata: map[string]interface{}{
 			"post_id": postID.String(),
 		},
+		GroupKey:  groupKey(models.NotificationPostLike, &postID),
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().AddDate(0, 0, 30),
 	}
@@ -269,6 +300,251 @@ func (f *NotificationFactory) NewPostCommentNotification(commenterID, postOwnerI
 			"post_id":         postID.String(),
 			"comment_preview": commentPreview,
 		},
+		GroupKey:  groupKey(models.NotificationPostComment, &postID),
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().AddDate(0, 0, 30),
+	}
+}
+
+// NewPostMentionNotification creates a notification when someone mentions a user in a post
+func (f *NotificationFactory) NewPostMentionNotification(authorID, mentionedID, postID uuid.UUID, authorUsername, postPreview string) *models.Notification {
+	actionURL := fmt.Sprintf("/posts/%s", postID.String())
+	targetType := "post"
+
+	return &models.Notification{
+		UserID:       mentionedID,
+		Type:         models.NotificationPostMention,
+		Category:     models.CategorySocial,
+		Title:        fmt.Sprintf("%s mentioned you in a post", authorUsername),
+		Message:      &postPreview,
+		ActorID:      &authorID,
+		TargetID:     &postID,
+		TargetType:   &targetType,
+		ActionURL:    &actionURL,
+		IsActionable: false,
+		ActionTaken:  false,
+		Metadata: map[string]interface{}{
+			"post_id": postID.String(),
+			"preview": postPreview,
+		},
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().AddDate(0, 0, 30),
+	}
+}
+
+// NewPostShareNotification creates a notification when someone shares a post
+func (f *NotificationFactory) NewPostShareNotification(sharerID, postOwnerID, postID uuid.UUID, sharerUsername string) *models.Notification {
+	actionURL := fmt.Sprintf("/posts/%s", postID.String())
+	message := "shared your post"
+
+	return &models.Notification{
+		UserID:       postOwnerID,
+		Type:         models.NotificationPostShare,
+		Category:     models.CategorySocial,
+		Title:        fmt.Sprintf("%s shared your post", sharerUsername),
+		Message:      &message,
+		ActorID:      &sharerID,
+		TargetID:     &postID,
+		ActionURL:    &actionURL,
+		IsActionable: false,
+		ActionTaken:  false,
+		Metadata: map[string]interface{}{
+			"post_id": postID.String(),
+		},
+		GroupKey:  groupKey(models.NotificationPostShare, &postID),
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().AddDate(0, 0, 30),
+	}
+}
+
+// NewPollEndedNotification creates a notification with the final results of a poll
+// The author and the voters get different titles
+func (f *NotificationFactory) NewPollEndedNotification(userID, postID, pollID uuid.UUID, question string, isAuthor bool, totalVotes int, winningOption string) *models.Notification {
+	actionURL := fmt.Sprintf("/posts/%s", postID.String())
+	targetType := "poll"
+
+	title := fmt.Sprintf("A poll you voted in has ended: %s", question)
+	if isAuthor {
+		title = fmt.Sprintf("Your poll has ended: %s", question)
+	}
+
+	message := fmt.Sprintf("%d votes", totalVotes)
+	if totalVotes == 1 {
+		message = "1 vote"
+	}
+	if winningOption != "" {
+		message = fmt.Sprintf("%s · Top answer: %s", message, winningOption)
+	}
+
+	return &models.Notification{
+		UserID:       userID,
+		Type:         models.NotificationPollEnded,
+		Category:     models.CategorySocial,
+		Title:        title,
+		Message:      &message,
+		TargetID:     &pollID,
+		TargetType:   &targetType,
+		ActionURL:    &actionURL,
+		IsActionable: false,
+		ActionTaken:  false,
+		Metadata: map[string]interface{}{
+			"post_id":        postID.String(),
+			"poll_id":        pollID.String(),
+			"total_votes":    totalVotes,
+			"winning_option": winningOption,
+		},
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().AddDate(0, 0, 30),
+	}
+}
+
+// =====================================================
+// COMMENT NOTIFICATIONS
+// =====================================================
+
+// NewCommentReplyNotification creates a notification when someone replies to a comment
+func (f *NotificationFactory) NewCommentReplyNotification(replierID, parentAuthorID, postID, parentCommentID uuid.UUID, replierUsername, replyPreview string) *models.Notification {
+	actionURL := fmt.Sprintf("/posts/%s?comment=%s", postID.String(), parentCommentID.String())
+	targetType := "comment"
+	message := fmt.Sprintf("replied: %s", replyPreview)
+
+	return &models.Notification{
+		UserID:       parentAuthorID,
+		Type:         models.NotificationCommentReply,
+		Category:     models.CategorySocial,
+		Title:        fmt.Sprintf("%s replied to your comment", replierUsername),
+		Message:      &message,
+		ActorID:      &replierID,
+		TargetID:     &parentCommentID,
+		TargetType:   &targetType,
+		ActionURL:    &actionURL,
+		IsActionable: false,
+		ActionTaken:  false,
+		Metadata: map[string]interface{}{
+			"post_id":       postID.String(),
+			"comment_id":    parentCommentID.String(),
+			"reply_preview": replyPreview,
+		},
+		GroupKey:  groupKey(models.NotificationCommentReply, &parentCommentID),
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().AddDate(0, 0, 30),
+	}
+}
+
+// NewCommentMentionNotification creates a notification when someone mentions a user in a comment
+func (f *NotificationFactory) NewCommentMentionNotification(authorID, mentionedID, postID, commentID uuid.UUID, authorUsername, commentPreview string) *models.Notification {
+	actionURL := fmt.Sprintf("/posts/%s?comment=%s", postID.String(), commentID.String())
+	targetType := "comment"
+
+	return &models.Notification{
+		UserID:       mentionedID,
+		Type:         models.NotificationCommentMention,
+		Category:     models.CategorySocial,
+		Title:        fmt.Sprintf("%s mentioned you in a comment", authorUsername),
+		Message:      &commentPreview,
+		ActorID:      &authorID,
+		TargetID:     &commentID,
+		TargetType:   &targetType,
+		ActionURL:    &actionURL,
+		IsActionable: false,
+		ActionTaken:  false,
+		Metadata: map[string]interface{}{
+			"post_id":         postID.String(),
+			"comment_id":      commentID.String(),
+			"comment_preview": commentPreview,
+		},
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().AddDate(0, 0, 30),
+	}
+}
+
+// =====================================================
+// EVENT NOTIFICATIONS
+// =====================================================
+
+// NewEventApprovedNotification tells an event's creator it was approved and is now public
+func (f *NotificationFactory) NewEventApprovedNotification(creatorID, eventID uuid.UUID, eventTitle string) *models.Notification {
+	actionURL := fmt.Sprintf("/events/%s", eventID.String())
+	targetType := "event"
+	message := "Your event is now live and open for applications"
+
+	return &models.Notification{
+		UserID:       creatorID,
+		Type:         models.NotificationEventApproved,
+		Category:     models.CategoryEvents,
+		Title:        fmt.Sprintf("Your event \"%s\" was approved", eventTitle),
+		Message:      &message,
+		TargetID:     &eventID,
+		TargetType:   &targetType,
+		ActionURL:    &actionURL,
+		IsActionable: false,
+		ActionTaken:  false,
+		Metadata: map[string]interface{}{
+			"event_id":    eventID.String(),
+			"event_title": eventTitle,
+		},
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().AddDate(0, 0, 30),
+	}
+}
+
+// NewEventRejectedNotification tells an event's creator it was rejected, with the reason if given
+func (f *NotificationFactory) NewEventRejectedNotification(creatorID, eventID uuid.UUID, eventTitle, reason string) *models.Notification {
+	actionURL := fmt.Sprintf("/events/%s", eventID.String())
+	targetType := "event"
+	message := "Your event was not approved"
+	if reason != "" {
+		message = fmt.Sprintf("Reason: %s", reason)
+	}
+
+	return &models.Notification{
+		UserID:       creatorID,
+		Type:         models.NotificationEventRejected,
+		Category:     models.CategoryEvents,
+		Title:        fmt.Sprintf("Your event \"%s\" was not approved", eventTitle),
+		Message:      &message,
+		TargetID:     &eventID,
+		TargetType:   &targetType,
+		ActionURL:    &actionURL,
+		IsActionable: false,
+		ActionTaken:  false,
+		Metadata: map[string]interface{}{
+			"event_id":         eventID.String(),
+			"event_title":      eventTitle,
+			"rejection_reason": reason,
+		},
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().AddDate(0, 0, 30),
+	}
+}
+
+// =====================================================
+// COURSE NOTIFICATIONS
+// =====================================================
+
+// NewCourseEnrollmentNotification tells a course's creator someone enrolled
+func (f *NotificationFactory) NewCourseEnrollmentNotification(studentID, creatorID, courseID uuid.UUID, studentUsername, courseTitle, courseSlug string) *models.Notification {
+	actionURL := fmt.Sprintf("/courses/%s", courseSlug)
+	targetType := "course"
+	message := courseTitle
+
+	return &models.Notification{
+		UserID:       creatorID,
+		Type:         models.NotificationCourseEnrollment,
+		Category:     models.CategoryLearning,
+		Title:        fmt.Sprintf("%s enrolled in your course", studentUsername),
+		Message:      &message,
+		ActorID:      &studentID,
+		TargetID:     &courseID,
+		TargetType:   &targetType,
+		ActionURL:    &actionURL,
+		IsActionable: false,
+		ActionTaken:  false,
+		Metadata: map[string]interface{}{
+			"course_id":    courseID.String(),
+			"course_title": courseTitle,
+		},
+		GroupKey:  groupKey(models.NotificationCourseEnrollment, &courseID),
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().AddDate(0, 0, 30),
 	}
@@ -384,3 +660,29 @@ func (f *NotificationFactory) NewSystemAnnouncementNotification(userID uuid.UUID
 		ExpiresAt:    time.Now().AddDate(0, 0, 30),
 	}
 }
+
+// NewHeldSummaryNotification summarizes notifications held during quiet hours or a snooze
+// Only sent as a push, it isn't stored
+func (f *NotificationFactory) NewHeldSummaryNotification(userID uuid.UUID, count int) *models.Notification {
+	actionURL := "/notifications"
+	message := "Open your notifications to catch up"
+	heldKey := "held_summary"
+
+	return &models.Notification{
+		ID:           uuid.New(),
+		UserID:       userID,
+		Type:         models.NotificationSystemAnnouncement,
+		Category:     models.CategorySystem,
+		Title:        fmt.Sprintf("You have %d new notifications", count),
+		Message:      &message,
+		ActionURL:    &actionURL,
+		IsActionable: false,
+		ActionTaken:  false,
+		Metadata: map[string]interface{}{
+			"count": count,
+		},
+		GroupKey:  &heldKey,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().AddDate(0, 0, 30),
+	}
+}
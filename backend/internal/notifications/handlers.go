@@ -1,6 +1,7 @@
 package notifications
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -444,6 +445,16 @@ func (h *Handlers) UpdatePreferences(c *gin.Context) {
 		return
 	}
 
+	for category := range req.CategoryChannels {
+		if !models.IsValidCategory(category) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Unknown notification category: " + category,
+			})
+			return
+		}
+	}
+
 	// Update preferences
 	if err := h.service.UpdatePreferences(c.Request.Context(), currentUserID, &req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -459,6 +470,223 @@ func (h *Handlers) UpdatePreferences(c *gin.Context) {
 	})
 }
 
+// SnoozeNotifications handles POST /api/v1/notifications/snooze
+func (h *Handlers) SnoozeNotifications(c *gin.Context) {
+	// Get current user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	currentUserID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	var req models.SnoozeNotificationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	until, err := h.service.Snooze(c.Request.Context(), currentUserID, req.Hours)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to snooze notifications",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "Notifications snoozed",
+		"snoozed_until": until,
+	})
+}
+
+// UnsnoozeNotifications handles DELETE /api/v1/notifications/snooze
+func (h *Handlers) UnsnoozeNotifications(c *gin.Context) {
+	// Get current user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	currentUserID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	if err := h.service.Unsnooze(c.Request.Context(), currentUserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to end snooze",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Snooze ended",
+	})
+}
+
+// =====================================================
+// WEB PUSH
+// =====================================================
+
+// GetPushPublicKey handles GET /api/v1/notifications/push/public-key
+func (h *Handlers) GetPushPublicKey(c *gin.Context) {
+	if h.service.pushSvc == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Push notifications are not available",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"public_key": h.service.pushSvc.PublicKey(),
+	})
+}
+
+// RegisterPushSubscription handles POST /api/v1/notifications/push/subscriptions
+func (h *Handlers) RegisterPushSubscription(c *gin.Context) {
+	// Get current user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	currentUserID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	if h.service.pushSvc == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Push notifications are not available",
+		})
+		return
+	}
+
+	// Parse request body (the browser's PushSubscription.toJSON())
+	var req models.RegisterPushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.service.pushSvc.Subscribe(c.Request.Context(), currentUserID, &req, c.GetHeader("User-Agent"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidPushSubscription) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid push subscription",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to register push subscription",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":      true,
+		"message":      "Push subscription registered",
+		"subscription": subscription,
+	})
+}
+
+// UnregisterPushSubscription handles DELETE /api/v1/notifications/push/subscriptions
+func (h *Handlers) UnregisterPushSubscription(c *gin.Context) {
+	// Get current user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	currentUserID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	if h.service.pushSvc == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Push notifications are not available",
+		})
+		return
+	}
+
+	var req models.UnregisterPushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	if err := h.service.pushSvc.Unsubscribe(c.Request.Context(), currentUserID, req.Endpoint); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to remove push subscription",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Push subscription removed",
+	})
+}
+
 // =====================================================
 // SETUP ROUTES
 // =====================================================
@@ -484,5 +712,12 @@ func (h *Handlers) SetupRoutes(router *gin.RouterGroup) {
 		// Preferences
 		notifications.GET("/preferences", h.GetPreferences)
 		notifications.PATCH("/preferences", h.UpdatePreferences)
+		notifications.POST("/snooze", h.SnoozeNotifications)
+		notifications.DELETE("/snooze", h.UnsnoozeNotifications)
+
+		// Web Push
+		notifications.GET("/push/public-key", h.GetPushPublicKey)
+		notifications.POST("/push/subscriptions", h.RegisterPushSubscription)
+		notifications.DELETE("/push/subscriptions", h.UnregisterPushSubscription)
 	}
 }
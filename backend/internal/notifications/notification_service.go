@@ -2,8 +2,11 @@ package notifications
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"upvista-community-backend/internal/models"
 	"upvista-community-backend/internal/repository"
@@ -19,6 +22,7 @@ type NotificationService struct {
 	wsManager *websocket.Manager
 	factory   *NotificationFactory
 	emailSvc  *EmailService
+	pushSvc   *PushService
 }
 
 // NewNotificationService creates a new notification service
@@ -37,6 +41,11 @@ func NewNotificationService(
 	}
 }
 
+// SetPushService enables Web Push delivery
+func (s *NotificationService) SetPushService(pushSvc *PushService) {
+	s.pushSvc = pushSvc
+}
+
 // =====================================================
 // CREATE NOTIFICATIONS
 // =====================================================
@@ -45,7 +54,12 @@ func NewNotificationService(
 func (s *NotificationService) CreateNotification(ctx context.Context, notification *models.Notification) error {
 	log.Printf("[NotificationService] Creating notification: type=%s user=%s", notification.Type, notification.UserID)
 
-	// Check if user has in-app notifications enabled
+	// Users aren't notified about their own activity (liking their own post, replying to themselves...)
+	if notification.ActorID != nil && *notification.ActorID == notification.UserID {
+		return nil
+	}
+
+	// Get the user's delivery preferences
 	prefs, err := s.repo.GetPreferences(ctx, notification.UserID)
 	if err != nil {
 		log.Printf("[NotificationService] Failed to get preferences for user %s: %v", notification.UserID, err)
@@ -59,25 +73,34 @@ func (s *NotificationService) CreateNotification(ctx context.Context, notificati
 		}
 	}
 
-	// Check if in-app notifications are enabled
-	if !prefs.InAppEnabled {
-		log.Printf("[NotificationService] In-app notifications disabled for user %s", notification.UserID)
+	// Pick the channels for this notification's category
+	channels := prefs.ChannelsFor(notification.Type, notification.Category)
+	if !channels.InApp && !channels.Email && !channels.Push {
+		log.Printf("[NotificationService] All channels disabled for %s notifications of user %s", notification.Category, notification.UserID)
 		return nil
 	}
+	notification.InApp = channels.InApp
 
-	// Check if this category is enabled
-	if !prefs.IsCategoryEnabled(notification.Category) {
-		log.Printf("[NotificationService] Category %s disabled for user %s", notification.Category, notification.UserID)
-		return nil
-	}
+	sendEmail := channels.Email && prefs.EmailFrequency == models.EmailFrequencyInstant && s.emailSvc != nil
+	sendPush := channels.Push && s.pushSvc != nil
 
-	// Save notification to database
-	if err := s.repo.Create(ctx, notification); err != nil {
-		log.Printf("[NotificationService] Failed to create notification: %v", err)
-		return fmt.Errorf("failed to create notification: %w", err)
+	// Email and push wait for quiet hours or a snooze to end, the inbox is updated right away
+	now := time.Now()
+	if (sendEmail || sendPush) && prefs.ShouldHold(now) {
+		deliverAt := prefs.NextDeliveryTime(now)
+		notification.DeliverAt = &deliverAt
+		notification.PendingChannels = nil
+		if sendEmail {
+			notification.PendingChannels = append(notification.PendingChannels, models.ChannelEmail)
+		}
+		if sendPush {
+			notification.PendingChannels = append(notification.PendingChannels, models.ChannelPush)
+		}
+		sendEmail, sendPush = false, false
+		log.Printf("[NotificationService] Holding %v for user %s until %s", notification.PendingChannels, notification.UserID, deliverAt.Format(time.RFC3339))
 	}
 
-	// Fetch actor user details if needed
+	// Fetch actor user details if needed (grouped titles use the username)
 	if notification.ActorID != nil && notification.ActorUser == nil {
 		actor, err := s.userRepo.GetUserByID(ctx, *notification.ActorID)
 		if err == nil {
@@ -85,28 +108,172 @@ func (s *NotificationService) CreateNotification(ctx context.Context, notificati
 		}
 	}
 
+	// Collapse into the user's unread notification for the same group if there is one
+	if notification.GroupKey != nil {
+		grouped, err := s.joinGroup(ctx, notification)
+		if err != nil {
+			log.Printf("[NotificationService] Failed to join notification group %s: %v", *notification.GroupKey, err)
+		}
+		if grouped != nil {
+			s.deliverGroupUpdate(ctx, notification, grouped, sendPush)
+			return nil
+		}
+	}
+
+	// Save notification to database
+	if err := s.repo.Create(ctx, notification); err != nil {
+		if !errors.Is(err, repository.ErrNotificationGroupExists) {
+			log.Printf("[NotificationService] Failed to create notification: %v", err)
+			return fmt.Errorf("failed to create notification: %w", err)
+		}
+
+		// Another actor started the group concurrently, join it instead
+		grouped, joinErr := s.joinGroup(ctx, notification)
+		if joinErr != nil || grouped == nil {
+			log.Printf("[NotificationService] Failed to join notification group %s: %v", *notification.GroupKey, joinErr)
+			return fmt.Errorf("failed to create notification: %w", err)
+		}
+		s.deliverGroupUpdate(ctx, notification, grouped, sendPush)
+		return nil
+	}
+
 	// Send via WebSocket (real-time)
-	s.sendViaWebSocket(notification)
+	if notification.InApp {
+		s.sendViaWebSocket(notification)
+	}
 
 	// Send via email if enabled and instant delivery
-	if prefs.ShouldSendEmail(notification.Type) && prefs.EmailFrequency == models.EmailFrequencyInstant {
-		// Get user email
-		user, err := s.userRepo.GetUserByID(ctx, notification.UserID)
-		if err == nil && s.emailSvc != nil {
-			// Send email asynchronously (don't block notification creation)
-			go func() {
-				if err := s.emailSvc.SendNotificationEmail(context.Background(), user.Email, notification); err != nil {
-					log.Printf("[NotificationService] Failed to send email to %s: %v", user.Email, err)
-				}
-			}()
-			log.Printf("[NotificationService] Email notification queued for user %s", notification.UserID)
-		}
+	if sendEmail {
+		s.sendEmail(ctx, notification)
+	}
+
+	// Send via Web Push
+	if sendPush {
+		go s.pushSvc.SendNotification(context.Background(), notification)
 	}
 
 	log.Printf("[NotificationService] Notification created successfully: id=%s", notification.ID)
 	return nil
 }
 
+// sendEmail emails a notification asynchronously (doesn't block notification creation)
+func (s *NotificationService) sendEmail(ctx context.Context, notification *models.Notification) {
+	user, err := s.userRepo.GetUserByID(ctx, notification.UserID)
+	if err != nil {
+		log.Printf("[NotificationService] Failed to get user %s for email: %v", notification.UserID, err)
+		return
+	}
+
+	go func() {
+		if err := s.emailSvc.SendNotificationEmail(context.Background(), user, notification); err != nil {
+			log.Printf("[NotificationService] Failed to send email to %s: %v", user.Email, err)
+		}
+	}()
+	log.Printf("[NotificationService] Email notification queued for user %s", notification.UserID)
+}
+
+// Attempts to join a group before giving up when other actors keep joining concurrently
+const maxGroupJoinAttempts = 3
+
+// joinGroup merges a notification into the user's unread notification with the same group key
+// Returns nil when there is no such group (the caller creates the notification instead)
+func (s *NotificationService) joinGroup(ctx context.Context, notification *models.Notification) (*models.Notification, error) {
+	for attempt := 0; attempt < maxGroupJoinAttempts; attempt++ {
+		group, err := s.repo.GetUnreadGroup(ctx, notification.UserID, *notification.GroupKey)
+		if err != nil {
+			return nil, err
+		}
+		if group == nil {
+			return nil, nil
+		}
+
+		previousCount := group.ActorCount
+		s.mergeIntoGroup(group, notification)
+
+		updated, err := s.repo.UpdateGroup(ctx, group, previousCount)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			return group, nil
+		}
+		// The group changed (or was read) since we loaded it, try again
+	}
+
+	return nil, fmt.Errorf("notification group kept changing")
+}
+
+// mergeIntoGroup adds the notification's actor to a group and refreshes its content
+func (s *NotificationService) mergeIntoGroup(group, notification *models.Notification) {
+	if notification.ActorID != nil {
+		actorID := *notification.ActorID
+
+		// The newest actor goes first; an actor already in the recent list isn't counted twice
+		actorIDs := []uuid.UUID{actorID}
+		isNewActor := true
+		for _, existing := range group.ActorIDs {
+			if existing == actorID {
+				isNewActor = false
+				continue
+			}
+			actorIDs = append(actorIDs, existing)
+		}
+		if len(actorIDs) > models.MaxGroupedActors {
+			actorIDs = actorIDs[:models.MaxGroupedActors]
+		}
+
+		group.ActorIDs = actorIDs
+		if isNewActor {
+			group.ActorCount++
+		}
+		group.ActorID = notification.ActorID
+	}
+	group.ActorUser = notification.ActorUser
+
+	actorUsername := ""
+	if notification.ActorUser != nil {
+		actorUsername = notification.ActorUser.Username
+	}
+	group.Title = s.factory.GroupedTitle(notification.Title, actorUsername, group.ActorCount-1)
+	group.Message = notification.Message
+	group.Metadata = notification.Metadata
+	group.ActionURL = notification.ActionURL // Some targets depend on the actor (a follow links to the follower)
+	group.ActionTaken = false                // Inline actions apply to the newest actor
+	group.UpdatedAt = time.Now()
+	if !notification.ExpiresAt.IsZero() {
+		group.ExpiresAt = notification.ExpiresAt
+	}
+}
+
+// deliverGroupUpdate pushes a grouped notification that changed in place
+// Email isn't sent again for each new actor
+func (s *NotificationService) deliverGroupUpdate(ctx context.Context, notification, group *models.Notification, sendPush bool) {
+	notification.ID = group.ID
+
+	if s.wsManager != nil && group.InApp {
+		s.wsManager.BroadcastNotificationUpdate(group.UserID, group.ToResponse())
+		s.sendCountUpdate(ctx, group.UserID)
+	}
+
+	// The push carries the group key as its tag so the browser replaces the previous one
+	if sendPush {
+		go s.pushSvc.SendNotification(context.Background(), group)
+	}
+
+	// A held push is delivered once for the group, with the content it has by then
+	if notification.DeliverAt != nil && containsChannel(notification.PendingChannels, models.ChannelPush) {
+		pending := group.PendingChannels
+		if !containsChannel(pending, models.ChannelPush) {
+			pending = append(pending, models.ChannelPush)
+		}
+		if err := s.repo.HoldDelivery(ctx, group.ID, pending, *notification.DeliverAt); err != nil {
+			log.Printf("[NotificationService] Failed to hold push for group %s: %v", group.ID, err)
+		}
+	}
+
+	log.Printf("[NotificationService] Notification grouped: id=%s actors=%d", group.ID, group.ActorCount)
+}
+
 // CreateBulkNotifications creates multiple notifications at once
 func (s *NotificationService) CreateBulkNotifications(ctx context.Context, notifications []*models.Notification) error {
 	for _, notification := range notifications {
@@ -263,21 +430,7 @@ func (s *NotificationService) GetPreferences(ctx context.Context, userID uuid.UU
 
 // UpdatePreferences updates notification preferences
 func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uuid.UUID, req *models.UpdateNotificationPreferencesRequest) error {
-	// Get current preferences
-	prefs, err := s.repo.GetPreferences(ctx, userID)
-	if err != nil {
-		// Create new preferences if they don't exist
-		prefs = &models.NotificationPreferences{
-			UserID:               userID,
-			EmailEnabled:         true,
-			EmailTypes:           make(map[string]bool),
-			EmailFrequency:       models.EmailFrequencyInstant,
-			InAppEnabled:         true,
-			PushEnabled:          false,
-			InlineActionsEnabled: true,
-			CategoriesEnabled:    make(map[string]bool),
-		}
-	}
+	prefs := s.preferencesForUpdate(ctx, userID)
 
 	// Update fields if provided
 	if req.EmailEnabled != nil {
@@ -301,16 +454,85 @@ func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uuid
 	if req.CategoriesEnabled != nil {
 		prefs.CategoriesEnabled = req.CategoriesEnabled
 	}
+	if req.QuietHoursEnabled != nil {
+		prefs.QuietHoursEnabled = *req.QuietHoursEnabled
+	}
+	if req.QuietHoursStart != nil {
+		prefs.QuietHoursStart = *req.QuietHoursStart
+	}
+	if req.QuietHoursEnd != nil {
+		prefs.QuietHoursEnd = *req.QuietHoursEnd
+	}
+	if req.Timezone != nil {
+		prefs.Timezone = *req.Timezone
+	}
+	if req.CategoryChannels != nil {
+		if prefs.CategoryChannels == nil {
+			prefs.CategoryChannels = make(map[string]models.ChannelSelection)
+		}
+		if prefs.CategoriesEnabled == nil {
+			prefs.CategoriesEnabled = make(map[string]bool)
+		}
+		for category, channels := range req.CategoryChannels {
+			prefs.CategoryChannels[category] = channels
+			prefs.CategoriesEnabled[category] = channels.InApp // Keep the category filters in sync
+		}
+	}
 
 	// Save preferences
 	if err := s.repo.UpsertPreferences(ctx, prefs); err != nil {
 		return fmt.Errorf("failed to update preferences: %w", err)
 	}
 
+	// Quiet hours or the timezone may have changed
+	s.rescheduleHeld(ctx, prefs)
+
 	log.Printf("[NotificationService] Preferences updated for user %s", userID)
 	return nil
 }
 
+// DisableEmails turns off notification emails for the account using an address
+// Called when the address hard bounces or its owner reports our email as spam
+func (s *NotificationService) DisableEmails(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		return nil // Not an account address (or the account was deleted)
+	}
+
+	prefs := s.preferencesForUpdate(ctx, user.ID)
+	if !prefs.EmailEnabled {
+		return nil
+	}
+
+	prefs.EmailEnabled = false
+	if err := s.repo.UpsertPreferences(ctx, prefs); err != nil {
+		return fmt.Errorf("failed to disable emails: %w", err)
+	}
+
+	log.Printf("[NotificationService] Notification emails disabled for user %s", user.ID)
+	return nil
+}
+
+// preferencesForUpdate loads a user's preferences, or the defaults if they have none yet
+func (s *NotificationService) preferencesForUpdate(ctx context.Context, userID uuid.UUID) *models.NotificationPreferences {
+	prefs, err := s.repo.GetPreferences(ctx, userID)
+	if err == nil {
+		return prefs
+	}
+
+	// Create new preferences if they don't exist
+	return &models.NotificationPreferences{
+		UserID:               userID,
+		EmailEnabled:         true,
+		EmailTypes:           make(map[string]bool),
+		EmailFrequency:       models.EmailFrequencyInstant,
+		InAppEnabled:         true,
+		PushEnabled:          false,
+		InlineActionsEnabled: true,
+		CategoriesEnabled:    make(map[string]bool),
+	}
+}
+
 // =====================================================
 // CLEANUP
 // =====================================================
@@ -402,3 +624,143 @@ func (s *NotificationService) CreateCollaborationAcceptedNotification(ctx contex
 	notification := s.factory.NewCollaborationAcceptedNotification(acceptorID, requesterID, acceptorUsername)
 	return s.CreateNotification(ctx, notification)
 }
+
+// CreatePostLikeNotification creates and sends a post like notification
+func (s *NotificationService) CreatePostLikeNotification(ctx context.Context, likerID, postOwnerID, postID uuid.UUID) error {
+	liker, err := s.userRepo.GetUserByID(ctx, likerID)
+	if err != nil {
+		return fmt.Errorf("failed to get liker: %w", err)
+	}
+
+	notification := s.factory.NewPostLikeNotification(likerID, postOwnerID, postID, liker.Username)
+	notification.ActorUser = liker
+	return s.CreateNotification(ctx, notification)
+}
+
+// CreatePostCommentNotification creates and sends a notification for a comment on a post
+func (s *NotificationService) CreatePostCommentNotification(ctx context.Context, commenterID, postOwnerID, postID uuid.UUID, content string) error {
+	commenter, err := s.userRepo.GetUserByID(ctx, commenterID)
+	if err != nil {
+		return fmt.Errorf("failed to get commenter: %w", err)
+	}
+
+	notification := s.factory.NewPostCommentNotification(commenterID, postOwnerID, postID, commenter.Username, previewText(content))
+	notification.ActorUser = commenter
+	return s.CreateNotification(ctx, notification)
+}
+
+// CreatePostMentionNotifications notifies every user mentioned in a post
+func (s *NotificationService) CreatePostMentionNotifications(ctx context.Context, authorID, postID uuid.UUID, mentionedIDs []uuid.UUID, content string) error {
+	author, err := s.userRepo.GetUserByID(ctx, authorID)
+	if err != nil {
+		return fmt.Errorf("failed to get post author: %w", err)
+	}
+
+	preview := previewText(content)
+	for _, mentionedID := range mentionedIDs {
+		notification := s.factory.NewPostMentionNotification(authorID, mentionedID, postID, author.Username, preview)
+		notification.ActorUser = author
+		if err := s.CreateNotification(ctx, notification); err != nil {
+			log.Printf("[NotificationService] Failed to notify mentioned user %s: %v", mentionedID, err)
+		}
+	}
+	return nil
+}
+
+// CreatePostShareNotification creates and sends a post share notification
+func (s *NotificationService) CreatePostShareNotification(ctx context.Context, sharerID, postOwnerID, postID uuid.UUID) error {
+	sharer, err := s.userRepo.GetUserByID(ctx, sharerID)
+	if err != nil {
+		return fmt.Errorf("failed to get sharer: %w", err)
+	}
+
+	notification := s.factory.NewPostShareNotification(sharerID, postOwnerID, postID, sharer.Username)
+	notification.ActorUser = sharer
+	return s.CreateNotification(ctx, notification)
+}
+
+// CreateCommentReplyNotification creates and sends a notification for a reply to a comment
+func (s *NotificationService) CreateCommentReplyNotification(ctx context.Context, replierID, parentAuthorID, postID, parentCommentID uuid.UUID, content string) error {
+	replier, err := s.userRepo.GetUserByID(ctx, replierID)
+	if err != nil {
+		return fmt.Errorf("failed to get replier: %w", err)
+	}
+
+	notification := s.factory.NewCommentReplyNotification(replierID, parentAuthorID, postID, parentCommentID, replier.Username, previewText(content))
+	notification.ActorUser = replier
+	return s.CreateNotification(ctx, notification)
+}
+
+// CreateCommentMentionNotifications notifies every user mentioned in a comment
+func (s *NotificationService) CreateCommentMentionNotifications(ctx context.Context, authorID, postID, commentID uuid.UUID, mentionedIDs []uuid.UUID, content string) error {
+	author, err := s.userRepo.GetUserByID(ctx, authorID)
+	if err != nil {
+		return fmt.Errorf("failed to get comment author: %w", err)
+	}
+
+	preview := previewText(content)
+	for _, mentionedID := range mentionedIDs {
+		notification := s.factory.NewCommentMentionNotification(authorID, mentionedID, postID, commentID, author.Username, preview)
+		notification.ActorUser = author
+		if err := s.CreateNotification(ctx, notification); err != nil {
+			log.Printf("[NotificationService] Failed to notify mentioned user %s: %v", mentionedID, err)
+		}
+	}
+	return nil
+}
+
+// CreatePollEndedNotifications sends a poll's final results to its author and voters
+func (s *NotificationService) CreatePollEndedNotifications(ctx context.Context, authorID uuid.UUID, voterIDs []uuid.UUID, postID, pollID uuid.UUID, question string, totalVotes int, winningOption string) error {
+	if err := s.CreateNotification(ctx, s.factory.NewPollEndedNotification(authorID, postID, pollID, question, true, totalVotes, winningOption)); err != nil {
+		log.Printf("[NotificationService] Failed to notify poll author %s: %v", authorID, err)
+	}
+
+	for _, voterID := range voterIDs {
+		if voterID == authorID {
+			continue
+		}
+		notification := s.factory.NewPollEndedNotification(voterID, postID, pollID, question, false, totalVotes, winningOption)
+		if err := s.CreateNotification(ctx, notification); err != nil {
+			log.Printf("[NotificationService] Failed to notify poll voter %s: %v", voterID, err)
+		}
+	}
+	return nil
+}
+
+// CreateEventApprovedNotification creates and sends an event approval notification
+func (s *NotificationService) CreateEventApprovedNotification(ctx context.Context, creatorID, eventID uuid.UUID, eventTitle string) error {
+	notification := s.factory.NewEventApprovedNotification(creatorID, eventID, eventTitle)
+	return s.CreateNotification(ctx, notification)
+}
+
+// CreateEventRejectedNotification creates and sends an event rejection notification
+func (s *NotificationService) CreateEventRejectedNotification(ctx context.Context, creatorID, eventID uuid.UUID, eventTitle, reason string) error {
+	notification := s.factory.NewEventRejectedNotification(creatorID, eventID, eventTitle, reason)
+	return s.CreateNotification(ctx, notification)
+}
+
+// CreateCourseEnrollmentNotification creates and sends a course enrollment notification
+func (s *NotificationService) CreateCourseEnrollmentNotification(ctx context.Context, studentID, creatorID, courseID uuid.UUID, courseTitle, courseSlug string) error {
+	student, err := s.userRepo.GetUserByID(ctx, studentID)
+	if err != nil {
+		return fmt.Errorf("failed to get student: %w", err)
+	}
+
+	notification := s.factory.NewCourseEnrollmentNotification(studentID, creatorID, courseID, student.Username, courseTitle, courseSlug)
+	notification.ActorUser = student
+	return s.CreateNotification(ctx, notification)
+}
+
+// Maximum characters of user content quoted in a notification
+const maxPreviewLength = 100
+
+// previewText shortens user content for a notification message
+func previewText(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+
+	runes := []rune(content)
+	if len(runes) <= maxPreviewLength {
+		return content
+	}
+	return string(runes[:maxPreviewLength]) + "..."
+}
@@ -0,0 +1,337 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/repository"
+	"upvista-community-backend/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// How long push services keep a message for an offline browser
+const pushTTL = 24 * time.Hour
+
+// ErrInvalidPushSubscription is returned when a browser subscription can't be used for delivery
+var ErrInvalidPushSubscription = errors.New("invalid push subscription")
+
+// pushServiceDomains are the push services of browsers: FCM (Chrome, Edge, Opera), Mozilla autopush (Firefox),
+// Apple web push (Safari) and WNS (legacy Edge). Subscriptions elsewhere are refused, the server only posts to these.
+var pushServiceDomains = []string{
+	"fcm.googleapis.com",
+	"android.googleapis.com",
+	"push.services.mozilla.com",
+	"push.apple.com",
+	"notify.windows.com",
+}
+
+// PushService delivers notifications to subscribed browsers via Web Push
+type PushService struct {
+	repo       repository.PushSubscriptionRepository
+	keys       *vapidKeys
+	subject    string
+	httpClient *http.Client
+}
+
+// pushPayload is the JSON the service worker receives in its push event
+type pushPayload struct {
+	ID        uuid.UUID                   `json:"id"`
+	Type      models.NotificationType     `json:"type"`
+	Category  models.NotificationCategory `json:"category"`
+	Title     string                      `json:"title"`
+	Body      string                      `json:"body,omitempty"`
+	URL       string                      `json:"url,omitempty"`
+	Icon      string                      `json:"icon,omitempty"`
+	Tag       string                      `json:"tag"` // Notifications with the same tag replace each other in the browser
+	CreatedAt time.Time                   `json:"created_at"`
+}
+
+// NewPushService creates a Web Push service from a base64url VAPID key pair
+func NewPushService(repo repository.PushSubscriptionRepository, publicKey, privateKey, subject string) (*PushService, error) {
+	keys, err := parseVAPIDKeys(publicKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PushService{
+		repo:       repo,
+		keys:       keys,
+		subject:    subject,
+		httpClient: newPushClient(),
+	}, nil
+}
+
+// newPushClient creates the HTTP client pushes are sent with: public addresses only, checked on every
+// connection, no proxy and no redirects, so an endpoint can't get a request into the internal network
+func newPushClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: utils.PublicDialControl,
+	}
+	transport := &http.Transport{
+		Proxy:               nil, // A proxy would connect on our behalf, past the address check
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        32,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   10 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// validPushEndpoint reports whether an endpoint is an https URL of a known push service
+func validPushEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return false
+	}
+	if port := u.Port(); port != "" && port != "443" {
+		return false
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for _, domain := range pushServiceDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublicKey returns the VAPID public key browsers pass as applicationServerKey
+func (s *PushService) PublicKey() string {
+	return s.keys.publicKey
+}
+
+// =====================================================
+// SUBSCRIPTIONS
+// =====================================================
+
+// Subscribe registers a browser's push subscription for a user
+func (s *PushService) Subscribe(ctx context.Context, userID uuid.UUID, req *models.RegisterPushSubscriptionRequest, userAgent string) (*models.PushSubscription, error) {
+	if !validPushEndpoint(req.Endpoint) {
+		return nil, ErrInvalidPushSubscription
+	}
+
+	// Reject keys we couldn't encrypt for rather than failing on every delivery
+	rawP256dh, err := decodeBase64URL(req.Keys.P256dh)
+	if err != nil {
+		return nil, ErrInvalidPushSubscription
+	}
+	if _, err := ecdh.P256().NewPublicKey(rawP256dh); err != nil {
+		return nil, ErrInvalidPushSubscription
+	}
+	if authSecret, err := decodeBase64URL(req.Keys.Auth); err != nil || len(authSecret) != 16 {
+		return nil, ErrInvalidPushSubscription
+	}
+
+	subscription := &models.PushSubscription{
+		UserID:    userID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: userAgent,
+	}
+	if req.ExpirationTime != nil {
+		expiresAt := time.UnixMilli(*req.ExpirationTime)
+		if expiresAt.Before(time.Now()) {
+			return nil, ErrInvalidPushSubscription
+		}
+		subscription.ExpiresAt = &expiresAt
+	}
+
+	if err := s.repo.UpsertSubscription(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to save push subscription: %w", err)
+	}
+
+	log.Printf("[PushService] Registered push subscription for user %s", userID)
+	return subscription, nil
+}
+
+// Unsubscribe removes a browser's push subscription
+func (s *PushService) Unsubscribe(ctx context.Context, userID uuid.UUID, endpoint string) error {
+	if err := s.repo.DeleteSubscription(ctx, userID, endpoint); err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	return nil
+}
+
+// =====================================================
+// DELIVERY
+// =====================================================
+
+// SendNotification pushes a notification to every browser the user subscribed
+// Subscriptions that expired or that the push service reports as gone are pruned
+func (s *PushService) SendNotification(ctx context.Context, notification *models.Notification) {
+	subscriptions, err := s.repo.GetSubscriptionsByUserID(ctx, notification.UserID)
+	if err != nil {
+		log.Printf("[PushService] Failed to load push subscriptions for user %s: %v", notification.UserID, err)
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	payload, err := buildPushPayload(notification)
+	if err != nil {
+		log.Printf("[PushService] Failed to build push payload: %v", err)
+		return
+	}
+
+	urgency := "normal"
+	if notification.Category == models.CategoryMessages {
+		urgency = "high"
+	}
+
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if subscription.ExpiresAt != nil && subscription.ExpiresAt.Before(now) {
+			s.prune(ctx, subscription, "expired")
+			continue
+		}
+
+		if err := s.send(ctx, subscription, payload, urgency); err != nil {
+			log.Printf("[PushService] Failed to push to user %s: %v", notification.UserID, err)
+		}
+	}
+}
+
+// send encrypts and posts a payload to one subscription's push service
+func (s *PushService) send(ctx context.Context, subscription *models.PushSubscription, payload []byte, urgency string) error {
+	// Subscriptions saved before endpoints were checked
+	if !validPushEndpoint(subscription.Endpoint) {
+		s.prune(ctx, subscription, "unknown push service")
+		return ErrInvalidPushSubscription
+	}
+
+	body, err := encryptPushPayload(payload, subscription.P256dh, subscription.Auth)
+	if err != nil {
+		// The keys can't be used, so the subscription never will be
+		s.prune(ctx, subscription, err.Error())
+		return err
+	}
+
+	authorization, err := s.keys.authorization(subscription.Endpoint, s.subject)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", urgency)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		if err := s.repo.MarkSubscriptionUsed(ctx, subscription.ID); err != nil {
+			log.Printf("[PushService] Failed to record push delivery: %v", err)
+		}
+		return nil
+
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		// The browser unsubscribed or the subscription expired
+		s.prune(ctx, subscription, fmt.Sprintf("HTTP %d", resp.StatusCode))
+		return nil
+
+	default:
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("push service returned HTTP %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+}
+
+// prune deletes a subscription that can no longer receive pushes
+func (s *PushService) prune(ctx context.Context, subscription *models.PushSubscription, reason string) {
+	if err := s.repo.DeleteSubscriptionByID(ctx, subscription.ID); err != nil {
+		log.Printf("[PushService] Failed to prune push subscription %s: %v", subscription.ID, err)
+		return
+	}
+	log.Printf("[PushService] Pruned push subscription %s for user %s (%s)", subscription.ID, subscription.UserID, reason)
+}
+
+// buildPushPayload serializes a notification, shortening the body to fit in one push message
+func buildPushPayload(notification *models.Notification) ([]byte, error) {
+	payload := pushPayload{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Category:  notification.Category,
+		Title:     notification.Title,
+		Tag:       notification.ID.String(),
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.GroupKey != nil {
+		payload.Tag = *notification.GroupKey
+	}
+	if notification.Message != nil {
+		payload.Body = *notification.Message
+	}
+	if notification.ActionURL != nil {
+		payload.URL = *notification.ActionURL
+	}
+	if notification.ActorUser != nil && notification.ActorUser.ProfilePicture != nil {
+		payload.Icon = *notification.ActorUser.ProfilePicture
+	}
+
+	for {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) <= maxPushPayloadSize {
+			return data, nil
+		}
+		if payload.Body == "" {
+			return nil, fmt.Errorf("push payload too large: %d bytes", len(data))
+		}
+
+		keep := len(payload.Body) - (len(data) - maxPushPayloadSize) - len("...")
+		if keep <= 0 {
+			payload.Body = ""
+			continue
+		}
+		payload.Body = truncateUTF8(payload.Body, keep) + "..."
+	}
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
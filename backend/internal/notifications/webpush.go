@@ -0,0 +1,193 @@
+package notifications
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+// =====================================================
+// WEB PUSH PROTOCOL (RFC 8291 / RFC 8292)
+// =====================================================
+
+const (
+	// Record size advertised in the aes128gcm header (a single record per message)
+	pushRecordSize = 4096
+
+	// Push services accept at most 4096 bytes of body: header (86) + tag (16) + delimiter (1)
+	maxPushPayloadSize = 4096 - 86 - 16 - 1
+
+	// Lifetime of the VAPID JWT (RFC 8292 caps it at 24 hours)
+	vapidTokenLifetime = 12 * time.Hour
+)
+
+// vapidKeys is the application server key pair identifying us to push services
+type vapidKeys struct {
+	signingKey *ecdsa.PrivateKey
+	publicKey  string // base64url uncompressed point, sent as "k=" and handed to browsers
+}
+
+// parseVAPIDKeys decodes a base64url private key and checks it matches the public key
+func parseVAPIDKeys(publicKey, privateKey string) (*vapidKeys, error) {
+	rawPrivate, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	ecdhKey, err := ecdh.P256().NewPrivateKey(rawPrivate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	rawPublic := ecdhKey.PublicKey().Bytes()
+
+	if configured, err := decodeBase64URL(publicKey); err != nil || string(configured) != string(rawPublic) {
+		return nil, fmt.Errorf("VAPID public key does not match the private key")
+	}
+
+	// Uncompressed point: 0x04 || X (32 bytes) || Y (32 bytes)
+	signingKey := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(rawPublic[1:33]),
+			Y:     new(big.Int).SetBytes(rawPublic[33:]),
+		},
+		D: new(big.Int).SetBytes(rawPrivate),
+	}
+
+	return &vapidKeys{
+		signingKey: signingKey,
+		publicKey:  base64.RawURLEncoding.EncodeToString(rawPublic),
+	}, nil
+}
+
+// authorization builds the VAPID Authorization header for a push endpoint
+func (k *vapidKeys) authorization(endpoint, subject string) (string, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+
+	claims := jwt.MapClaims{
+		"aud": endpointURL.Scheme + "://" + endpointURL.Host,
+		"exp": time.Now().Add(vapidTokenLifetime).Unix(),
+		"sub": subject,
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(k.signingKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+
+	return fmt.Sprintf("vapid t=%s, k=%s", token, k.publicKey), nil
+}
+
+// encryptPushPayload encrypts a message for a subscription using aes128gcm (RFC 8291)
+// p256dh and auth are the base64url keys from the browser's PushSubscription
+func encryptPushPayload(plaintext []byte, p256dh, auth string) ([]byte, error) {
+	if len(plaintext) > maxPushPayloadSize {
+		return nil, fmt.Errorf("push payload too large: %d bytes (max %d)", len(plaintext), maxPushPayloadSize)
+	}
+
+	rawUAPublic, err := decodeBase64URL(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(rawUAPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64URL(auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, fmt.Errorf("invalid auth secret")
+	}
+
+	// Fresh key pair and salt for every message
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := make([]byte, 0, 14+65+65)
+	keyInfo = append(keyInfo, "WebPush: info\x00"...)
+	keyInfo = append(keyInfo, rawUAPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+
+	ikm, err := hkdfBytes(ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	// Content encryption key and nonce (RFC 8188)
+	cek, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt (16) || record size (4) || key id length (1) || key id (as_public)
+	body := make([]byte, 0, 86+len(plaintext)+1+gcm.Overhead())
+	body = append(body, salt...)
+	body = binary.BigEndian.AppendUint32(body, pushRecordSize)
+	body = append(body, byte(len(asPublic)))
+	body = append(body, asPublic...)
+
+	// Single (last) record: plaintext followed by the 0x02 padding delimiter
+	record := make([]byte, 0, len(plaintext)+1)
+	record = append(record, plaintext...)
+	record = append(record, 0x02)
+
+	return gcm.Seal(body, nonce, record, nil), nil
+}
+
+// hkdfBytes derives length bytes with HKDF-SHA-256
+func hkdfBytes(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeBase64URL accepts base64url with or without padding (browsers differ)
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,406 @@
+package posts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Autosaves this close to the latest revision update it instead of adding one
+const draftRevisionWindow = 5 * time.Minute
+
+// Revisions kept per draft
+const maxDraftRevisions = 20
+
+// CreateDraft starts a draft, it can be saved incomplete and is only checked when published
+func (s *Service) CreateDraft(ctx context.Context, req *models.CreateDraftRequest, userID uuid.UUID) (*models.Post, error) {
+	content := req.DraftContent
+
+	post := &models.Post{
+		UserID:   userID,
+		PostType: req.PostType,
+		IsDraft:  true,
+		Draft:    &content,
+	}
+	setDraftFields(post, &content)
+	post.Media = s.resolveMedia(ctx, post.MediaURLs)
+
+	if err := s.postRepo.CreatePost(ctx, post); err != nil {
+		return nil, fmt.Errorf("failed to create draft: %w", err)
+	}
+
+	s.recordDraftRevision(ctx, post.ID, post.DraftRevision, content)
+	s.attachMedia(ctx, models.MediaOwnerPost, post.ID, draftMediaURLs(&content)...)
+
+	return post, nil
+}
+
+// GetDrafts retrieves a user's drafts, of one post type if postType isn't empty
+func (s *Service) GetDrafts(ctx context.Context, userID uuid.UUID, postType string, limit, offset int) ([]models.Post, int, error) {
+	drafts, total, err := s.postRepo.GetUserDrafts(ctx, userID, postType, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range drafts {
+		if drafts[i].Draft == nil {
+			content := draftContent(&drafts[i])
+			drafts[i].Draft = &content
+		}
+	}
+	return drafts, total, nil
+}
+
+// GetDraft retrieves one of a user's drafts with everything saved in it
+func (s *Service) GetDraft(ctx context.Context, postID, userID uuid.UUID) (*models.Post, error) {
+	post, err := s.ownDraft(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.Draft == nil {
+		content := draftContent(post)
+		post.Draft = &content
+	}
+	return post, nil
+}
+
+// SaveDraft replaces the content of a draft (autosave)
+// Fails with ErrDraftConflict if the draft was saved over the revision the edits were made on
+func (s *Service) SaveDraft(ctx context.Context, postID, userID uuid.UUID, req *models.SaveDraftRequest) (*models.Post, error) {
+	post, err := s.ownDraft(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Revision != nil && *req.Revision != post.DraftRevision {
+		return nil, models.ErrDraftConflict
+	}
+
+	if err := s.saveDraft(ctx, post, req.DraftContent); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// GetDraftRevisions retrieves the earlier states of a draft, the latest first
+func (s *Service) GetDraftRevisions(ctx context.Context, postID, userID uuid.UUID) ([]models.DraftRevision, error) {
+	if _, err := s.ownDraft(ctx, postID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.postRepo.GetDraftRevisions(ctx, postID, maxDraftRevisions)
+}
+
+// RestoreDraftRevision saves an earlier state of a draft as its content, the current one stays in the revisions
+func (s *Service) RestoreDraftRevision(ctx context.Context, postID, revisionID, userID uuid.UUID) (*models.Post, error) {
+	post, err := s.ownDraft(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := s.postRepo.GetDraftRevision(ctx, postID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.saveDraft(ctx, post, revision.Content); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// PublishDraft publishes a draft now, or schedules it if scheduledFor is set
+// The draft is checked like a new post and keeps its ID, its poll or article is created now
+func (s *Service) PublishDraft(ctx context.Context, postID, userID uuid.UUID, scheduledFor *time.Time) (*models.Post, error) {
+	post, err := s.ownDraft(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	content := draftContent(post)
+	req, err := content.PublishRequest(post.PostType)
+	if err != nil {
+		return nil, err
+	}
+	if scheduledFor != nil {
+		if err := models.ValidateSchedule(*scheduledFor); err != nil {
+			return nil, err
+		}
+		req.ScheduledFor = scheduledFor
+	}
+
+	// Published drafts show up in feeds as new posts
+	now := time.Now()
+	revision := post.DraftRevision
+	setDraftFields(post, &content)
+	post.IsDraft = false
+	post.IsPublished = scheduledFor == nil
+	post.ScheduledFor = scheduledFor
+	post.PublishedAt = nil
+	if post.IsPublished {
+		post.PublishedAt = &now
+	}
+	post.Draft = nil
+	post.DraftRevision = revision + 1
+	post.CreatedAt, post.UpdatedAt = now, now
+	post.Media = s.resolveMedia(ctx, post.MediaURLs)
+	post.LinkPreviews = nil
+	if s.linkPreviewer != nil && post.PostType != "article" {
+		post.LinkPreviews = s.linkPreviewer.Previews(ctx, post.Content)
+	}
+
+	updates := draftUpdates(post)
+	updates["is_draft"] = false
+	updates["is_published"] = post.IsPublished
+	updates["published_at"] = post.PublishedAt
+	updates["scheduled_for"] = post.ScheduledFor
+	updates["draft"] = nil
+	updates["link_previews"] = post.LinkPreviews
+	updates["created_at"] = now
+	updates["updated_at"] = now
+
+	claimed, err := s.postRepo.UpdateDraft(ctx, postID, revision, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, models.ErrDraftConflict
+	}
+
+	// Drafts from before draft content was saved have their poll or article already, it is created again from the draft
+	if post.Poll != nil {
+		if err := s.pollRepo.DeletePoll(ctx, post.Poll.ID); err != nil {
+			fmt.Printf("Warning: failed to delete poll %s of draft %s: %v\n", post.Poll.ID, postID, err)
+		}
+		post.Poll = nil
+	}
+	if post.Article != nil {
+		if err := s.articleRepo.DeleteArticle(ctx, post.Article.ID); err != nil {
+			fmt.Printf("Warning: failed to delete article %s of draft %s: %v\n", post.Article.ID, postID, err)
+		}
+		post.Article = nil
+	}
+
+	if err := s.createPostContent(ctx, post, req); err != nil {
+		s.revertToDraft(ctx, post, content)
+		return nil, err
+	}
+
+	// Media taken out while editing is released, the published post keeps what it uses
+	mediaURLs := append([]string{}, post.MediaURLs...)
+	if post.Article != nil {
+		mediaURLs = append(mediaURLs, post.Article.CoverImageURL)
+		mediaURLs = append(mediaURLs, articleImageURLs(post.Article.ContentHTML)...)
+	}
+	s.detachMedia(ctx, models.MediaOwnerPost, postID)
+	s.attachMedia(ctx, models.MediaOwnerPost, postID, mediaURLs...)
+
+	if err := s.postRepo.PruneDraftRevisions(ctx, postID, 0); err != nil {
+		fmt.Printf("Warning: failed to delete revisions of published draft %s: %v\n", postID, err)
+	}
+
+	// Scheduled drafts are announced when the scheduler publishes them
+	if post.IsPublished {
+		s.announcePost(ctx, postID, now)
+	}
+
+	return post, nil
+}
+
+// DiscardDraft deletes a draft with its revisions
+func (s *Service) DiscardDraft(ctx context.Context, postID, userID uuid.UUID) error {
+	if _, err := s.ownDraft(ctx, postID, userID); err != nil {
+		return err
+	}
+
+	deleted, err := s.postRepo.DeleteDraft(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return models.ErrNotDraft
+	}
+
+	s.detachMedia(ctx, models.MediaOwnerPost, postID)
+	return nil
+}
+
+// ownDraft returns a user's post if it is still a draft
+func (s *Service) ownDraft(ctx context.Context, postID, userID uuid.UUID) (*models.Post, error) {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, models.ErrPostNotFound
+	}
+	if post.UserID != userID {
+		return nil, models.ErrUnauthorized
+	}
+	if !post.IsDraft || post.IsPublished || post.DeletedAt != nil {
+		return nil, models.ErrNotDraft
+	}
+	return post, nil
+}
+
+// saveDraft saves content as the next revision of a draft
+func (s *Service) saveDraft(ctx context.Context, post *models.Post, content models.DraftContent) error {
+	revision := post.DraftRevision
+
+	setDraftFields(post, &content)
+	post.Draft = &content
+	post.DraftRevision = revision + 1
+	post.UpdatedAt = time.Now()
+	post.Media = s.resolveMedia(ctx, post.MediaURLs)
+
+	updates := draftUpdates(post)
+	updates["draft"] = post.Draft
+	updates["updated_at"] = post.UpdatedAt
+
+	saved, err := s.postRepo.UpdateDraft(ctx, post.ID, revision, updates)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return models.ErrDraftConflict
+	}
+
+	s.recordDraftRevision(ctx, post.ID, post.DraftRevision, content)
+	s.attachMedia(ctx, models.MediaOwnerPost, post.ID, draftMediaURLs(&content)...)
+	return nil
+}
+
+// recordDraftRevision keeps a saved state of a draft
+// Saves in quick succession update the latest revision, so autosave doesn't push out older ones
+func (s *Service) recordDraftRevision(ctx context.Context, postID uuid.UUID, revision int, content models.DraftContent) {
+	latest, err := s.postRepo.GetDraftRevisions(ctx, postID, 1)
+	if err != nil {
+		fmt.Printf("Warning: failed to get revisions of draft %s: %v\n", postID, err)
+		return
+	}
+
+	if len(latest) > 0 && time.Since(latest[0].CreatedAt) < draftRevisionWindow {
+		if err := s.postRepo.UpdateDraftRevision(ctx, latest[0].ID, revision, content); err != nil {
+			fmt.Printf("Warning: failed to update revision of draft %s: %v\n", postID, err)
+		}
+		return
+	}
+
+	if err := s.postRepo.CreateDraftRevision(ctx, &models.DraftRevision{
+		PostID:   postID,
+		Revision: revision,
+		Content:  content,
+	}); err != nil {
+		fmt.Printf("Warning: failed to record revision of draft %s: %v\n", postID, err)
+		return
+	}
+
+	if err := s.postRepo.PruneDraftRevisions(ctx, postID, maxDraftRevisions); err != nil {
+		fmt.Printf("Warning: failed to prune revisions of draft %s: %v\n", postID, err)
+	}
+}
+
+// revertToDraft turns a post back into a draft when its poll or article couldn't be created at publishing
+func (s *Service) revertToDraft(ctx context.Context, post *models.Post, content models.DraftContent) {
+	err := s.postRepo.UpdatePost(ctx, post.ID, map[string]interface{}{
+		"is_draft":      true,
+		"is_published":  false,
+		"published_at":  nil,
+		"scheduled_for": nil,
+		"draft":         content,
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to revert post %s to a draft: %v\n", post.ID, err)
+	}
+}
+
+// setDraftFields copies the content of a draft to its post, which is what feeds and drafts lists show
+func setDraftFields(post *models.Post, content *models.DraftContent) {
+	post.Content = content.Content
+	post.MediaURLs = content.MediaURLs
+	post.MediaTypes = content.MediaTypes
+	post.Visibility = content.Visibility
+	post.AllowsComments = content.AllowsComments
+	post.AllowsSharing = content.AllowsSharing
+	post.IsNSFW = content.IsNSFW
+
+	if post.Visibility == "" {
+		post.Visibility = "public"
+	}
+}
+
+// draftUpdates are the post columns saved along with a draft
+func draftUpdates(post *models.Post) map[string]interface{} {
+	return map[string]interface{}{
+		"content":         post.Content,
+		"media_urls":      post.MediaURLs,
+		"media_types":     post.MediaTypes,
+		"media":           post.Media,
+		"visibility":      post.Visibility,
+		"allows_comments": post.AllowsComments,
+		"allows_sharing":  post.AllowsSharing,
+		"is_nsfw":         post.IsNSFW,
+		"draft_revision":  post.DraftRevision,
+	}
+}
+
+// draftContent returns what a draft holds
+// Drafts from before draft content was saved have it in their post, poll and article
+func draftContent(post *models.Post) models.DraftContent {
+	if post.Draft != nil {
+		return *post.Draft
+	}
+
+	content := models.DraftContent{
+		Content:        post.Content,
+		MediaURLs:      post.MediaURLs,
+		MediaTypes:     post.MediaTypes,
+		Visibility:     post.Visibility,
+		AllowsComments: post.AllowsComments,
+		AllowsSharing:  post.AllowsSharing,
+		IsNSFW:         post.IsNSFW,
+	}
+
+	if post.Poll != nil {
+		options := make([]string, len(post.Poll.Options))
+		for i, option := range post.Poll.Options {
+			options[i] = option.OptionText
+		}
+		content.Poll = &models.DraftPoll{
+			Question:              post.Poll.Question,
+			Options:               options,
+			DurationHours:         post.Poll.DurationHours,
+			AllowMultipleVotes:    post.Poll.AllowMultipleVotes,
+			ShowResultsBeforeVote: post.Poll.ShowResultsBeforeVote,
+			AllowVoteChanges:      post.Poll.AllowVoteChanges,
+			AnonymousVotes:        post.Poll.AnonymousVotes,
+		}
+	}
+
+	if post.Article != nil {
+		content.Article = &models.DraftArticle{
+			Title:           post.Article.Title,
+			Subtitle:        post.Article.Subtitle,
+			ContentHTML:     post.Article.ContentHTML,
+			CoverImageURL:   post.Article.CoverImageURL,
+			MetaTitle:       post.Article.MetaTitle,
+			MetaDescription: post.Article.MetaDescription,
+			Category:        post.Article.Category,
+			Tags:            post.Article.Tags,
+		}
+	}
+
+	return content
+}
+
+// draftMediaURLs returns the media a draft uses
+func draftMediaURLs(content *models.DraftContent) []string {
+	urls := append([]string{}, content.MediaURLs...)
+	if content.Article != nil {
+		urls = append(urls, content.Article.CoverImageURL)
+		urls = append(urls, articleImageURLs(content.Article.ContentHTML)...)
+	}
+	return urls
+}
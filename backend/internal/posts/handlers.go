@@ -10,11 +10,13 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
+	"upvista-community-backend/internal/inspect"
+	"upvista-community-backend/internal/media"
 	"upvista-community-backend/internal/messaging"
 	"upvista-community-backend/internal/models"
 	"upvista-community-backend/internal/utils"
+	apperr "upvista-community-backend/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -26,15 +28,17 @@ type Handlers struct {
 	feedService    *FeedService
 	storageService *utils.StorageService
 	mediaOptimizer *messaging.MediaOptimizer
+	mediaService   *media.Service
 }
 
 // NewHandlers creates new post handlers
-func NewHandlers(service *Service, feedService *FeedService, storageService *utils.StorageService, mediaOptimizer *messaging.MediaOptimizer) *Handlers {
+func NewHandlers(service *Service, feedService *FeedService, storageService *utils.StorageService, mediaOptimizer *messaging.MediaOptimizer, mediaService *media.Service) *Handlers {
 	return &Handlers{
 		service:        service,
 		feedService:    feedService,
 		storageService: storageService,
 		mediaOptimizer: mediaOptimizer,
+		mediaService:   mediaService,
 	}
 }
 
@@ -85,7 +89,7 @@ func (h *Handlers) CreatePost(c *gin.Context) {
 
 	post, err := h.service.CreatePost(c.Request.Context(), &req, uid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondScheduleError(c, err)
 		return
 	}
 
@@ -229,6 +233,411 @@ func (h *Handlers) GetUserPosts(c *gin.Context) {
 	})
 }
 
+// ============================================
+// SCHEDULED POSTS ENDPOINTS
+// ============================================
+
+// GetScheduledPosts handles GET /api/v1/posts/scheduled
+func (h *Handlers) GetScheduledPosts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	posts, total, err := h.service.GetScheduledPosts(c.Request.Context(), uid, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PostsResponse{
+		Success: true,
+		Posts:   posts,
+		Total:   total,
+		Page:    offset / limit,
+		Limit:   limit,
+		HasMore: offset+limit < total,
+	})
+}
+
+// ReschedulePost handles PATCH /api/v1/posts/:id/schedule
+func (h *Handlers) ReschedulePost(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	var req models.ReschedulePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ReschedulePost(c.Request.Context(), postID, uid, req.ScheduledFor); err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "Post rescheduled successfully",
+		"scheduled_for": req.ScheduledFor,
+	})
+}
+
+// CancelScheduledPost handles DELETE /api/v1/posts/:id/schedule
+func (h *Handlers) CancelScheduledPost(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	if err := h.service.CancelScheduledPost(c.Request.Context(), postID, uid); err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Scheduled post cancelled, it was kept as a draft",
+	})
+}
+
+// respondScheduleError reports invalid schedules and posts that can't be rescheduled with their own status,
+// anything else as a server error
+func respondScheduleError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrScheduleInPast, models.ErrScheduleTooFar, models.ErrScheduledDraft:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case models.ErrPostNotScheduled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case models.ErrPostNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case models.ErrUnauthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ============================================
+// DRAFTS ENDPOINTS
+// ============================================
+
+// ListDrafts handles GET /api/v1/posts/drafts
+func (h *Handlers) ListDrafts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	postType := c.Query("type")
+	if postType != "" && postType != "post" && postType != "poll" && postType != "article" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type, use post, poll or article"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	drafts, total, err := h.service.GetDrafts(c.Request.Context(), uid, postType, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DraftsResponse{
+		Success: true,
+		Drafts:  drafts,
+		Total:   total,
+		Limit:   limit,
+		HasMore: offset+limit < total,
+	})
+}
+
+// CreateDraft handles POST /api/v1/posts/drafts
+func (h *Handlers) CreateDraft(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	var req models.CreateDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	draft, err := h.service.CreateDraft(c.Request.Context(), &req, uid)
+	if err != nil {
+		respondDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.PostResponse{
+		Success: true,
+		Post:    draft,
+		Message: "Draft saved",
+	})
+}
+
+// GetDraft handles GET /api/v1/posts/drafts/:id
+func (h *Handlers) GetDraft(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	draft, err := h.service.GetDraft(c.Request.Context(), postID, uid)
+	if err != nil {
+		respondDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PostResponse{
+		Success: true,
+		Post:    draft,
+	})
+}
+
+// SaveDraft handles PUT /api/v1/posts/drafts/:id (autosave)
+func (h *Handlers) SaveDraft(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	var req models.SaveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	draft, err := h.service.SaveDraft(c.Request.Context(), postID, uid, &req)
+	if err != nil {
+		respondDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PostResponse{
+		Success: true,
+		Post:    draft,
+		Message: "Draft saved",
+	})
+}
+
+// GetDraftRevisions handles GET /api/v1/posts/drafts/:id/revisions
+func (h *Handlers) GetDraftRevisions(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	revisions, err := h.service.GetDraftRevisions(c.Request.Context(), postID, uid)
+	if err != nil {
+		respondDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"revisions": revisions,
+	})
+}
+
+// RestoreDraftRevision handles POST /api/v1/posts/drafts/:id/revisions/:revisionId/restore
+func (h *Handlers) RestoreDraftRevision(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	revisionID, err := uuid.Parse(c.Param("revisionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	draft, err := h.service.RestoreDraftRevision(c.Request.Context(), postID, revisionID, uid)
+	if err != nil {
+		respondDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PostResponse{
+		Success: true,
+		Post:    draft,
+		Message: "Revision restored",
+	})
+}
+
+// PublishDraft handles POST /api/v1/posts/drafts/:id/publish
+func (h *Handlers) PublishDraft(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	// The body is optional, without it the draft is published now
+	var req models.PublishDraftRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	post, err := h.service.PublishDraft(c.Request.Context(), postID, uid, req.ScheduledFor)
+	if err != nil {
+		respondDraftError(c, err)
+		return
+	}
+
+	message := "Draft published"
+	if post.ScheduledFor != nil {
+		message = "Draft scheduled"
+	}
+
+	c.JSON(http.StatusOK, models.PostResponse{
+		Success: true,
+		Post:    post,
+		Message: message,
+	})
+}
+
+// DiscardDraft handles DELETE /api/v1/posts/drafts/:id
+func (h *Handlers) DiscardDraft(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uid, _ := uuid.Parse(userID.(string))
+
+	if err := h.service.DiscardDraft(c.Request.Context(), postID, uid); err != nil {
+		respondDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Draft discarded",
+	})
+}
+
+// respondDraftError reports drafts that changed or aren't drafts anymore as conflicts,
+// drafts that can't be published yet as bad requests, anything else as schedule errors are
+func respondDraftError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrNotDraft, models.ErrDraftConflict:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case models.ErrPostNotFound, models.ErrUnauthorized:
+		respondScheduleError(c, err)
+		return
+	}
+
+	if appErr, ok := err.(*models.AppError); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": appErr.Error()})
+		return
+	}
+	respondScheduleError(c, err)
+}
+
 // ============================================
 // ENGAGEMENT ENDPOINTS
 // ============================================
@@ -1041,11 +1450,11 @@ func (h *Handlers) UploadImage(c *gin.Context) {
 
 	// Get quality parameter
 	quality := c.DefaultQuery("quality", "standard")
-	var optimizeQuality messaging.OptimizeImageQuality
+	var imageQuality media.Quality
 	if quality == "hd" {
-		optimizeQuality = messaging.QualityHD
+		imageQuality = media.QualityHD
 	} else {
-		optimizeQuality = messaging.QualityStandard
+		imageQuality = media.QualityStandard
 	}
 
 	// Get file from form (expecting "file" field name)
@@ -1070,28 +1479,23 @@ func (h *Handlers) UploadImage(c *gin.Context) {
 		return
 	}
 
-	// Optimize image
-	optimizedData, newFormat, err := h.mediaOptimizer.OptimizeImageFromBytes(fileData, optimizeQuality)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to optimize image"})
-		return
-	}
-
-	// Upload to Supabase Storage (use "public" bucket for posts)
-	fileName := fmt.Sprintf("posts/%s/%s_%d.%s", uid.String(), uuid.New().String(), time.Now().Unix(), newFormat)
-	uploadedURL, err := h.storageService.UploadFile(c.Request.Context(), "public", fileName, bytes.NewReader(optimizedData), "image/"+newFormat)
+	// Render the size variants and upload them (use "public" bucket for posts), an image posted before is reused
+	info, err := h.mediaService.StoreImage(c.Request.Context(), uid, "public", fileData, header.Header.Get("Content-Type"), imageQuality)
 	if err != nil {
 		log.Printf("[UploadImage] Failed to upload: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		appErr := apperr.GetAppError(err)
+		c.JSON(appErr.Code, gin.H{"error": appErr.Message})
 		return
 	}
+	primary := info.Primary()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"url":     uploadedURL,
+		"url":     info.URL,
 		"name":    header.Filename,
-		"size":    len(optimizedData),
-		"type":    "image/" + newFormat,
+		"size":    primary.Size,
+		"type":    "image/" + primary.Format,
+		"media":   info,
 	})
 }
 
@@ -1134,24 +1538,29 @@ func (h *Handlers) UploadVideo(c *gin.Context) {
 	// Sanitize filename
 	sanitizedFilename := sanitizeFilename(header.Filename)
 
-	// Upload video to Supabase Storage
-	videoFileName := fmt.Sprintf("posts/%s/video_%s_%s", uid.String(), uuid.New().String(), sanitizedFilename)
-	uploadedURL, err := h.storageService.UploadFile(c.Request.Context(), "public", videoFileName, bytes.NewReader(fileData), header.Header.Get("Content-Type"))
+	// Stored under its content hash, a video posted before is reused
+	// Thumbnail, duration, dimensions and an MP4 copy are added in the background and saved to the post
+	info, err := h.mediaService.StoreFile(c.Request.Context(), uid, models.MediaKindVideo, "public", bytes.NewReader(fileData), sanitizedFilename, header.Header.Get("Content-Type"))
 	if err != nil {
 		log.Printf("[UploadVideo] Failed to upload: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload video"})
+		respondUploadError(c, err, "Failed to upload video")
 		return
 	}
+	uploadedURL := info.URL
 
-	// Get optional thumbnail from form
+	// Optional thumbnail from the client, shown until processing finishes
 	var thumbnailURL string
 	thumbnailFile, thumbnailHeader, err := c.Request.FormFile("thumbnail")
 	if err == nil {
 		defer thumbnailFile.Close()
 		thumbnailData, err := io.ReadAll(thumbnailFile)
 		if err == nil {
-			thumbnailFileName := fmt.Sprintf("posts/%s/thumb_%s.jpg", uid.String(), uuid.New().String())
-			thumbnailURL, _ = h.storageService.UploadFile(c.Request.Context(), "public", thumbnailFileName, bytes.NewReader(thumbnailData), thumbnailHeader.Header.Get("Content-Type"))
+			// Not stored through the media service, so inspected here: it has to be an image
+			upload := inspect.Upload{UserID: uid, Kind: models.MediaKindImage, Bucket: "public", Filename: thumbnailHeader.Filename, ContentType: thumbnailHeader.Header.Get("Content-Type")}
+			if inspected, err := h.mediaService.Inspect(c.Request.Context(), upload, bytes.NewReader(thumbnailData)); err == nil {
+				thumbnailFileName := fmt.Sprintf("posts/%s/thumb_%s%s", uid.String(), uuid.New().String(), inspected.Extension(".jpg"))
+				thumbnailURL, _ = h.storageService.UploadFile(c.Request.Context(), "public", thumbnailFileName, bytes.NewReader(thumbnailData), inspected.ContentType)
+			}
 		}
 	}
 
@@ -1162,6 +1571,7 @@ func (h *Handlers) UploadVideo(c *gin.Context) {
 		"name":          header.Filename,
 		"size":          header.Size,
 		"type":          header.Header.Get("Content-Type"),
+		"media":         info,
 	})
 }
 
@@ -1201,20 +1611,32 @@ func (h *Handlers) UploadAudio(c *gin.Context) {
 		return
 	}
 
-	// Upload to Supabase Storage
-	fileName := fmt.Sprintf("posts/%s/audio_%s_%d.webm", uid.String(), uuid.New().String(), time.Now().Unix())
-	uploadedURL, err := h.storageService.UploadFile(c.Request.Context(), "public", fileName, bytes.NewReader(fileData), "audio/webm")
+	// Stored under its content hash, audio posted before is reused
+	// Duration, waveform and an MP3 copy are added in the background and saved to the post
+	info, err := h.mediaService.StoreFile(c.Request.Context(), uid, models.MediaKindAudio, "public", bytes.NewReader(fileData), header.Filename, header.Header.Get("Content-Type"))
 	if err != nil {
 		log.Printf("[UploadAudio] Failed to upload: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload audio"})
+		respondUploadError(c, err, "Failed to upload audio")
 		return
 	}
+	uploadedURL := info.URL
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"url":     uploadedURL,
 		"name":    header.Filename,
 		"size":    len(fileData),
-		"type":    "audio/webm",
+		"type":    header.Header.Get("Content-Type"),
+		"media":   info,
 	})
 }
+
+// respondUploadError reports a file refused by inspection with its own status and message,
+// anything else as a failed upload
+func respondUploadError(c *gin.Context, err error, message string) {
+	if appErr, ok := err.(*apperr.AppError); ok {
+		c.JSON(appErr.Code, gin.H{"error": appErr.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
@@ -3,6 +3,8 @@ package posts
 import (
 	"context"
 	"fmt"
+	"html"
+	"regexp"
 	"time"
 
 	"upvista-community-backend/internal/models"
@@ -19,6 +21,42 @@ type Service struct {
 	articleRepo repository.ArticleRepository
 	commentRepo repository.CommentRepository
 	wsManager   *websocket.Manager
+
+	// Optional, used to authorize topics of "connections" posts
+	relationshipRepo repository.RelationshipRepository
+
+	notificationService NotificationService // Optional, set after initialization
+	mediaResolver       MediaResolver       // Optional, attaches image variants and video/audio metadata to new posts
+	mediaReferences     MediaReferences     // Optional, keeps media used by posts and comments from being collected
+	linkPreviewer       LinkPreviewer       // Optional, attaches previews of the links in posts
+}
+
+// LinkPreviewer builds previews of the links in a text
+type LinkPreviewer interface {
+	Previews(ctx context.Context, text string) []models.LinkPreview
+	Refresh(ctx context.Context, text string, previous []models.LinkPreview) []models.LinkPreview
+}
+
+// MediaResolver looks up the variants, dimensions and processing status of uploaded media by URL
+type MediaResolver interface {
+	ResolveMedia(ctx context.Context, urls []string) ([]models.MediaInfo, error)
+}
+
+// MediaReferences records which posts and comments use uploaded media, files nothing uses are deleted
+type MediaReferences interface {
+	Attach(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID, urls ...string) error
+	Detach(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID) error
+}
+
+// NotificationService interface for creating notifications (avoid circular dependency)
+type NotificationService interface {
+	CreatePostLikeNotification(ctx context.Context, likerID, postOwnerID, postID uuid.UUID) error
+	CreatePostCommentNotification(ctx context.Context, commenterID, postOwnerID, postID uuid.UUID, content string) error
+	CreatePostMentionNotifications(ctx context.Context, authorID, postID uuid.UUID, mentionedIDs []uuid.UUID, content string) error
+	CreatePostShareNotification(ctx context.Context, sharerID, postOwnerID, postID uuid.UUID) error
+	CreateCommentReplyNotification(ctx context.Context, replierID, parentAuthorID, postID, parentCommentID uuid.UUID, content string) error
+	CreateCommentMentionNotifications(ctx context.Context, authorID, postID, commentID uuid.UUID, mentionedIDs []uuid.UUID, content string) error
+	CreatePollEndedNotifications(ctx context.Context, authorID uuid.UUID, voterIDs []uuid.UUID, postID, pollID uuid.UUID, question string, totalVotes int, winningOption string) error
 }
 
 // NewService creates a new post service
@@ -38,6 +76,55 @@ func NewService(
 	}
 }
 
+// SetNotificationService enables notifications for likes, comments, replies, mentions, shares and poll results
+func (s *Service) SetNotificationService(notificationService NotificationService) {
+	s.notificationService = notificationService
+}
+
+// SetMediaResolver attaches the variants, dimensions and blurhash of uploaded media to new posts
+func (s *Service) SetMediaResolver(mediaResolver MediaResolver) {
+	s.mediaResolver = mediaResolver
+}
+
+// SetMediaReferences records the media used by posts and comments, and releases it when they are deleted
+func (s *Service) SetMediaReferences(mediaReferences MediaReferences) {
+	s.mediaReferences = mediaReferences
+}
+
+// SetLinkPreviewer attaches previews of the links in posts when they are created or edited
+func (s *Service) SetLinkPreviewer(linkPreviewer LinkPreviewer) {
+	s.linkPreviewer = linkPreviewer
+}
+
+// MediaProcessed updates the media of posts using a video or audio file once background processing finishes
+func (s *Service) MediaProcessed(ctx context.Context, info *models.MediaInfo) {
+	posts, err := s.postRepo.GetPostsByMediaURL(ctx, info.URL)
+	if err != nil {
+		fmt.Printf("Warning: failed to find posts using %s: %v\n", info.URL, err)
+		return
+	}
+
+	for _, post := range posts {
+		byURL := make(map[string]models.MediaInfo, len(post.Media)+1)
+		for _, m := range post.Media {
+			byURL[m.URL] = m
+		}
+		byURL[info.URL] = *info
+
+		// Keep media in media_urls order
+		media := make([]models.MediaInfo, 0, len(byURL))
+		for _, u := range post.MediaURLs {
+			if m, ok := byURL[u]; ok {
+				media = append(media, m)
+			}
+		}
+
+		if err := s.postRepo.UpdatePost(ctx, post.ID, map[string]interface{}{"media": media}); err != nil {
+			fmt.Printf("Warning: failed to update media of post %s: %v\n", post.ID, err)
+		}
+	}
+}
+
 // ============================================
 // POST OPERATIONS
 // ============================================
@@ -60,8 +147,15 @@ func (s *Service) CreatePost(ctx context.Context, req *models.CreatePostRequest,
 		AllowsComments: req.AllowsComments,
 		AllowsSharing:  req.AllowsSharing,
 		IsDraft:        req.IsDraft,
-		IsPublished:    !req.IsDraft,
+		IsPublished:    !req.IsDraft && req.ScheduledFor == nil,
 		IsNSFW:         req.IsNSFW,
+		ScheduledFor:   req.ScheduledFor,
+	}
+
+	// Drafts keep their poll or article with the rest of the draft until they are published
+	if post.IsDraft {
+		content := models.NewDraftContent(req)
+		post.Draft = &content
 	}
 
 	// Set defaults
@@ -74,19 +168,38 @@ func (s *Service) CreatePost(ctx context.Context, req *models.CreatePostRequest,
 		post.PublishedAt = &now
 	}
 
+	post.Media = s.resolveMedia(ctx, post.MediaURLs)
+
+	// Articles link inside their own body, other posts show a card for the links in their text
+	if s.linkPreviewer != nil && post.PostType != "article" && !post.IsDraft {
+		post.LinkPreviews = s.linkPreviewer.Previews(ctx, post.Content)
+	}
+
 	// Create post in database
 	if err := s.postRepo.CreatePost(ctx, post); err != nil {
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
 
-	// Extract and create hashtags
-	if err := s.postRepo.ExtractAndCreateHashtags(ctx, post.ID, post.Content); err != nil {
-		fmt.Printf("Warning: failed to extract hashtags: %v\n", err)
+	// Scheduled posts and drafts get their hashtags and mentions when they are published
+	var mentionedIDs []uuid.UUID
+	if post.IsPublished {
+		// Extract and create hashtags
+		if err := s.postRepo.ExtractAndCreateHashtags(ctx, post.ID, post.Content); err != nil {
+			fmt.Printf("Warning: failed to extract hashtags: %v\n", err)
+		}
+
+		// Extract and create mentions
+		var err error
+		mentionedIDs, err = s.postRepo.ExtractAndCreateMentions(ctx, post.ID, post.Content)
+		if err != nil {
+			fmt.Printf("Warning: failed to extract mentions: %v\n", err)
+		}
 	}
 
-	// Extract and create mentions
-	if err := s.postRepo.ExtractAndCreateMentions(ctx, post.ID, post.Content); err != nil {
-		fmt.Printf("Warning: failed to extract mentions: %v\n", err)
+	// Only mentioned users who can see the post are notified, the notification previews it
+	mentionedIDs = s.usersWhoCanSee(ctx, post, mentionedIDs)
+	if len(mentionedIDs) > 0 && s.notificationService != nil {
+		_ = s.notificationService.CreatePostMentionNotifications(ctx, userID, post.ID, mentionedIDs, post.Content)
 	}
 
 	// Broadcast new post to all users (for real-time feed updates)
@@ -95,6 +208,31 @@ func (s *Service) CreatePost(ctx context.Context, req *models.CreatePostRequest,
 	}
 
 	// Handle type-specific creation
+	if !post.IsDraft {
+		if err := s.createPostContent(ctx, post, req); err != nil {
+			return nil, err
+		}
+	}
+
+	mediaURLs := append([]string{}, post.MediaURLs...)
+	if post.Article != nil {
+		mediaURLs = append(mediaURLs, post.Article.CoverImageURL)
+		mediaURLs = append(mediaURLs, articleImageURLs(post.Article.ContentHTML)...)
+	} else if post.Draft != nil {
+		mediaURLs = draftMediaURLs(post.Draft)
+	}
+	s.attachMedia(ctx, models.MediaOwnerPost, post.ID, mediaURLs...)
+
+	// Broadcast to followers (if published)
+	if post.IsPublished {
+		s.broadcastNewPost(post)
+	}
+
+	return post, nil
+}
+
+// createPostContent creates the poll or article of a post
+func (s *Service) createPostContent(ctx context.Context, post *models.Post, req *models.CreatePostRequest) error {
 	switch post.PostType {
 	case "poll":
 		if req.Poll != nil {
@@ -108,8 +246,13 @@ func (s *Service) CreatePost(ctx context.Context, req *models.CreatePostRequest,
 				AnonymousVotes:        req.Poll.AnonymousVotes,
 			}
 
+			// Scheduled polls run from when they are published
+			if post.ScheduledFor != nil {
+				poll.EndsAt = post.ScheduledFor.Add(time.Duration(poll.DurationHours) * time.Hour)
+			}
+
 			if err := s.pollRepo.CreatePoll(ctx, poll, req.Poll.Options); err != nil {
-				return nil, fmt.Errorf("failed to create poll: %w", err)
+				return fmt.Errorf("failed to create poll: %w", err)
 			}
 
 			post.Poll = poll
@@ -130,19 +273,13 @@ func (s *Service) CreatePost(ctx context.Context, req *models.CreatePostRequest,
 			}
 
 			if err := s.articleRepo.CreateArticle(ctx, article); err != nil {
-				return nil, fmt.Errorf("failed to create article: %w", err)
+				return fmt.Errorf("failed to create article: %w", err)
 			}
 
 			post.Article = article
 		}
 	}
-
-	// Broadcast to followers (if published)
-	if post.IsPublished {
-		s.broadcastNewPost(post)
-	}
-
-	return post, nil
+	return nil
 }
 
 // GetPost retrieves a post with all data
@@ -152,6 +289,14 @@ func (s *Service) GetPost(ctx context.Context, postID, viewerID uuid.UUID) (*mod
 		return nil, err
 	}
 
+	// Drafts and scheduled posts are only seen by their author
+	if !post.IsPublished {
+		if post.UserID != viewerID {
+			return nil, models.ErrPostNotFound
+		}
+		return post, nil
+	}
+
 	// Increment views
 	go s.postRepo.IncrementViews(context.Background(), postID)
 
@@ -171,6 +316,9 @@ func (s *Service) GetArticleBySlug(ctx context.Context, slug string, viewerID uu
 	if err != nil {
 		return nil, fmt.Errorf("failed to get post: %w", err)
 	}
+	if !post.IsPublished && post.UserID != viewerID {
+		return nil, models.ErrPostNotFound
+	}
 
 	// Ensure article is attached (it should be from GetPost, but double-check)
 	if post.Article == nil {
@@ -200,6 +348,11 @@ func (s *Service) UpdatePost(ctx context.Context, postID, userID uuid.UUID, upda
 
 	if updates.Content != nil {
 		updatesMap["content"] = *updates.Content
+
+		// Links removed from the text lose their card, new ones get one
+		if s.linkPreviewer != nil && post.PostType != "article" {
+			updatesMap["link_previews"] = s.linkPreviewer.Refresh(ctx, *updates.Content, post.LinkPreviews)
+		}
 	}
 	if updates.Visibility != nil {
 		updatesMap["visibility"] = *updates.Visibility
@@ -224,18 +377,206 @@ func (s *Service) UpdatePost(ctx context.Context, postID, userID uuid.UUID, upda
 	return s.postRepo.UpdatePost(ctx, postID, updatesMap)
 }
 
+// ============================================
+// SCHEDULED POSTS
+// ============================================
+
+// GetScheduledPosts retrieves a user's posts waiting to be published
+func (s *Service) GetScheduledPosts(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Post, int, error) {
+	return s.postRepo.GetUserScheduledPosts(ctx, userID, limit, offset)
+}
+
+// ReschedulePost moves a scheduled post to another time
+func (s *Service) ReschedulePost(ctx context.Context, postID, userID uuid.UUID, scheduledFor time.Time) error {
+	if err := models.ValidateSchedule(scheduledFor); err != nil {
+		return err
+	}
+
+	post, err := s.scheduledPost(ctx, postID, userID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.postRepo.UpdateScheduledPost(ctx, postID, map[string]interface{}{
+		"scheduled_for": scheduledFor,
+	})
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return models.ErrPostNotScheduled
+	}
+
+	if post.Poll != nil {
+		s.restartPoll(ctx, post.Poll, scheduledFor)
+	}
+	return nil
+}
+
+// CancelScheduledPost stops a post from being published, it is kept as a draft
+func (s *Service) CancelScheduledPost(ctx context.Context, postID, userID uuid.UUID) error {
+	if _, err := s.scheduledPost(ctx, postID, userID); err != nil {
+		return err
+	}
+
+	updated, err := s.postRepo.UpdateScheduledPost(ctx, postID, map[string]interface{}{
+		"scheduled_for": nil,
+		"is_draft":      true,
+	})
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return models.ErrPostNotScheduled
+	}
+	return nil
+}
+
+// scheduledPost returns a user's post if it is still waiting to be published
+func (s *Service) scheduledPost(ctx context.Context, postID, userID uuid.UUID) (*models.Post, error) {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, models.ErrPostNotFound
+	}
+	if post.UserID != userID {
+		return nil, models.ErrUnauthorized
+	}
+	if post.IsPublished || post.ScheduledFor == nil || post.DeletedAt != nil {
+		return nil, models.ErrPostNotScheduled
+	}
+	return post, nil
+}
+
+// PublishDuePosts publishes scheduled posts whose time has come
+// Each post is claimed before publishing, so it is announced once even with several workers
+func (s *Service) PublishDuePosts(ctx context.Context, limit int) (int, error) {
+	due, err := s.postRepo.GetDueScheduledPosts(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due scheduled posts: %w", err)
+	}
+
+	published := 0
+	for _, post := range due {
+		now := time.Now()
+		claimed, err := s.postRepo.PublishScheduledPost(ctx, post.ID, now)
+		if err != nil {
+			fmt.Printf("Warning: failed to publish scheduled post %s: %v\n", post.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		s.announcePost(ctx, post.ID, now)
+		published++
+	}
+
+	return published, nil
+}
+
+// announcePost does for a published scheduled post or draft what creating it would have done:
+// hashtags, mentions and their notifications, the live broadcast, and starts its poll
+func (s *Service) announcePost(ctx context.Context, postID uuid.UUID, publishedAt time.Time) {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		fmt.Printf("Warning: failed to load published post %s: %v\n", postID, err)
+		return
+	}
+
+	if post.Poll != nil {
+		s.restartPoll(ctx, post.Poll, publishedAt)
+	}
+
+	if err := s.postRepo.ExtractAndCreateHashtags(ctx, post.ID, post.Content); err != nil {
+		fmt.Printf("Warning: failed to extract hashtags: %v\n", err)
+	}
+
+	mentionedIDs, err := s.postRepo.ExtractAndCreateMentions(ctx, post.ID, post.Content)
+	if err != nil {
+		fmt.Printf("Warning: failed to extract mentions: %v\n", err)
+	}
+	mentionedIDs = s.usersWhoCanSee(ctx, post, mentionedIDs)
+	if len(mentionedIDs) > 0 && s.notificationService != nil {
+		_ = s.notificationService.CreatePostMentionNotifications(ctx, post.UserID, post.ID, mentionedIDs, post.Content)
+	}
+
+	s.broadcastNewPost(post)
+}
+
+// restartPoll makes a scheduled poll last its full duration from when it starts
+func (s *Service) restartPoll(ctx context.Context, poll *models.Poll, startsAt time.Time) {
+	endsAt := startsAt.Add(time.Duration(poll.DurationHours) * time.Hour)
+	if err := s.pollRepo.SetPollEndsAt(ctx, poll.ID, endsAt); err != nil {
+		fmt.Printf("Warning: failed to move the end of poll %s: %v\n", poll.ID, err)
+		return
+	}
+	poll.EndsAt = endsAt
+}
+
 // DeletePost deletes a post
 func (s *Service) DeletePost(ctx context.Context, postID, userID uuid.UUID) error {
 	if err := s.postRepo.DeletePost(ctx, postID, userID); err != nil {
 		return err
 	}
 
+	// Deleted posts stay viewable for 30 days, within the media grace period
+	s.detachMedia(ctx, models.MediaOwnerPost, postID)
+
 	// Broadcast deletion
 	s.broadcastPostDeleted(postID)
 
 	return nil
 }
 
+// resolveMedia looks up the media of a post
+// Media uploaded through /posts/upload-* comes with variants or processing metadata, the post is still saved without them
+func (s *Service) resolveMedia(ctx context.Context, urls []string) []models.MediaInfo {
+	if s.mediaResolver == nil || len(urls) == 0 {
+		return nil
+	}
+	media, err := s.mediaResolver.ResolveMedia(ctx, urls)
+	if err != nil {
+		fmt.Printf("Warning: failed to resolve post media: %v\n", err)
+		return nil
+	}
+	if len(media) == 0 {
+		return nil
+	}
+	return media
+}
+
+// attachMedia records the media a post or comment uses, failures only leave it unprotected from collection
+func (s *Service) attachMedia(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID, urls ...string) {
+	if s.mediaReferences == nil {
+		return
+	}
+	if err := s.mediaReferences.Attach(ctx, ownerType, ownerID, urls...); err != nil {
+		fmt.Printf("Warning: failed to record media of %s %s: %v\n", ownerType, ownerID, err)
+	}
+}
+
+// detachMedia releases the media of a deleted post or comment
+func (s *Service) detachMedia(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID) {
+	if s.mediaReferences == nil {
+		return
+	}
+	if err := s.mediaReferences.Detach(ctx, ownerType, ownerID); err != nil {
+		fmt.Printf("Warning: failed to release media of %s %s: %v\n", ownerType, ownerID, err)
+	}
+}
+
+// articleImagePattern matches the src of images embedded in article HTML
+var articleImagePattern = regexp.MustCompile(`(?i)<img\s[^>]*?src=["']([^"']+)["']`)
+
+// articleImageURLs returns the URLs of the images embedded in an article
+func articleImageURLs(contentHTML string) []string {
+	var urls []string
+	for _, match := range articleImagePattern.FindAllStringSubmatch(contentHTML, -1) {
+		urls = append(urls, html.UnescapeString(match[1]))
+	}
+	return urls
+}
+
 // ============================================
 // ENGAGEMENT
 // ============================================
@@ -251,6 +592,10 @@ func (s *Service) LikePost(ctx context.Context, postID, userID uuid.UUID) error
 	if post != nil {
 		// Broadcast like event
 		s.broadcastPostLiked(postID, post.LikesCount, userID, post.UserID)
+
+		if s.notificationService != nil {
+			_ = s.notificationService.CreatePostLikeNotification(ctx, userID, post.UserID, postID)
+		}
 	}
 
 	return nil
@@ -258,7 +603,17 @@ func (s *Service) LikePost(ctx context.Context, postID, userID uuid.UUID) error
 
 // UnlikePost unlikes a post
 func (s *Service) UnlikePost(ctx context.Context, postID, userID uuid.UUID) error {
-	return s.postRepo.UnlikePost(ctx, postID, userID)
+	if err := s.postRepo.UnlikePost(ctx, postID, userID); err != nil {
+		return err
+	}
+
+	// Update live counters for viewers
+	post, _ := s.postRepo.GetPostByID(ctx, postID)
+	if post != nil {
+		s.broadcastPostUnliked(postID, post.LikesCount)
+	}
+
+	return nil
 }
 
 // SharePost shares a post
@@ -270,7 +625,11 @@ func (s *Service) SharePost(ctx context.Context, postID, userID uuid.UUID, comme
 	// Get post to notify author
 	post, _ := s.postRepo.GetPostByID(ctx, postID)
 	if post != nil {
-		s.broadcastPostShared(postID, userID, post.UserID)
+		s.broadcastPostShared(postID, post.SharesCount, userID, post.UserID)
+
+		if s.notificationService != nil {
+			_ = s.notificationService.CreatePostShareNotification(ctx, userID, post.UserID, postID)
+		}
 	}
 
 	return nil
@@ -310,6 +669,82 @@ func (s *Service) GetPollResults(ctx context.Context, pollID, viewerID uuid.UUID
 	return s.pollRepo.GetPollResults(ctx, pollID, viewerID)
 }
 
+// NotifyEndedPolls sends the final results of polls that ended to their authors and voters
+// Each poll is claimed before notifying, so results are announced once even with several workers
+func (s *Service) NotifyEndedPolls(ctx context.Context, limit int) (int, error) {
+	if s.notificationService == nil {
+		return 0, nil
+	}
+
+	pollIDs, err := s.pollRepo.GetEndedPollsPendingNotification(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get ended polls: %w", err)
+	}
+
+	notified := 0
+	for _, pollID := range pollIDs {
+		poll, err := s.pollRepo.GetPoll(ctx, pollID)
+		if err != nil {
+			fmt.Printf("Warning: failed to load ended poll %s: %v\n", pollID, err)
+			continue
+		}
+		post, err := s.postRepo.GetPostByID(ctx, poll.PostID)
+		if err != nil {
+			fmt.Printf("Warning: failed to load post of ended poll %s: %v\n", pollID, err)
+			continue
+		}
+
+		// A scheduled poll restarts when it is published, it hasn't really ended
+		if !post.IsPublished && post.ScheduledFor != nil {
+			continue
+		}
+
+		claimed, err := s.pollRepo.MarkResultsNotified(ctx, pollID)
+		if err != nil {
+			fmt.Printf("Warning: failed to claim poll %s: %v\n", pollID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		// Polls of drafts (cancelled scheduled posts included) were never seen, publishing creates them again
+		if !post.IsPublished {
+			continue
+		}
+
+		if err := s.notifyPollEnded(ctx, poll, post); err != nil {
+			fmt.Printf("Warning: failed to notify results of poll %s: %v\n", pollID, err)
+			continue
+		}
+		notified++
+	}
+
+	return notified, nil
+}
+
+// notifyPollEnded sends one poll's final results
+func (s *Service) notifyPollEnded(ctx context.Context, poll *models.Poll, post *models.Post) error {
+	voterIDs, err := s.pollRepo.GetPollVoterIDs(ctx, poll.ID)
+	if err != nil {
+		return err
+	}
+
+	// Ties have no single top answer
+	winningOption := ""
+	topVotes := 0
+	for _, option := range poll.Options {
+		if option.VotesCount > topVotes {
+			topVotes = option.VotesCount
+			winningOption = option.OptionText
+		} else if option.VotesCount == topVotes {
+			winningOption = ""
+		}
+	}
+
+	return s.notificationService.CreatePollEndedNotifications(ctx, post.UserID, voterIDs, post.ID, poll.ID, poll.Question, poll.TotalVotes, winningOption)
+}
+
 // ============================================
 // COMMENTS
 // ============================================
@@ -333,15 +768,59 @@ func (s *Service) CreateComment(ctx context.Context, req *models.CreateCommentRe
 		return nil, err
 	}
 
+	s.attachMedia(ctx, models.MediaOwnerComment, comment.ID, comment.MediaURL)
+
 	// Get post to notify author
 	post, _ := s.postRepo.GetPostByID(ctx, req.PostID)
 	if post != nil {
-		s.broadcastNewComment(comment, post.UserID)
+		s.broadcastNewComment(comment, post)
+		s.notifyNewComment(ctx, comment, post)
 	}
 
 	return comment, nil
 }
 
+// notifyNewComment notifies the post author, the parent comment's author and mentioned users
+// Each user gets at most one notification for the comment
+func (s *Service) notifyNewComment(ctx context.Context, comment *models.Comment, post *models.Post) {
+	if s.notificationService == nil {
+		return
+	}
+
+	notified := map[uuid.UUID]bool{comment.UserID: true}
+
+	if comment.ParentCommentID != nil {
+		parentComment, _ := s.commentRepo.GetComment(ctx, *comment.ParentCommentID)
+		if parentComment != nil && !notified[parentComment.UserID] {
+			_ = s.notificationService.CreateCommentReplyNotification(ctx, comment.UserID, parentComment.UserID, post.ID, parentComment.ID, comment.Content)
+			notified[parentComment.UserID] = true
+		}
+	}
+
+	if !notified[post.UserID] {
+		_ = s.notificationService.CreatePostCommentNotification(ctx, comment.UserID, post.UserID, post.ID, comment.Content)
+		notified[post.UserID] = true
+	}
+
+	mentionedIDs, err := s.postRepo.ResolveMentions(ctx, comment.Content)
+	if err != nil {
+		fmt.Printf("Warning: failed to resolve comment mentions: %v\n", err)
+		return
+	}
+
+	recipients := make([]uuid.UUID, 0, len(mentionedIDs))
+	for _, mentionedID := range mentionedIDs {
+		if !notified[mentionedID] {
+			recipients = append(recipients, mentionedID)
+		}
+	}
+	// Users who can't see the post don't get a preview of a comment on it
+	recipients = s.usersWhoCanSee(ctx, post, recipients)
+	if len(recipients) > 0 {
+		_ = s.notificationService.CreateCommentMentionNotifications(ctx, comment.UserID, post.ID, comment.ID, recipients, comment.Content)
+	}
+}
+
 // GetComments retrieves comments for a post
 func (s *Service) GetComments(ctx context.Context, postID uuid.UUID, limit, offset int) ([]models.Comment, int, error) {
 	return s.commentRepo.GetComments(ctx, postID, limit, offset)
@@ -381,6 +860,11 @@ func (s *Service) UpdateComment(ctx context.Context, commentID uuid.UUID, conten
 			}
 			// Broadcast to post author and anyone viewing the post
 			s.wsManager.BroadcastToUserWithData(post.UserID, event)
+			s.broadcastToPost(post.ID, map[string]interface{}{
+				"type":    "comment_updated",
+				"comment": updatedComment,
+				"post_id": post.ID,
+			})
 		}
 	}
 
@@ -389,7 +873,12 @@ func (s *Service) UpdateComment(ctx context.Context, commentID uuid.UUID, conten
 
 // DeleteComment deletes a comment
 func (s *Service) DeleteComment(ctx context.Context, commentID, userID uuid.UUID) error {
-	return s.commentRepo.DeleteComment(ctx, commentID, userID)
+	if err := s.commentRepo.DeleteComment(ctx, commentID, userID); err != nil {
+		return err
+	}
+
+	s.detachMedia(ctx, models.MediaOwnerComment, commentID)
+	return nil
 }
 
 // LikeComment likes a comment
@@ -413,6 +902,9 @@ func (s *Service) broadcastNewPost(post *models.Post) {
 		// TODO: Implement broadcast to all followers or all users
 		// For now, the post will appear in feeds when users refresh
 		s.wsManager.BroadcastToUserWithData(post.UserID, event)
+
+		// Live hashtag pages
+		s.broadcastToHashtags(post)
 		fmt.Printf("[Posts] New post created: %s\n", post.ID)
 	}
 }
@@ -427,17 +919,43 @@ func (s *Service) broadcastPostLiked(postID uuid.UUID, likesCount int, likerID,
 		}
 		// Notify post author
 		s.wsManager.BroadcastToUserWithData(authorID, event)
+
+		// Update live counters for viewers
+		s.broadcastToPost(postID, map[string]interface{}{
+			"type":        "post_liked",
+			"post_id":     postID,
+			"likes_count": likesCount,
+			"liker_id":    likerID,
+		})
 	}
 }
 
-func (s *Service) broadcastNewComment(comment *models.Comment, postAuthorID uuid.UUID) {
+func (s *Service) broadcastPostUnliked(postID uuid.UUID, likesCount int) {
+	if s.wsManager != nil {
+		s.broadcastToPost(postID, map[string]interface{}{
+			"type":        "post_unliked",
+			"post_id":     postID,
+			"likes_count": likesCount,
+		})
+	}
+}
+
+func (s *Service) broadcastNewComment(comment *models.Comment, post *models.Post) {
 	if s.wsManager != nil {
 		event := map[string]interface{}{
 			"type":    "new_comment",
 			"comment": comment,
 		}
 		// Notify post author
-		s.wsManager.BroadcastToUserWithData(postAuthorID, event)
+		s.wsManager.BroadcastToUserWithData(post.UserID, event)
+
+		// Update the comment thread and counter for viewers
+		s.broadcastToPost(post.ID, map[string]interface{}{
+			"type":           "new_comment",
+			"comment":        comment,
+			"post_id":        post.ID,
+			"comments_count": post.CommentsCount,
+		})
 
 		// If it's a reply, notify parent comment author
 		if comment.ParentCommentID != nil {
@@ -453,7 +971,7 @@ func (s *Service) broadcastNewComment(comment *models.Comment, postAuthorID uuid
 	}
 }
 
-func (s *Service) broadcastPostShared(postID, sharerID, authorID uuid.UUID) {
+func (s *Service) broadcastPostShared(postID uuid.UUID, sharesCount int, sharerID, authorID uuid.UUID) {
 	if s.wsManager != nil {
 		event := map[string]interface{}{
 			"type":      "post_shared",
@@ -461,18 +979,43 @@ func (s *Service) broadcastPostShared(postID, sharerID, authorID uuid.UUID) {
 			"sharer_id": sharerID,
 		}
 		s.wsManager.BroadcastToUserWithData(authorID, event)
+
+		s.broadcastToPost(postID, map[string]interface{}{
+			"type":         "post_shared",
+			"post_id":      postID,
+			"shares_count": sharesCount,
+		})
 	}
 }
 
 func (s *Service) broadcastPostDeleted(postID uuid.UUID) {
-	// For broadcasts to all users, would need a broadcast-all method
-	// For now, just log
+	// Feeds drop the post on refresh, viewers of the post are told right away
 	fmt.Printf("[Posts] Post deleted: %s\n", postID)
+	if s.wsManager != nil {
+		s.broadcastToPost(postID, map[string]interface{}{
+			"type":    "post_deleted",
+			"post_id": postID,
+		})
+	}
 }
 
 func (s *Service) broadcastPollVote(pollID uuid.UUID, results *models.PollResults) {
-	// Broadcast poll results to viewers
+	// Broadcast poll results to viewers of the post
 	fmt.Printf("[Posts] Poll vote recorded: %s\n", pollID)
+	if s.wsManager != nil && results.Poll != nil {
+		event := map[string]interface{}{
+			"type":        "poll_results",
+			"post_id":     results.Poll.PostID,
+			"poll_id":     pollID,
+			"total_votes": results.TotalVotes,
+			"is_ended":    results.IsEnded,
+		}
+		// Viewers include people who haven't voted yet, so hide the breakdown when the poll does
+		if results.Poll.ShowResultsBeforeVote || results.IsEnded {
+			event["options"] = results.Options
+		}
+		s.broadcastToPost(results.Poll.PostID, event)
+	}
 }
 
 // GetUserLikedPosts retrieves all posts that a user has liked
@@ -0,0 +1,103 @@
+package posts
+
+import (
+	"context"
+	"regexp"
+
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Hashtag topics use the normalized tag (lowercase, without '#')
+var hashtagTopicPattern = regexp.MustCompile(`^[a-z0-9_]{1,100}$`)
+
+// SetRelationshipRepository enables "connections" visibility checks for post topics
+// Without it only the author can subscribe to such posts
+func (s *Service) SetRelationshipRepository(relationshipRepo repository.RelationshipRepository) {
+	s.relationshipRepo = relationshipRepo
+}
+
+// AuthorizePostTopic allows subscribing to "post:<id>" when the user can see the post
+func (s *Service) AuthorizePostTopic(ctx context.Context, userID uuid.UUID, id string) error {
+	postID, err := uuid.Parse(id)
+	if err != nil {
+		return models.ErrPostNotFound
+	}
+
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	return s.checkPostVisible(ctx, post, userID)
+}
+
+// AuthorizeHashtagTopic allows subscribing to "hashtag:<tag>" for any normalized tag
+func (s *Service) AuthorizeHashtagTopic(ctx context.Context, userID uuid.UUID, tag string) error {
+	if !hashtagTopicPattern.MatchString(tag) {
+		return models.ErrInvalidHashtag
+	}
+	return nil
+}
+
+// checkPostVisible verifies the user may see the post given its visibility
+func (s *Service) checkPostVisible(ctx context.Context, post *models.Post, userID uuid.UUID) error {
+	if post.UserID == userID {
+		return nil
+	}
+	if !post.IsPublished {
+		return models.ErrPostNotFound
+	}
+
+	switch post.Visibility {
+	case "public":
+		return nil
+	case "connections":
+		if s.relationshipRepo == nil {
+			return models.ErrUnauthorized
+		}
+		status, err := s.relationshipRepo.GetRelationshipStatus(ctx, userID, post.UserID)
+		if err != nil || !status.IsConnected || status.IsBlocked {
+			return models.ErrUnauthorized
+		}
+		return nil
+	default:
+		return models.ErrUnauthorized
+	}
+}
+
+// usersWhoCanSee keeps the users allowed to see the post, so notifications never preview it to anyone else
+func (s *Service) usersWhoCanSee(ctx context.Context, post *models.Post, userIDs []uuid.UUID) []uuid.UUID {
+	visible := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if s.checkPostVisible(ctx, post, userID) == nil {
+			visible = append(visible, userID)
+		}
+	}
+	return visible
+}
+
+// broadcastToPost sends an event to everyone viewing a post
+func (s *Service) broadcastToPost(postID uuid.UUID, event map[string]interface{}) {
+	topic := "post:" + postID.String()
+	event["topic"] = topic
+	s.wsManager.BroadcastToTopicWithData(topic, event)
+}
+
+// broadcastToHashtags sends a public post to everyone following its hashtags live
+func (s *Service) broadcastToHashtags(post *models.Post) {
+	if post.Visibility != "public" {
+		return
+	}
+
+	for _, tag := range repository.ExtractHashtags(post.Content) {
+		topic := "hashtag:" + tag
+		s.wsManager.BroadcastToTopicWithData(topic, map[string]interface{}{
+			"type":  "new_post",
+			"topic": topic,
+			"post":  post,
+		})
+	}
+}
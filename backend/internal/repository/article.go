@@ -15,6 +15,7 @@ type ArticleRepository interface {
 	GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error)
 	GetArticleByPostID(ctx context.Context, postID uuid.UUID) (*models.Article, error)
 	UpdateArticle(ctx context.Context, articleID uuid.UUID, updates map[string]interface{}) error
+	DeleteArticle(ctx context.Context, articleID uuid.UUID) error
 
 	// Analytics
 	IncrementViews(ctx context.Context, articleID uuid.UUID) error
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// EmailOutboxRepository defines the data-access contract for the email outbox and suppression list
+type EmailOutboxRepository interface {
+	CreateEmail(ctx context.Context, email *models.OutboxEmail) error
+	GetEmailByID(ctx context.Context, id uuid.UUID) (*models.OutboxEmail, error)
+	GetEmailByProviderMessageID(ctx context.Context, providerMessageID string) (*models.OutboxEmail, error)
+	// ListEmails returns the newest emails, optionally filtered by status and recipient
+	ListEmails(ctx context.Context, status models.EmailStatus, toEmail string, limit int) ([]*models.OutboxEmail, error)
+
+	// GetDueEmails returns pending emails whose next attempt is due, oldest first
+	GetDueEmails(ctx context.Context, limit int) ([]*models.OutboxEmail, error)
+	// ClaimEmail moves a pending email to sending, returns false if another worker already claimed it
+	ClaimEmail(ctx context.Context, id uuid.UUID) (bool, error)
+	// MarkEmailSent and MarkEmailFailed also clear the bodies, they hold one-time links
+	MarkEmailSent(ctx context.Context, id uuid.UUID, attempts int, transport, providerMessageID string) error
+	// ScheduleRetry puts an email back to pending after a failed attempt
+	ScheduleRetry(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkEmailFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string) error
+	// UpdateEmailStatus records a status reported by the provider (delivered, bounced, complained)
+	UpdateEmailStatus(ctx context.Context, id uuid.UUID, status models.EmailStatus, reason string) error
+	// ReleaseStaleEmails returns emails stuck in sending since before lockedBefore to pending
+	ReleaseStaleEmails(ctx context.Context, lockedBefore time.Time) (int, error)
+
+	// Suppress stops all future email to an address
+	Suppress(ctx context.Context, email, reason string) error
+	IsSuppressed(ctx context.Context, email string) (bool, error)
+}
@@ -38,6 +38,21 @@ func NewSessionRepository(cfg *config.Config) (SessionRepository, error) {
 	return nil, fmt.Errorf("unsupported data provider: %s", provider)
 }
 
+// NewPasskeyRepository creates a concrete PasskeyRepository based on config
+func NewPasskeyRepository(cfg *config.Config) (PasskeyRepository, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Server.DataProvider))
+	if provider == "" {
+		provider = "supabase" // default
+	}
+
+	// Supabase via PostgREST
+	if provider == "supabase" {
+		return NewSupabasePasskeyRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data provider: %s", provider)
+}
+
 // NewRelationshipRepository creates a concrete RelationshipRepository based on config
 func NewRelationshipRepository(cfg *config.Config) (RelationshipRepository, error) {
 	provider := strings.ToLower(strings.TrimSpace(cfg.Server.DataProvider))
@@ -112,3 +127,108 @@ func NewCourseRepository(cfg *config.Config) (CourseRepository, error) {
 
 	return nil, fmt.Errorf("unsupported data provider: %s", provider)
 }
+
+// NewPushSubscriptionRepository creates a concrete PushSubscriptionRepository based on config
+func NewPushSubscriptionRepository(cfg *config.Config) (PushSubscriptionRepository, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Server.DataProvider))
+	if provider == "" {
+		provider = "supabase" // default
+	}
+
+	// Supabase via PostgREST
+	if provider == "supabase" {
+		return NewSupabasePushSubscriptionRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data provider: %s", provider)
+}
+
+// NewEmailOutboxRepository creates a concrete EmailOutboxRepository based on config
+func NewEmailOutboxRepository(cfg *config.Config) (EmailOutboxRepository, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Server.DataProvider))
+	if provider == "" {
+		provider = "supabase" // default
+	}
+
+	// Supabase via PostgREST
+	if provider == "supabase" {
+		return NewSupabaseEmailOutboxRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data provider: %s", provider)
+}
+
+// NewUploadSessionRepository creates a concrete UploadSessionRepository based on config
+func NewUploadSessionRepository(cfg *config.Config) (UploadSessionRepository, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Server.DataProvider))
+	if provider == "" {
+		provider = "supabase" // default
+	}
+
+	// Supabase via PostgREST
+	if provider == "supabase" {
+		return NewSupabaseUploadSessionRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data provider: %s", provider)
+}
+
+// NewMediaAssetRepository creates a concrete MediaAssetRepository based on config
+func NewMediaAssetRepository(cfg *config.Config) (MediaAssetRepository, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Server.DataProvider))
+	if provider == "" {
+		provider = "supabase" // default
+	}
+
+	// Supabase via PostgREST
+	if provider == "supabase" {
+		return NewSupabaseMediaAssetRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data provider: %s", provider)
+}
+
+// NewMediaJobRepository creates a concrete MediaJobRepository based on config
+func NewMediaJobRepository(cfg *config.Config) (MediaJobRepository, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Server.DataProvider))
+	if provider == "" {
+		provider = "supabase" // default
+	}
+
+	// Supabase via PostgREST
+	if provider == "supabase" {
+		return NewSupabaseMediaJobRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data provider: %s", provider)
+}
+
+// NewMediaReferenceRepository creates a concrete MediaReferenceRepository based on config
+func NewMediaReferenceRepository(cfg *config.Config) (MediaReferenceRepository, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Server.DataProvider))
+	if provider == "" {
+		provider = "supabase" // default
+	}
+
+	// Supabase via PostgREST
+	if provider == "supabase" {
+		return NewSupabaseMediaReferenceRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data provider: %s", provider)
+}
+
+// NewQuarantineRepository creates a concrete QuarantineRepository based on config
+func NewQuarantineRepository(cfg *config.Config) (QuarantineRepository, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Server.DataProvider))
+	if provider == "" {
+		provider = "supabase" // default
+	}
+
+	// Supabase via PostgREST
+	if provider == "supabase" {
+		return NewSupabaseQuarantineRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data provider: %s", provider)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrMediaAssetExists is returned by CreateAsset when the bucket already has an asset with the same
+// content hash (another upload of the same file won the race)
+var ErrMediaAssetExists = errors.New("media asset with this content already exists")
+
+// MediaAssetRepository defines the data-access contract for uploaded images, videos, audio and files
+type MediaAssetRepository interface {
+	CreateAsset(ctx context.Context, asset *models.MediaAsset) error
+	// GetAsset returns nil if the asset doesn't exist
+	GetAsset(ctx context.Context, id uuid.UUID) (*models.MediaAsset, error)
+	// GetAssetByHash returns the asset of a bucket with the given content hash, nil if there is none
+	GetAssetByHash(ctx context.Context, bucket, contentHash string) (*models.MediaAsset, error)
+	// TouchAsset restarts the grace period of an unreferenced asset, returns false if it has references
+	// or doesn't exist anymore
+	TouchAsset(ctx context.Context, id uuid.UUID) (bool, error)
+	// UpdateMedia stores the result of processing a video or audio asset
+	UpdateMedia(ctx context.Context, id uuid.UUID, info *models.MediaInfo) error
+	// GetAssetsByURLs returns the assets whose primary URL is one of urls, unknown URLs are skipped
+	GetAssetsByURLs(ctx context.Context, urls []string) ([]*models.MediaAsset, error)
+
+	// GetOrphanedAssets returns assets unreferenced since before the given time, longest unreferenced first
+	GetOrphanedAssets(ctx context.Context, unreferencedBefore time.Time, limit int) ([]*models.MediaAsset, error)
+	// DeleteOrphanedAsset deletes an asset if it is still unreferenced since before the given time,
+	// returns false if it was referenced or touched in the meantime
+	DeleteOrphanedAsset(ctx context.Context, id uuid.UUID, unreferencedBefore time.Time) (bool, error)
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MediaJobRepository defines the data-access contract for the video and audio processing queue
+type MediaJobRepository interface {
+	CreateJob(ctx context.Context, job *models.MediaJob) error
+
+	// GetDueJobs returns pending jobs whose next attempt is due, oldest first
+	GetDueJobs(ctx context.Context, limit int) ([]*models.MediaJob, error)
+	// ClaimJob moves a pending job to processing, returns false if another worker already claimed it
+	ClaimJob(ctx context.Context, id uuid.UUID) (bool, error)
+	MarkJobCompleted(ctx context.Context, id uuid.UUID, attempts int) error
+	// ScheduleRetry puts a job back to pending after a failed attempt
+	ScheduleRetry(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkJobFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string) error
+	// ReleaseStaleJobs returns jobs stuck in processing since before lockedBefore to pending
+	ReleaseStaleJobs(ctx context.Context, lockedBefore time.Time) (int, error)
+}
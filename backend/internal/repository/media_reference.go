@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MediaReferenceRepository defines the data-access contract for the posts, messages and events using media assets
+// Reference counts on media_assets are kept up to date by database triggers
+type MediaReferenceRepository interface {
+	// AddReferences records references, ones that already exist are skipped
+	AddReferences(ctx context.Context, refs []*models.MediaReference) error
+	// RemoveReferences removes every reference held by an owner
+	RemoveReferences(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID) error
+}
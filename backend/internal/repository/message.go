@@ -51,12 +51,19 @@ type MessageRepository interface {
 	// UpdateMessageStatus updates the delivery/read status of a message
 	UpdateMessageStatus(ctx context.Context, messageID uuid.UUID, status models.MessageStatus) error
 
+	// UpdateAttachmentMedia stores processed video or audio media on every message with that attachment URL
+	// and returns the updated messages
+	UpdateAttachmentMedia(ctx context.Context, attachmentURL string, media *models.MediaInfo) ([]*models.Message, error)
+
 	// MarkMessagesAsRead marks all unread messages in a conversation as read
 	MarkMessagesAsRead(ctx context.Context, conversationID, readerID uuid.UUID) error
 
 	// DeleteMessage soft-deletes a message for a user
 	DeleteMessage(ctx context.Context, messageID, userID uuid.UUID) error
 
+	// ClearMessageAttachment removes the attachment of a message its sender unsent
+	ClearMessageAttachment(ctx context.Context, messageID, senderID uuid.UUID) error
+
 	// SearchMessages searches messages by content for a user
 	SearchMessages(ctx context.Context, userID uuid.UUID, query string, limit, offset int) ([]*models.Message, error)
 
@@ -80,8 +87,8 @@ type MessageRepository interface {
 	// EDIT MESSAGES
 	// ============================================
 
-	// EditMessage updates the content of a message
-	EditMessage(ctx context.Context, messageID uuid.UUID, newContent string, editorID uuid.UUID) error
+	// EditMessage updates the content of a message and the previews of the links in it
+	EditMessage(ctx context.Context, messageID uuid.UUID, newContent string, linkPreviews []models.LinkPreview, editorID uuid.UUID) error
 
 	// GetMessageEditHistory retrieves the edit history for a message
 	GetMessageEditHistory(ctx context.Context, messageID uuid.UUID) ([]map[string]interface{}, error)
@@ -2,12 +2,18 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"upvista-community-backend/internal/models"
 
 	"github.com/google/uuid"
 )
 
+// ErrNotificationGroupExists is returned by Create when the user already has an unread
+// notification with the same group key (another actor won the race to start the group)
+var ErrNotificationGroupExists = errors.New("unread notification group already exists")
+
 // NotificationRepository defines the interface for notification data access
 type NotificationRepository interface {
 	// Notification CRUD
@@ -21,6 +27,13 @@ type NotificationRepository interface {
 	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
 	UpdateActionTaken(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
 
+	// Grouping
+	// GetUnreadGroup returns the user's unread notification for a group key, or nil if there is none
+	GetUnreadGroup(ctx context.Context, userID uuid.UUID, groupKey string) (*models.Notification, error)
+	// UpdateGroup saves a grouped notification if it is still unread and still has previousCount actors
+	// Returns false when it changed concurrently (or was read) and nothing was written
+	UpdateGroup(ctx context.Context, notification *models.Notification, previousCount int) (bool, error)
+
 	// Preferences
 	GetPreferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error)
 	UpsertPreferences(ctx context.Context, prefs *models.NotificationPreferences) error
@@ -28,6 +41,19 @@ type NotificationRepository interface {
 	// Cleanup
 	DeleteExpired(ctx context.Context) (int, error)
 
-	// Batch operations for digest
-	GetUnreadForDigest(ctx context.Context, frequency models.EmailFrequency) (map[uuid.UUID][]*models.Notification, error)
+	// Deferred delivery (quiet hours and snoozes)
+	// HoldDelivery records channels to deliver later, replacing any pending ones
+	HoldDelivery(ctx context.Context, id uuid.UUID, channels []models.NotificationChannel, deliverAt time.Time) error
+	// GetDueDeliveries returns held notifications whose delivery time is before the given time, oldest first
+	GetDueDeliveries(ctx context.Context, before time.Time, limit int) ([]*models.Notification, error)
+	// ClaimDelivery clears a notification's held channels, returns false if another worker already did
+	ClaimDelivery(ctx context.Context, id uuid.UUID) (bool, error)
+	// RescheduleDeliveries moves all of a user's held notifications to a new delivery time
+	RescheduleDeliveries(ctx context.Context, userID uuid.UUID, deliverAt time.Time) error
+
+	// Digests
+	GetPreferencesByFrequency(ctx context.Context, frequency models.EmailFrequency) ([]*models.NotificationPreferences, error)
+	// GetUnreadSince returns a user's unread notifications created after since, newest first
+	GetUnreadSince(ctx context.Context, userID uuid.UUID, since time.Time, limit int) ([]*models.Notification, error)
+	MarkDigestSent(ctx context.Context, userID uuid.UUID, sentAt time.Time) error
 }
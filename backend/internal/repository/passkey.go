@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PasskeyRepository defines the data-access contract for WebAuthn credentials
+type PasskeyRepository interface {
+	CreatePasskey(ctx context.Context, passkey *models.Passkey) error
+	GetPasskeyByID(ctx context.Context, passkeyID uuid.UUID) (*models.Passkey, error)
+	GetPasskeyByCredentialID(ctx context.Context, credentialID string) (*models.Passkey, error)
+	GetPasskeysByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Passkey, error)
+	UpdatePasskeyUsage(ctx context.Context, passkeyID uuid.UUID, signCount uint32, cloneWarning, backupState bool) error
+	RenamePasskey(ctx context.Context, passkeyID, userID uuid.UUID, name string) error
+	DeletePasskey(ctx context.Context, passkeyID, userID uuid.UUID) error
+}
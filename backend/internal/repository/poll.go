@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 	"upvista-community-backend/internal/models"
 
 	"github.com/google/uuid"
@@ -14,6 +15,8 @@ type PollRepository interface {
 	GetPoll(ctx context.Context, pollID uuid.UUID) (*models.Poll, error)
 	GetPollByPostID(ctx context.Context, postID uuid.UUID) (*models.Poll, error)
 	ClosePoll(ctx context.Context, pollID uuid.UUID) error
+	SetPollEndsAt(ctx context.Context, pollID uuid.UUID, endsAt time.Time) error
+	DeletePoll(ctx context.Context, pollID uuid.UUID) error
 
 	// Voting
 	VotePoll(ctx context.Context, pollID, optionID, userID uuid.UUID) error
@@ -24,4 +27,9 @@ type PollRepository interface {
 	// Results
 	GetPollResults(ctx context.Context, pollID, viewerID uuid.UUID) (*models.PollResults, error)
 	GetPollOptions(ctx context.Context, pollID uuid.UUID) ([]models.PollOption, error)
+
+	// Ended polls
+	GetEndedPollsPendingNotification(ctx context.Context, limit int) ([]uuid.UUID, error)
+	MarkResultsNotified(ctx context.Context, pollID uuid.UUID) (bool, error)
+	GetPollVoterIDs(ctx context.Context, pollID uuid.UUID) ([]uuid.UUID, error)
 }
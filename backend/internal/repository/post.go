@@ -16,8 +16,25 @@ type PostRepository interface {
 	GetPostByID(ctx context.Context, postID uuid.UUID) (*models.Post, error)
 	GetUserPosts(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Post, int, error)
 	UpdatePost(ctx context.Context, postID uuid.UUID, updates map[string]interface{}) error
+	GetPostsByMediaURL(ctx context.Context, mediaURL string) ([]models.Post, error)
 	DeletePost(ctx context.Context, postID, userID uuid.UUID) error
 
+	// Scheduled posts
+	GetUserScheduledPosts(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Post, int, error)
+	GetDueScheduledPosts(ctx context.Context, limit int) ([]models.Post, error)
+	PublishScheduledPost(ctx context.Context, postID uuid.UUID, publishedAt time.Time) (bool, error)
+	UpdateScheduledPost(ctx context.Context, postID uuid.UUID, updates map[string]interface{}) (bool, error)
+
+	// Drafts
+	GetUserDrafts(ctx context.Context, userID uuid.UUID, postType string, limit, offset int) ([]models.Post, int, error)
+	UpdateDraft(ctx context.Context, postID uuid.UUID, revision int, updates map[string]interface{}) (bool, error)
+	DeleteDraft(ctx context.Context, postID uuid.UUID) (bool, error)
+	CreateDraftRevision(ctx context.Context, revision *models.DraftRevision) error
+	UpdateDraftRevision(ctx context.Context, revisionID uuid.UUID, revision int, content models.DraftContent) error
+	GetDraftRevisions(ctx context.Context, postID uuid.UUID, limit int) ([]models.DraftRevision, error)
+	GetDraftRevision(ctx context.Context, postID, revisionID uuid.UUID) (*models.DraftRevision, error)
+	PruneDraftRevisions(ctx context.Context, postID uuid.UUID, keep int) error
+
 	// Feed queries
 	GetHomeFeed(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Post, int, error)
 	GetFollowingFeed(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Post, int, error)
@@ -49,7 +66,8 @@ type PostRepository interface {
 	UnfollowHashtag(ctx context.Context, hashtagID, userID uuid.UUID) error
 
 	// Mentions
-	ExtractAndCreateMentions(ctx context.Context, postID uuid.UUID, content string) error
+	ExtractAndCreateMentions(ctx context.Context, postID uuid.UUID, content string) ([]uuid.UUID, error)
+	ResolveMentions(ctx context.Context, content string) ([]uuid.UUID, error)
 
 	// Search
 	SearchPosts(ctx context.Context, query string, userID uuid.UUID, limit, offset int) ([]models.Post, int, error)
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PushSubscriptionRepository defines the data-access contract for Web Push subscriptions
+type PushSubscriptionRepository interface {
+	// UpsertSubscription stores a subscription, replacing any existing one for the same endpoint
+	UpsertSubscription(ctx context.Context, subscription *models.PushSubscription) error
+	GetSubscriptionsByUserID(ctx context.Context, userID uuid.UUID) ([]*models.PushSubscription, error)
+	MarkSubscriptionUsed(ctx context.Context, subscriptionID uuid.UUID) error
+	DeleteSubscription(ctx context.Context, userID uuid.UUID, endpoint string) error
+	// DeleteSubscriptionByID removes a subscription the push service reported as gone
+	DeleteSubscriptionByID(ctx context.Context, subscriptionID uuid.UUID) error
+	// DeleteExpiredSubscriptions removes subscriptions past their expiration time
+	DeleteExpiredSubscriptions(ctx context.Context) (int, error)
+}
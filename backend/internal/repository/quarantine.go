@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"upvista-community-backend/internal/models"
+)
+
+// QuarantineRepository defines the data-access contract for uploads rejected by inspection
+type QuarantineRepository interface {
+	// CreateQuarantinedFile records a file moved to the quarantine bucket, setting its ID and CreatedAt
+	CreateQuarantinedFile(ctx context.Context, file *models.QuarantinedFile) error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"upvista-community-backend/internal/models"
 
@@ -14,6 +15,9 @@ type SessionRepository interface {
 	GetSessionByID(ctx context.Context, sessionID uuid.UUID) (*models.UserSession, error)
 	GetSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]*models.UserSession, error)
 	GetSessionByTokenHash(ctx context.Context, tokenHash string) (*models.UserSession, error)
+	GetSessionByRetiredTokenHash(ctx context.Context, tokenHash string) (*models.UserSession, error)
+	RetireSessionToken(ctx context.Context, sessionID uuid.UUID, tokenHash string) error
+	RotateSessionToken(ctx context.Context, sessionID uuid.UUID, currentHash, newHash string, expiresAt time.Time) (bool, error)
 	DeleteSession(ctx context.Context, sessionID uuid.UUID) error
 	DeleteSessionByTokenHash(ctx context.Context, tokenHash string) error
 	DeleteAllUserSessions(ctx context.Context, userID uuid.UUID, exceptSessionID *uuid.UUID) error
@@ -425,6 +425,14 @@ func (r *SupabaseArticleRepository) UpdateArticle(ctx context.Context, articleID
 	return err
 }
 
+// DeleteArticle deletes an article with its tags
+func (r *SupabaseArticleRepository) DeleteArticle(ctx context.Context, articleID uuid.UUID) error {
+	query := fmt.Sprintf("?id=eq.%s", articleID.String())
+	_, err := r.makeRequest("DELETE", "articles", query, nil)
+
+	return err
+}
+
 // IncrementViews increments the view count
 func (r *SupabaseArticleRepository) IncrementViews(ctx context.Context, articleID uuid.UUID) error {
 	// Naive increment: read then write (may have race conditions under heavy load)
@@ -0,0 +1,517 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// SupabaseEmailOutboxRepository implements EmailOutboxRepository for Supabase
+type SupabaseEmailOutboxRepository struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewSupabaseEmailOutboxRepository creates a new Supabase email outbox repository
+func NewSupabaseEmailOutboxRepository(baseURL, apiKey string) *SupabaseEmailOutboxRepository {
+	return &SupabaseEmailOutboxRepository{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// outboxEmailRow mirrors the email_outbox table
+type outboxEmailRow struct {
+	ID                uuid.UUID          `json:"id"`
+	ToEmail           string             `json:"to_email"`
+	Subject           string             `json:"subject"`
+	HTMLBody          string             `json:"html_body"`
+	TextBody          string             `json:"text_body"`
+	Template          *string            `json:"template"`
+	Status            models.EmailStatus `json:"status"`
+	Attempts          int                `json:"attempts"`
+	NextAttemptAt     time.Time          `json:"next_attempt_at"`
+	LockedAt          *time.Time         `json:"locked_at"`
+	LastError         *string            `json:"last_error"`
+	Transport         *string            `json:"transport"`
+	ProviderMessageID *string            `json:"provider_message_id"`
+	SentAt            *time.Time         `json:"sent_at"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+}
+
+func (row *outboxEmailRow) toModel() *models.OutboxEmail {
+	return &models.OutboxEmail{
+		ID:                row.ID,
+		ToEmail:           row.ToEmail,
+		Subject:           row.Subject,
+		HTMLBody:          row.HTMLBody,
+		TextBody:          row.TextBody,
+		Template:          row.Template,
+		Status:            row.Status,
+		Attempts:          row.Attempts,
+		NextAttemptAt:     row.NextAttemptAt,
+		LockedAt:          row.LockedAt,
+		LastError:         row.LastError,
+		Transport:         row.Transport,
+		ProviderMessageID: row.ProviderMessageID,
+		SentAt:            row.SentAt,
+		CreatedAt:         row.CreatedAt,
+		UpdatedAt:         row.UpdatedAt,
+	}
+}
+
+func (r *SupabaseEmailOutboxRepository) outboxURL(query url.Values) string {
+	u := fmt.Sprintf("%s/rest/v1/email_outbox", r.baseURL)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (r *SupabaseEmailOutboxRepository) suppressionsURL(query url.Values) string {
+	u := fmt.Sprintf("%s/rest/v1/email_suppressions", r.baseURL)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (r *SupabaseEmailOutboxRepository) setHeaders(req *http.Request, prefer string) {
+	req.Header.Set("apikey", r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+}
+
+// CreateEmail queues an email, setting its ID and timestamps
+func (r *SupabaseEmailOutboxRepository) CreateEmail(ctx context.Context, email *models.OutboxEmail) error {
+	now := time.Now()
+	if email.NextAttemptAt.IsZero() {
+		email.NextAttemptAt = now
+	}
+
+	emailData := map[string]interface{}{
+		"to_email":        strings.ToLower(email.ToEmail),
+		"subject":         email.Subject,
+		"html_body":       email.HTMLBody,
+		"text_body":       email.TextBody,
+		"template":        email.Template,
+		"status":          email.Status,
+		"attempts":        email.Attempts,
+		"next_attempt_at": email.NextAttemptAt.UTC().Format(time.RFC3339Nano),
+		"last_error":      email.LastError,
+		"created_at":      now,
+		"updated_at":      now,
+	}
+
+	body, err := json.Marshal(emailData)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.outboxURL(nil), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] CreateEmail failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	var rows []outboxEmailRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return apperr.ErrDatabaseError
+	}
+
+	if len(rows) > 0 {
+		email.ID = rows[0].ID
+		email.CreatedAt = rows[0].CreatedAt
+		email.UpdatedAt = rows[0].UpdatedAt
+	}
+
+	return nil
+}
+
+// GetEmailByID retrieves an outbox email, nil if it doesn't exist
+func (r *SupabaseEmailOutboxRepository) GetEmailByID(ctx context.Context, id uuid.UUID) (*models.OutboxEmail, error) {
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("select", "*")
+
+	return r.getOne(ctx, q)
+}
+
+// GetEmailByProviderMessageID retrieves the email a provider webhook refers to, nil if it isn't known
+func (r *SupabaseEmailOutboxRepository) GetEmailByProviderMessageID(ctx context.Context, providerMessageID string) (*models.OutboxEmail, error) {
+	q := url.Values{}
+	q.Set("provider_message_id", "eq."+providerMessageID)
+	q.Set("select", "*")
+
+	return r.getOne(ctx, q)
+}
+
+// ListEmails returns the newest emails, optionally filtered by status and recipient
+func (r *SupabaseEmailOutboxRepository) ListEmails(ctx context.Context, status models.EmailStatus, toEmail string, limit int) ([]*models.OutboxEmail, error) {
+	q := url.Values{}
+	q.Set("select", "*")
+	q.Set("order", "created_at.desc")
+	q.Set("limit", strconv.Itoa(limit))
+	if status != "" {
+		q.Set("status", "eq."+string(status))
+	}
+	if toEmail != "" {
+		q.Set("to_email", "eq."+strings.ToLower(toEmail))
+	}
+
+	return r.list(ctx, q, "ListEmails")
+}
+
+// GetDueEmails returns pending emails whose next attempt is due, oldest first
+func (r *SupabaseEmailOutboxRepository) GetDueEmails(ctx context.Context, limit int) ([]*models.OutboxEmail, error) {
+	q := url.Values{}
+	q.Set("select", "*")
+	q.Set("status", "eq."+string(models.EmailStatusPending))
+	q.Set("next_attempt_at", "lte."+time.Now().UTC().Format(time.RFC3339Nano))
+	q.Set("order", "next_attempt_at.asc")
+	q.Set("limit", strconv.Itoa(limit))
+
+	return r.list(ctx, q, "GetDueEmails")
+}
+
+// ClaimEmail moves a pending email to sending, returns false if another worker already claimed it
+func (r *SupabaseEmailOutboxRepository) ClaimEmail(ctx context.Context, id uuid.UUID) (bool, error) {
+	now := time.Now()
+	body, err := json.Marshal(map[string]interface{}{
+		"status":     models.EmailStatusSending,
+		"locked_at":  now,
+		"updated_at": now,
+	})
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("status", "eq."+string(models.EmailStatusPending))
+	q.Set("select", "id")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.outboxURL(q), bytes.NewReader(body))
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] ClaimEmail failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return false, apperr.ErrDatabaseError
+	}
+
+	var claimed []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claimed); err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+
+	return len(claimed) > 0, nil
+}
+
+// MarkEmailSent records that the transport accepted an email
+func (r *SupabaseEmailOutboxRepository) MarkEmailSent(ctx context.Context, id uuid.UUID, attempts int, transport, providerMessageID string) error {
+	now := time.Now()
+	update := map[string]interface{}{
+		"status":     models.EmailStatusSent,
+		"attempts":   attempts,
+		"transport":  transport,
+		"locked_at":  nil,
+		"last_error": nil,
+		"html_body":  "", // Bodies hold one-time links, they aren't kept once sent
+		"text_body":  "",
+		"sent_at":    now,
+		"updated_at": now,
+	}
+	if providerMessageID != "" {
+		update["provider_message_id"] = providerMessageID
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+
+	return r.patch(ctx, r.outboxURL(q), update, "MarkEmailSent")
+}
+
+// ScheduleRetry puts an email back to pending after a failed attempt
+func (r *SupabaseEmailOutboxRepository) ScheduleRetry(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error {
+	update := map[string]interface{}{
+		"status":          models.EmailStatusPending,
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt.UTC().Format(time.RFC3339Nano),
+		"locked_at":       nil,
+		"last_error":      lastError,
+		"updated_at":      time.Now(),
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+
+	return r.patch(ctx, r.outboxURL(q), update, "ScheduleRetry")
+}
+
+// MarkEmailFailed gives up on an email
+func (r *SupabaseEmailOutboxRepository) MarkEmailFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string) error {
+	update := map[string]interface{}{
+		"status":     models.EmailStatusFailed,
+		"attempts":   attempts,
+		"locked_at":  nil,
+		"last_error": lastError,
+		"html_body":  "",
+		"text_body":  "",
+		"updated_at": time.Now(),
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+
+	return r.patch(ctx, r.outboxURL(q), update, "MarkEmailFailed")
+}
+
+// UpdateEmailStatus records a status reported by the provider
+func (r *SupabaseEmailOutboxRepository) UpdateEmailStatus(ctx context.Context, id uuid.UUID, status models.EmailStatus, reason string) error {
+	update := map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+	if reason != "" {
+		update["last_error"] = reason
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+
+	return r.patch(ctx, r.outboxURL(q), update, "UpdateEmailStatus")
+}
+
+// ReleaseStaleEmails returns emails stuck in sending (a worker crashed mid-send) to pending
+func (r *SupabaseEmailOutboxRepository) ReleaseStaleEmails(ctx context.Context, lockedBefore time.Time) (int, error) {
+	now := time.Now()
+	body, err := json.Marshal(map[string]interface{}{
+		"status":          models.EmailStatusPending,
+		"locked_at":       nil,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	})
+	if err != nil {
+		return 0, apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("status", "eq."+string(models.EmailStatusSending))
+	q.Set("locked_at", "lt."+lockedBefore.UTC().Format(time.RFC3339Nano))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.outboxURL(q), bytes.NewReader(body))
+	if err != nil {
+		return 0, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "count=exact,return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return 0, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] ReleaseStaleEmails failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return 0, apperr.ErrDatabaseError
+	}
+
+	// Parse count from Content-Range header
+	releasedCount := 0
+	if contentRange := resp.Header.Get("Content-Range"); contentRange != "" {
+		var total int
+		if n, _ := fmt.Sscanf(contentRange, "*/%d", &total); n == 1 {
+			releasedCount = total
+		}
+	}
+
+	return releasedCount, nil
+}
+
+// Suppress adds an address to the suppression list, keeping the first reason if it is already there
+func (r *SupabaseEmailOutboxRepository) Suppress(ctx context.Context, email, reason string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"email":      strings.ToLower(email),
+		"reason":     reason,
+		"created_at": time.Now(),
+	})
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("on_conflict", "email")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.suppressionsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "resolution=ignore-duplicates,return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] Suppress failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
+
+// IsSuppressed reports whether an address is on the suppression list
+func (r *SupabaseEmailOutboxRepository) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	q := url.Values{}
+	q.Set("email", "eq."+strings.ToLower(email))
+	q.Set("select", "email")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.suppressionsURL(q), nil)
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] IsSuppressed failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return false, apperr.ErrDatabaseError
+	}
+
+	var rows []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+
+	return len(rows) > 0, nil
+}
+
+func (r *SupabaseEmailOutboxRepository) getOne(ctx context.Context, q url.Values) (*models.OutboxEmail, error) {
+	emails, err := r.list(ctx, q, "get outbox email")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(emails) == 0 {
+		return nil, nil
+	}
+
+	return emails[0], nil
+}
+
+func (r *SupabaseEmailOutboxRepository) list(ctx context.Context, q url.Values, operation string) ([]*models.OutboxEmail, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.outboxURL(q), nil)
+	if err != nil {
+		return nil, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] %s failed: HTTP %d - %s", operation, resp.StatusCode, string(bodyBytes))
+		return nil, apperr.ErrDatabaseError
+	}
+
+	var rows []outboxEmailRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+
+	result := make([]*models.OutboxEmail, len(rows))
+	for i := range rows {
+		result[i] = rows[i].toModel()
+	}
+
+	return result, nil
+}
+
+func (r *SupabaseEmailOutboxRepository) patch(ctx context.Context, u string, update map[string]interface{}, operation string) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, u, bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] %s failed: HTTP %d - %s", operation, resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
@@ -0,0 +1,428 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// SupabaseMediaAssetRepository implements MediaAssetRepository for Supabase
+type SupabaseMediaAssetRepository struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewSupabaseMediaAssetRepository creates a new Supabase media asset repository
+func NewSupabaseMediaAssetRepository(baseURL, apiKey string) *SupabaseMediaAssetRepository {
+	return &SupabaseMediaAssetRepository{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// mediaAssetRow mirrors the media_assets table
+type mediaAssetRow struct {
+	ID                uuid.UUID             `json:"id"`
+	UserID            uuid.UUID             `json:"user_id"`
+	Bucket            string                `json:"bucket"`
+	ContentHash       *string               `json:"content_hash"`
+	Size              *int64                `json:"size"`
+	RefCount          int                   `json:"ref_count"`
+	UnreferencedSince *time.Time            `json:"unreferenced_since"`
+	URL               string                `json:"url"`
+	Kind              models.MediaKind      `json:"kind"`
+	Status            models.MediaStatus    `json:"status"`
+	Width             int                   `json:"width"`
+	Height            int                   `json:"height"`
+	Blurhash          *string               `json:"blurhash"`
+	Variants          []models.MediaVariant `json:"variants"`
+	Duration          *float64              `json:"duration"`
+	ThumbnailURL      *string               `json:"thumbnail_url"`
+	Waveform          []int                 `json:"waveform"`
+	VideoCodec        *string               `json:"video_codec"`
+	AudioCodec        *string               `json:"audio_codec"`
+	CreatedAt         time.Time             `json:"created_at"`
+	UpdatedAt         time.Time             `json:"updated_at"`
+}
+
+func (row *mediaAssetRow) toModel() *models.MediaAsset {
+	variants := row.Variants
+	if variants == nil {
+		variants = []models.MediaVariant{}
+	}
+
+	asset := &models.MediaAsset{
+		ID:                row.ID,
+		UserID:            row.UserID,
+		Bucket:            row.Bucket,
+		RefCount:          row.RefCount,
+		UnreferencedSince: row.UnreferencedSince,
+		MediaInfo: models.MediaInfo{
+			URL:      row.URL,
+			Kind:     row.Kind,
+			Status:   row.Status,
+			Width:    row.Width,
+			Height:   row.Height,
+			Variants: variants,
+			Waveform: row.Waveform,
+		},
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if row.ContentHash != nil {
+		asset.ContentHash = *row.ContentHash
+	}
+	if row.Size != nil {
+		asset.Size = *row.Size
+	}
+	if row.Blurhash != nil {
+		asset.Blurhash = *row.Blurhash
+	}
+	if row.Duration != nil {
+		asset.Duration = *row.Duration
+	}
+	if row.ThumbnailURL != nil {
+		asset.ThumbnailURL = *row.ThumbnailURL
+	}
+	if row.VideoCodec != nil {
+		asset.VideoCodec = *row.VideoCodec
+	}
+	if row.AudioCodec != nil {
+		asset.AudioCodec = *row.AudioCodec
+	}
+	return asset
+}
+
+// mediaFields returns the columns filled in by processing, empty values are stored as null
+func mediaFields(info *models.MediaInfo) map[string]interface{} {
+	optional := func(value string) interface{} {
+		if value == "" {
+			return nil
+		}
+		return value
+	}
+
+	fields := map[string]interface{}{
+		"kind":          info.Kind,
+		"status":        info.Status,
+		"width":         info.Width,
+		"height":        info.Height,
+		"blurhash":      optional(info.Blurhash),
+		"variants":      info.Variants,
+		"thumbnail_url": optional(info.ThumbnailURL),
+		"waveform":      info.Waveform,
+		"video_codec":   optional(info.VideoCodec),
+		"audio_codec":   optional(info.AudioCodec),
+		"duration":      nil,
+	}
+	if info.Variants == nil {
+		fields["variants"] = []models.MediaVariant{}
+	}
+	if info.Duration > 0 {
+		fields["duration"] = info.Duration
+	}
+	return fields
+}
+
+func (r *SupabaseMediaAssetRepository) assetsURL(query url.Values) string {
+	u := fmt.Sprintf("%s/rest/v1/media_assets", r.baseURL)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (r *SupabaseMediaAssetRepository) setHeaders(req *http.Request, prefer string) {
+	req.Header.Set("apikey", r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+}
+
+// CreateAsset records an uploaded file, setting its ID and creation time
+// Returns ErrMediaAssetExists if the bucket already has an asset with the same content hash
+func (r *SupabaseMediaAssetRepository) CreateAsset(ctx context.Context, asset *models.MediaAsset) error {
+	now := time.Now()
+	assetData := mediaFields(&asset.MediaInfo)
+	assetData["user_id"] = asset.UserID
+	assetData["bucket"] = asset.Bucket
+	assetData["url"] = asset.URL
+	assetData["size"] = asset.Size
+	if asset.ContentHash != "" {
+		assetData["content_hash"] = asset.ContentHash
+	}
+	assetData["created_at"] = now
+	assetData["updated_at"] = now
+
+	body, err := json.Marshal(assetData)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.assetsURL(nil), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	// Unique on (bucket, content_hash)
+	if resp.StatusCode == http.StatusConflict && asset.ContentHash != "" {
+		return ErrMediaAssetExists
+	}
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] CreateMediaAsset failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	var rows []mediaAssetRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return apperr.ErrDatabaseError
+	}
+
+	if len(rows) > 0 {
+		*asset = *rows[0].toModel()
+	}
+
+	return nil
+}
+
+// GetAsset retrieves a media asset, nil if it doesn't exist
+func (r *SupabaseMediaAssetRepository) GetAsset(ctx context.Context, id uuid.UUID) (*models.MediaAsset, error) {
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("select", "*")
+
+	assets, err := r.list(ctx, q, "GetMediaAsset")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(assets) == 0 {
+		return nil, nil
+	}
+
+	return assets[0], nil
+}
+
+// GetAssetByHash retrieves the asset of a bucket with the given content hash, nil if there is none
+func (r *SupabaseMediaAssetRepository) GetAssetByHash(ctx context.Context, bucket, contentHash string) (*models.MediaAsset, error) {
+	q := url.Values{}
+	q.Set("bucket", "eq."+bucket)
+	q.Set("content_hash", "eq."+contentHash)
+	q.Set("select", "*")
+
+	assets, err := r.list(ctx, q, "GetMediaAssetByHash")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(assets) == 0 {
+		return nil, nil
+	}
+
+	return assets[0], nil
+}
+
+// TouchAsset restarts the grace period of an unreferenced asset, so it isn't collected before its new upload is used
+func (r *SupabaseMediaAssetRepository) TouchAsset(ctx context.Context, id uuid.UUID) (bool, error) {
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("ref_count", "eq.0")
+
+	return r.patch(ctx, q, map[string]interface{}{"unreferenced_since": time.Now()}, "TouchMediaAsset")
+}
+
+// UpdateMedia stores the result of processing an asset
+func (r *SupabaseMediaAssetRepository) UpdateMedia(ctx context.Context, id uuid.UUID, info *models.MediaInfo) error {
+	update := mediaFields(info)
+	update["updated_at"] = time.Now()
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.assetsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] UpdateMediaAsset failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
+
+// GetAssetsByURLs returns the assets whose primary URL is one of urls
+func (r *SupabaseMediaAssetRepository) GetAssetsByURLs(ctx context.Context, urls []string) ([]*models.MediaAsset, error) {
+	if len(urls) == 0 {
+		return []*models.MediaAsset{}, nil
+	}
+
+	// URLs contain commas and parentheses, so every value of the in-list is quoted
+	quoted := make([]string, len(urls))
+	for i, u := range urls {
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(u) + `"`
+	}
+
+	q := url.Values{}
+	q.Set("select", "*")
+	q.Set("url", "in.("+strings.Join(quoted, ",")+")")
+
+	return r.list(ctx, q, "GetMediaAssetsByURLs")
+}
+
+// GetOrphanedAssets retrieves assets unreferenced since before the given time, longest unreferenced first
+func (r *SupabaseMediaAssetRepository) GetOrphanedAssets(ctx context.Context, unreferencedBefore time.Time, limit int) ([]*models.MediaAsset, error) {
+	q := url.Values{}
+	q.Set("select", "*")
+	q.Set("ref_count", "eq.0")
+	q.Set("unreferenced_since", "lt."+unreferencedBefore.UTC().Format(time.RFC3339Nano))
+	q.Set("order", "unreferenced_since.asc")
+	q.Set("limit", strconv.Itoa(limit))
+
+	return r.list(ctx, q, "GetOrphanedMediaAssets")
+}
+
+// DeleteOrphanedAsset deletes an asset only if it is still unreferenced since before the given time
+// The filters are evaluated by the delete itself, so a reference added or an upload reusing the asset
+// in the meantime keeps it
+func (r *SupabaseMediaAssetRepository) DeleteOrphanedAsset(ctx context.Context, id uuid.UUID, unreferencedBefore time.Time) (bool, error) {
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("ref_count", "eq.0")
+	q.Set("unreferenced_since", "lt."+unreferencedBefore.UTC().Format(time.RFC3339Nano))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, r.assetsURL(q), nil)
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] DeleteOrphanedMediaAsset failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return false, apperr.ErrDatabaseError
+	}
+
+	var rows []mediaAssetRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+
+	return len(rows) > 0, nil
+}
+
+// patch updates the assets matching q, returns whether any matched
+func (r *SupabaseMediaAssetRepository) patch(ctx context.Context, q url.Values, update map[string]interface{}, operation string) (bool, error) {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.assetsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] %s failed: HTTP %d - %s", operation, resp.StatusCode, string(bodyBytes))
+		return false, apperr.ErrDatabaseError
+	}
+
+	var rows []mediaAssetRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+
+	return len(rows) > 0, nil
+}
+
+func (r *SupabaseMediaAssetRepository) list(ctx context.Context, q url.Values, operation string) ([]*models.MediaAsset, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.assetsURL(q), nil)
+	if err != nil {
+		return nil, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] %s failed: HTTP %d - %s", operation, resp.StatusCode, string(bodyBytes))
+		return nil, apperr.ErrDatabaseError
+	}
+
+	var rows []mediaAssetRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+
+	result := make([]*models.MediaAsset, len(rows))
+	for i := range rows {
+		result[i] = rows[i].toModel()
+	}
+
+	return result, nil
+}
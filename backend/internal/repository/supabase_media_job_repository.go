@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// SupabaseMediaJobRepository implements MediaJobRepository for Supabase
+type SupabaseMediaJobRepository struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewSupabaseMediaJobRepository creates a new Supabase media job repository
+func NewSupabaseMediaJobRepository(baseURL, apiKey string) *SupabaseMediaJobRepository {
+	return &SupabaseMediaJobRepository{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// mediaJobRow mirrors the media_jobs table
+type mediaJobRow struct {
+	ID            uuid.UUID             `json:"id"`
+	AssetID       uuid.UUID             `json:"asset_id"`
+	Status        models.MediaJobStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	NextAttemptAt time.Time             `json:"next_attempt_at"`
+	LockedAt      *time.Time            `json:"locked_at"`
+	LastError     *string               `json:"last_error"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+func (row *mediaJobRow) toModel() *models.MediaJob {
+	return &models.MediaJob{
+		ID:            row.ID,
+		AssetID:       row.AssetID,
+		Status:        row.Status,
+		Attempts:      row.Attempts,
+		NextAttemptAt: row.NextAttemptAt,
+		LockedAt:      row.LockedAt,
+		LastError:     row.LastError,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}
+}
+
+func (r *SupabaseMediaJobRepository) jobsURL(query url.Values) string {
+	u := fmt.Sprintf("%s/rest/v1/media_jobs", r.baseURL)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (r *SupabaseMediaJobRepository) setHeaders(req *http.Request, prefer string) {
+	req.Header.Set("apikey", r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+}
+
+// CreateJob queues a processing job, setting its ID and timestamps
+func (r *SupabaseMediaJobRepository) CreateJob(ctx context.Context, job *models.MediaJob) error {
+	now := time.Now()
+	if job.NextAttemptAt.IsZero() {
+		job.NextAttemptAt = now
+	}
+	if job.Status == "" {
+		job.Status = models.MediaJobStatusPending
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"asset_id":        job.AssetID,
+		"status":          job.Status,
+		"attempts":        job.Attempts,
+		"next_attempt_at": job.NextAttemptAt.UTC().Format(time.RFC3339Nano),
+		"created_at":      now,
+		"updated_at":      now,
+	})
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.jobsURL(nil), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] CreateMediaJob failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	var rows []mediaJobRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return apperr.ErrDatabaseError
+	}
+
+	if len(rows) > 0 {
+		job.ID = rows[0].ID
+		job.CreatedAt = rows[0].CreatedAt
+		job.UpdatedAt = rows[0].UpdatedAt
+	}
+
+	return nil
+}
+
+// GetDueJobs returns pending jobs whose next attempt is due, oldest first
+func (r *SupabaseMediaJobRepository) GetDueJobs(ctx context.Context, limit int) ([]*models.MediaJob, error) {
+	q := url.Values{}
+	q.Set("select", "*")
+	q.Set("status", "eq."+string(models.MediaJobStatusPending))
+	q.Set("next_attempt_at", "lte."+time.Now().UTC().Format(time.RFC3339Nano))
+	q.Set("order", "next_attempt_at.asc")
+	q.Set("limit", strconv.Itoa(limit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.jobsURL(q), nil)
+	if err != nil {
+		return nil, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] GetDueMediaJobs failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return nil, apperr.ErrDatabaseError
+	}
+
+	var rows []mediaJobRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+
+	result := make([]*models.MediaJob, len(rows))
+	for i := range rows {
+		result[i] = rows[i].toModel()
+	}
+
+	return result, nil
+}
+
+// ClaimJob moves a pending job to processing, returns false if another worker already claimed it
+func (r *SupabaseMediaJobRepository) ClaimJob(ctx context.Context, id uuid.UUID) (bool, error) {
+	now := time.Now()
+	body, err := json.Marshal(map[string]interface{}{
+		"status":     models.MediaJobStatusProcessing,
+		"locked_at":  now,
+		"updated_at": now,
+	})
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("status", "eq."+string(models.MediaJobStatusPending))
+	q.Set("select", "id")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.jobsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] ClaimMediaJob failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return false, apperr.ErrDatabaseError
+	}
+
+	var claimed []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claimed); err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+
+	return len(claimed) > 0, nil
+}
+
+// MarkJobCompleted records that a job finished
+func (r *SupabaseMediaJobRepository) MarkJobCompleted(ctx context.Context, id uuid.UUID, attempts int) error {
+	update := map[string]interface{}{
+		"status":     models.MediaJobStatusCompleted,
+		"attempts":   attempts,
+		"locked_at":  nil,
+		"last_error": nil,
+		"updated_at": time.Now(),
+	}
+
+	return r.patch(ctx, id, update, "MarkMediaJobCompleted")
+}
+
+// ScheduleRetry puts a job back to pending after a failed attempt
+func (r *SupabaseMediaJobRepository) ScheduleRetry(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error {
+	update := map[string]interface{}{
+		"status":          models.MediaJobStatusPending,
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt.UTC().Format(time.RFC3339Nano),
+		"locked_at":       nil,
+		"last_error":      lastError,
+		"updated_at":      time.Now(),
+	}
+
+	return r.patch(ctx, id, update, "ScheduleMediaJobRetry")
+}
+
+// MarkJobFailed gives up on a job
+func (r *SupabaseMediaJobRepository) MarkJobFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string) error {
+	update := map[string]interface{}{
+		"status":     models.MediaJobStatusFailed,
+		"attempts":   attempts,
+		"locked_at":  nil,
+		"last_error": lastError,
+		"updated_at": time.Now(),
+	}
+
+	return r.patch(ctx, id, update, "MarkMediaJobFailed")
+}
+
+// ReleaseStaleJobs returns jobs stuck in processing (a worker crashed mid-job) to pending
+func (r *SupabaseMediaJobRepository) ReleaseStaleJobs(ctx context.Context, lockedBefore time.Time) (int, error) {
+	now := time.Now()
+	body, err := json.Marshal(map[string]interface{}{
+		"status":          models.MediaJobStatusPending,
+		"locked_at":       nil,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	})
+	if err != nil {
+		return 0, apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("status", "eq."+string(models.MediaJobStatusProcessing))
+	q.Set("locked_at", "lt."+lockedBefore.UTC().Format(time.RFC3339Nano))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.jobsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return 0, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "count=exact,return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return 0, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] ReleaseStaleMediaJobs failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return 0, apperr.ErrDatabaseError
+	}
+
+	// Parse count from Content-Range header
+	releasedCount := 0
+	if contentRange := resp.Header.Get("Content-Range"); contentRange != "" {
+		var total int
+		if n, _ := fmt.Sscanf(contentRange, "*/%d", &total); n == 1 {
+			releasedCount = total
+		}
+	}
+
+	return releasedCount, nil
+}
+
+func (r *SupabaseMediaJobRepository) patch(ctx context.Context, id uuid.UUID, update map[string]interface{}, operation string) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.jobsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] %s failed: HTTP %d - %s", operation, resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// SupabaseMediaReferenceRepository implements MediaReferenceRepository for Supabase
+type SupabaseMediaReferenceRepository struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewSupabaseMediaReferenceRepository creates a new Supabase media reference repository
+func NewSupabaseMediaReferenceRepository(baseURL, apiKey string) *SupabaseMediaReferenceRepository {
+	return &SupabaseMediaReferenceRepository{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (r *SupabaseMediaReferenceRepository) referencesURL(query url.Values) string {
+	u := fmt.Sprintf("%s/rest/v1/media_references", r.baseURL)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (r *SupabaseMediaReferenceRepository) setHeaders(req *http.Request, prefer string) {
+	req.Header.Set("apikey", r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+}
+
+// AddReferences records references in one request, existing ones are left as they are
+func (r *SupabaseMediaReferenceRepository) AddReferences(ctx context.Context, refs []*models.MediaReference) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]map[string]interface{}, len(refs))
+	for i, ref := range refs {
+		rows[i] = map[string]interface{}{
+			"asset_id":   ref.AssetID,
+			"owner_type": ref.OwnerType,
+			"owner_id":   ref.OwnerID,
+			"created_at": now,
+		}
+	}
+
+	body, err := json.Marshal(rows)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("on_conflict", "asset_id,owner_type,owner_id")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.referencesURL(q), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "resolution=ignore-duplicates,return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] AddMediaReferences failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
+
+// RemoveReferences removes every reference held by an owner
+func (r *SupabaseMediaReferenceRepository) RemoveReferences(ctx context.Context, ownerType models.MediaOwnerType, ownerID uuid.UUID) error {
+	q := url.Values{}
+	q.Set("owner_type", "eq."+string(ownerType))
+	q.Set("owner_id", "eq."+ownerID.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, r.referencesURL(q), nil)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] RemoveMediaReferences failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"time"
@@ -74,15 +75,17 @@ func (su *supabaseUser) toUser() (*models.User, error) {
 
 // supabaseMessage is a helper for unmarshaling Message with string timestamps
 type supabaseMessage struct {
-	ID             uuid.UUID          `json:"id"`
-	ConversationID uuid.UUID          `json:"conversation_id"`
-	SenderID       uuid.UUID          `json:"sender_id"`
-	Content        string             `json:"content"`
-	MessageType    models.MessageType `json:"message_type"`
-	AttachmentURL  *string            `json:"attachment_url"`
-	AttachmentName *string            `json:"attachment_name"`
-	AttachmentSize *int               `json:"attachment_size"`
-	AttachmentType *string            `json:"attachment_type"`
+	ID             uuid.UUID            `json:"id"`
+	ConversationID uuid.UUID            `json:"conversation_id"`
+	SenderID       uuid.UUID            `json:"sender_id"`
+	Content        string               `json:"content"`
+	MessageType    models.MessageType   `json:"message_type"`
+	AttachmentURL  *string              `json:"attachment_url"`
+	AttachmentName *string              `json:"attachment_name"`
+	AttachmentSize *int                 `json:"attachment_size"`
+	AttachmentType *string              `json:"attachment_type"`
+	Media          *models.MediaInfo    `json:"media"`
+	LinkPreviews   []models.LinkPreview `json:"link_previews"`
 	// Video-specific fields
 	ThumbnailURL  *string              `json:"thumbnail_url"`
 	VideoDuration *int                 `json:"video_duration"`
@@ -126,6 +129,8 @@ func (sm *supabaseMessage) toMessage() (*models.Message, error) {
 		AttachmentName: sm.AttachmentName,
 		AttachmentSize: sm.AttachmentSize,
 		AttachmentType: sm.AttachmentType,
+		Media:          sm.Media,
+		LinkPreviews:   sm.LinkPreviews,
 		// Video-specific fields
 		ThumbnailURL:  sm.ThumbnailURL,
 		VideoDuration: sm.VideoDuration,
@@ -695,6 +700,24 @@ func (r *supabaseMessageRepository) CreateMessage(ctx context.Context, message *
 	if message.AttachmentType != nil {
 		payload["attachment_type"] = *message.AttachmentType
 	}
+	if message.Media != nil {
+		payload["media"] = message.Media
+	}
+	if len(message.LinkPreviews) > 0 {
+		payload["link_previews"] = message.LinkPreviews
+	}
+	if message.ThumbnailURL != nil {
+		payload["thumbnail_url"] = *message.ThumbnailURL
+	}
+	if message.VideoDuration != nil {
+		payload["video_duration"] = *message.VideoDuration
+	}
+	if message.VideoWidth != nil {
+		payload["video_width"] = *message.VideoWidth
+	}
+	if message.VideoHeight != nil {
+		payload["video_height"] = *message.VideoHeight
+	}
 	if message.ReplyToID != nil {
 		payload["reply_to_id"] = message.ReplyToID.String()
 	}
@@ -843,6 +866,59 @@ func (r *supabaseMessageRepository) UpdateMessageStatus(ctx context.Context, mes
 	return nil
 }
 
+// UpdateAttachmentMedia stores processed media on every message with that attachment (forwards included)
+// Processed videos also fill in the thumbnail, duration and dimensions
+func (r *supabaseMessageRepository) UpdateAttachmentMedia(ctx context.Context, attachmentURL string, media *models.MediaInfo) ([]*models.Message, error) {
+	updates := map[string]interface{}{
+		"media":      media,
+		"updated_at": time.Now().Format(time.RFC3339),
+	}
+	if media.Kind == models.MediaKindVideo && media.Status == models.MediaStatusReady {
+		if media.ThumbnailURL != "" {
+			updates["thumbnail_url"] = media.ThumbnailURL
+		}
+		updates["video_duration"] = int(math.Round(media.Duration))
+		updates["video_width"] = media.Width
+		updates["video_height"] = media.Height
+	}
+
+	payload, _ := json.Marshal(updates)
+	query := url.Values{}
+	query.Set("attachment_url", "eq."+attachmentURL)
+	query.Set("select", "*")
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPatch, r.messagesURL(query), bytes.NewReader(payload))
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to update attachment media: %s", string(body))
+	}
+
+	var sbMessages []*supabaseMessage
+	if err := json.NewDecoder(resp.Body).Decode(&sbMessages); err != nil {
+		return nil, err
+	}
+
+	messages := make([]*models.Message, 0, len(sbMessages))
+	for _, sbMsg := range sbMessages {
+		msg, err := sbMsg.toMessage()
+		if err != nil {
+			log.Printf("Failed to convert message: %v", err)
+			continue
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
 // MarkMessagesAsRead marks all unread messages in a conversation as read
 func (r *supabaseMessageRepository) MarkMessagesAsRead(ctx context.Context, conversationID, readerID uuid.UUID) error {
 	updates := map[string]interface{}{
@@ -877,6 +953,43 @@ func (r *supabaseMessageRepository) DeleteMessage(ctx context.Context, messageID
 	return nil
 }
 
+// ClearMessageAttachment removes the attachment of a message its sender unsent, so the file can be released
+func (r *supabaseMessageRepository) ClearMessageAttachment(ctx context.Context, messageID, senderID uuid.UUID) error {
+	updates := map[string]interface{}{
+		"attachment_url":  nil,
+		"attachment_name": nil,
+		"attachment_size": nil,
+		"attachment_type": nil,
+		"media":           nil,
+		"thumbnail_url":   nil,
+		"video_duration":  nil,
+		"video_width":     nil,
+		"video_height":    nil,
+		"updated_at":      time.Now().Format(time.RFC3339),
+	}
+
+	payload, _ := json.Marshal(updates)
+	query := url.Values{}
+	query.Set("id", "eq."+messageID.String())
+	query.Set("sender_id", "eq."+senderID.String())
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPatch, r.messagesURL(query), bytes.NewReader(payload))
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to clear message attachment: %s", string(body))
+	}
+
+	return nil
+}
+
 // SearchMessages searches messages by content
 func (r *supabaseMessageRepository) SearchMessages(ctx context.Context, userID uuid.UUID, searchQuery string, limit, offset int) ([]*models.Message, error) {
 	// Note: Full-text search requires RPC function or advanced PostgREST features
@@ -1251,7 +1364,7 @@ func (r *supabaseMessageRepository) GetPinnedMessages(ctx context.Context, conve
 // ============================================
 
 // EditMessage updates the content of a message
-func (r *supabaseMessageRepository) EditMessage(ctx context.Context, messageID uuid.UUID, newContent string, editorID uuid.UUID) error {
+func (r *supabaseMessageRepository) EditMessage(ctx context.Context, messageID uuid.UUID, newContent string, linkPreviews []models.LinkPreview, editorID uuid.UUID) error {
 	// First, get the original message to save its content to history
 	originalMsg, err := r.GetMessage(ctx, messageID)
 	if err != nil {
@@ -1280,9 +1393,10 @@ func (r *supabaseMessageRepository) EditMessage(ctx context.Context, messageID u
 	// Update the message
 	now := time.Now().Format(time.RFC3339)
 	updates := map[string]interface{}{
-		"content":    newContent,
-		"edited_at":  now,
-		"edit_count": originalMsg.EditCount + 1,
+		"content":       newContent,
+		"link_previews": linkPreviews,
+		"edited_at":     now,
+		"edit_count":    originalMsg.EditCount + 1,
 	}
 
 	// Store original content if this is the first edit
@@ -1356,6 +1470,12 @@ func (r *supabaseMessageRepository) ForwardMessage(ctx context.Context, messageI
 		AttachmentName:  originalMsg.AttachmentName,
 		AttachmentSize:  originalMsg.AttachmentSize,
 		AttachmentType:  originalMsg.AttachmentType,
+		Media:           originalMsg.Media,
+		LinkPreviews:    originalMsg.LinkPreviews,
+		ThumbnailURL:    originalMsg.ThumbnailURL,
+		VideoDuration:   originalMsg.VideoDuration,
+		VideoWidth:      originalMsg.VideoWidth,
+		VideoHeight:     originalMsg.VideoHeight,
 		ForwardedFromID: &messageID,
 		IsForwarded:     true,
 		Status:          models.MessageStatusSent,
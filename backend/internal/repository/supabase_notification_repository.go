@@ -76,6 +76,7 @@ func (r *SupabaseNotificationRepository) Create(ctx context.Context, notificatio
 		"is_read":       false,
 		"is_actionable": notification.IsActionable,
 		"action_taken":  false,
+		"in_app":        notification.InApp,
 	}
 
 	if notification.Message != nil {
@@ -99,6 +100,17 @@ func (r *SupabaseNotificationRepository) Create(ctx context.Context, notificatio
 	if len(notification.Metadata) > 0 {
 		payload["metadata"] = notification.Metadata
 	}
+	if notification.GroupKey != nil {
+		payload["group_key"] = *notification.GroupKey
+	}
+	if notification.ActorID != nil {
+		payload["actor_ids"] = []string{notification.ActorID.String()}
+		payload["actor_count"] = 1
+	}
+	if notification.DeliverAt != nil && len(notification.PendingChannels) > 0 {
+		payload["pending_channels"] = notification.PendingChannels
+		payload["deliver_at"] = notification.DeliverAt.UTC().Format(time.RFC3339Nano)
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -119,6 +131,11 @@ func (r *SupabaseNotificationRepository) Create(ctx context.Context, notificatio
 	}
 	defer resp.Body.Close()
 
+	// The partial unique index on (user_id, group_key) allows one unread notification per group
+	if resp.StatusCode == http.StatusConflict && notification.GroupKey != nil {
+		return ErrNotificationGroupExists
+	}
+
 	if resp.StatusCode != http.StatusCreated {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		log.Printf("[NotificationRepo] Create failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
@@ -135,6 +152,14 @@ func (r *SupabaseNotificationRepository) Create(ctx context.Context, notificatio
 		if idStr, ok := created[0]["id"].(string); ok {
 			notification.ID, _ = uuid.Parse(idStr)
 		}
+		if createdAt, ok := created[0]["created_at"].(string); ok {
+			notification.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+			notification.UpdatedAt = notification.CreatedAt
+		}
+		if notification.ActorID != nil {
+			notification.ActorIDs = []uuid.UUID{*notification.ActorID}
+			notification.ActorCount = 1
+		}
 		log.Printf("[NotificationRepo] Notification created successfully: id=%s", notification.ID)
 	}
 
@@ -180,10 +205,11 @@ func (r *SupabaseNotificationRepository) GetByID(ctx context.Context, id uuid.UU
 func (r *SupabaseNotificationRepository) GetByUser(ctx context.Context, userID uuid.UUID, category *models.NotificationCategory, unread *bool, limit int, offset int) ([]*models.Notification, int, error) {
 	q := url.Values{}
 	q.Set("user_id", "eq."+userID.String())
-	q.Set("order", "created_at.desc")
+	q.Set("order", "updated_at.desc") // Groups move to the top when an actor joins
 	q.Set("limit", fmt.Sprintf("%d", limit))
 	q.Set("offset", fmt.Sprintf("%d", offset))
 	q.Set("select", "*,actor:users!actor_id(id,username,display_name,profile_picture,is_verified)")
+	q.Set("in_app", "eq.true") // Email/push-only notifications aren't shown in the inbox
 
 	if category != nil {
 		q.Set("category", "eq."+string(*category))
@@ -245,6 +271,7 @@ func (r *SupabaseNotificationRepository) GetUnreadCount(ctx context.Context, use
 	q := url.Values{}
 	q.Set("user_id", "eq."+userID.String())
 	q.Set("is_read", "eq.false")
+	q.Set("in_app", "eq.true")
 	q.Set("select", "id")
 
 	if category != nil {
@@ -282,16 +309,7 @@ func (r *SupabaseNotificationRepository) GetUnreadCount(ctx context.Context, use
 func (r *SupabaseNotificationRepository) GetCategoryCounts(ctx context.Context, userID uuid.UUID) (map[string]int, error) {
 	counts := make(map[string]int)
 
-	categories := []models.NotificationCategory{
-		models.CategorySocial,
-		models.CategoryMessages,
-		models.CategoryProjects,
-		models.CategoryCommunities,
-		models.CategoryPayments,
-		models.CategorySystem,
-	}
-
-	for _, category := range categories {
+	for _, category := range models.NotificationCategories {
 		count, err := r.GetUnreadCount(ctx, userID, &category)
 		if err != nil {
 			log.Printf("[NotificationRepo] Failed to get count for category %s: %v", category, err)
@@ -451,6 +469,109 @@ func (r *SupabaseNotificationRepository) UpdateActionTaken(ctx context.Context,
 	return nil
 }
 
+// =====================================================
+// GROUPING
+// =====================================================
+
+// GetUnreadGroup returns the user's unread notification for a group key, or nil if there is none
+func (r *SupabaseNotificationRepository) GetUnreadGroup(ctx context.Context, userID uuid.UUID, groupKey string) (*models.Notification, error) {
+	q := url.Values{}
+	q.Set("user_id", "eq."+userID.String())
+	q.Set("group_key", "eq."+groupKey)
+	q.Set("is_read", "eq.false")
+	q.Set("limit", "1")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.notificationsURL(q), nil)
+	if err != nil {
+		return nil, err
+	}
+	r.setHeaders(req, "")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[NotificationRepo] GetUnreadGroup failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("failed to fetch notification group: %d", resp.StatusCode)
+	}
+
+	var rawNotifications []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&rawNotifications); err != nil {
+		return nil, err
+	}
+
+	if len(rawNotifications) == 0 {
+		return nil, nil
+	}
+
+	return r.parseNotification(rawNotifications[0])
+}
+
+// UpdateGroup saves a grouped notification if it is still unread and still has previousCount actors
+func (r *SupabaseNotificationRepository) UpdateGroup(ctx context.Context, notification *models.Notification, previousCount int) (bool, error) {
+	actorIDs := make([]string, len(notification.ActorIDs))
+	for i, actorID := range notification.ActorIDs {
+		actorIDs[i] = actorID.String()
+	}
+
+	update := map[string]interface{}{
+		"title":        notification.Title,
+		"message":      notification.Message,
+		"actor_ids":    actorIDs,
+		"actor_count":  notification.ActorCount,
+		"action_url":   notification.ActionURL,
+		"action_taken": notification.ActionTaken,
+		"metadata":     notification.Metadata,
+		"updated_at":   notification.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		"expires_at":   notification.ExpiresAt.UTC().Format(time.RFC3339Nano),
+	}
+	if notification.ActorID != nil {
+		update["actor_id"] = notification.ActorID.String()
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return false, err
+	}
+
+	// Compare-and-set on the actor count so concurrent joins don't overwrite each other
+	q := url.Values{}
+	q.Set("id", "eq."+notification.ID.String())
+	q.Set("user_id", "eq."+notification.UserID.String())
+	q.Set("is_read", "eq.false")
+	q.Set("actor_count", fmt.Sprintf("eq.%d", previousCount))
+	q.Set("select", "id")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.notificationsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[NotificationRepo] UpdateGroup failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return false, fmt.Errorf("failed to update notification group: %d", resp.StatusCode)
+	}
+
+	var updated []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return false, err
+	}
+
+	return len(updated) > 0, nil
+}
+
 // =====================================================
 // PREFERENCES
 // =====================================================
@@ -490,30 +611,42 @@ func (r *SupabaseNotificationRepository) GetPreferences(ctx context.Context, use
 
 // UpsertPreferences creates or updates notification preferences
 func (r *SupabaseNotificationRepository) UpsertPreferences(ctx context.Context, prefs *models.NotificationPreferences) error {
+	// Booleans are always sent so that switching a channel off is persisted
 	payload := map[string]interface{}{
-		"user_id": prefs.UserID.String(),
+		"user_id":                prefs.UserID.String(),
+		"email_enabled":          prefs.EmailEnabled,
+		"in_app_enabled":         prefs.InAppEnabled,
+		"push_enabled":           prefs.PushEnabled,
+		"inline_actions_enabled": prefs.InlineActionsEnabled,
+		"quiet_hours_enabled":    prefs.QuietHoursEnabled,
 	}
 
-	if prefs.EmailEnabled {
-		payload["email_enabled"] = prefs.EmailEnabled
-	}
 	if prefs.EmailTypes != nil {
 		payload["email_types"] = prefs.EmailTypes
 	}
 	if prefs.EmailFrequency != "" {
 		payload["email_frequency"] = string(prefs.EmailFrequency)
 	}
-	if prefs.InAppEnabled {
-		payload["in_app_enabled"] = prefs.InAppEnabled
+	if prefs.CategoriesEnabled != nil {
+		payload["categories_enabled"] = prefs.CategoriesEnabled
 	}
-	if prefs.PushEnabled {
-		payload["push_enabled"] = prefs.PushEnabled
+	if prefs.QuietHoursStart != "" {
+		payload["quiet_hours_start"] = prefs.QuietHoursStart
 	}
-	if prefs.InlineActionsEnabled {
-		payload["inline_actions_enabled"] = prefs.InlineActionsEnabled
+	if prefs.QuietHoursEnd != "" {
+		payload["quiet_hours_end"] = prefs.QuietHoursEnd
 	}
-	if prefs.CategoriesEnabled != nil {
-		payload["categories_enabled"] = prefs.CategoriesEnabled
+	if prefs.Timezone != "" {
+		payload["timezone"] = prefs.Timezone
+	}
+	if prefs.CategoryChannels != nil {
+		payload["category_channels"] = prefs.CategoryChannels
+	}
+	// Always sent so that ending a snooze is persisted
+	if prefs.SnoozedUntil != nil {
+		payload["snoozed_until"] = prefs.SnoozedUntil.UTC().Format(time.RFC3339Nano)
+	} else {
+		payload["snoozed_until"] = nil
 	}
 
 	body, err := json.Marshal(payload)
@@ -577,11 +710,246 @@ func (r *SupabaseNotificationRepository) DeleteExpired(ctx context.Context) (int
 	return deletedCount, nil
 }
 
-// GetUnreadForDigest retrieves unread notifications for users based on email frequency
-func (r *SupabaseNotificationRepository) GetUnreadForDigest(ctx context.Context, frequency models.EmailFrequency) (map[uuid.UUID][]*models.Notification, error) {
-	// This would need a more complex query joining with preferences
-	// For now, return empty map - will implement in digest service
-	return make(map[uuid.UUID][]*models.Notification), nil
+// =====================================================
+// DEFERRED DELIVERY
+// =====================================================
+
+// HoldDelivery records channels to deliver later, replacing any pending ones
+func (r *SupabaseNotificationRepository) HoldDelivery(ctx context.Context, id uuid.UUID, channels []models.NotificationChannel, deliverAt time.Time) error {
+	update := map[string]interface{}{
+		"pending_channels": channels,
+		"deliver_at":       deliverAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+
+	return r.patchNotifications(ctx, q, update, "HoldDelivery")
+}
+
+// GetDueDeliveries returns held notifications whose delivery time is before the given time, oldest first
+func (r *SupabaseNotificationRepository) GetDueDeliveries(ctx context.Context, before time.Time, limit int) ([]*models.Notification, error) {
+	q := url.Values{}
+	q.Set("deliver_at", "lte."+before.UTC().Format(time.RFC3339))
+	q.Set("order", "deliver_at.asc")
+	q.Set("limit", fmt.Sprintf("%d", limit))
+	q.Set("select", "*,actor:users!actor_id(id,username,display_name,profile_picture,is_verified)")
+
+	return r.listNotifications(ctx, q, "GetDueDeliveries")
+}
+
+// ClaimDelivery clears a notification's held channels, returns false if another worker already did
+func (r *SupabaseNotificationRepository) ClaimDelivery(ctx context.Context, id uuid.UUID) (bool, error) {
+	update := map[string]interface{}{
+		"pending_channels": []string{},
+		"deliver_at":       nil,
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return false, err
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("deliver_at", "not.is.null")
+	q.Set("select", "id")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.notificationsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[NotificationRepo] ClaimDelivery failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return false, fmt.Errorf("failed to claim delivery: %d", resp.StatusCode)
+	}
+
+	var claimed []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claimed); err != nil {
+		return false, err
+	}
+
+	return len(claimed) > 0, nil
+}
+
+// RescheduleDeliveries moves all of a user's held notifications to a new delivery time
+func (r *SupabaseNotificationRepository) RescheduleDeliveries(ctx context.Context, userID uuid.UUID, deliverAt time.Time) error {
+	update := map[string]interface{}{
+		"deliver_at": deliverAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	q := url.Values{}
+	q.Set("user_id", "eq."+userID.String())
+	q.Set("deliver_at", "not.is.null")
+
+	return r.patchNotifications(ctx, q, update, "RescheduleDeliveries")
+}
+
+// =====================================================
+// DIGESTS
+// =====================================================
+
+// GetPreferencesByFrequency returns the preferences of users who get email digests at a frequency
+func (r *SupabaseNotificationRepository) GetPreferencesByFrequency(ctx context.Context, frequency models.EmailFrequency) ([]*models.NotificationPreferences, error) {
+	q := url.Values{}
+	q.Set("email_frequency", "eq."+string(frequency))
+	q.Set("email_enabled", "eq.true")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.preferencesURL(q), nil)
+	if err != nil {
+		return nil, err
+	}
+	r.setHeaders(req, "")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[NotificationRepo] GetPreferencesByFrequency failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("failed to fetch preferences: %d", resp.StatusCode)
+	}
+
+	var rawPrefs []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&rawPrefs); err != nil {
+		return nil, err
+	}
+
+	prefs := make([]*models.NotificationPreferences, 0, len(rawPrefs))
+	for _, raw := range rawPrefs {
+		parsed, err := r.parsePreferences(raw)
+		if err != nil {
+			log.Printf("[NotificationRepo] Failed to parse preferences: %v", err)
+			continue
+		}
+		prefs = append(prefs, parsed)
+	}
+
+	return prefs, nil
+}
+
+// GetUnreadSince returns a user's unread notifications created after since, newest first
+func (r *SupabaseNotificationRepository) GetUnreadSince(ctx context.Context, userID uuid.UUID, since time.Time, limit int) ([]*models.Notification, error) {
+	q := url.Values{}
+	q.Set("user_id", "eq."+userID.String())
+	q.Set("is_read", "eq.false")
+	q.Set("created_at", "gt."+since.UTC().Format(time.RFC3339))
+	q.Set("order", "created_at.desc")
+	q.Set("limit", fmt.Sprintf("%d", limit))
+	q.Set("select", "*,actor:users!actor_id(id,username,display_name,profile_picture,is_verified)")
+
+	return r.listNotifications(ctx, q, "GetUnreadSince")
+}
+
+// MarkDigestSent records when a user's last digest was sent
+func (r *SupabaseNotificationRepository) MarkDigestSent(ctx context.Context, userID uuid.UUID, sentAt time.Time) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"last_digest_at": sentAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	q.Set("user_id", "eq."+userID.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.preferencesURL(q), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[NotificationRepo] MarkDigestSent failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("failed to mark digest sent: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// listNotifications fetches and parses notifications matching a query
+func (r *SupabaseNotificationRepository) listNotifications(ctx context.Context, q url.Values, operation string) ([]*models.Notification, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.notificationsURL(q), nil)
+	if err != nil {
+		return nil, err
+	}
+	r.setHeaders(req, "")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[NotificationRepo] %s failed: HTTP %d - %s", operation, resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("failed to fetch notifications: %d", resp.StatusCode)
+	}
+
+	var rawNotifications []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&rawNotifications); err != nil {
+		return nil, err
+	}
+
+	notifications := make([]*models.Notification, 0, len(rawNotifications))
+	for _, raw := range rawNotifications {
+		notification, err := r.parseNotification(raw)
+		if err != nil {
+			log.Printf("[NotificationRepo] Failed to parse notification: %v", err)
+			continue
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// patchNotifications applies an update to the notifications matching a query
+func (r *SupabaseNotificationRepository) patchNotifications(ctx context.Context, q url.Values, update map[string]interface{}, operation string) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.notificationsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[NotificationRepo] %s failed: HTTP %d - %s", operation, resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("failed to update notifications: %d", resp.StatusCode)
+	}
+
+	return nil
 }
 
 // =====================================================
@@ -594,6 +962,7 @@ func (r *SupabaseNotificationRepository) parseNotification(raw map[string]interf
 		IsRead:       false,
 		IsActionable: false,
 		ActionTaken:  false,
+		InApp:        true,
 		Metadata:     make(map[string]interface{}),
 	}
 
@@ -654,12 +1023,46 @@ func (r *SupabaseNotificationRepository) parseNotification(raw map[string]interf
 	if actionTaken, ok := raw["action_taken"].(bool); ok {
 		notification.ActionTaken = actionTaken
 	}
+	if inApp, ok := raw["in_app"].(bool); ok {
+		notification.InApp = inApp
+	}
 
 	// Parse metadata
 	if metadata, ok := raw["metadata"].(map[string]interface{}); ok {
 		notification.Metadata = metadata
 	}
 
+	// Parse grouping
+	if groupKey, ok := raw["group_key"].(string); ok && groupKey != "" {
+		notification.GroupKey = &groupKey
+	}
+	if actorIDs, ok := raw["actor_ids"].([]interface{}); ok {
+		for _, rawID := range actorIDs {
+			if idStr, ok := rawID.(string); ok {
+				if actorID, err := uuid.Parse(idStr); err == nil {
+					notification.ActorIDs = append(notification.ActorIDs, actorID)
+				}
+			}
+		}
+	}
+	if actorCount, ok := raw["actor_count"].(float64); ok {
+		notification.ActorCount = int(actorCount)
+	}
+
+	// Parse deferred delivery
+	if pendingChannels, ok := raw["pending_channels"].([]interface{}); ok {
+		for _, rawChannel := range pendingChannels {
+			if channel, ok := rawChannel.(string); ok {
+				notification.PendingChannels = append(notification.PendingChannels, models.NotificationChannel(channel))
+			}
+		}
+	}
+	if deliverAt, ok := raw["deliver_at"].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339, deliverAt); err == nil {
+			notification.DeliverAt = &parsed
+		}
+	}
+
 	// Parse timestamps
 	if createdAt, ok := raw["created_at"].(string); ok {
 		notification.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
@@ -667,6 +1070,9 @@ func (r *SupabaseNotificationRepository) parseNotification(raw map[string]interf
 	if expiresAt, ok := raw["expires_at"].(string); ok {
 		notification.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
 	}
+	if updatedAt, ok := raw["updated_at"].(string); ok {
+		notification.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	}
 
 	// Parse actor (joined user data)
 	if actor, ok := raw["actor"].(map[string]interface{}); ok && actor != nil {
@@ -716,6 +1122,20 @@ func (r *SupabaseNotificationRepository) parsePreferences(raw map[string]interfa
 	if inlineActionsEnabled, ok := raw["inline_actions_enabled"].(bool); ok {
 		prefs.InlineActionsEnabled = inlineActionsEnabled
 	}
+	if quietHoursEnabled, ok := raw["quiet_hours_enabled"].(bool); ok {
+		prefs.QuietHoursEnabled = quietHoursEnabled
+	}
+
+	// Parse quiet hours window
+	if start, ok := raw["quiet_hours_start"].(string); ok {
+		prefs.QuietHoursStart = start
+	}
+	if end, ok := raw["quiet_hours_end"].(string); ok {
+		prefs.QuietHoursEnd = end
+	}
+	if timezone, ok := raw["timezone"].(string); ok {
+		prefs.Timezone = timezone
+	}
 
 	// Parse email frequency
 	if frequency, ok := raw["email_frequency"].(string); ok {
@@ -738,10 +1158,34 @@ func (r *SupabaseNotificationRepository) parsePreferences(raw map[string]interfa
 		}
 	}
 
-	// Parse timestamp
+	if categoryChannels, ok := raw["category_channels"].(map[string]interface{}); ok {
+		prefs.CategoryChannels = make(map[string]models.ChannelSelection, len(categoryChannels))
+		for category, v := range categoryChannels {
+			channels, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			inApp, _ := channels["in_app"].(bool)
+			email, _ := channels["email"].(bool)
+			push, _ := channels["push"].(bool)
+			prefs.CategoryChannels[category] = models.ChannelSelection{InApp: inApp, Email: email, Push: push}
+		}
+	}
+
+	// Parse timestamps
 	if updatedAt, ok := raw["updated_at"].(string); ok {
 		prefs.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
 	}
+	if snoozedUntil, ok := raw["snoozed_until"].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339, snoozedUntil); err == nil {
+			prefs.SnoozedUntil = &parsed
+		}
+	}
+	if lastDigestAt, ok := raw["last_digest_at"].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339, lastDigestAt); err == nil {
+			prefs.LastDigestAt = &parsed
+		}
+	}
 
 	return prefs, nil
 }
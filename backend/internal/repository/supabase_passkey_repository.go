@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// SupabasePasskeyRepository implements PasskeyRepository for Supabase
+type SupabasePasskeyRepository struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewSupabasePasskeyRepository creates a new Supabase passkey repository
+func NewSupabasePasskeyRepository(baseURL, apiKey string) *SupabasePasskeyRepository {
+	return &SupabasePasskeyRepository{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// passkeyRow mirrors the user_passkeys table (binary fields are stored base64-encoded)
+type passkeyRow struct {
+	ID              uuid.UUID  `json:"id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	CredentialID    string     `json:"credential_id"`
+	PublicKey       string     `json:"public_key"`
+	AttestationType string     `json:"attestation_type"`
+	AAGUID          string     `json:"aaguid"`
+	SignCount       uint32     `json:"sign_count"`
+	CloneWarning    bool       `json:"clone_warning"`
+	Transports      []string   `json:"transports"`
+	BackupEligible  bool       `json:"backup_eligible"`
+	BackupState     bool       `json:"backup_state"`
+	Name            string     `json:"name"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func (row *passkeyRow) toModel() *models.Passkey {
+	publicKey, _ := base64.StdEncoding.DecodeString(row.PublicKey)
+	aaguid, _ := base64.StdEncoding.DecodeString(row.AAGUID)
+
+	return &models.Passkey{
+		ID:              row.ID,
+		UserID:          row.UserID,
+		CredentialID:    row.CredentialID,
+		PublicKey:       publicKey,
+		AttestationType: row.AttestationType,
+		AAGUID:          aaguid,
+		SignCount:       row.SignCount,
+		CloneWarning:    row.CloneWarning,
+		Transports:      row.Transports,
+		BackupEligible:  row.BackupEligible,
+		BackupState:     row.BackupState,
+		Name:            row.Name,
+		LastUsedAt:      row.LastUsedAt,
+		CreatedAt:       row.CreatedAt,
+	}
+}
+
+func (r *SupabasePasskeyRepository) passkeysURL(query url.Values) string {
+	u := fmt.Sprintf("%s/rest/v1/user_passkeys", r.baseURL)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (r *SupabasePasskeyRepository) setHeaders(req *http.Request, prefer string) {
+	req.Header.Set("apikey", r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+}
+
+// CreatePasskey stores a newly registered credential
+func (r *SupabasePasskeyRepository) CreatePasskey(ctx context.Context, passkey *models.Passkey) error {
+	transports := passkey.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+
+	passkeyData := map[string]interface{}{
+		"user_id":          passkey.UserID,
+		"credential_id":    passkey.CredentialID,
+		"public_key":       base64.StdEncoding.EncodeToString(passkey.PublicKey),
+		"attestation_type": passkey.AttestationType,
+		"aaguid":           base64.StdEncoding.EncodeToString(passkey.AAGUID),
+		"sign_count":       passkey.SignCount,
+		"transports":       transports,
+		"backup_eligible":  passkey.BackupEligible,
+		"backup_state":     passkey.BackupState,
+		"name":             passkey.Name,
+		"created_at":       time.Now(),
+	}
+
+	body, err := json.Marshal(passkeyData)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.passkeysURL(nil), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return apperr.NewAppError(http.StatusConflict, "Passkey is already registered")
+	}
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] CreatePasskey failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	var rows []passkeyRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return apperr.ErrDatabaseError
+	}
+
+	if len(rows) > 0 {
+		passkey.ID = rows[0].ID
+		passkey.CreatedAt = rows[0].CreatedAt
+	}
+
+	return nil
+}
+
+// GetPasskeyByID retrieves a passkey by its ID
+func (r *SupabasePasskeyRepository) GetPasskeyByID(ctx context.Context, passkeyID uuid.UUID) (*models.Passkey, error) {
+	q := url.Values{}
+	q.Set("id", "eq."+passkeyID.String())
+	q.Set("select", "*")
+
+	return r.fetchOne(ctx, q)
+}
+
+// GetPasskeyByCredentialID retrieves a passkey by its base64url-encoded credential ID
+func (r *SupabasePasskeyRepository) GetPasskeyByCredentialID(ctx context.Context, credentialID string) (*models.Passkey, error) {
+	q := url.Values{}
+	q.Set("credential_id", "eq."+credentialID)
+	q.Set("select", "*")
+
+	return r.fetchOne(ctx, q)
+}
+
+// GetPasskeysByUserID retrieves all passkeys registered by a user
+func (r *SupabasePasskeyRepository) GetPasskeysByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Passkey, error) {
+	q := url.Values{}
+	q.Set("user_id", "eq."+userID.String())
+	q.Set("select", "*")
+	q.Set("order", "created_at.asc")
+
+	rows, err := r.fetch(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.Passkey, len(rows))
+	for i := range rows {
+		result[i] = rows[i].toModel()
+	}
+
+	return result, nil
+}
+
+// UpdatePasskeyUsage records a successful assertion (signature counter, clone warning, backup state)
+func (r *SupabasePasskeyRepository) UpdatePasskeyUsage(ctx context.Context, passkeyID uuid.UUID, signCount uint32, cloneWarning, backupState bool) error {
+	q := url.Values{}
+	q.Set("id", "eq."+passkeyID.String())
+
+	return r.update(ctx, q, map[string]interface{}{
+		"sign_count":    signCount,
+		"clone_warning": cloneWarning,
+		"backup_state":  backupState,
+		"last_used_at":  time.Now(),
+	})
+}
+
+// RenamePasskey changes the display name of a user's passkey
+func (r *SupabasePasskeyRepository) RenamePasskey(ctx context.Context, passkeyID, userID uuid.UUID, name string) error {
+	q := url.Values{}
+	q.Set("id", "eq."+passkeyID.String())
+	q.Set("user_id", "eq."+userID.String())
+
+	return r.update(ctx, q, map[string]interface{}{
+		"name": name,
+	})
+}
+
+// DeletePasskey removes a user's passkey
+func (r *SupabasePasskeyRepository) DeletePasskey(ctx context.Context, passkeyID, userID uuid.UUID) error {
+	q := url.Values{}
+	q.Set("id", "eq."+passkeyID.String())
+	q.Set("user_id", "eq."+userID.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, r.passkeysURL(q), nil)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] DeletePasskey failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
+
+func (r *SupabasePasskeyRepository) fetchOne(ctx context.Context, q url.Values) (*models.Passkey, error) {
+	rows, err := r.fetch(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, apperr.ErrPasskeyNotFound
+	}
+
+	return rows[0].toModel(), nil
+}
+
+func (r *SupabasePasskeyRepository) fetch(ctx context.Context, q url.Values) ([]passkeyRow, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.passkeysURL(q), nil)
+	if err != nil {
+		return nil, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] fetch passkeys failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return nil, apperr.ErrDatabaseError
+	}
+
+	var rows []passkeyRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+
+	return rows, nil
+}
+
+func (r *SupabasePasskeyRepository) update(ctx context.Context, q url.Values, fields map[string]interface{}) error {
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.passkeysURL(q), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] update passkey failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
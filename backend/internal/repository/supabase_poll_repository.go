@@ -30,8 +30,10 @@ func (r *SupabasePollRepository) CreatePoll(ctx context.Context, poll *models.Po
 		poll.ID = uuid.New()
 	}
 
-	// Calculate end time
-	poll.EndsAt = time.Now().Add(time.Duration(poll.DurationHours) * time.Hour)
+	// Calculate end time, unless the poll is scheduled to start later
+	if poll.EndsAt.IsZero() {
+		poll.EndsAt = time.Now().Add(time.Duration(poll.DurationHours) * time.Hour)
+	}
 
 	// Create poll
 	payload := map[string]interface{}{
@@ -332,6 +334,26 @@ func (r *SupabasePollRepository) GetPollByPostID(ctx context.Context, postID uui
 	return poll, nil
 }
 
+// SetPollEndsAt moves the end of a poll, for scheduled posts published at another time than planned
+func (r *SupabasePollRepository) SetPollEndsAt(ctx context.Context, pollID uuid.UUID, endsAt time.Time) error {
+	updates := map[string]interface{}{
+		"ends_at": endsAt,
+	}
+
+	query := fmt.Sprintf("?id=eq.%s", pollID.String())
+	_, err := r.makeRequest("PATCH", "polls", query, updates)
+
+	return err
+}
+
+// DeletePoll deletes a poll with its options and votes
+func (r *SupabasePollRepository) DeletePoll(ctx context.Context, pollID uuid.UUID) error {
+	query := fmt.Sprintf("?id=eq.%s", pollID.String())
+	_, err := r.makeRequest("DELETE", "polls", query, nil)
+
+	return err
+}
+
 // ClosePoll closes a poll (no more voting)
 func (r *SupabasePollRepository) ClosePoll(ctx context.Context, pollID uuid.UUID) error {
 	updates := map[string]interface{}{
@@ -522,3 +544,73 @@ func (r *SupabasePollRepository) GetPollOptions(ctx context.Context, pollID uuid
 
 	return options, nil
 }
+
+// GetEndedPollsPendingNotification returns polls that have ended but whose results weren't announced yet
+func (r *SupabasePollRepository) GetEndedPollsPendingNotification(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	query := fmt.Sprintf("?ends_at=lt.%s&results_notified=eq.false&select=id&order=ends_at.asc&limit=%d",
+		time.Now().UTC().Format(time.RFC3339), limit)
+
+	data, err := r.makeRequest("GET", "polls", query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var polls []struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if err := json.Unmarshal(data, &polls); err != nil {
+		return nil, err
+	}
+
+	pollIDs := make([]uuid.UUID, 0, len(polls))
+	for _, poll := range polls {
+		pollIDs = append(pollIDs, poll.ID)
+	}
+
+	return pollIDs, nil
+}
+
+// MarkResultsNotified claims a poll's results announcement
+// Returns false if another worker already claimed it
+func (r *SupabasePollRepository) MarkResultsNotified(ctx context.Context, pollID uuid.UUID) (bool, error) {
+	updates := map[string]interface{}{
+		"results_notified": true,
+	}
+
+	query := fmt.Sprintf("?id=eq.%s&results_notified=eq.false", pollID.String())
+	data, err := r.makeRequest("PATCH", "polls", query, updates)
+	if err != nil {
+		return false, err
+	}
+
+	var updated []map[string]interface{}
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return false, err
+	}
+
+	return len(updated) > 0, nil
+}
+
+// GetPollVoterIDs returns the users who voted in a poll
+func (r *SupabasePollRepository) GetPollVoterIDs(ctx context.Context, pollID uuid.UUID) ([]uuid.UUID, error) {
+	query := fmt.Sprintf("?poll_id=eq.%s&select=user_id", pollID.String())
+
+	data, err := r.makeRequest("GET", "poll_votes", query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var votes []struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	if err := json.Unmarshal(data, &votes); err != nil {
+		return nil, err
+	}
+
+	voterIDs := make([]uuid.UUID, 0, len(votes))
+	for _, vote := range votes {
+		voterIDs = append(voterIDs, vote.UserID)
+	}
+
+	return voterIDs, nil
+}
@@ -95,6 +95,8 @@ func (r *SupabasePostRepository) CreatePost(ctx context.Context, post *models.Po
 		"content":         post.Content,
 		"media_urls":      post.MediaURLs,
 		"media_types":     post.MediaTypes,
+		"media":           post.Media,
+		"link_previews":   post.LinkPreviews,
 		"visibility":      post.Visibility,
 		"allows_comments": post.AllowsComments,
 		"allows_sharing":  post.AllowsSharing,
@@ -102,6 +104,9 @@ func (r *SupabasePostRepository) CreatePost(ctx context.Context, post *models.Po
 		"is_draft":        post.IsDraft,
 		"is_nsfw":         post.IsNSFW,
 		"published_at":    post.PublishedAt,
+		"scheduled_for":   post.ScheduledFor,
+		"draft":           post.Draft,
+		"draft_revision":  post.DraftRevision,
 	}
 
 	data, err := r.makeRequest("POST", "posts", "", payload)
@@ -241,6 +246,11 @@ func (r *SupabasePostRepository) CreatePost(ctx context.Context, post *models.Po
 		post.CreatedAt = parseRequiredTime(postData["created_at"])
 		post.UpdatedAt = parseRequiredTime(postData["updated_at"])
 		post.PublishedAt = parseTime(postData["published_at"])
+		post.ScheduledFor = parseTime(postData["scheduled_for"])
+		post.Draft = parseDraftContent(postData["draft"])
+		if draftRevision, ok := postData["draft_revision"].(float64); ok {
+			post.DraftRevision = int(draftRevision)
+		}
 		if deletedAt := parseTime(postData["deleted_at"]); deletedAt != nil {
 			post.DeletedAt = deletedAt
 		}
@@ -262,6 +272,8 @@ func (r *SupabasePostRepository) CreatePost(ctx context.Context, post *models.Po
 				}
 			}
 		}
+		post.Media = parseMediaInfos(postData["media"])
+		post.LinkPreviews = parseLinkPreviews(postData["link_previews"])
 	}
 
 	return nil
@@ -394,6 +406,11 @@ func (r *SupabasePostRepository) parsePostFromMap(postData map[string]interface{
 	post.CreatedAt = parseRequiredTime(postData["created_at"])
 	post.UpdatedAt = parseRequiredTime(postData["updated_at"])
 	post.PublishedAt = parseTime(postData["published_at"])
+	post.ScheduledFor = parseTime(postData["scheduled_for"])
+	post.Draft = parseDraftContent(postData["draft"])
+	if draftRevision, ok := postData["draft_revision"].(float64); ok {
+		post.DraftRevision = int(draftRevision)
+	}
 	if deletedAt := parseTime(postData["deleted_at"]); deletedAt != nil {
 		post.DeletedAt = deletedAt
 	}
@@ -415,10 +432,66 @@ func (r *SupabasePostRepository) parsePostFromMap(postData map[string]interface{
 			}
 		}
 	}
+	post.Media = parseMediaInfos(postData["media"])
+	post.LinkPreviews = parseLinkPreviews(postData["link_previews"])
 
 	return post, nil
 }
 
+// parseMediaInfos parses the media jsonb column, nil if it is empty or malformed
+func parseMediaInfos(val interface{}) []models.MediaInfo {
+	if val == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil
+	}
+
+	var media []models.MediaInfo
+	if err := json.Unmarshal(data, &media); err != nil {
+		return nil
+	}
+	return media
+}
+
+// parseLinkPreviews parses the link_previews jsonb column, nil if it is empty or malformed
+func parseLinkPreviews(val interface{}) []models.LinkPreview {
+	if val == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil
+	}
+
+	var previews []models.LinkPreview
+	if err := json.Unmarshal(data, &previews); err != nil {
+		return nil
+	}
+	return previews
+}
+
+// parseDraftContent parses the draft jsonb column, nil for posts that aren't drafts
+func parseDraftContent(val interface{}) *models.DraftContent {
+	if val == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil
+	}
+
+	var draft models.DraftContent
+	if err := json.Unmarshal(data, &draft); err != nil {
+		return nil
+	}
+	return &draft
+}
+
 // parsePostsFromJSON parses multiple posts from JSON data
 func (r *SupabasePostRepository) parsePostsFromJSON(data []byte) ([]models.Post, error) {
 	var postsData []map[string]interface{}
@@ -761,6 +834,308 @@ func (r *SupabasePostRepository) UpdatePost(ctx context.Context, postID uuid.UUI
 	return nil
 }
 
+// GetPostsByMediaURL retrieves the posts whose media_urls contain a URL, drafts included
+func (r *SupabasePostRepository) GetPostsByMediaURL(ctx context.Context, mediaURL string) ([]models.Post, error) {
+	// Array literal, the URL is quoted since it can contain commas
+	element := `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(mediaURL) + `"`
+	query := fmt.Sprintf("?media_urls=cs.%s&deleted_at=is.null", url.QueryEscape("{"+element+"}"))
+
+	data, err := r.makeRequest("GET", "posts", query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posts by media URL: %w", err)
+	}
+
+	posts, err := r.parsePostsFromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+// scheduledFilter matches posts still waiting to be published
+const scheduledFilter = "is_published=eq.false&scheduled_for=not.is.null&deleted_at=is.null"
+
+// GetUserScheduledPosts retrieves a user's scheduled posts, the next to be published first
+func (r *SupabasePostRepository) GetUserScheduledPosts(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Post, int, error) {
+	query := fmt.Sprintf("?user_id=eq.%s&%s&order=scheduled_for.asc&limit=%d&offset=%d",
+		userID.String(), scheduledFilter, limit, offset)
+
+	data, err := r.makeRequest("GET", "posts", query, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get scheduled posts: %w", err)
+	}
+
+	posts, err := r.parsePostsFromJSON(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse scheduled posts: %w", err)
+	}
+
+	countQuery := fmt.Sprintf("?user_id=eq.%s&%s&select=count", userID.String(), scheduledFilter)
+	countData, _ := r.makeRequest("GET", "posts", countQuery, nil)
+	total := len(posts) // Fallback
+
+	if countData != nil {
+		var countResult []map[string]int
+		if err := json.Unmarshal(countData, &countResult); err == nil && len(countResult) > 0 {
+			total = countResult[0]["count"]
+		}
+	}
+
+	if len(posts) == 0 {
+		return []models.Post{}, total, nil
+	}
+
+	for i := range posts {
+		r.loadPostAuthor(ctx, &posts[i])
+	}
+
+	if err := r.batchLoadPostTypeData(ctx, posts, userID); err != nil {
+		fmt.Printf("Warning: failed to load type-specific data: %v\n", err)
+	}
+
+	return posts, total, nil
+}
+
+// GetDueScheduledPosts retrieves scheduled posts whose time has come, the longest waiting first
+func (r *SupabasePostRepository) GetDueScheduledPosts(ctx context.Context, limit int) ([]models.Post, error) {
+	query := fmt.Sprintf("?%s&scheduled_for=lte.%s&order=scheduled_for.asc&limit=%d",
+		scheduledFilter, url.QueryEscape(time.Now().UTC().Format(time.RFC3339)), limit)
+
+	data, err := r.makeRequest("GET", "posts", query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due scheduled posts: %w", err)
+	}
+
+	posts, err := r.parsePostsFromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse due scheduled posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+// PublishScheduledPost claims a due scheduled post and publishes it
+// Returns false if it was already published, rescheduled, cancelled or deleted in the meantime
+// Feeds are ordered by creation, so the post is dated from when it is published
+func (r *SupabasePostRepository) PublishScheduledPost(ctx context.Context, postID uuid.UUID, publishedAt time.Time) (bool, error) {
+	updates := map[string]interface{}{
+		"is_published":  true,
+		"published_at":  publishedAt,
+		"created_at":    publishedAt,
+		"updated_at":    publishedAt,
+		"scheduled_for": nil,
+	}
+
+	query := fmt.Sprintf("?id=eq.%s&%s&scheduled_for=lte.%s",
+		postID.String(), scheduledFilter, url.QueryEscape(publishedAt.UTC().Format(time.RFC3339)))
+	data, err := r.makeRequest("PATCH", "posts", query, updates)
+	if err != nil {
+		return false, fmt.Errorf("failed to publish scheduled post: %w", err)
+	}
+
+	var updated []map[string]interface{}
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return false, err
+	}
+
+	return len(updated) > 0, nil
+}
+
+// UpdateScheduledPost updates a post only while it is scheduled
+// Returns false if it was published, cancelled or deleted in the meantime
+func (r *SupabasePostRepository) UpdateScheduledPost(ctx context.Context, postID uuid.UUID, updates map[string]interface{}) (bool, error) {
+	updates["updated_at"] = time.Now()
+
+	query := fmt.Sprintf("?id=eq.%s&%s", postID.String(), scheduledFilter)
+	data, err := r.makeRequest("PATCH", "posts", query, updates)
+	if err != nil {
+		return false, fmt.Errorf("failed to update scheduled post: %w", err)
+	}
+
+	var updated []map[string]interface{}
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return false, err
+	}
+
+	return len(updated) > 0, nil
+}
+
+// draftFilter matches drafts, scheduled posts aren't drafts
+const draftFilter = "is_draft=eq.true&is_published=eq.false&deleted_at=is.null"
+
+// draftRevisionsTable keeps earlier states of drafts
+const draftRevisionsTable = "post_draft_revisions"
+
+// GetUserDrafts retrieves a user's drafts, the last edited first, of one post type if postType isn't empty
+func (r *SupabasePostRepository) GetUserDrafts(ctx context.Context, userID uuid.UUID, postType string, limit, offset int) ([]models.Post, int, error) {
+	filter := fmt.Sprintf("user_id=eq.%s&%s", userID.String(), draftFilter)
+	if postType != "" {
+		filter += "&post_type=eq." + url.QueryEscape(postType)
+	}
+
+	query := fmt.Sprintf("?%s&order=updated_at.desc&limit=%d&offset=%d", filter, limit, offset)
+	data, err := r.makeRequest("GET", "posts", query, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get drafts: %w", err)
+	}
+
+	posts, err := r.parsePostsFromJSON(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse drafts: %w", err)
+	}
+
+	countData, _ := r.makeRequest("GET", "posts", "?"+filter+"&select=count", nil)
+	total := len(posts) // Fallback
+
+	if countData != nil {
+		var countResult []map[string]int
+		if err := json.Unmarshal(countData, &countResult); err == nil && len(countResult) > 0 {
+			total = countResult[0]["count"]
+		}
+	}
+
+	if len(posts) == 0 {
+		return []models.Post{}, total, nil
+	}
+	return posts, total, nil
+}
+
+// UpdateDraft updates a draft only if it is still at revision, so concurrent saves can't overwrite each other
+// Returns false if it was saved, published or discarded in the meantime
+func (r *SupabasePostRepository) UpdateDraft(ctx context.Context, postID uuid.UUID, revision int, updates map[string]interface{}) (bool, error) {
+	if _, ok := updates["updated_at"]; !ok {
+		updates["updated_at"] = time.Now()
+	}
+
+	query := fmt.Sprintf("?id=eq.%s&%s&draft_revision=eq.%d", postID.String(), draftFilter, revision)
+	data, err := r.makeRequest("PATCH", "posts", query, updates)
+	if err != nil {
+		return false, fmt.Errorf("failed to update draft: %w", err)
+	}
+
+	var updated []map[string]interface{}
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return false, err
+	}
+
+	return len(updated) > 0, nil
+}
+
+// DeleteDraft deletes a draft with its revisions, drafts aren't kept with deleted posts
+// Returns false if it isn't a draft anymore
+func (r *SupabasePostRepository) DeleteDraft(ctx context.Context, postID uuid.UUID) (bool, error) {
+	query := fmt.Sprintf("?id=eq.%s&%s", postID.String(), draftFilter)
+	data, err := r.makeRequest("DELETE", "posts", query, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete draft: %w", err)
+	}
+
+	var deleted []map[string]interface{}
+	if err := json.Unmarshal(data, &deleted); err != nil {
+		return false, err
+	}
+
+	return len(deleted) > 0, nil
+}
+
+// CreateDraftRevision records a state of a draft
+func (r *SupabasePostRepository) CreateDraftRevision(ctx context.Context, revision *models.DraftRevision) error {
+	if revision.ID == uuid.Nil {
+		revision.ID = uuid.New()
+	}
+	now := time.Now()
+	revision.CreatedAt, revision.UpdatedAt = now, now
+
+	payload := map[string]interface{}{
+		"id":         revision.ID,
+		"post_id":    revision.PostID,
+		"revision":   revision.Revision,
+		"content":    revision.Content,
+		"created_at": now,
+		"updated_at": now,
+	}
+
+	if _, err := r.makeRequest("POST", draftRevisionsTable, "", payload); err != nil {
+		return fmt.Errorf("failed to create draft revision: %w", err)
+	}
+	return nil
+}
+
+// UpdateDraftRevision replaces the state kept in a revision with a later save
+func (r *SupabasePostRepository) UpdateDraftRevision(ctx context.Context, revisionID uuid.UUID, revision int, content models.DraftContent) error {
+	updates := map[string]interface{}{
+		"revision":   revision,
+		"content":    content,
+		"updated_at": time.Now(),
+	}
+
+	query := fmt.Sprintf("?id=eq.%s", revisionID.String())
+	if _, err := r.makeRequest("PATCH", draftRevisionsTable, query, updates); err != nil {
+		return fmt.Errorf("failed to update draft revision: %w", err)
+	}
+	return nil
+}
+
+// GetDraftRevisions retrieves the revisions of a draft, the latest first
+func (r *SupabasePostRepository) GetDraftRevisions(ctx context.Context, postID uuid.UUID, limit int) ([]models.DraftRevision, error) {
+	query := fmt.Sprintf("?post_id=eq.%s&order=revision.desc&limit=%d", postID.String(), limit)
+	data, err := r.makeRequest("GET", draftRevisionsTable, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft revisions: %w", err)
+	}
+
+	revisions := []models.DraftRevision{}
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, fmt.Errorf("failed to parse draft revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetDraftRevision retrieves one revision of a draft
+func (r *SupabasePostRepository) GetDraftRevision(ctx context.Context, postID, revisionID uuid.UUID) (*models.DraftRevision, error) {
+	query := fmt.Sprintf("?id=eq.%s&post_id=eq.%s", revisionID.String(), postID.String())
+	data, err := r.makeRequest("GET", draftRevisionsTable, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft revision: %w", err)
+	}
+
+	var revisions []models.DraftRevision
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, fmt.Errorf("failed to parse draft revision: %w", err)
+	}
+	if len(revisions) == 0 {
+		return nil, models.ErrPostNotFound
+	}
+	return &revisions[0], nil
+}
+
+// PruneDraftRevisions deletes the revisions of a draft beyond the latest keep
+func (r *SupabasePostRepository) PruneDraftRevisions(ctx context.Context, postID uuid.UUID, keep int) error {
+	query := fmt.Sprintf("?post_id=eq.%s&select=id&order=revision.desc&offset=%d&limit=100", postID.String(), keep)
+	data, err := r.makeRequest("GET", draftRevisionsTable, query, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get old draft revisions: %w", err)
+	}
+
+	var old []struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if err := json.Unmarshal(data, &old); err != nil || len(old) == 0 {
+		return err
+	}
+
+	ids := make([]string, len(old))
+	for i, revision := range old {
+		ids[i] = revision.ID.String()
+	}
+	query = fmt.Sprintf("?id=in.(%s)", strings.Join(ids, ","))
+	if _, err := r.makeRequest("DELETE", draftRevisionsTable, query, nil); err != nil {
+		return fmt.Errorf("failed to delete old draft revisions: %w", err)
+	}
+	return nil
+}
+
 // DeletePost soft deletes a post
 func (r *SupabasePostRepository) DeletePost(ctx context.Context, postID, userID uuid.UUID) error {
 	// Verify ownership
@@ -1045,7 +1420,8 @@ func (r *SupabasePostRepository) GetSavedPosts(ctx context.Context, userID uuid.
 		postIDs[i] = record.PostID.String()
 	}
 
-	postsQuery := fmt.Sprintf("?id=in.(%s)&select=*", strings.Join(postIDs, ","))
+	// Drafts never show up among saved posts, even if their ID was saved
+	postsQuery := fmt.Sprintf("?id=in.(%s)&is_published=eq.true&select=*", strings.Join(postIDs, ","))
 	postsData, err := r.makeRequest("GET", "posts", postsQuery, nil)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get posts: %w", err)
@@ -1164,7 +1540,7 @@ func (r *SupabasePostRepository) SearchPosts(ctx context.Context, query string,
 
 // ExtractAndCreateHashtags extracts hashtags from content and creates associations
 func (r *SupabasePostRepository) ExtractAndCreateHashtags(ctx context.Context, postID uuid.UUID, content string) error {
-	hashtags := extractHashtags(content)
+	hashtags := ExtractHashtags(content)
 
 	for _, tag := range hashtags {
 		// Get or create hashtag
@@ -1186,8 +1562,30 @@ func (r *SupabasePostRepository) ExtractAndCreateHashtags(ctx context.Context, p
 }
 
 // ExtractAndCreateMentions extracts @mentions and creates associations
-func (r *SupabasePostRepository) ExtractAndCreateMentions(ctx context.Context, postID uuid.UUID, content string) error {
+// Returns the IDs of the mentioned users that exist
+func (r *SupabasePostRepository) ExtractAndCreateMentions(ctx context.Context, postID uuid.UUID, content string) ([]uuid.UUID, error) {
+	mentionedIDs, err := r.ResolveMentions(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range mentionedIDs {
+		// Create mention
+		payload := map[string]interface{}{
+			"post_id":           postID,
+			"mentioned_user_id": userID,
+		}
+
+		r.makeRequest("POST", "post_mentions", "", payload)
+	}
+
+	return mentionedIDs, nil
+}
+
+// ResolveMentions returns the IDs of the users @mentioned in text, skipping unknown usernames
+func (r *SupabasePostRepository) ResolveMentions(ctx context.Context, content string) ([]uuid.UUID, error) {
 	mentions := extractMentions(content)
+	mentionedIDs := make([]uuid.UUID, 0, len(mentions))
 
 	for _, username := range mentions {
 		// Get user ID by username
@@ -1204,16 +1602,10 @@ func (r *SupabasePostRepository) ExtractAndCreateMentions(ctx context.Context, p
 			continue
 		}
 
-		// Create mention
-		payload := map[string]interface{}{
-			"post_id":           postID,
-			"mentioned_user_id": users[0].ID,
-		}
-
-		r.makeRequest("POST", "post_mentions", "", payload)
+		mentionedIDs = append(mentionedIDs, users[0].ID)
 	}
 
-	return nil
+	return mentionedIDs, nil
 }
 
 // GetHashtagByTag retrieves a hashtag by its tag
@@ -1948,7 +2340,7 @@ func (r *SupabasePostRepository) getOrCreateHashtag(ctx context.Context, tag str
 }
 
 // extractHashtags extracts hashtags from text
-func extractHashtags(text string) []string {
+func ExtractHashtags(text string) []string {
 	re := regexp.MustCompile(`#([a-zA-Z0-9_]+)`)
 	matches := re.FindAllStringSubmatch(text, -1)
 
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// SupabasePushSubscriptionRepository implements PushSubscriptionRepository for Supabase
+type SupabasePushSubscriptionRepository struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewSupabasePushSubscriptionRepository creates a new Supabase push subscription repository
+func NewSupabasePushSubscriptionRepository(baseURL, apiKey string) *SupabasePushSubscriptionRepository {
+	return &SupabasePushSubscriptionRepository{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// pushSubscriptionRow mirrors the push_subscriptions table
+type pushSubscriptionRow struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Endpoint   string     `json:"endpoint"`
+	P256dh     string     `json:"p256dh"`
+	Auth       string     `json:"auth"`
+	UserAgent  string     `json:"user_agent"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (row *pushSubscriptionRow) toModel() *models.PushSubscription {
+	return &models.PushSubscription{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Endpoint:   row.Endpoint,
+		P256dh:     row.P256dh,
+		Auth:       row.Auth,
+		UserAgent:  row.UserAgent,
+		ExpiresAt:  row.ExpiresAt,
+		LastUsedAt: row.LastUsedAt,
+		CreatedAt:  row.CreatedAt,
+	}
+}
+
+func (r *SupabasePushSubscriptionRepository) subscriptionsURL(query url.Values) string {
+	u := fmt.Sprintf("%s/rest/v1/push_subscriptions", r.baseURL)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (r *SupabasePushSubscriptionRepository) setHeaders(req *http.Request, prefer string) {
+	req.Header.Set("apikey", r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+}
+
+// UpsertSubscription stores a subscription keyed by its endpoint
+// A browser re-subscribing (or a different user signing in on it) replaces the previous row
+func (r *SupabasePushSubscriptionRepository) UpsertSubscription(ctx context.Context, subscription *models.PushSubscription) error {
+	subscriptionData := map[string]interface{}{
+		"user_id":    subscription.UserID,
+		"endpoint":   subscription.Endpoint,
+		"p256dh":     subscription.P256dh,
+		"auth":       subscription.Auth,
+		"user_agent": subscription.UserAgent,
+		"expires_at": subscription.ExpiresAt,
+		"created_at": time.Now(),
+	}
+
+	body, err := json.Marshal(subscriptionData)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("on_conflict", "endpoint")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.subscriptionsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "resolution=merge-duplicates,return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] UpsertSubscription failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	var rows []pushSubscriptionRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return apperr.ErrDatabaseError
+	}
+
+	if len(rows) > 0 {
+		subscription.ID = rows[0].ID
+		subscription.CreatedAt = rows[0].CreatedAt
+	}
+
+	return nil
+}
+
+// GetSubscriptionsByUserID retrieves every browser a user has subscribed for push
+func (r *SupabasePushSubscriptionRepository) GetSubscriptionsByUserID(ctx context.Context, userID uuid.UUID) ([]*models.PushSubscription, error) {
+	q := url.Values{}
+	q.Set("user_id", "eq."+userID.String())
+	q.Set("select", "*")
+	q.Set("order", "created_at.asc")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.subscriptionsURL(q), nil)
+	if err != nil {
+		return nil, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] fetch push subscriptions failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return nil, apperr.ErrDatabaseError
+	}
+
+	var rows []pushSubscriptionRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+
+	result := make([]*models.PushSubscription, len(rows))
+	for i := range rows {
+		result[i] = rows[i].toModel()
+	}
+
+	return result, nil
+}
+
+// MarkSubscriptionUsed records a successful delivery
+func (r *SupabasePushSubscriptionRepository) MarkSubscriptionUsed(ctx context.Context, subscriptionID uuid.UUID) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"last_used_at": time.Now(),
+	})
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+subscriptionID.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.subscriptionsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] MarkSubscriptionUsed failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
+
+// DeleteSubscription removes a user's subscription for an endpoint
+func (r *SupabasePushSubscriptionRepository) DeleteSubscription(ctx context.Context, userID uuid.UUID, endpoint string) error {
+	q := url.Values{}
+	q.Set("user_id", "eq."+userID.String())
+	q.Set("endpoint", "eq."+endpoint)
+
+	return r.delete(ctx, q)
+}
+
+// DeleteSubscriptionByID removes a subscription by its ID
+func (r *SupabasePushSubscriptionRepository) DeleteSubscriptionByID(ctx context.Context, subscriptionID uuid.UUID) error {
+	q := url.Values{}
+	q.Set("id", "eq."+subscriptionID.String())
+
+	return r.delete(ctx, q)
+}
+
+// DeleteExpiredSubscriptions removes subscriptions whose expiration time has passed
+func (r *SupabasePushSubscriptionRepository) DeleteExpiredSubscriptions(ctx context.Context) (int, error) {
+	q := url.Values{}
+	q.Set("expires_at", "lt."+time.Now().UTC().Format(time.RFC3339))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, r.subscriptionsURL(q), nil)
+	if err != nil {
+		return 0, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "count=exact,return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return 0, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] DeleteExpiredSubscriptions failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return 0, apperr.ErrDatabaseError
+	}
+
+	// Parse count from Content-Range header
+	deletedCount := 0
+	if contentRange := resp.Header.Get("Content-Range"); contentRange != "" {
+		var total int
+		if n, _ := fmt.Sscanf(contentRange, "*/%d", &total); n == 1 {
+			deletedCount = total
+		}
+	}
+
+	return deletedCount, nil
+}
+
+func (r *SupabasePushSubscriptionRepository) delete(ctx context.Context, q url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, r.subscriptionsURL(q), nil)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] delete push subscription failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// SupabaseQuarantineRepository implements QuarantineRepository for Supabase
+type SupabaseQuarantineRepository struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewSupabaseQuarantineRepository creates a new Supabase quarantine repository
+func NewSupabaseQuarantineRepository(baseURL, apiKey string) *SupabaseQuarantineRepository {
+	return &SupabaseQuarantineRepository{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (r *SupabaseQuarantineRepository) quarantineURL() string {
+	return fmt.Sprintf("%s/rest/v1/quarantined_files", r.baseURL)
+}
+
+func (r *SupabaseQuarantineRepository) setHeaders(req *http.Request, prefer string) {
+	req.Header.Set("apikey", r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+}
+
+// CreateQuarantinedFile records a quarantined upload
+func (r *SupabaseQuarantineRepository) CreateQuarantinedFile(ctx context.Context, file *models.QuarantinedFile) error {
+	if file.ID == uuid.Nil {
+		file.ID = uuid.New()
+	}
+	file.CreatedAt = time.Now()
+
+	row := map[string]interface{}{
+		"id":            file.ID,
+		"user_id":       file.UserID,
+		"bucket":        file.Bucket,
+		"object_key":    file.ObjectKey,
+		"target_bucket": file.TargetBucket,
+		"filename":      file.Filename,
+		"declared_type": file.DeclaredType,
+		"detected_type": file.DetectedType,
+		"size":          file.Size,
+		"sha256":        file.SHA256,
+		"reason":        file.Reason,
+		"threat":        file.Threat,
+		"created_at":    file.CreatedAt.Format(time.RFC3339Nano),
+	}
+
+	body, err := json.Marshal(row)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.quarantineURL(), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] CreateQuarantinedFile failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
@@ -41,6 +41,15 @@ func (r *SupabaseSessionRepository) sessionsURL(query url.Values) string {
 	return u
 }
 
+// retiredTokensURL is where the refresh tokens sessions rotated away from are kept
+func (r *SupabaseSessionRepository) retiredTokensURL(query url.Values) string {
+	u := fmt.Sprintf("%s/rest/v1/session_retired_tokens", r.baseURL)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
 func (r *SupabaseSessionRepository) setHeaders(req *http.Request, prefer string) {
 	req.Header.Set("apikey", r.apiKey)
 	req.Header.Set("Authorization", "Bearer "+r.apiKey)
@@ -53,13 +62,14 @@ func (r *SupabaseSessionRepository) setHeaders(req *http.Request, prefer string)
 // CreateSession creates a new session
 func (r *SupabaseSessionRepository) CreateSession(ctx context.Context, session *models.UserSession) error {
 	sessionData := map[string]interface{}{
-		"user_id":     session.UserID,
-		"token_hash":  session.TokenHash,
-		"device_info": session.DeviceInfo,
-		"ip_address":  session.IPAddress,
-		"user_agent":  session.UserAgent,
-		"expires_at":  session.ExpiresAt,
-		"created_at":  time.Now(),
+		"user_id":      session.UserID,
+		"token_hash":   session.TokenHash,
+		"device_info":  session.DeviceInfo,
+		"ip_address":   session.IPAddress,
+		"user_agent":   session.UserAgent,
+		"expires_at":   session.ExpiresAt,
+		"last_used_at": time.Now(),
+		"created_at":   time.Now(),
 	}
 
 	body, err := json.Marshal(sessionData)
@@ -208,6 +218,126 @@ func (r *SupabaseSessionRepository) GetSessionByTokenHash(ctx context.Context, t
 	return &sessions[0], nil
 }
 
+// GetSessionByRetiredTokenHash retrieves the session a refresh token was rotated away from, however long ago
+// A match means an already-used refresh token is being replayed
+func (r *SupabaseSessionRepository) GetSessionByRetiredTokenHash(ctx context.Context, tokenHash string) (*models.UserSession, error) {
+	q := url.Values{}
+	q.Set("token_hash", "eq."+tokenHash)
+	q.Set("select", "session_id")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.retiredTokensURL(q), nil)
+	if err != nil {
+		return nil, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, apperr.ErrDatabaseError
+	}
+
+	var retired []struct {
+		SessionID uuid.UUID `json:"session_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&retired); err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+
+	if len(retired) == 0 {
+		return nil, apperr.NewAppError(404, "Session not found")
+	}
+
+	return r.GetSessionByID(ctx, retired[0].SessionID)
+}
+
+// RetireSessionToken records a refresh token hash of the session as used
+// Recording a hash twice is ignored, it is the same token
+func (r *SupabaseSessionRepository) RetireSessionToken(ctx context.Context, sessionID uuid.UUID, tokenHash string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"token_hash": tokenHash,
+		"session_id": sessionID,
+		"retired_at": time.Now(),
+	})
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("on_conflict", "token_hash")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.retiredTokensURL(q), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "resolution=ignore-duplicates,return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] RetireSessionToken failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
+
+// RotateSessionToken swaps the session's refresh token hash
+// The old hash must be retired first with RetireSessionToken for reuse detection
+// The update only applies if currentHash is still the active hash; false means another request rotated it first
+func (r *SupabaseSessionRepository) RotateSessionToken(ctx context.Context, sessionID uuid.UUID, currentHash, newHash string, expiresAt time.Time) (bool, error) {
+	q := url.Values{}
+	q.Set("id", "eq."+sessionID.String())
+	q.Set("token_hash", "eq."+currentHash)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"token_hash":          newHash,
+		"previous_token_hash": currentHash,
+		"expires_at":          expiresAt,
+		"last_used_at":        time.Now(),
+	})
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.sessionsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] RotateSessionToken failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return false, apperr.ErrDatabaseError
+	}
+
+	var sessions []models.UserSession
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+
+	return len(sessions) > 0, nil
+}
+
 // DeleteSession deletes a specific session
 func (r *SupabaseSessionRepository) DeleteSession(ctx context.Context, sessionID uuid.UUID) error {
 	q := url.Values{}
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"upvista-community-backend/internal/models"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// SupabaseUploadSessionRepository implements UploadSessionRepository for Supabase
+type SupabaseUploadSessionRepository struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewSupabaseUploadSessionRepository creates a new Supabase upload session repository
+func NewSupabaseUploadSessionRepository(baseURL, apiKey string) *SupabaseUploadSessionRepository {
+	return &SupabaseUploadSessionRepository{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// uploadSessionRow mirrors the upload_sessions table
+type uploadSessionRow struct {
+	ID           uuid.UUID            `json:"id"`
+	UserID       uuid.UUID            `json:"user_id"`
+	Purpose      models.UploadPurpose `json:"purpose"`
+	Filename     string               `json:"filename"`
+	ContentType  string               `json:"content_type"`
+	Size         int64                `json:"size"`
+	UploadOffset int64                `json:"upload_offset"`
+	Checksum     *string              `json:"checksum"`
+	Parts        []string             `json:"parts"`
+	Bucket       string               `json:"bucket"`
+	ObjectKey    string               `json:"object_key"`
+	Status       models.UploadStatus  `json:"status"`
+	URL          *string              `json:"url"`
+	ExpiresAt    time.Time            `json:"expires_at"`
+	CompletedAt  *time.Time           `json:"completed_at"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+func (row *uploadSessionRow) toModel() *models.UploadSession {
+	parts := row.Parts
+	if parts == nil {
+		parts = []string{}
+	}
+
+	return &models.UploadSession{
+		ID:          row.ID,
+		UserID:      row.UserID,
+		Purpose:     row.Purpose,
+		Filename:    row.Filename,
+		ContentType: row.ContentType,
+		Size:        row.Size,
+		Offset:      row.UploadOffset,
+		Checksum:    row.Checksum,
+		Parts:       parts,
+		Bucket:      row.Bucket,
+		ObjectKey:   row.ObjectKey,
+		Status:      row.Status,
+		URL:         row.URL,
+		ExpiresAt:   row.ExpiresAt,
+		CompletedAt: row.CompletedAt,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+func (r *SupabaseUploadSessionRepository) sessionsURL(query url.Values) string {
+	u := fmt.Sprintf("%s/rest/v1/upload_sessions", r.baseURL)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (r *SupabaseUploadSessionRepository) setHeaders(req *http.Request, prefer string) {
+	req.Header.Set("apikey", r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+}
+
+// CreateSession stores a new upload session, setting its ID and timestamps
+func (r *SupabaseUploadSessionRepository) CreateSession(ctx context.Context, session *models.UploadSession) error {
+	now := time.Now()
+	sessionData := map[string]interface{}{
+		"user_id":       session.UserID,
+		"purpose":       session.Purpose,
+		"filename":      session.Filename,
+		"content_type":  session.ContentType,
+		"size":          session.Size,
+		"upload_offset": 0,
+		"checksum":      session.Checksum,
+		"parts":         []string{},
+		"bucket":        session.Bucket,
+		"object_key":    session.ObjectKey,
+		"status":        models.UploadStatusUploading,
+		"expires_at":    session.ExpiresAt.UTC().Format(time.RFC3339Nano),
+		"created_at":    now,
+		"updated_at":    now,
+	}
+
+	body, err := json.Marshal(sessionData)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.sessionsURL(nil), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] CreateUploadSession failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	var rows []uploadSessionRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return apperr.ErrDatabaseError
+	}
+
+	if len(rows) > 0 {
+		*session = *rows[0].toModel()
+	}
+
+	return nil
+}
+
+// GetSession retrieves an upload session, nil if it doesn't exist
+func (r *SupabaseUploadSessionRepository) GetSession(ctx context.Context, id uuid.UUID) (*models.UploadSession, error) {
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("select", "*")
+
+	sessions, err := r.list(ctx, q, "GetUploadSession")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+
+	return sessions[0], nil
+}
+
+// AppendPart records a stored chunk if the session is still at fromOffset
+// The offset filter makes concurrent chunks for the same offset race safely, only one of them is recorded
+func (r *SupabaseUploadSessionRepository) AppendPart(ctx context.Context, id uuid.UUID, fromOffset, toOffset int64, parts []string, expiresAt time.Time) (bool, error) {
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("upload_offset", "eq."+strconv.FormatInt(fromOffset, 10))
+	q.Set("status", "eq."+string(models.UploadStatusUploading))
+	q.Set("select", "id")
+
+	return r.claim(ctx, q, map[string]interface{}{
+		"upload_offset": toOffset,
+		"parts":         parts,
+		"expires_at":    expiresAt.UTC().Format(time.RFC3339Nano),
+		"updated_at":    time.Now(),
+	}, "AppendUploadPart")
+}
+
+// UpdateStatus moves a session from one status to another
+func (r *SupabaseUploadSessionRepository) UpdateStatus(ctx context.Context, id uuid.UUID, from, to models.UploadStatus) (bool, error) {
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+	q.Set("status", "eq."+string(from))
+	q.Set("select", "id")
+
+	return r.claim(ctx, q, map[string]interface{}{
+		"status":     to,
+		"updated_at": time.Now(),
+	}, "UpdateUploadStatus")
+}
+
+// MarkCompleted records the URL of the assembled file, the session is kept until expiresAt for status queries
+func (r *SupabaseUploadSessionRepository) MarkCompleted(ctx context.Context, id uuid.UUID, fileURL string, expiresAt time.Time) error {
+	now := time.Now()
+	body, err := json.Marshal(map[string]interface{}{
+		"status":       models.UploadStatusCompleted,
+		"url":          fileURL,
+		"parts":        []string{},
+		"expires_at":   expiresAt.UTC().Format(time.RFC3339Nano),
+		"completed_at": now,
+		"updated_at":   now,
+	})
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.sessionsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=minimal")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] MarkUploadCompleted failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
+
+// GetExpiredSessions returns sessions that expired before the given time, oldest first
+func (r *SupabaseUploadSessionRepository) GetExpiredSessions(ctx context.Context, before time.Time, limit int) ([]*models.UploadSession, error) {
+	q := url.Values{}
+	q.Set("select", "*")
+	q.Set("expires_at", "lt."+before.UTC().Format(time.RFC3339Nano))
+	q.Set("order", "expires_at.asc")
+	q.Set("limit", strconv.Itoa(limit))
+
+	return r.list(ctx, q, "GetExpiredUploadSessions")
+}
+
+// DeleteSession deletes an upload session
+func (r *SupabaseUploadSessionRepository) DeleteSession(ctx context.Context, id uuid.UUID) error {
+	q := url.Values{}
+	q.Set("id", "eq."+id.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, r.sessionsURL(q), nil)
+	if err != nil {
+		return apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] DeleteUploadSession failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return apperr.ErrDatabaseError
+	}
+
+	return nil
+}
+
+// claim applies a filtered PATCH and reports whether a row matched the filter
+func (r *SupabaseUploadSessionRepository) claim(ctx context.Context, q url.Values, update map[string]interface{}, operation string) (bool, error) {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.sessionsURL(q), bytes.NewReader(body))
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "return=representation")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] %s failed: HTTP %d - %s", operation, resp.StatusCode, string(bodyBytes))
+		return false, apperr.ErrDatabaseError
+	}
+
+	var claimed []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claimed); err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+
+	return len(claimed) > 0, nil
+}
+
+func (r *SupabaseUploadSessionRepository) list(ctx context.Context, q url.Values, operation string) ([]*models.UploadSession, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.sessionsURL(q), nil)
+	if err != nil {
+		return nil, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(req, "")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Supabase] %s failed: HTTP %d - %s", operation, resp.StatusCode, string(bodyBytes))
+		return nil, apperr.ErrDatabaseError
+	}
+
+	var rows []uploadSessionRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, apperr.ErrDatabaseError
+	}
+
+	result := make([]*models.UploadSession, len(rows))
+	for i := range rows {
+		result[i] = rows[i].toModel()
+	}
+
+	return result, nil
+}
@@ -137,6 +137,9 @@ func (r *SupabaseUserRepository) CreateUser(ctx context.Context, user *models.Us
 	if user.OAuthProvider != nil {
 		userData["oauth_provider"] = *user.OAuthProvider
 	}
+	if user.Language != "" {
+		userData["language"] = user.Language
+	}
 	if user.ProfilePicture != nil {
 		userData["profile_picture"] = *user.ProfilePicture
 	}
@@ -270,12 +273,41 @@ func (r *SupabaseUserRepository) fetchOne(ctx context.Context, q url.Values) (*m
 			user.PendingEmailExpiresAt = &t
 		}
 	}
+	// Parse two-factor authentication fields
+	if twoFactorEnabled, ok := rawUser["two_factor_enabled"].(bool); ok {
+		user.TwoFactorEnabled = twoFactorEnabled
+	}
+	if twoFactorSecret, ok := rawUser["two_factor_secret"].(string); ok && twoFactorSecret != "" {
+		user.TwoFactorSecret = &twoFactorSecret
+	}
+	if recoveryCodesRaw, ok := rawUser["two_factor_recovery_codes"].([]interface{}); ok {
+		recoveryCodes := make([]string, 0, len(recoveryCodesRaw))
+		for _, v := range recoveryCodesRaw {
+			if code, ok := v.(string); ok && code != "" {
+				recoveryCodes = append(recoveryCodes, code)
+			}
+		}
+		user.TwoFactorRecoveryCodes = recoveryCodes
+	}
+	if twoFactorEnabledAtStr, ok := rawUser["two_factor_enabled_at"].(string); ok && twoFactorEnabledAtStr != "" {
+		t := parseFlexibleTime(twoFactorEnabledAtStr)
+		if !t.IsZero() {
+			user.TwoFactorEnabledAt = &t
+		}
+	}
+	if twoFactorLastStep, ok := rawUser["two_factor_last_step"].(float64); ok {
+		user.TwoFactorLastStep = int64(twoFactorLastStep)
+	}
+
 	if oauthProvider, ok := rawUser["oauth_provider"].(string); ok && oauthProvider != "" {
 		user.OAuthProvider = &oauthProvider
 	}
 	if profilePicture, ok := rawUser["profile_picture"].(string); ok && profilePicture != "" {
 		user.ProfilePicture = &profilePicture
 	}
+	if language, ok := rawUser["language"].(string); ok && language != "" {
+		user.Language = language
+	}
 
 	// Parse Profile System Phase 1 fields
 	if bio, ok := rawUser["bio"].(string); ok && bio != "" {
@@ -1425,6 +1457,118 @@ func (r *SupabaseUserRepository) DecrementFollowingCount(ctx context.Context, us
 	return r.updateUserFields(ctx, userID, update)
 }
 
+// SetTwoFactorSecret stores a pending TOTP secret (2FA stays disabled until the first code is verified)
+func (r *SupabaseUserRepository) SetTwoFactorSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	update := map[string]interface{}{
+		"two_factor_secret": secret,
+		"updated_at":        time.Now().Format("2006-01-02T15:04:05.999999999Z07:00"),
+	}
+
+	return r.updateUserFields(ctx, userID, update)
+}
+
+// EnableTwoFactor turns on 2FA and stores the hashed recovery codes
+func (r *SupabaseUserRepository) EnableTwoFactor(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error {
+	now := time.Now().Format("2006-01-02T15:04:05.999999999Z07:00")
+	update := map[string]interface{}{
+		"two_factor_enabled":        true,
+		"two_factor_recovery_codes": recoveryCodeHashes,
+		"two_factor_enabled_at":     now,
+		"updated_at":                now,
+	}
+
+	return r.updateUserFields(ctx, userID, update)
+}
+
+// DisableTwoFactor turns off 2FA and clears the secret and recovery codes
+func (r *SupabaseUserRepository) DisableTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	update := map[string]interface{}{
+		"two_factor_enabled":        false,
+		"two_factor_secret":         nil,
+		"two_factor_recovery_codes": []string{},
+		"two_factor_enabled_at":     nil,
+		"two_factor_last_step":      nil,
+		"updated_at":                time.Now().Format("2006-01-02T15:04:05.999999999Z07:00"),
+	}
+
+	return r.updateUserFields(ctx, userID, update)
+}
+
+// UpdateRecoveryCodes replaces the stored recovery code hashes (used after consuming or regenerating codes)
+func (r *SupabaseUserRepository) UpdateRecoveryCodes(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error {
+	update := map[string]interface{}{
+		"two_factor_recovery_codes": recoveryCodeHashes,
+		"updated_at":                time.Now().Format("2006-01-02T15:04:05.999999999Z07:00"),
+	}
+
+	return r.updateUserFields(ctx, userID, update)
+}
+
+// UseRecoveryCode stores the recovery codes left after one is used, only if they are still previousHashes
+// Returns false if codes were used or regenerated in the meantime, so a code can't be used twice concurrently
+func (r *SupabaseUserRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, previousHashes, remainingHashes []string) (bool, error) {
+	update := map[string]interface{}{
+		"two_factor_recovery_codes": remainingHashes,
+		"updated_at":                time.Now().Format("2006-01-02T15:04:05.999999999Z07:00"),
+	}
+
+	q := url.Values{}
+	q.Set("two_factor_recovery_codes", "eq.{"+strings.Join(previousHashes, ",")+"}")
+
+	return r.updateUserFieldsIf(ctx, userID, q, update)
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code, only if it is later than the last one
+// Returns false if a code of that step or a later one was already accepted
+func (r *SupabaseUserRepository) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	update := map[string]interface{}{
+		"two_factor_last_step": step,
+	}
+
+	q := url.Values{}
+	q.Set("or", fmt.Sprintf("(two_factor_last_step.is.null,two_factor_last_step.lt.%d)", step))
+
+	return r.updateUserFieldsIf(ctx, userID, q, update)
+}
+
+// updateUserFieldsIf updates user fields only if the user also matches the filters
+// Returns whether the user matched
+func (r *SupabaseUserRepository) updateUserFieldsIf(ctx context.Context, userID uuid.UUID, filters url.Values, fields map[string]interface{}) (bool, error) {
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	filters.Set("id", "eq."+userID.String())
+	filters.Set("select", "id")
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPatch, r.usersURL(filters), bytes.NewReader(body))
+	if err != nil {
+		return false, apperr.ErrInternalServer
+	}
+
+	r.setHeaders(httpReq, "return=representation")
+
+	resp, err := r.http.Do(httpReq)
+	if err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		log.Printf("[Supabase] updateUserFieldsIf failed: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return false, apperr.ErrDatabaseError
+	}
+
+	var updated []map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &updated); err != nil {
+		return false, apperr.ErrDatabaseError
+	}
+
+	return len(updated) > 0, nil
+}
+
 // Helper function to update user fields
 func (r *SupabaseUserRepository) updateUserFields(ctx context.Context, userID uuid.UUID, fields map[string]interface{}) error {
 	body, err := json.Marshal(fields)
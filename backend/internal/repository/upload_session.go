@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// UploadSessionRepository defines the data-access contract for resumable upload sessions
+type UploadSessionRepository interface {
+	CreateSession(ctx context.Context, session *models.UploadSession) error
+	// GetSession returns nil if the session doesn't exist
+	GetSession(ctx context.Context, id uuid.UUID) (*models.UploadSession, error)
+	// AppendPart records a stored chunk, returns false if the offset moved since fromOffset (a concurrent chunk won)
+	AppendPart(ctx context.Context, id uuid.UUID, fromOffset, toOffset int64, parts []string, expiresAt time.Time) (bool, error)
+	// UpdateStatus moves a session between statuses, returns false if it wasn't in the from status
+	UpdateStatus(ctx context.Context, id uuid.UUID, from, to models.UploadStatus) (bool, error)
+	MarkCompleted(ctx context.Context, id uuid.UUID, url string, expiresAt time.Time) error
+	// GetExpiredSessions returns sessions that expired before the given time, oldest first
+	GetExpiredSessions(ctx context.Context, before time.Time, limit int) ([]*models.UploadSession, error)
+	DeleteSession(ctx context.Context, id uuid.UUID) error
+}
@@ -47,6 +47,14 @@ type UserRepository interface {
 	UpdateSocialLinks(ctx context.Context, userID uuid.UUID, socialLinks map[string]*string) error
 	UpdateStatVisibility(ctx context.Context, userID uuid.UUID, statVisibility map[string]bool) error
 
+	// Two-factor authentication
+	SetTwoFactorSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	EnableTwoFactor(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID) error
+	UpdateRecoveryCodes(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, previousHashes, remainingHashes []string) (bool, error)
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+
 	// Search
 	SearchUsers(ctx context.Context, query string, limit int, offset int) ([]*models.User, int, error)
 
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocalHandlers serves objects of the local driver
+// Public buckets are served to anyone, private buckets only through unexpired signed URLs
+type LocalHandlers struct {
+	storage        *LocalStorage
+	privateBuckets map[string]bool
+}
+
+// NewLocalHandlers creates handlers serving local objects
+func NewLocalHandlers(storage *LocalStorage, privateBuckets []string) *LocalHandlers {
+	private := make(map[string]bool, len(privateBuckets))
+	for _, bucket := range privateBuckets {
+		private[bucket] = true
+	}
+
+	return &LocalHandlers{
+		storage:        storage,
+		privateBuckets: private,
+	}
+}
+
+// ServeObject streams an object, with Range support for audio and video
+// GET /media/:bucket/*key
+func (h *LocalHandlers) ServeObject(c *gin.Context) {
+	bucket := c.Param("bucket")
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if ValidateObject(bucket, key) != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "File not found",
+		})
+		return
+	}
+
+	if h.privateBuckets[bucket] && !h.storage.VerifySignature(bucket, key, c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "Invalid or expired link",
+		})
+		return
+	}
+
+	file, info, contentType, err := h.storage.open(bucket, key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "File not found",
+		})
+		return
+	}
+	defer file.Close()
+
+	// Objects stored before content types were recorded fall back to their extension
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	// Objects are served from the API origin: only media is shown inline, and nothing can run scripts
+	c.Header("Content-Security-Policy", "sandbox")
+	if !servedInline(contentType) {
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)}))
+	}
+	if h.privateBuckets[bucket] {
+		c.Header("Cache-Control", "private, no-store")
+	} else {
+		c.Header("Cache-Control", "public, max-age=86400")
+	}
+
+	http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime(), file)
+}
+
+// servedInline reports whether a content type is shown in the browser rather than downloaded
+// SVG is an image that can hold scripts, it is downloaded like documents
+func servedInline(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "image/svg+xml" {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "video/") || strings.HasPrefix(mediaType, "audio/")
+}
+
+// SetupRoutes registers the object route on the root router
+func (h *LocalHandlers) SetupRoutes(router gin.IRoutes) {
+	router.GET(localMediaPath+":bucket/*key", h.ServeObject)
+	router.HEAD(localMediaPath+":bucket/*key", h.ServeObject)
+}
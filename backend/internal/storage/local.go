@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Objects of the local driver are served by LocalHandlers under this path
+const localMediaPath = "/media/"
+
+// Content types given at upload are kept under this directory, mirroring the objects
+// It isn't a valid bucket name, so no object can be stored there
+const contentTypesDir = ".content-types"
+
+// LocalStorage stores objects on the local filesystem as <dir>/<bucket>/<key> (development, single server)
+type LocalStorage struct {
+	dir        string
+	publicURL  string
+	signingKey []byte
+}
+
+// NewLocalStorage creates a local filesystem driver
+// publicURL is where this server is reachable, signingKey signs URLs of private objects
+func NewLocalStorage(dir, publicURL, signingKey string) (*LocalStorage, error) {
+	if dir == "" {
+		dir = "uploads"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalStorage{
+		dir:        dir,
+		publicURL:  strings.TrimRight(publicURL, "/"),
+		signingKey: []byte("storage:" + signingKey), // Domain-separated from other uses of the key
+	}, nil
+}
+
+// Name returns "local"
+func (s *LocalStorage) Name() string {
+	return "local"
+}
+
+func (s *LocalStorage) path(bucket, key string) string {
+	return filepath.Join(s.dir, bucket, filepath.FromSlash(key))
+}
+
+func (s *LocalStorage) contentTypePath(bucket, key string) string {
+	return filepath.Join(s.dir, contentTypesDir, bucket, filepath.FromSlash(key))
+}
+
+// Put streams an object to disk through a temporary file, so readers never see a partial upload
+// The content type is recorded next to it, objects are served with it rather than one guessed from the key
+func (s *LocalStorage) Put(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error {
+	if err := ValidateObject(bucket, key); err != nil {
+		return err
+	}
+
+	path := s.path(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	// Recorded first, a replaced object is never served with the previous object's type
+	if err := s.writeContentType(bucket, key, contentType); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+
+	return nil
+}
+
+// Get opens an object
+func (s *LocalStorage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	if err := ValidateObject(bucket, key); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(s.path(bucket, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes an object
+func (s *LocalStorage) Delete(ctx context.Context, bucket, key string) error {
+	if err := ValidateObject(bucket, key); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path(bucket, key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	if err := os.Remove(s.contentTypePath(bucket, key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete content type: %w", err)
+	}
+	return nil
+}
+
+// writeContentType records an object's content type, an empty type removes the record
+func (s *LocalStorage) writeContentType(bucket, key, contentType string) error {
+	path := s.contentTypePath(bucket, key)
+	if contentType == "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to clear content type: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(contentType), 0o644); err != nil {
+		return fmt.Errorf("failed to record content type: %w", err)
+	}
+	return nil
+}
+
+// URL returns the URL LocalHandlers serves an object at
+func (s *LocalStorage) URL(bucket, key string) string {
+	return s.publicURL + localMediaPath + bucket + "/" + escapePath(key)
+}
+
+// ParseURL accepts URLs returned by URL and SignedURL
+func (s *LocalStorage) ParseURL(rawURL string) (string, string, bool) {
+	prefix := s.publicURL + localMediaPath
+	if !strings.HasPrefix(rawURL, prefix) {
+		return "", "", false
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", false
+	}
+	return splitObjectPath(strings.TrimPrefix(u.Path, strings.TrimSuffix(localMediaPath, "/")))
+}
+
+// SignedURL returns the object URL with an expiry and an HMAC over bucket, key and expiry
+func (s *LocalStorage) SignedURL(ctx context.Context, bucket, key string, expiresIn time.Duration) (string, error) {
+	if err := ValidateObject(bucket, key); err != nil {
+		return "", err
+	}
+
+	expires := time.Now().Add(expiresIn).Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("signature", s.sign(bucket, key, expires))
+
+	return s.URL(bucket, key) + "?" + q.Encode(), nil
+}
+
+// VerifySignature checks a signed URL's expiry and signature
+func (s *LocalStorage) VerifySignature(bucket, key, expires, signature string) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.sign(bucket, key, expiresAt)))
+}
+
+func (s *LocalStorage) sign(bucket, key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "%s\n%s\n%d", bucket, key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// open opens an object for serving, with its size, modification time and the content type given at upload
+// The content type is empty for objects stored before types were recorded
+func (s *LocalStorage) open(bucket, key string) (*os.File, os.FileInfo, string, error) {
+	file, err := os.Open(s.path(bucket, key))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return nil, nil, "", os.ErrNotExist
+	}
+
+	contentType, _ := os.ReadFile(s.contentTypePath(bucket, key))
+	return file, info, strings.TrimSpace(string(contentType)), nil
+}
@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 limits presigned URLs to 7 days
+const s3MaxPresignExpiry = 7 * 24 * time.Hour
+
+// S3Options configures the S3-compatible driver
+type S3Options struct {
+	Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000 (MinIO)
+	Region          string
+	Bucket          string // Storage buckets are key prefixes in this S3 bucket
+	AccessKeyID     string
+	SecretAccessKey string
+	ForcePathStyle  bool   // endpoint/bucket/key instead of bucket.endpoint/key (MinIO)
+	PublicURL       string // Optional CDN or public bucket URL for permanent object URLs
+}
+
+// S3Storage stores objects in an S3-compatible service, requests are signed with AWS Signature V4
+type S3Storage struct {
+	opts     S3Options
+	endpoint *url.URL
+	http     *http.Client
+}
+
+// NewS3Storage creates an S3-compatible driver
+func NewS3Storage(opts S3Options) (*S3Storage, error) {
+	endpoint, err := url.Parse(strings.TrimRight(opts.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint: %q", opts.Endpoint)
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	opts.PublicURL = strings.TrimRight(opts.PublicURL, "/")
+
+	return &S3Storage{
+		opts:     opts,
+		endpoint: endpoint,
+		http:     &http.Client{},
+	}, nil
+}
+
+// Name returns "s3"
+func (s *S3Storage) Name() string {
+	return "s3"
+}
+
+// objectURL is the S3 API URL of an object
+func (s *S3Storage) objectURL(bucket, key string) *url.URL {
+	u := *s.endpoint
+	objectPath := "/" + bucket + "/" + key
+	if s.opts.ForcePathStyle {
+		objectPath = "/" + s.opts.Bucket + objectPath
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+	}
+	u.Path = objectPath
+	u.RawPath = escapePath(objectPath) // Strictly encoded, sent and signed as is
+	return &u
+}
+
+// Put streams an object to S3
+// S3 needs the length up front, so bodies of unknown size are spooled to a temporary file first
+func (s *S3Storage) Put(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error {
+	if err := ValidateObject(bucket, key); err != nil {
+		return err
+	}
+
+	if size < 0 {
+		spool, err := os.CreateTemp("", "s3-upload-*")
+		if err != nil {
+			return fmt.Errorf("failed to buffer upload: %w", err)
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+
+		if size, err = io.Copy(spool, body); err != nil {
+			return fmt.Errorf("failed to buffer upload: %w", err)
+		}
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to buffer upload: %w", err)
+		}
+		body = spool
+	}
+
+	if size == 0 {
+		body = http.NoBody // Otherwise sent chunked, which S3 rejects
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(bucket, key).String(), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, time.Now())
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		log.Printf("[Storage] S3 upload of %s/%s failed: HTTP %d - %s", bucket, key, resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// Get downloads an object
+func (s *S3Storage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	if err := ValidateObject(bucket, key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(bucket, key).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	s.sign(req, time.Now())
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return resp.Body, nil
+}
+
+// Delete removes an object (S3 answers 204 whether or not it existed)
+func (s *S3Storage) Delete(ctx context.Context, bucket, key string) error {
+	if err := ValidateObject(bucket, key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(bucket, key).String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	s.sign(req, time.Now())
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("delete failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// URL returns the permanent URL of an object, through the CDN when one is configured
+func (s *S3Storage) URL(bucket, key string) string {
+	if s.opts.PublicURL != "" {
+		return s.opts.PublicURL + "/" + escapePath(bucket+"/"+key)
+	}
+	return s.objectURL(bucket, key).String()
+}
+
+// ParseURL accepts permanent and presigned URLs of this bucket
+func (s *S3Storage) ParseURL(rawURL string) (string, string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", false
+	}
+
+	if s.opts.PublicURL != "" && strings.HasPrefix(rawURL, s.opts.PublicURL+"/") {
+		if base, err := url.Parse(s.opts.PublicURL); err == nil {
+			return splitObjectPath(strings.TrimPrefix(u.Path, base.Path))
+		}
+	}
+
+	base := s.objectURL("bucket", "key") // Any object, only the scheme, host and bucket prefix matter
+	if u.Scheme != base.Scheme || u.Host != base.Host {
+		return "", "", false
+	}
+	if s.opts.ForcePathStyle {
+		if !strings.HasPrefix(u.Path, "/"+s.opts.Bucket+"/") {
+			return "", "", false
+		}
+		return splitObjectPath(strings.TrimPrefix(u.Path, "/"+s.opts.Bucket))
+	}
+	return splitObjectPath(u.Path)
+}
+
+// SignedURL returns a presigned GET URL (query string authentication)
+func (s *S3Storage) SignedURL(ctx context.Context, bucket, key string, expiresIn time.Duration) (string, error) {
+	if err := ValidateObject(bucket, key); err != nil {
+		return "", err
+	}
+	if expiresIn > s3MaxPresignExpiry {
+		expiresIn = s3MaxPresignExpiry
+	}
+
+	return s.presign(s.objectURL(bucket, key), time.Now(), expiresIn), nil
+}
+
+// presign adds query string authentication to an object URL
+func (s *S3Storage) presign(u *url.URL, now time.Time, expiresIn time.Duration) string {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", s.opts.AccessKeyID+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(expiresIn.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	q.Set("X-Amz-Signature", s.signature(now, amzDate, scope, canonicalRequest))
+	u.RawQuery = canonicalQuery(q)
+
+	return u.String()
+}
+
+// sign adds AWS Signature V4 headers to a request
+// The payload is not hashed (UNSIGNED-PAYLOAD) so bodies can be streamed
+func (s *S3Storage) sign(req *http.Request, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-date":           amzDate,
+		"x-amz-content-sha256": "UNSIGNED-PAYLOAD",
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKeyID, scope, signedHeaders, s.signature(now, amzDate, scope, canonicalRequest)))
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.opts.Region + "/s3/aws4_request"
+}
+
+// signature derives the signing key for the day and signs the canonical request
+func (s *S3Storage) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery sorts and strictly encodes query parameters as Signature V4 requires
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for key := range q {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), q[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
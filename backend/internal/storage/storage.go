@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"upvista-community-backend/internal/config"
+)
+
+// ErrNotFound is returned when an object doesn't exist
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores uploaded files (objects) in buckets
+// Drivers: Supabase Storage, the local filesystem and S3-compatible services (AWS S3, MinIO)
+type Storage interface {
+	// Name identifies the driver ("supabase", "local", "s3")
+	Name() string
+	// Put streams an object into a bucket, replacing any object with the same key
+	// size is -1 when unknown
+	Put(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error
+	// Get opens an object for reading, the caller closes it
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	// Delete removes an object, deleting a missing object is not an error
+	Delete(ctx context.Context, bucket, key string) error
+	// URL is the permanent URL of an object, only reachable for public buckets
+	URL(bucket, key string) string
+	// ParseURL returns the bucket and key of a URL returned by URL, ok is false for other URLs
+	ParseURL(rawURL string) (bucket, key string, ok bool)
+	// SignedURL is a URL granting read access to an object until it expires
+	SignedURL(ctx context.Context, bucket, key string, expiresIn time.Duration) (string, error)
+}
+
+// NewStorage creates the driver selected by STORAGE_DRIVER
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.GetStorageDriver() {
+	case "supabase":
+		return NewSupabaseStorage(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey), nil
+	case "local":
+		return NewLocalStorage(cfg.Storage.LocalDir, cfg.GetStoragePublicURL(), cfg.JWT.Secret)
+	case "s3":
+		return NewS3Storage(S3Options{
+			Endpoint:        cfg.Storage.S3Endpoint,
+			Region:          cfg.Storage.S3Region,
+			Bucket:          cfg.Storage.S3Bucket,
+			AccessKeyID:     cfg.Storage.S3AccessKeyID,
+			SecretAccessKey: cfg.Storage.S3SecretAccessKey,
+			ForcePathStyle:  cfg.Storage.S3ForcePathStyle,
+			PublicURL:       cfg.Storage.PublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", cfg.Storage.Driver)
+	}
+}
+
+// Bucket names are used in paths and URLs
+var bucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidateObject rejects bucket names and keys that could escape their bucket
+func ValidateObject(bucket, key string) error {
+	if !bucketPattern.MatchString(bucket) {
+		return fmt.Errorf("invalid bucket name: %q", bucket)
+	}
+	if key == "" || len(key) > 1024 || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || strings.ContainsRune(key, 0) {
+		return fmt.Errorf("invalid object key: %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid object key: %q", key)
+		}
+	}
+	return nil
+}
+
+// splitObjectPath splits "bucket/key/parts" into its bucket and key
+func splitObjectPath(path string) (string, string, bool) {
+	bucket, key, found := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !found || ValidateObject(bucket, key) != nil {
+		return "", "", false
+	}
+	return bucket, key, true
+}
+
+// escapePath URL-encodes each segment of an object path, keeping the slashes
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode percent-encodes everything but unreserved characters (RFC 3986), as S3 signatures require
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SupabaseStorage stores objects in Supabase Storage
+type SupabaseStorage struct {
+	supabaseURL string
+	apiKey      string
+	http        *http.Client
+}
+
+// NewSupabaseStorage creates a new Supabase Storage driver
+func NewSupabaseStorage(supabaseURL, apiKey string) *SupabaseStorage {
+	return &SupabaseStorage{
+		supabaseURL: strings.TrimRight(supabaseURL, "/"),
+		apiKey:      apiKey,
+		// No overall timeout, uploads stream large videos; requests are bounded by their context
+		http: &http.Client{},
+	}
+}
+
+// Name returns "supabase"
+func (s *SupabaseStorage) Name() string {
+	return "supabase"
+}
+
+func (s *SupabaseStorage) objectURL(bucket, key string) string {
+	return fmt.Sprintf("%s/storage/v1/object/%s/%s", s.supabaseURL, bucket, escapePath(key))
+}
+
+func (s *SupabaseStorage) setHeaders(req *http.Request) {
+	req.Header.Set("apikey", s.apiKey)
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+}
+
+// Put streams an object to Supabase Storage
+// Supabase Storage API format: POST /storage/v1/object/{bucket}/{path}
+func (s *SupabaseStorage) Put(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error {
+	if err := ValidateObject(bucket, key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.objectURL(bucket, key), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+
+	s.setHeaders(req)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-upsert", "true") // Allow overwriting existing files
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Printf("[Storage] Supabase upload of %s/%s failed: HTTP %d - %s", bucket, key, resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// Get downloads an object with the service key (works for private buckets)
+func (s *SupabaseStorage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	if err := ValidateObject(bucket, key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(bucket, key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	s.setHeaders(req)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		// Supabase answers 400 with a "not_found" error for missing objects
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusNotFound || bytes.Contains(bodyBytes, []byte("not_found")) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return resp.Body, nil
+}
+
+// Delete removes an object
+func (s *SupabaseStorage) Delete(ctx context.Context, bucket, key string) error {
+	if err := ValidateObject(bucket, key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(bucket, key), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	s.setHeaders(req)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Ignore 404 errors (file might already be deleted)
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// URL returns the public URL of an object
+func (s *SupabaseStorage) URL(bucket, key string) string {
+	return fmt.Sprintf("%s/storage/v1/object/public/%s/%s", s.supabaseURL, bucket, escapePath(key))
+}
+
+// ParseURL accepts public URLs (/object/public/) and signed URLs (/object/sign/) of this project
+func (s *SupabaseStorage) ParseURL(rawURL string) (string, string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.HasPrefix(rawURL, s.supabaseURL+"/") {
+		return "", "", false
+	}
+
+	for _, prefix := range []string{"/storage/v1/object/public/", "/storage/v1/object/sign/"} {
+		if strings.HasPrefix(u.Path, prefix) {
+			return splitObjectPath(strings.TrimPrefix(u.Path, prefix))
+		}
+	}
+	return "", "", false
+}
+
+// SignedURL asks Supabase to sign a download URL
+// POST /storage/v1/object/sign/{bucket}/{path} {"expiresIn": seconds}
+func (s *SupabaseStorage) SignedURL(ctx context.Context, bucket, key string, expiresIn time.Duration) (string, error) {
+	if err := ValidateObject(bucket, key); err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"expiresIn": int(expiresIn.Seconds()),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signURL := fmt.Sprintf("%s/storage/v1/object/sign/%s/%s", s.supabaseURL, bucket, escapePath(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, signURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	s.setHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("sign failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var result struct {
+		SignedURL string `json:"signedURL"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode signed URL: %w", err)
+	}
+
+	// Returned relative to the storage API: /object/sign/{bucket}/{path}?token=...
+	return s.supabaseURL + "/storage/v1" + result.SignedURL, nil
+}
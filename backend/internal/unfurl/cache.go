@@ -0,0 +1,148 @@
+package unfurl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Cache keeps fetched previews so a popular link isn't fetched for every post and message using it
+// A nil preview records a link that couldn't be previewed, so it isn't retried on every post either
+type Cache interface {
+	// Get returns the cached preview of a link, found is false when it isn't cached
+	Get(ctx context.Context, link string) (preview *models.LinkPreview, found bool)
+	// Set caches the preview of a link for ttl
+	Set(ctx context.Context, link string, preview *models.LinkPreview, ttl time.Duration)
+}
+
+// maxMemoryEntries bounds the in-memory cache, entries are evicted at random beyond it
+const maxMemoryEntries = 10000
+
+// MemoryCache keeps previews in process memory (single-node setups)
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	preview   *models.LinkPreview
+	expiresAt time.Time
+}
+
+// NewMemoryCache creates an in-memory preview cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+// Get returns the cached preview of a link
+func (mc *MemoryCache) Get(ctx context.Context, link string) (*models.LinkPreview, bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	entry, ok := mc.entries[link]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(mc.entries, link)
+		return nil, false
+	}
+	return entry.preview, true
+}
+
+// Set caches the preview of a link for ttl
+func (mc *MemoryCache) Set(ctx context.Context, link string, preview *models.LinkPreview, ttl time.Duration) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if len(mc.entries) >= maxMemoryEntries {
+		mc.evict()
+	}
+	mc.entries[link] = memoryEntry{preview: preview, expiresAt: time.Now().Add(ttl)}
+}
+
+// evict drops expired entries, and random ones if the cache is still full
+func (mc *MemoryCache) evict() {
+	now := time.Now()
+	for link, entry := range mc.entries {
+		if now.After(entry.expiresAt) {
+			delete(mc.entries, link)
+		}
+	}
+	for link := range mc.entries {
+		if len(mc.entries) < maxMemoryEntries {
+			break
+		}
+		delete(mc.entries, link)
+	}
+}
+
+// previewKeyPrefix namespaces cached previews in a shared Redis instance
+const previewKeyPrefix = "linkpreview:"
+
+// redisCacheTimeout bounds every Redis call, a slow cache only costs a fetch
+const redisCacheTimeout = 2 * time.Second
+
+// RedisCache keeps previews in Redis so every replica shares them
+type RedisCache struct {
+	redis *redis.Client
+}
+
+// NewRedisCache creates a Redis-backed preview cache
+func NewRedisCache(redisClient *redis.Client) *RedisCache {
+	return &RedisCache{
+		redis: redisClient,
+	}
+}
+
+// Get returns the cached preview of a link
+// Redis errors are logged and treated as a miss
+func (rc *RedisCache) Get(ctx context.Context, link string) (*models.LinkPreview, bool) {
+	ctx, cancel := context.WithTimeout(ctx, redisCacheTimeout)
+	defer cancel()
+
+	data, err := rc.redis.Get(ctx, previewKey(link)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("[LinkPreview] Failed to read cached preview from Redis: %v", err)
+		}
+		return nil, false
+	}
+
+	var preview *models.LinkPreview // "null" for links that can't be previewed
+	if err := json.Unmarshal(data, &preview); err != nil {
+		return nil, false
+	}
+	return preview, true
+}
+
+// Set caches the preview of a link for ttl
+func (rc *RedisCache) Set(ctx context.Context, link string, preview *models.LinkPreview, ttl time.Duration) {
+	data, err := json.Marshal(preview)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redisCacheTimeout)
+	defer cancel()
+
+	if err := rc.redis.Set(ctx, previewKey(link), data, ttl).Err(); err != nil {
+		log.Printf("[LinkPreview] Failed to cache preview in Redis: %v", err)
+	}
+}
+
+// previewKey hashes links, which can be long and contain anything
+func previewKey(link string) string {
+	hash := sha256.Sum256([]byte(link))
+	return previewKeyPrefix + hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,159 @@
+package unfurl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"upvista-community-backend/internal/utils"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Limits of fetching
+const (
+	requestTimeout = 5 * time.Second // Per request, a preview takes at most two (page and oEmbed)
+	maxPageSize    = 1 << 20         // Metadata is in the head, the rest of the page is never read
+	maxOEmbedSize  = 64 << 10
+	maxRedirects   = 5
+	maxURLLength   = 2048
+)
+
+// ErrInvalidURL means a link can't be previewed: not http(s), credentials in it, an unusual port or a local host
+var ErrInvalidURL = errors.New("invalid link")
+
+// newClient creates the HTTP client pages are fetched with: public addresses only, no proxy,
+// a few redirects at most, each checked like the original link
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: utils.PublicDialControl, // Checked after DNS resolution, on every connection
+	}
+	transport := &http.Transport{
+		Proxy:                  nil, // A proxy would connect on our behalf, past the address check
+		DialContext:            dialer.DialContext,
+		ForceAttemptHTTP2:      true,
+		TLSHandshakeTimeout:    requestTimeout,
+		ResponseHeaderTimeout:  requestTimeout,
+		MaxResponseHeaderBytes: 64 << 10,
+		MaxIdleConns:           32,
+		IdleConnTimeout:        30 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   requestTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return validateURL(req.URL)
+		},
+	}
+}
+
+// parseURL parses a link and checks it can be fetched
+func parseURL(rawURL string) (*url.URL, error) {
+	if len(rawURL) > maxURLLength {
+		return nil, fmt.Errorf("%w: too long", ErrInvalidURL)
+	}
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if err := validateURL(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// validateURL rejects what can't be a public web page: other schemes, credentials, ports other than 80 and 443,
+// and hosts that are local by name or address. Resolved addresses are checked again when connecting.
+func validateURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: only http and https links are previewed", ErrInvalidURL)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in link", ErrInvalidURL)
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		return fmt.Errorf("%w: port %s", ErrInvalidURL, port)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: local host", ErrInvalidURL)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !utils.PublicAddr(addr) {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, utils.ErrBlockedAddress)
+	}
+	return nil
+}
+
+// page is a fetched document
+type page struct {
+	url         *url.URL // Where redirects ended
+	contentType string   // Media type, without parameters
+	body        []byte   // Up to the size limit, HTML is decoded to UTF-8
+}
+
+// fetch gets a page, reading at most limit bytes of it
+// Bodies of media the preview is built from without reading them (images, video, audio) aren't read
+func (s *Service) fetch(ctx context.Context, target, accept string, limit int64) (*page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	header := resp.Header.Get("Content-Type")
+	contentType, _, _ := mime.ParseMediaType(header)
+	result := &page{url: resp.Request.URL, contentType: strings.ToLower(contentType)}
+	if isMedia(result.contentType) {
+		return result, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+
+	if isHTML(result.contentType) {
+		// Declared by the header or a meta tag, defaulting to UTF-8
+		if decoded, err := charset.NewReader(bytes.NewReader(body), header); err == nil {
+			if utf8Body, err := io.ReadAll(decoded); err == nil {
+				body = utf8Body
+			}
+		}
+	}
+	result.body = body
+	return result, nil
+}
+
+func isHTML(contentType string) bool {
+	return contentType == "text/html" || contentType == "application/xhtml+xml"
+}
+
+func isMedia(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/") || strings.HasPrefix(contentType, "video/") || strings.HasPrefix(contentType, "audio/")
+}
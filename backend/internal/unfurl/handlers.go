@@ -0,0 +1,57 @@
+package unfurl
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers manages link preview HTTP handlers
+type Handlers struct {
+	service *Service
+}
+
+// NewHandlers creates new link preview handlers
+func NewHandlers(service *Service) *Handlers {
+	return &Handlers{
+		service: service,
+	}
+}
+
+// GetPreview handles GET /api/v1/link-preview?url=...
+// Composers show the card while the post or message is written, which also caches it for when it is sent
+func (h *Handlers) GetPreview(c *gin.Context) {
+	link := c.Query("url")
+	if link == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "url is required",
+		})
+		return
+	}
+
+	preview, err := h.service.Unfurl(c.Request.Context(), link)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, ErrInvalidURL) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "No preview available for this link",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"preview": preview,
+	})
+}
+
+// SetupRoutes registers the link preview route on a protected group
+// Every preview can cost a fetch, middleware should rate limit it
+func (h *Handlers) SetupRoutes(router *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	router.GET("/link-preview", append(middleware, h.GetPreview)...)
+}
@@ -0,0 +1,238 @@
+package unfurl
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"upvista-community-backend/internal/models"
+
+	"golang.org/x/net/html"
+)
+
+// Longest text kept in a preview, in characters
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 500
+	maxNameLength        = 100
+)
+
+// metadata is what a page's head says about it
+type metadata struct {
+	title     string            // <title>
+	meta      map[string]string // <meta property|name=... content=...>, first of each
+	canonical string            // <link rel="canonical">
+	icon      string            // <link rel="icon">
+	touchIcon string            // <link rel="apple-touch-icon">, when there is no icon
+	oembed    string            // <link rel="alternate" type="application/json+oembed">
+}
+
+// parseMetadata reads the head of an HTML page, stopping at the body
+func parseMetadata(body []byte) *metadata {
+	md := &metadata{meta: make(map[string]string)}
+	z := html.NewTokenizer(bytes.NewReader(body))
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return md
+
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return md
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "body":
+				return md
+			case "title":
+				if md.title == "" && z.Next() == html.TextToken {
+					md.title = string(z.Text())
+				}
+			case "meta":
+				if hasAttr {
+					md.addMeta(attributes(z))
+				}
+			case "link":
+				if hasAttr {
+					md.addLink(attributes(z))
+				}
+			}
+		}
+	}
+}
+
+// attributes reads the attributes of the current tag, names lowercased
+func attributes(z *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		key, val, more := z.TagAttr()
+		attrs[string(key)] = string(val)
+		if !more {
+			return attrs
+		}
+	}
+}
+
+func (md *metadata) addMeta(attrs map[string]string) {
+	key := attrs["property"] // Open Graph
+	if key == "" {
+		key = attrs["name"] // Twitter Cards and plain HTML
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	if key == "" {
+		return
+	}
+	if _, seen := md.meta[key]; !seen {
+		md.meta[key] = strings.TrimSpace(attrs["content"])
+	}
+}
+
+func (md *metadata) addLink(attrs map[string]string) {
+	href := strings.TrimSpace(attrs["href"])
+	if href == "" {
+		return
+	}
+
+	for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+		switch rel {
+		case "canonical":
+			if md.canonical == "" {
+				md.canonical = href
+			}
+		case "icon":
+			if md.icon == "" {
+				md.icon = href
+			}
+		case "apple-touch-icon":
+			if md.touchIcon == "" {
+				md.touchIcon = href
+			}
+		case "alternate":
+			if strings.EqualFold(attrs["type"], "application/json+oembed") && md.oembed == "" {
+				md.oembed = href
+			}
+		}
+	}
+}
+
+// first returns the first non-empty meta value among keys
+func (md *metadata) first(keys ...string) string {
+	for _, key := range keys {
+		if val := md.meta[key]; val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// preview builds a preview from a page's metadata: Open Graph first, then Twitter Cards, then plain HTML
+func (md *metadata) preview(pageURL *url.URL) *models.LinkPreview {
+	preview := &models.LinkPreview{
+		Type:        previewType(md.first("og:type"), md.first("twitter:card")),
+		Title:       clean(md.first("og:title", "twitter:title"), maxTitleLength),
+		Description: clean(md.first("og:description", "twitter:description", "description"), maxDescriptionLength),
+		SiteName:    clean(md.first("og:site_name", "application-name"), maxNameLength),
+		AuthorName:  clean(md.first("author", "twitter:creator"), maxNameLength),
+		ImageURL:    resolve(pageURL, md.first("og:image:secure_url", "og:image", "og:image:url", "twitter:image", "twitter:image:src")),
+		ImageWidth:  atoi(md.first("og:image:width")),
+		ImageHeight: atoi(md.first("og:image:height")),
+		ImageAlt:    clean(md.first("og:image:alt", "twitter:image:alt"), maxDescriptionLength),
+		IconURL:     resolve(pageURL, md.icon),
+	}
+	if preview.IconURL == "" {
+		preview.IconURL = resolve(pageURL, md.touchIcon)
+	}
+	if preview.Title == "" {
+		preview.Title = clean(md.title, maxTitleLength)
+	}
+	if preview.ImageURL == "" {
+		preview.ImageWidth, preview.ImageHeight = 0, 0
+	}
+
+	preview.CanonicalURL = resolve(pageURL, md.first("og:url"))
+	if preview.CanonicalURL == "" {
+		preview.CanonicalURL = resolve(pageURL, md.canonical)
+	}
+	if preview.CanonicalURL == "" {
+		preview.CanonicalURL = pageURL.String()
+	}
+
+	// Players are HTML pages meant for an iframe, og:video can also be a plain video file
+	if player := embedURL(pageURL, md.first("twitter:player")); player != "" {
+		preview.EmbedURL = player
+		preview.EmbedWidth = atoi(md.first("twitter:player:width"))
+		preview.EmbedHeight = atoi(md.first("twitter:player:height"))
+	} else if strings.EqualFold(md.first("og:video:type"), "text/html") {
+		preview.EmbedURL = embedURL(pageURL, md.first("og:video:secure_url", "og:video:url", "og:video"))
+		preview.EmbedWidth = atoi(md.first("og:video:width"))
+		preview.EmbedHeight = atoi(md.first("og:video:height"))
+	}
+	if preview.EmbedURL == "" {
+		preview.EmbedWidth, preview.EmbedHeight = 0, 0
+	}
+
+	return preview
+}
+
+// previewType maps og:type and twitter:card to a preview type
+func previewType(ogType, twitterCard string) models.LinkPreviewType {
+	ogType = strings.ToLower(ogType)
+	switch {
+	case strings.HasPrefix(ogType, "video"):
+		return models.LinkPreviewVideo
+	case strings.HasPrefix(ogType, "music"):
+		return models.LinkPreviewAudio
+	case ogType == "article":
+		return models.LinkPreviewArticle
+	case strings.EqualFold(twitterCard, "player"):
+		return models.LinkPreviewVideo
+	}
+	return models.LinkPreviewWebsite
+}
+
+// resolve makes a URL found in a page absolute, "" unless it is http(s)
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(u.String()) > maxURLLength {
+		return ""
+	}
+	return u.String()
+}
+
+// embedURL resolves a player URL, which must be https: it is shown in an iframe on our pages
+func embedURL(base *url.URL, ref string) string {
+	if u := resolve(base, ref); strings.HasPrefix(u, "https://") {
+		return u
+	}
+	return ""
+}
+
+// clean collapses whitespace and shortens text to limit characters
+func clean(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "")
+	}
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
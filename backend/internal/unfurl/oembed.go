@@ -0,0 +1,170 @@
+package unfurl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"upvista-community-backend/internal/models"
+
+	"golang.org/x/net/html"
+)
+
+// oembedProvider is a site whose oEmbed endpoint is known, so links to it don't need their page fetched:
+// popular players often serve crawlers a consent or login page instead of their metadata
+type oembedProvider struct {
+	hosts    []string // Hostnames, subdomains included
+	paths    []string // Path prefixes of embeddable pages, any path when empty
+	endpoint string   // The link is passed in the url parameter
+}
+
+var oembedProviders = []oembedProvider{
+	{hosts: []string{"youtube.com"}, paths: []string{"/watch", "/shorts/", "/live/", "/playlist"}, endpoint: "https://www.youtube.com/oembed?format=json"},
+	{hosts: []string{"youtu.be"}, endpoint: "https://www.youtube.com/oembed?format=json"},
+	{hosts: []string{"vimeo.com"}, endpoint: "https://vimeo.com/api/oembed.json"},
+	{hosts: []string{"open.spotify.com"}, endpoint: "https://open.spotify.com/oembed"},
+	{hosts: []string{"soundcloud.com"}, endpoint: "https://soundcloud.com/oembed?format=json"},
+}
+
+// oembedEndpoint returns the oEmbed URL of a link to a known provider, "" for other links
+func oembedEndpoint(link *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(link.Hostname()), "www.")
+	for _, provider := range oembedProviders {
+		if !matchesHost(host, provider.hosts) || !matchesPath(link.Path, provider.paths) {
+			continue
+		}
+
+		endpoint, err := url.Parse(provider.endpoint)
+		if err != nil {
+			return ""
+		}
+		query := endpoint.Query()
+		query.Set("url", link.String())
+		endpoint.RawQuery = query.Encode()
+		return endpoint.String()
+	}
+	return ""
+}
+
+func matchesHost(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesPath(path string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// oembedResponse is an oEmbed JSON response
+type oembedResponse struct {
+	Type            string    `json:"type"` // photo, video, link or rich
+	Title           string    `json:"title"`
+	AuthorName      string    `json:"author_name"`
+	ProviderName    string    `json:"provider_name"`
+	URL             string    `json:"url"` // The image, for photos
+	Width           dimension `json:"width"`
+	Height          dimension `json:"height"`
+	HTML            string    `json:"html"` // Embed code, for video and rich types
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	ThumbnailWidth  dimension `json:"thumbnail_width"`
+	ThumbnailHeight dimension `json:"thumbnail_height"`
+}
+
+// fetchOEmbed gets the oEmbed description of a link from endpoint
+func (s *Service) fetchOEmbed(ctx context.Context, endpoint string) (*oembedResponse, *url.URL, error) {
+	u, err := parseURL(endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err := s.fetch(ctx, u.String(), "application/json", maxOEmbedSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch oEmbed: %w", err)
+	}
+
+	var resp oembedResponse
+	if err := json.Unmarshal(p.body, &resp); err != nil {
+		return nil, nil, fmt.Errorf("invalid oEmbed response: %w", err)
+	}
+	return &resp, p.url, nil
+}
+
+// apply fills what a preview is missing from an oEmbed response, and adds the player
+// The embed code itself is never kept, only the https URL of the iframe it contains
+func (o *oembedResponse) apply(preview *models.LinkPreview, base *url.URL) {
+	if preview.Title == "" {
+		preview.Title = clean(o.Title, maxTitleLength)
+	}
+	if preview.AuthorName == "" {
+		preview.AuthorName = clean(o.AuthorName, maxNameLength)
+	}
+	if preview.SiteName == "" {
+		preview.SiteName = clean(o.ProviderName, maxNameLength)
+	}
+
+	switch strings.ToLower(o.Type) {
+	case "photo":
+		if preview.ImageURL == "" {
+			preview.ImageURL = resolve(base, o.URL)
+			preview.ImageWidth, preview.ImageHeight = int(o.Width), int(o.Height)
+		}
+		if preview.Type == models.LinkPreviewWebsite {
+			preview.Type = models.LinkPreviewPhoto
+		}
+	case "video", "rich":
+		if src := iframeSource(o.HTML); src != "" && preview.EmbedURL == "" {
+			if preview.EmbedURL = embedURL(base, src); preview.EmbedURL != "" {
+				preview.EmbedWidth, preview.EmbedHeight = int(o.Width), int(o.Height)
+			}
+		}
+		if strings.EqualFold(o.Type, "video") && preview.Type == models.LinkPreviewWebsite {
+			preview.Type = models.LinkPreviewVideo
+		}
+	}
+
+	if preview.ImageURL == "" && o.ThumbnailURL != "" {
+		if preview.ImageURL = resolve(base, o.ThumbnailURL); preview.ImageURL != "" {
+			preview.ImageWidth, preview.ImageHeight = int(o.ThumbnailWidth), int(o.ThumbnailHeight)
+		}
+	}
+}
+
+// iframeSource returns the src of the first iframe in embed code
+func iframeSource(embed string) string {
+	z := html.NewTokenizer(strings.NewReader(embed))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if name, hasAttr := z.TagName(); string(name) == "iframe" && hasAttr {
+				return strings.TrimSpace(attributes(z)["src"])
+			}
+		}
+	}
+}
+
+// dimension is a size in an oEmbed response, providers send numbers or strings
+type dimension int
+
+func (d *dimension) UnmarshalJSON(data []byte) error {
+	if n, err := strconv.ParseFloat(strings.Trim(string(data), `"`), 64); err == nil && n > 0 && n < 100000 {
+		*d = dimension(n)
+	}
+	return nil // Sizes are optional, a malformed one doesn't spoil the response
+}
@@ -0,0 +1,279 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"upvista-community-backend/internal/config"
+	"upvista-community-backend/internal/models"
+)
+
+const (
+	// unfurlTimeout bounds building one preview, page and oEmbed included. It outlasts the wait of a post
+	// or message, so a slow site's preview is still cached for the next one.
+	unfurlTimeout = 10 * time.Second
+
+	// failureTTL is how long a link that couldn't be previewed isn't fetched again
+	failureTTL = 30 * time.Minute
+
+	acceptPage = "text/html,application/xhtml+xml;q=0.9,image/*;q=0.8,*/*;q=0.5"
+)
+
+// errNotPreviewable means a link was fetched but there is nothing to show: not a page or an image, or a page without metadata
+var errNotPreviewable = errors.New("link can't be previewed")
+
+// linkPattern finds http(s) links in plain text, trailing punctuation is trimmed afterwards
+var linkPattern = regexp.MustCompile("(?i)\\bhttps?://[^\\s<>\"'`]+")
+
+// Service builds link previews for posts and messages from Open Graph, Twitter Card and oEmbed metadata
+// Pages are fetched from public addresses only (see newClient) and previews are cached
+type Service struct {
+	client    *http.Client
+	cache     Cache
+	userAgent string
+	timeout   time.Duration // How long creating a post or message waits for its previews
+	cacheTTL  time.Duration
+	maxLinks  int
+}
+
+// NewService creates a link preview service
+func NewService(cfg *config.Config, cache Cache) *Service {
+	maxLinks := cfg.LinkPreview.MaxLinks
+	if maxLinks <= 0 {
+		maxLinks = 3
+	}
+
+	return &Service{
+		client:    newClient(),
+		cache:     cache,
+		userAgent: cfg.LinkPreview.UserAgent,
+		timeout:   cfg.GetLinkPreviewTimeout(),
+		cacheTTL:  cfg.GetLinkPreviewCacheTTL(),
+		maxLinks:  maxLinks,
+	}
+}
+
+// Unfurl returns the preview of a link, from the cache when it was fetched recently
+// Fails with ErrInvalidURL for links that are never fetched
+func (s *Service) Unfurl(ctx context.Context, link string) (*models.LinkPreview, error) {
+	u, err := parseURL(link)
+	if err != nil {
+		return nil, err
+	}
+
+	key := cacheKey(u)
+	if preview, found := s.cache.Get(ctx, key); found {
+		if preview == nil {
+			return nil, errNotPreviewable
+		}
+		return withURL(preview, link), nil
+	}
+
+	preview, err := s.build(ctx, u)
+	if err != nil {
+		// Only the site's failures are remembered, not running out of time waiting for it
+		if ctx.Err() == nil {
+			s.cache.Set(ctx, key, nil, failureTTL)
+		}
+		return nil, err
+	}
+
+	s.cache.Set(ctx, key, preview, s.cacheTTL)
+	return withURL(preview, link), nil
+}
+
+// Previews returns the previews of the first links in a text, in order, leaving out links that can't be previewed
+// Links are fetched in parallel and waited for up to the configured timeout, the ones still loading are only
+// cached, for the next post or message with them
+func (s *Service) Previews(ctx context.Context, text string) []models.LinkPreview {
+	return s.Refresh(ctx, text, nil)
+}
+
+// Refresh returns the previews of the links in edited text, keeping the previous preview of a link
+// that can't be fetched right now
+func (s *Service) Refresh(ctx context.Context, text string, previous []models.LinkPreview) []models.LinkPreview {
+	links := extractLinks(text, s.maxLinks)
+	if len(links) == 0 {
+		return nil
+	}
+
+	type result struct {
+		index   int
+		preview *models.LinkPreview
+	}
+	results := make(chan result, len(links)) // Buffered, so fetches finishing after the wait don't block
+	for i, link := range links {
+		go func(i int, link string) {
+			// Detached from the request, see above
+			fetchCtx, cancel := context.WithTimeout(context.Background(), unfurlTimeout)
+			defer cancel()
+
+			preview, err := s.Unfurl(fetchCtx, link)
+			if err != nil && !errors.Is(err, ErrInvalidURL) && !errors.Is(err, errNotPreviewable) {
+				log.Printf("[LinkPreview] No preview for a link to %s: %v", hostOf(link), withoutURL(err))
+			}
+			results <- result{index: i, preview: preview}
+		}(i, link)
+	}
+
+	previews := make([]*models.LinkPreview, len(links))
+	wait := time.NewTimer(s.timeout)
+	defer wait.Stop()
+collect:
+	for received := 0; received < len(links); received++ {
+		select {
+		case r := <-results:
+			previews[r.index] = r.preview
+		case <-wait.C:
+			break collect
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	kept := make(map[string]models.LinkPreview, len(previous))
+	for _, preview := range previous {
+		kept[preview.URL] = preview
+	}
+
+	var out []models.LinkPreview
+	for i, link := range links {
+		if previews[i] != nil {
+			out = append(out, *previews[i])
+		} else if preview, ok := kept[link]; ok {
+			out = append(out, preview)
+		}
+	}
+	return out
+}
+
+// build fetches what a link points to and makes a preview of it
+func (s *Service) build(ctx context.Context, link *url.URL) (*models.LinkPreview, error) {
+	// Known players describe themselves through oEmbed, better than what their pages tell crawlers
+	if endpoint := oembedEndpoint(link); endpoint != "" {
+		if oembed, base, err := s.fetchOEmbed(ctx, endpoint); err == nil && oembed.Title != "" {
+			preview := &models.LinkPreview{Type: models.LinkPreviewWebsite, CanonicalURL: link.String()}
+			oembed.apply(preview, base)
+			return complete(preview, link), nil
+		}
+	}
+
+	p, err := s.fetch(ctx, link.String(), acceptPage, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasPrefix(p.contentType, "image/"):
+		preview := &models.LinkPreview{Type: models.LinkPreviewPhoto, CanonicalURL: p.url.String(), ImageURL: p.url.String()}
+		return complete(preview, p.url), nil
+	case !isHTML(p.contentType):
+		return nil, errNotPreviewable
+	}
+
+	md := parseMetadata(p.body)
+	preview := md.preview(p.url)
+
+	// Pages declaring an oEmbed endpoint fill in what their metadata lacks, the player in particular
+	incomplete := preview.Title == "" || preview.ImageURL == "" || (preview.Type == models.LinkPreviewVideo && preview.EmbedURL == "")
+	if endpoint := resolve(p.url, md.oembed); endpoint != "" && incomplete {
+		if oembed, base, err := s.fetchOEmbed(ctx, endpoint); err == nil {
+			oembed.apply(preview, base)
+		}
+	}
+
+	if preview.Title == "" && preview.Description == "" && preview.ImageURL == "" {
+		return nil, errNotPreviewable
+	}
+	return complete(preview, p.url), nil
+}
+
+// complete names the site after its host when the page doesn't, and stamps the preview
+func complete(preview *models.LinkPreview, pageURL *url.URL) *models.LinkPreview {
+	if preview.SiteName == "" {
+		preview.SiteName = strings.TrimPrefix(strings.ToLower(pageURL.Hostname()), "www.")
+	}
+	preview.FetchedAt = time.Now().UTC()
+	return preview
+}
+
+// withURL returns a copy of a cached preview for a link as written, which can differ from the cache key
+func withURL(preview *models.LinkPreview, link string) *models.LinkPreview {
+	p := *preview
+	p.URL = link
+	return &p
+}
+
+// cacheKey identifies a link regardless of how its scheme and host are cased and of its fragment
+func cacheKey(u *url.URL) string {
+	key := *u
+	key.Scheme = strings.ToLower(key.Scheme)
+	key.Host = strings.ToLower(key.Host)
+	key.Fragment, key.RawFragment = "", ""
+	return key.String()
+}
+
+// extractLinks returns the first limit distinct links in a text that can be previewed
+func extractLinks(text string, limit int) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, match := range linkPattern.FindAllString(text, -1) {
+		link := trimLink(match)
+		if seen[link] {
+			continue
+		}
+		seen[link] = true
+
+		if _, err := parseURL(link); err != nil {
+			continue
+		}
+		if links = append(links, link); len(links) == limit {
+			break
+		}
+	}
+	return links
+}
+
+// trimLink drops punctuation ending the sentence around a link, and closing brackets that aren't
+// part of it (Wikipedia links can end with a parenthesis, markdown links are wrapped in one)
+func trimLink(link string) string {
+	for link != "" {
+		last := link[len(link)-1]
+		switch last {
+		case '.', ',', ';', ':', '!', '?', '*':
+			link = link[:len(link)-1]
+			continue
+		case ')', ']', '}':
+			open := map[byte]string{')': "(", ']': "[", '}': "{"}[last]
+			if strings.Count(link, open) < strings.Count(link, string(last)) {
+				link = link[:len(link)-1]
+				continue
+			}
+		}
+		return link
+	}
+	return link
+}
+
+// hostOf returns the host of a link, logs name the site rather than links shared in private messages
+func hostOf(link string) string {
+	if u, err := url.Parse(link); err == nil {
+		return u.Hostname()
+	}
+	return "an invalid host"
+}
+
+// withoutURL strips the link from a request error
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,272 @@
+package uploads
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"upvista-community-backend/internal/models"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Content type of chunk requests, as in tus
+const chunkContentType = "application/offset+octet-stream"
+
+// Handlers manages resumable upload HTTP handlers
+type Handlers struct {
+	service *Service
+}
+
+// NewHandlers creates new resumable upload handlers
+func NewHandlers(service *Service) *Handlers {
+	return &Handlers{
+		service: service,
+	}
+}
+
+// CreateUpload handles POST /api/v1/uploads
+func (h *Handlers) CreateUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	session, err := h.service.CreateUpload(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Location", "/api/v1/uploads/"+session.ID.String())
+	c.JSON(http.StatusCreated, gin.H{
+		"success":    true,
+		"message":    "Upload created",
+		"upload":     session,
+		"chunk_size": h.service.ChunkSize(),
+	})
+}
+
+// GetUpload handles GET /api/v1/uploads/:id
+func (h *Handlers) GetUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := uploadID(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.service.GetUpload(c.Request.Context(), id, userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"upload":  session,
+	})
+}
+
+// HeadUpload handles HEAD /api/v1/uploads/:id
+// Cheap progress check before resuming: Upload-Offset and Upload-Length headers, no body
+func (h *Handlers) HeadUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := uploadID(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.service.GetUpload(c.Request.Context(), id, userID)
+	if err != nil {
+		c.Status(apperr.GetAppError(err).Code)
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Status(http.StatusOK)
+}
+
+// WriteChunk handles PATCH /api/v1/uploads/:id
+// Headers: Content-Type application/offset+octet-stream, Upload-Offset, Content-Length,
+// optional Upload-Checksum "sha256 <base64 digest>"
+func (h *Handlers) WriteChunk(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := uploadID(c)
+	if !ok {
+		return
+	}
+
+	if !strings.HasPrefix(c.GetHeader("Content-Type"), chunkContentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"success": false,
+			"message": "Content-Type must be " + chunkContentType,
+		})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Upload-Offset header is missing or invalid",
+		})
+		return
+	}
+
+	if c.Request.ContentLength < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{
+			"success": false,
+			"message": "Content-Length header is required",
+		})
+		return
+	}
+	if c.Request.ContentLength > h.service.ChunkSize() {
+		respondError(c, apperr.ErrUploadChunkTooLarge)
+		return
+	}
+
+	session, err := h.service.WriteChunk(c.Request.Context(), id, userID, offset, c.Request.Body, c.Request.ContentLength, c.GetHeader("Upload-Checksum"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Chunk stored",
+		"upload":  session,
+	})
+}
+
+// CompleteUpload handles POST /api/v1/uploads/:id/complete
+func (h *Handlers) CompleteUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := uploadID(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.service.CompleteUpload(c.Request.Context(), id, userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Upload completed",
+		"upload":  session,
+	})
+}
+
+// AbortUpload handles DELETE /api/v1/uploads/:id
+func (h *Handlers) AbortUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := uploadID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.AbortUpload(c.Request.Context(), id, userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Upload deleted",
+	})
+}
+
+// SetupRoutes registers the resumable upload routes on a protected group
+// createMiddleware (the upload quota) only applies to starting uploads, not to each chunk
+func (h *Handlers) SetupRoutes(router *gin.RouterGroup, createMiddleware ...gin.HandlerFunc) {
+	uploads := router.Group("/uploads")
+	{
+		uploads.POST("", append(createMiddleware, h.CreateUpload)...)
+		uploads.GET("/:id", h.GetUpload)
+		uploads.HEAD("/:id", h.HeadUpload)
+		uploads.PATCH("/:id", h.WriteChunk)
+		uploads.POST("/:id/complete", h.CompleteUpload)
+		uploads.DELETE("/:id", h.AbortUpload)
+	}
+}
+
+// setUploadHeaders sets the tus progress headers
+func setUploadHeaders(c *gin.Context, session *models.UploadSession) {
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.Size, 10))
+	c.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "no-store")
+}
+
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid user ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func uploadID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid upload ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondError(c *gin.Context, err error) {
+	appErr := apperr.GetAppError(err)
+	c.JSON(appErr.Code, gin.H{
+		"success": false,
+		"message": appErr.Message,
+	})
+}
@@ -0,0 +1,541 @@
+package uploads
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"upvista-community-backend/internal/config"
+	"upvista-community-backend/internal/inspect"
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/repository"
+	"upvista-community-backend/internal/storage"
+	"upvista-community-backend/internal/utils"
+	apperr "upvista-community-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// purposeSpec is where a completed upload is stored and what it may contain
+type purposeSpec struct {
+	bucket       string
+	keyFormat    string           // Formatted with the user ID, a random ID and the sanitized filename
+	maxSize      int64            // Bytes
+	contentTypes []string         // Accepted content type prefixes, any type if empty
+	content      models.MediaKind // What the completed file must sniff as
+	mediaKind    models.MediaKind // Completed files are queued for processing, stored as plain files if empty
+}
+
+// Same buckets, key layouts and limits as the single-request upload endpoints
+var purposes = map[models.UploadPurpose]purposeSpec{
+	models.UploadPurposePostVideo: {
+		bucket:       "public",
+		keyFormat:    "posts/%s/video_%s_%s",
+		maxSize:      100 * 1024 * 1024,
+		contentTypes: []string{"video/"},
+		content:      models.MediaKindVideo,
+		mediaKind:    models.MediaKindVideo,
+	},
+	models.UploadPurposeMessageVideo: {
+		bucket:       "chat-attachments",
+		keyFormat:    "messages/%s/video_%s_%s",
+		maxSize:      100 * 1024 * 1024,
+		contentTypes: []string{"video/"},
+		content:      models.MediaKindVideo,
+		mediaKind:    models.MediaKindVideo,
+	},
+	models.UploadPurposeMessageFile: {
+		bucket:    "chat-attachments",
+		keyFormat: "messages/%s/file_%s_%s",
+		maxSize:   100 * 1024 * 1024,
+		content:   models.MediaKindFile,
+	},
+	models.UploadPurposeEventCover: {
+		bucket:       "event-covers",
+		keyFormat:    "events/%s/%s_%s",
+		maxSize:      20 * 1024 * 1024,
+		contentTypes: []string{"image/"},
+		content:      models.MediaKindImage,
+	},
+}
+
+var checksumPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Service implements resumable uploads: a session is created with the total size, chunks are
+// stored as separate objects in the parts bucket, and completing the upload streams them into
+// the final object. Any server can take any chunk, no state is kept in memory.
+type Service struct {
+	repo        repository.UploadSessionRepository
+	storage     *utils.StorageService
+	partsBucket string
+	chunkSize   int64
+	sessionTTL  time.Duration
+	media       MediaProcessor // Optional, deduplicates completed files and processes videos
+	inspector   FileInspector  // Optional, checks completed files before they can be used
+}
+
+// MediaProcessor records completed uploads by content hash and queues video and audio for background
+// processing (implemented by media.Service)
+type MediaProcessor interface {
+	// AdoptUpload returns the stored media, which is an earlier identical upload if there is one
+	AdoptUpload(ctx context.Context, userID uuid.UUID, kind models.MediaKind, bucket, key, contentHash string, size int64) *models.MediaInfo
+	SignMedia(ctx context.Context, info *models.MediaInfo) *models.MediaInfo
+}
+
+// FileInspector sniffs, scans and, if they are unsafe, quarantines completed uploads (implemented by inspect.Inspector)
+type FileInspector interface {
+	// InspectObject deletes a refused file and moves a suspicious one to quarantine
+	InspectObject(ctx context.Context, upload inspect.Upload, key string) (*inspect.Result, error)
+}
+
+// NewService creates a new resumable upload service
+func NewService(repo repository.UploadSessionRepository, storageSvc *utils.StorageService, cfg *config.Config) *Service {
+	return &Service{
+		repo:        repo,
+		storage:     storageSvc,
+		partsBucket: cfg.Storage.UploadPartsBucket,
+		chunkSize:   cfg.Storage.UploadChunkSize,
+		sessionTTL:  cfg.GetUploadSessionTTL(),
+	}
+}
+
+// SetMediaProcessor stores completed files once per bucket and queues completed videos for probing,
+// thumbnailing and transcoding
+func (s *Service) SetMediaProcessor(media MediaProcessor) {
+	s.media = media
+}
+
+// SetInspector checks completed files the way single-request uploads are checked: content type, hidden
+// payloads, archives and malware
+func (s *Service) SetInspector(inspector FileInspector) {
+	s.inspector = inspector
+}
+
+// ChunkSize returns the largest chunk accepted per request
+func (s *Service) ChunkSize() int64 {
+	return s.chunkSize
+}
+
+// CreateUpload starts a resumable upload
+func (s *Service) CreateUpload(ctx context.Context, userID uuid.UUID, req *models.CreateUploadRequest) (*models.UploadSession, error) {
+	spec, ok := purposes[req.Purpose]
+	if !ok {
+		return nil, apperr.NewAppError(400, fmt.Sprintf("Unknown upload purpose: %s", req.Purpose))
+	}
+
+	if req.Size > spec.maxSize {
+		return nil, apperr.NewAppError(413, fmt.Sprintf("File too large (max %dMB)", spec.maxSize/(1024*1024)))
+	}
+
+	contentType := strings.ToLower(strings.TrimSpace(req.ContentType))
+	if !allowedContentType(contentType, spec.contentTypes) {
+		return nil, apperr.NewAppError(400, fmt.Sprintf("File type %s is not allowed for %s", contentType, req.Purpose))
+	}
+
+	session := &models.UploadSession{
+		UserID:      userID,
+		Purpose:     req.Purpose,
+		Filename:    req.Filename,
+		ContentType: contentType,
+		Size:        req.Size,
+		Bucket:      spec.bucket,
+		ObjectKey:   fmt.Sprintf(spec.keyFormat, userID.String(), uuid.New().String(), sanitizeFilename(req.Filename)),
+		ExpiresAt:   time.Now().Add(s.sessionTTL),
+	}
+
+	if req.Checksum != "" {
+		checksum := strings.ToLower(req.Checksum)
+		if !checksumPattern.MatchString(checksum) {
+			return nil, apperr.NewAppError(400, "Checksum must be a hex-encoded SHA-256 digest")
+		}
+		session.Checksum = &checksum
+	}
+
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Uploads] User %s started upload %s (%s, %d bytes)", userID, session.ID, session.Purpose, session.Size)
+	return session, nil
+}
+
+// GetUpload returns one of the user's uploads
+func (s *Service) GetUpload(ctx context.Context, id, userID uuid.UUID) (*models.UploadSession, error) {
+	session, err := s.getOwnSession(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.sign(ctx, session)
+	return session, nil
+}
+
+// WriteChunk stores the chunk starting at offset
+// checksum is an optional "sha256 <base64 digest>" of the chunk (the tus Upload-Checksum header)
+func (s *Service) WriteChunk(ctx context.Context, id, userID uuid.UUID, offset int64, body io.Reader, length int64, checksum string) (*models.UploadSession, error) {
+	session, err := s.getOwnSession(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status != models.UploadStatusUploading {
+		return nil, apperr.ErrUploadNotUploading
+	}
+	if offset != session.Offset {
+		return nil, apperr.ErrUploadOffsetMismatch
+	}
+	if length <= 0 || length > s.chunkSize || offset+length > session.Size {
+		return nil, apperr.ErrUploadChunkTooLarge
+	}
+
+	expectedDigest, err := parseChecksumHeader(checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unique per request, so a concurrent chunk for the same offset never overwrites this one
+	partKey := fmt.Sprintf("%s/%012d-%s", session.ID, offset, uuid.New().String()[:8])
+	driver := s.storage.Driver()
+
+	digest := sha256.New()
+	counter := &countingReader{r: io.TeeReader(io.LimitReader(body, length), digest)}
+	if err := driver.Put(ctx, s.partsBucket, partKey, counter, length, "application/octet-stream"); err != nil {
+		log.Printf("[Uploads] Failed to store chunk of upload %s at offset %d: %v", id, offset, err)
+		s.deletePart(partKey)
+		return nil, apperr.NewAppError(500, "Failed to store chunk")
+	}
+
+	if counter.n != length {
+		s.deletePart(partKey)
+		return nil, apperr.NewAppError(400, "Chunk is shorter than its Content-Length")
+	}
+	if expectedDigest != nil && string(digest.Sum(nil)) != string(expectedDigest) {
+		s.deletePart(partKey)
+		return nil, apperr.ErrUploadChecksumMismatch
+	}
+
+	parts := append(append([]string{}, session.Parts...), partKey)
+	expiresAt := time.Now().Add(s.sessionTTL)
+
+	recorded, err := s.repo.AppendPart(ctx, id, offset, offset+length, parts, expiresAt)
+	if err != nil {
+		s.deletePart(partKey)
+		return nil, err
+	}
+	if !recorded {
+		// Another chunk for this offset was recorded first
+		s.deletePart(partKey)
+		return nil, apperr.ErrUploadOffsetMismatch
+	}
+
+	session.Offset = offset + length
+	session.Parts = parts
+	session.ExpiresAt = expiresAt
+	return session, nil
+}
+
+// CompleteUpload assembles the chunks into the final file and returns the session with its URL
+// Completing an already completed upload returns it again
+func (s *Service) CompleteUpload(ctx context.Context, id, userID uuid.UUID) (*models.UploadSession, error) {
+	session, err := s.getOwnSession(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status == models.UploadStatusCompleted {
+		s.sign(ctx, session)
+		return session, nil
+	}
+	if session.Status != models.UploadStatusUploading {
+		return nil, apperr.ErrUploadNotUploading
+	}
+	if session.Offset != session.Size {
+		return nil, apperr.ErrUploadIncomplete
+	}
+
+	claimed, err := s.repo.UpdateStatus(ctx, id, models.UploadStatusUploading, models.UploadStatusFinalizing)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, apperr.ErrUploadNotUploading
+	}
+
+	digest, err := s.assemble(ctx, session)
+	if err != nil {
+		log.Printf("[Uploads] Failed to assemble upload %s: %v", id, err)
+		// Let the client retry
+		if _, resetErr := s.repo.UpdateStatus(context.Background(), id, models.UploadStatusFinalizing, models.UploadStatusUploading); resetErr != nil {
+			log.Printf("[Uploads] Failed to reset upload %s: %v", id, resetErr)
+		}
+		return nil, apperr.NewAppError(500, "Failed to assemble upload")
+	}
+
+	driver := s.storage.Driver()
+	if session.Checksum != nil && hex.EncodeToString(digest) != *session.Checksum {
+		// The stored data is not what the client meant to send, start over
+		log.Printf("[Uploads] Upload %s failed its checksum", id)
+		if err := driver.Delete(context.Background(), session.Bucket, session.ObjectKey); err != nil {
+			log.Printf("[Uploads] Failed to delete %s/%s: %v", session.Bucket, session.ObjectKey, err)
+		}
+		s.discard(context.Background(), session)
+		return nil, apperr.ErrUploadChecksumMismatch
+	}
+
+	if s.inspector != nil {
+		upload := inspect.Upload{
+			UserID:      userID,
+			Kind:        purposes[session.Purpose].content,
+			Bucket:      session.Bucket,
+			Filename:    session.Filename,
+			ContentType: session.ContentType,
+		}
+		if _, err := s.inspector.InspectObject(ctx, upload, session.ObjectKey); err != nil {
+			if inspect.IsRejected(err) {
+				// The file is gone (deleted or quarantined), so is the upload
+				s.discard(context.Background(), session)
+				return nil, err
+			}
+
+			log.Printf("[Uploads] Failed to inspect upload %s: %v", id, err)
+			if _, resetErr := s.repo.UpdateStatus(context.Background(), id, models.UploadStatusFinalizing, models.UploadStatusUploading); resetErr != nil {
+				log.Printf("[Uploads] Failed to reset upload %s: %v", id, resetErr)
+			}
+			if apperr.IsAppError(err) {
+				return nil, err
+			}
+			return nil, apperr.NewAppError(500, "Failed to inspect upload")
+		}
+	}
+
+	// A file that was uploaded before is reused, the assembled copy is deleted
+	fileURL := driver.URL(session.Bucket, session.ObjectKey)
+	var media *models.MediaInfo
+	if s.media != nil {
+		kind := purposes[session.Purpose].mediaKind
+		if kind == "" {
+			kind = models.MediaKindFile
+		}
+		media = s.media.AdoptUpload(ctx, userID, kind, session.Bucket, session.ObjectKey, hex.EncodeToString(digest), session.Size)
+		fileURL = media.URL
+	}
+
+	expiresAt := time.Now().Add(s.sessionTTL)
+	if err := s.repo.MarkCompleted(ctx, id, fileURL, expiresAt); err != nil {
+		return nil, err
+	}
+
+	for _, partKey := range session.Parts {
+		s.deletePart(partKey)
+	}
+
+	now := time.Now()
+	session.Status = models.UploadStatusCompleted
+	session.URL = &fileURL
+	session.Parts = []string{}
+	session.ExpiresAt = expiresAt
+	session.CompletedAt = &now
+	s.sign(ctx, session)
+
+	// Processing only adds metadata and a web-friendly copy, the file is complete either way
+	if media != nil && media.Kind != models.MediaKindFile {
+		session.Media = s.media.SignMedia(ctx, media)
+	}
+
+	log.Printf("[Uploads] Upload %s completed: %s/%s", id, session.Bucket, session.ObjectKey)
+	return session, nil
+}
+
+// AbortUpload deletes an upload and its chunks, completed files are kept
+func (s *Service) AbortUpload(ctx context.Context, id, userID uuid.UUID) error {
+	session, err := s.getOwnSession(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if session.Status == models.UploadStatusFinalizing {
+		return apperr.ErrUploadNotUploading
+	}
+
+	s.discard(ctx, session)
+	return nil
+}
+
+// CleanupExpired deletes sessions that expired (abandoned uploads, and completed ones past their expiry)
+// with their chunks, returns how many were deleted
+func (s *Service) CleanupExpired(ctx context.Context, limit int) (int, error) {
+	sessions, err := s.repo.GetExpiredSessions(ctx, time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, session := range sessions {
+		s.discard(ctx, session)
+	}
+
+	return len(sessions), nil
+}
+
+// assemble streams the chunks, in order, into the destination object and returns its SHA-256
+func (s *Service) assemble(ctx context.Context, session *models.UploadSession) ([]byte, error) {
+	driver := s.storage.Driver()
+	parts := &partsReader{ctx: ctx, driver: driver, bucket: s.partsBucket, keys: session.Parts}
+	defer parts.Close()
+
+	digest := sha256.New()
+	counter := &countingReader{r: io.TeeReader(parts, digest)}
+	if err := driver.Put(ctx, session.Bucket, session.ObjectKey, counter, session.Size, session.ContentType); err != nil {
+		return nil, err
+	}
+	if counter.n != session.Size {
+		return nil, fmt.Errorf("assembled %d bytes, expected %d", counter.n, session.Size)
+	}
+
+	return digest.Sum(nil), nil
+}
+
+// discard deletes a session's chunks, then the session
+func (s *Service) discard(ctx context.Context, session *models.UploadSession) {
+	for _, partKey := range session.Parts {
+		s.deletePart(partKey)
+	}
+	if err := s.repo.DeleteSession(ctx, session.ID); err != nil {
+		log.Printf("[Uploads] Failed to delete upload %s: %v", session.ID, err)
+	}
+}
+
+func (s *Service) deletePart(partKey string) {
+	if err := s.storage.Driver().Delete(context.Background(), s.partsBucket, partKey); err != nil {
+		log.Printf("[Uploads] Failed to delete chunk %s: %v", partKey, err)
+	}
+}
+
+func (s *Service) getOwnSession(ctx context.Context, id, userID uuid.UUID) (*models.UploadSession, error) {
+	session, err := s.repo.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != userID {
+		return nil, apperr.ErrUploadNotFound
+	}
+	return session, nil
+}
+
+// sign sets the signed URL of completed uploads in private buckets
+func (s *Service) sign(ctx context.Context, session *models.UploadSession) {
+	if session.URL != nil && s.storage.IsPrivateBucket(session.Bucket) {
+		session.SignedURL = s.storage.SignURL(ctx, *session.URL)
+	}
+}
+
+// parseChecksumHeader parses "sha256 <base64 digest>", nil when no checksum was sent
+func parseChecksumHeader(header string) ([]byte, error) {
+	if header == "" {
+		return nil, nil
+	}
+
+	algorithm, encoded, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || strings.ToLower(algorithm) != "sha256" {
+		return nil, apperr.NewAppError(400, "Upload-Checksum must be \"sha256 <base64 digest>\"")
+	}
+
+	digest, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(digest) != sha256.Size {
+		return nil, apperr.NewAppError(400, "Upload-Checksum digest is not a base64-encoded SHA-256")
+	}
+	return digest, nil
+}
+
+func allowedContentType(contentType string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return contentType != ""
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeFilename keeps ASCII letters, digits, dashes and underscores so the name is safe in object keys
+func sanitizeFilename(filename string) string {
+	ext := filepath.Ext(filename)
+	name := strings.ReplaceAll(strings.TrimSuffix(filename, ext), " ", "_")
+	name = regexp.MustCompile(`[^a-zA-Z0-9_-]+`).ReplaceAllString(name, "")
+	if name == "" {
+		name = "file"
+	}
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	ext = regexp.MustCompile(`[^a-zA-Z0-9.]+`).ReplaceAllString(strings.ToLower(ext), "")
+	if ext == "" || ext == "." {
+		ext = ".bin"
+	}
+	return name + ext
+}
+
+// partsReader reads the chunks of an upload one after another, opening each only when it is reached
+type partsReader struct {
+	ctx     context.Context
+	driver  storage.Storage
+	bucket  string
+	keys    []string
+	current io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			part, err := r.driver.Get(r.ctx, r.bucket, r.keys[0])
+			if err != nil {
+				return 0, fmt.Errorf("failed to read chunk %s: %w", r.keys[0], err)
+			}
+			r.current = part
+			r.keys = r.keys[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
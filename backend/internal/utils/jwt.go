@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"time"
@@ -32,15 +34,18 @@ func (j *JWTService) SetBlacklist(blacklist *TokenBlacklist) {
 	j.blacklist = blacklist
 }
 
-// GenerateToken generates a JWT token for a user
-func (j *JWTService) GenerateToken(user *models.User) (string, error) {
+// GenerateToken generates a short-lived access token bound to a session
+// Returns the token and its expiry time
+func (j *JWTService) GenerateToken(user *models.User, sessionID uuid.UUID) (string, time.Time, error) {
 	now := time.Now()
+	expiresAt := now.Add(j.expiry)
 	claims := &models.JWTClaims{
-		UserID:   user.ID.String(),
-		Email:    user.Email,
-		Username: user.Username,
+		UserID:    user.ID.String(),
+		Email:     user.Email,
+		Username:  user.Username,
+		SessionID: sessionID.String(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(j.expiry)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
@@ -48,10 +53,15 @@ func (j *JWTService) GenerateToken(user *models.User) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(j.secretKey)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	return tokenString, nil
+	return tokenString, expiresAt, nil
+}
+
+// Expiry returns the lifetime of access tokens
+func (j *JWTService) Expiry() time.Duration {
+	return j.expiry
 }
 
 // ValidateToken validates a JWT token and returns the claims
@@ -78,12 +88,63 @@ func (j *JWTService) ValidateToken(tokenString string) (*models.JWTClaims, error
 		if time.Now().After(claims.ExpiresAt.Time) {
 			return nil, errors.New("token has expired")
 		}
+		// Single-purpose tokens (e.g. 2FA challenges) must never be accepted as access tokens
+		if claims.Purpose != "" || claims.SessionID == "" {
+			return nil, errors.New("invalid token")
+		}
+		// Access dies with its session (logout, "log out all devices", refresh token reuse)
+		if j.blacklist != nil && j.blacklist.IsSessionRevoked(claims.SessionID) {
+			return nil, errors.New("session has been revoked")
+		}
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
 
+// GenerateChallengeToken generates a short-lived, single-purpose token (e.g. for the 2FA login step)
+func (j *JWTService) GenerateChallengeToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &models.JWTClaims{
+		UserID:   user.ID.String(),
+		Email:    user.Email,
+		Username: user.Username,
+		Purpose:  purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.New().String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secretKey)
+}
+
+// ValidateChallengeToken validates a single-purpose token and checks it was issued for the given purpose
+func (j *JWTService) ValidateChallengeToken(tokenString, purpose string) (*models.JWTClaims, error) {
+	if j.blacklist != nil && j.blacklist.IsBlacklisted(tokenString) {
+		return nil, errors.New("token has been revoked")
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return j.secretKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*models.JWTClaims)
+	if !ok || !token.Valid || purpose == "" || claims.Purpose != purpose {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
 // ExtractUserIDFromToken extracts user ID from a JWT token
 func (j *JWTService) ExtractUserIDFromToken(tokenString string) (uuid.UUID, error) {
 	claims, err := j.ValidateToken(tokenString)
@@ -99,28 +160,13 @@ func (j *JWTService) ExtractUserIDFromToken(tokenString string) (uuid.UUID, erro
 	return userID, nil
 }
 
-// GenerateRefreshToken generates a refresh token (optional implementation)
-func (j *JWTService) GenerateRefreshToken(user *models.User) (string, error) {
-	// For now, we'll use the same token generation but with longer expiry
-	// In production, you might want to use a different approach for refresh tokens
-	now := time.Now()
-	claims := &models.JWTClaims{
-		UserID:   user.ID.String(),
-		Email:    user.Email,
-		Username: user.Username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(7 * 24 * time.Hour)), // 7 days
-			IssuedAt:  jwt.NewNumericDate(now),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(j.secretKey)
-	if err != nil {
+// GenerateRefreshToken generates an opaque refresh token (only its hash is stored)
+func GenerateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
-
-	return tokenString, nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
 // IsTokenExpired checks if a token is expired
@@ -132,7 +178,7 @@ func (j *JWTService) IsTokenExpired(tokenString string) bool {
 	return time.Now().After(claims.ExpiresAt.Time)
 }
 
-// HashToken creates a SHA256 hash of a JWT token for storage
+// HashToken creates a SHA256 hash of a token for storage
 func HashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
 	return hex.EncodeToString(hash[:])
@@ -6,16 +6,77 @@ import (
 	"time"
 )
 
-// RateLimiter implements in-memory rate limiting with token bucket algorithm
-// and forgiveness mechanism for handling legitimate errors
+// RateLimitStore tracks request counts per key
+// Implementations decide the algorithm; all of them honour the burst (forgiveness) allowance
+type RateLimitStore interface {
+	// Allow consumes one request for key and reports: allowed, remaining requests, reset time
+	Allow(key string, limit, burst int, window time.Duration) (bool, int, time.Time)
+	// GetRemaining reports the remaining requests for key without consuming one
+	GetRemaining(key string, limit int, window time.Duration) int
+}
+
+// RateLimitPolicy describes the quota for a route group
+// Requests are counted per policy, so the same client has an independent quota in each group
+type RateLimitPolicy struct {
+	Name   string        // Namespaces the counters (e.g. "login", "upload")
+	Limit  int           // Requests allowed per window
+	Burst  int           // Extra requests tolerated beyond the limit before blocking
+	Window time.Duration // Window the limit applies to
+}
+
+// RateLimiter enforces rate limits on top of a pluggable store
 type RateLimiter struct {
+	store       RateLimitStore
+	forgiveness int // Default burst allowance for Allow
+}
+
+// NewRateLimiter creates a new in-memory rate limiter with forgiveness support (single-node setups)
+func NewRateLimiter(forgiveness int) *RateLimiter {
+	return NewRateLimiterWithStore(NewMemoryRateLimitStore(), forgiveness)
+}
+
+// NewRateLimiterWithStore creates a rate limiter backed by the given store
+// Use a shared store (e.g. Redis) when running more than one replica
+func NewRateLimiterWithStore(store RateLimitStore, forgiveness int) *RateLimiter {
+	return &RateLimiter{
+		store:       store,
+		forgiveness: forgiveness,
+	}
+}
+
+// Allow checks if a request from the given IP is allowed under the rate limit
+// Returns: allowed, remaining tokens, reset time
+func (rl *RateLimiter) Allow(ip string, limit int, window time.Duration) (bool, int, time.Time) {
+	return rl.store.Allow(ip, limit, rl.forgiveness, window)
+}
+
+// AllowPolicy checks if a request from the given client key (user or IP) is allowed under a policy
+// Returns: allowed, remaining requests, reset time
+func (rl *RateLimiter) AllowPolicy(key string, policy RateLimitPolicy) (bool, int, time.Time) {
+	return rl.store.Allow(policy.Name+":"+key, policy.Limit, policy.Burst, policy.Window)
+}
+
+// GetRemaining returns the remaining tokens for an IP without consuming
+func (rl *RateLimiter) GetRemaining(ip string, limit int, window time.Duration) int {
+	return rl.store.GetRemaining(ip, limit, window)
+}
+
+// Stop stops background work of the underlying store (useful for testing or graceful shutdown)
+func (rl *RateLimiter) Stop() {
+	if stopper, ok := rl.store.(interface{ Stop() }); ok {
+		stopper.Stop()
+	}
+}
+
+// MemoryRateLimitStore implements in-memory rate limiting with token bucket algorithm
+// and forgiveness (burst) mechanism for handling legitimate errors
+type MemoryRateLimitStore struct {
 	entries      sync.Map // map[string]*LimitEntry
-	forgiveness  int      // Number of extra requests allowed beyond limit
 	cleanupStop  chan struct{}
 	cleanupMutex sync.Mutex
 }
 
-// LimitEntry stores rate limit state for a single IP address
+// LimitEntry stores rate limit state for a single key
 type LimitEntry struct {
 	tokens          int           // Current tokens in bucket
 	lastRefill      time.Time     // Last time tokens were refilled
@@ -25,10 +86,9 @@ type LimitEntry struct {
 	mutex           sync.Mutex
 }
 
-// NewRateLimiter creates a new rate limiter with forgiveness support
-func NewRateLimiter(forgiveness int) *RateLimiter {
-	rl := &RateLimiter{
-		forgiveness: forgiveness,
+// NewMemoryRateLimitStore creates a new in-memory rate limit store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	rl := &MemoryRateLimitStore{
 		cleanupStop: make(chan struct{}),
 	}
 
@@ -38,13 +98,14 @@ func NewRateLimiter(forgiveness int) *RateLimiter {
 	return rl
 }
 
-// Allow checks if a request from the given IP is allowed under the rate limit
+// Allow checks if a request for the given key is allowed under the rate limit
+// burst is the number of forgiveness requests allowed once the bucket is empty
 // Returns: allowed, remaining tokens, reset time
-func (rl *RateLimiter) Allow(ip string, limit int, window time.Duration) (bool, int, time.Time) {
+func (rl *MemoryRateLimitStore) Allow(key string, limit, burst int, window time.Duration) (bool, int, time.Time) {
 	now := time.Now()
 
-	// Get or create entry for this IP
-	entryInterface, _ := rl.entries.LoadOrStore(ip, &LimitEntry{
+	// Get or create entry for this key
+	entryInterface, _ := rl.entries.LoadOrStore(key, &LimitEntry{
 		tokens:     limit,
 		lastRefill: now,
 		window:     window,
@@ -82,7 +143,7 @@ func (rl *RateLimiter) Allow(ip string, limit int, window time.Duration) (bool,
 	}
 
 	// No tokens, check if forgiveness is available
-	if entry.forgivenessUsed < rl.forgiveness {
+	if entry.forgivenessUsed < burst {
 		entry.forgivenessUsed++
 		return true, 0, resetTime // Allowed via forgiveness, but no tokens remaining
 	}
@@ -91,9 +152,9 @@ func (rl *RateLimiter) Allow(ip string, limit int, window time.Duration) (bool,
 	return false, 0, resetTime
 }
 
-// GetRemaining returns the remaining tokens for an IP without consuming
-func (rl *RateLimiter) GetRemaining(ip string, limit int, window time.Duration) int {
-	entryInterface, ok := rl.entries.Load(ip)
+// GetRemaining returns the remaining tokens for a key without consuming
+func (rl *MemoryRateLimitStore) GetRemaining(key string, limit int, window time.Duration) int {
+	entryInterface, ok := rl.entries.Load(key)
 	if !ok {
 		return limit
 	}
@@ -118,7 +179,7 @@ func (rl *RateLimiter) GetRemaining(ip string, limit int, window time.Duration)
 }
 
 // startCleanup runs periodic cleanup to remove expired entries
-func (rl *RateLimiter) startCleanup() {
+func (rl *MemoryRateLimitStore) startCleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
@@ -133,7 +194,7 @@ func (rl *RateLimiter) startCleanup() {
 }
 
 // cleanup removes entries that haven't been accessed in 2x the window duration
-func (rl *RateLimiter) cleanup() {
+func (rl *MemoryRateLimitStore) cleanup() {
 	now := time.Now()
 
 	rl.entries.Range(func(key, value interface{}) bool {
@@ -151,8 +212,8 @@ func (rl *RateLimiter) cleanup() {
 	})
 }
 
-// Stop stops the cleanup goroutine (useful for testing or graceful shutdown)
-func (rl *RateLimiter) Stop() {
+// Stop stops the cleanup goroutine
+func (rl *MemoryRateLimitStore) Stop() {
 	rl.cleanupMutex.Lock()
 	defer rl.cleanupMutex.Unlock()
 
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// rateLimitKeyPrefix namespaces rate limit counters in a shared Redis instance
+const rateLimitKeyPrefix = "ratelimit:"
+
+// slidingWindowScript implements a sliding-window counter atomically
+// The previous fixed window's count is weighted by how much of it still overlaps the sliding window
+// KEYS: current window counter, previous window counter
+// ARGV: limit, burst, window (ms), elapsed time in current window (ms), consume (1/0)
+// Returns: allowed (1/0), weighted count including this request when consumed
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local limit = tonumber(ARGV[1])
+local allowance = limit + tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local elapsed = tonumber(ARGV[4])
+
+local weighted = math.floor(previous * (window - elapsed) / window) + current
+if ARGV[5] == '0' then
+	return {1, weighted}
+end
+if weighted >= allowance then
+	return {0, weighted}
+end
+
+redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {1, weighted + 1}
+`)
+
+// RedisRateLimitStore implements rate limiting with sliding-window counters in Redis
+// so limits hold across every replica
+type RedisRateLimitStore struct {
+	redis *redis.Client
+}
+
+// NewRedisRateLimitStore creates a Redis-backed rate limit store
+func NewRedisRateLimitStore(redisClient *redis.Client) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		redis: redisClient,
+	}
+}
+
+// Allow checks if a request for the given key is allowed under the rate limit
+// burst is the number of extra requests tolerated beyond the limit
+// Returns: allowed, remaining requests, reset time
+// Redis errors are logged and the request is allowed so an outage doesn't take login down with it
+func (rs *RedisRateLimitStore) Allow(key string, limit, burst int, window time.Duration) (bool, int, time.Time) {
+	allowed, count, resetTime, err := rs.run(key, limit, burst, window, true)
+	if err != nil {
+		log.Printf("[RateLimit] Redis check failed for %s: %v", key, err)
+		return true, limit, resetTime
+	}
+
+	return allowed, max(0, limit-count), resetTime
+}
+
+// GetRemaining returns the remaining requests for a key without consuming
+func (rs *RedisRateLimitStore) GetRemaining(key string, limit int, window time.Duration) int {
+	_, count, _, err := rs.run(key, limit, 0, window, false)
+	if err != nil {
+		log.Printf("[RateLimit] Redis lookup failed for %s: %v", key, err)
+		return limit
+	}
+
+	return max(0, limit-count)
+}
+
+// run evaluates the sliding-window script for the window containing now
+func (rs *RedisRateLimitStore) run(key string, limit, burst int, window time.Duration, consume bool) (bool, int, time.Time, error) {
+	now := time.Now()
+	windowMs := window.Milliseconds()
+	if windowMs <= 0 {
+		windowMs = 1
+	}
+
+	nowMs := now.UnixMilli()
+	windowIndex := nowMs / windowMs
+	elapsed := nowMs - windowIndex*windowMs
+	resetTime := time.UnixMilli((windowIndex + 1) * windowMs)
+
+	keys := []string{
+		fmt.Sprintf("%s%s:%d", rateLimitKeyPrefix, key, windowIndex),
+		fmt.Sprintf("%s%s:%d", rateLimitKeyPrefix, key, windowIndex-1),
+	}
+
+	consumeArg := 0
+	if consume {
+		consumeArg = 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisStoreTimeout)
+	defer cancel()
+
+	result, err := slidingWindowScript.Run(ctx, rs.redis, keys, limit, burst, windowMs, elapsed, consumeArg).Slice()
+	if err != nil {
+		return false, 0, resetTime, err
+	}
+
+	if len(result) != 2 {
+		return false, 0, resetTime, fmt.Errorf("unexpected script result: %v", result)
+	}
+
+	allowed, _ := result[0].(int64)
+	count, _ := result[1].(int64)
+
+	return allowed == 1, int(count), resetTime, nil
+}
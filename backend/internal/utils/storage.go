@@ -1,9 +1,7 @@
 package utils
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -14,30 +12,50 @@ import (
 	"time"
 
 	"upvista-community-backend/internal/config"
+	"upvista-community-backend/internal/inspect"
+	"upvista-community-backend/internal/models"
+	"upvista-community-backend/internal/storage"
 	apperr "upvista-community-backend/pkg/errors"
 
 	"github.com/google/uuid"
 )
 
-// StorageService handles file storage operations with Supabase Storage
+// StorageService handles file storage operations on top of a storage driver (Supabase, local disk or S3)
 type StorageService struct {
-	config      *config.StorageConfig
-	supabaseURL string
-	apiKey      string
-	http        *http.Client
+	config         *config.StorageConfig
+	driver         storage.Storage
+	privateBuckets map[string]bool
+	signedURLTTL   time.Duration
+	inspector      *inspect.Inspector // Optional, checks profile pictures are images before they are stored
 }
 
 // NewStorageService creates a new storage service
-func NewStorageService(storageConfig *config.StorageConfig, supabaseURL, apiKey string) *StorageService {
+// Objects in privateBuckets are only handed out as signed URLs valid for signedURLTTL
+func NewStorageService(storageConfig *config.StorageConfig, driver storage.Storage, privateBuckets []string, signedURLTTL time.Duration) *StorageService {
+	private := make(map[string]bool, len(privateBuckets))
+	for _, bucket := range privateBuckets {
+		private[bucket] = true
+	}
+
 	return &StorageService{
-		config:      storageConfig,
-		supabaseURL: supabaseURL,
-		apiKey:      apiKey,
-		http:        &http.Client{Timeout: 30 * time.Second},
+		config:         storageConfig,
+		driver:         driver,
+		privateBuckets: private,
+		signedURLTTL:   signedURLTTL,
 	}
 }
 
-// UploadProfilePicture uploads a profile picture to Supabase Storage
+// SetInspector sniffs profile pictures and rejects anything but the allowed image types, whatever they are declared as
+func (s *StorageService) SetInspector(inspector *inspect.Inspector) {
+	s.inspector = inspector
+}
+
+// Driver returns the underlying storage driver
+func (s *StorageService) Driver() storage.Storage {
+	return s.driver
+}
+
+// UploadProfilePicture uploads a profile picture
 func (s *StorageService) UploadProfilePicture(ctx context.Context, userID uuid.UUID, file multipart.File, header *multipart.FileHeader) (string, error) {
 	// Validate file size
 	if header.Size > s.config.MaxFileSize {
@@ -64,131 +82,115 @@ func (s *StorageService) UploadProfilePicture(ctx context.Context, userID uuid.U
 		log.Printf("[Storage] Content-Type not provided, detected as: %s", contentType)
 	}
 
+	ext := filepath.Ext(header.Filename)
+	if s.inspector != nil {
+		// The declared type is only a claim, the stored type is the one sniffed from the content
+		upload := inspect.Upload{UserID: userID, Kind: models.MediaKindImage, Bucket: s.config.BucketName, Filename: header.Filename, ContentType: header.Header.Get("Content-Type")}
+		result, err := s.inspector.Inspect(ctx, upload, file)
+		if err != nil {
+			return "", err
+		}
+		contentType = result.ContentType
+		ext = result.Extension(ext)
+	}
+
 	if !s.isAllowedFileType(contentType) {
 		return "", apperr.NewAppError(400, fmt.Sprintf("File type %s is not allowed. Allowed types: %s", contentType, s.config.AllowedFileTypes))
 	}
 
 	// Generate unique filename
-	ext := filepath.Ext(header.Filename)
 	filename := fmt.Sprintf("%s/%s%s", userID.String(), uuid.New().String(), ext)
 
-	// Read file content
-	fileBytes, err := io.ReadAll(file)
-	if err != nil {
-		log.Printf("[Storage] Error reading file: %v", err)
-		return "", apperr.NewAppError(http.StatusInternalServerError, fmt.Sprintf("Failed to read file: %v", err))
-	}
-
-	// Upload to Supabase Storage
-	// Supabase Storage API format: POST /storage/v1/object/{bucket}/{path}
-	uploadURL := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.supabaseURL, s.config.BucketName, filename)
-	log.Printf("[Storage] Uploading to: %s (bucket: %s, filename: %s, size: %d bytes, content-type: %s)",
-		uploadURL, s.config.BucketName, filename, len(fileBytes), contentType)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(fileBytes))
-	if err != nil {
-		log.Printf("[Storage] Error creating request: %v", err)
-		return "", apperr.NewAppError(http.StatusInternalServerError, fmt.Sprintf("Failed to create upload request: %v", err))
-	}
+	log.Printf("[Storage] Uploading to %s (bucket: %s, filename: %s, size: %d bytes, content-type: %s)",
+		s.driver.Name(), s.config.BucketName, filename, header.Size, contentType)
 
-	// Set required Supabase Storage headers
-	req.Header.Set("apikey", s.apiKey)
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-	req.Header.Set("Content-Type", contentType)
-	req.Header.Set("x-upsert", "true") // Allow overwriting existing files
-
-	resp, err := s.http.Do(req)
-	if err != nil {
-		log.Printf("[Storage] Error uploading file to Supabase: %v", err)
+	// Streamed straight from the multipart file, never held in memory
+	if err := s.driver.Put(ctx, s.config.BucketName, filename, file, header.Size, contentType); err != nil {
+		log.Printf("[Storage] Error uploading file: %v", err)
 		return "", apperr.NewAppError(http.StatusInternalServerError, fmt.Sprintf("Failed to upload to storage: %v", err))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		errorMsg := fmt.Sprintf("Supabase storage error (status %d): %s", resp.StatusCode, string(bodyBytes))
-		log.Printf("[Storage] %s", errorMsg)
-		return "", apperr.NewAppError(resp.StatusCode, errorMsg)
-	}
-
-	// Get public URL
-	publicURL := fmt.Sprintf("%s/storage/v1/object/public/%s/%s", s.supabaseURL, s.config.BucketName, filename)
-
-	return publicURL, nil
+	return s.driver.URL(s.config.BucketName, filename), nil
 }
 
-// UploadFile uploads a file to Supabase Storage with custom bucket and path
+// UploadFile streams a file to a bucket and returns its permanent URL
+// Objects in private buckets can't be fetched with that URL, hand them out with SignURL
 func (s *StorageService) UploadFile(ctx context.Context, bucketName, filePath string, file io.Reader, contentType string) (string, error) {
-	// Read file content
-	fileBytes, err := io.ReadAll(file)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+	if err := s.driver.Put(ctx, bucketName, filePath, file, readerSize(file), contentType); err != nil {
+		return "", err
 	}
 
-	// Upload to Supabase Storage
-	uploadURL := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.supabaseURL, bucketName, filePath)
+	return s.driver.URL(bucketName, filePath), nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(fileBytes))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+// readerSize returns how many bytes are left in seekable readers (multipart files, bytes.Reader), -1 otherwise
+func readerSize(r io.Reader) int64 {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return -1
 	}
 
-	req.Header.Set("apikey", s.apiKey)
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-	req.Header.Set("Content-Type", contentType)
-
-	resp, err := s.http.Do(req)
+	current, err := seeker.Seek(0, io.SeekCurrent)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %w", err)
+		return -1
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return -1
 	}
+	return end - current
+}
 
-	// Get public URL
-	publicURL := fmt.Sprintf("%s/storage/v1/object/public/%s/%s", s.supabaseURL, bucketName, filePath)
+// DeleteFile deletes an object from a bucket
+func (s *StorageService) DeleteFile(ctx context.Context, bucketName, filePath string) error {
+	return s.driver.Delete(ctx, bucketName, filePath)
+}
 
-	return publicURL, nil
+// DeleteURL deletes the object behind a URL returned by UploadFile or SignURL
+func (s *StorageService) DeleteURL(ctx context.Context, fileURL string) error {
+	bucket, key, ok := s.driver.ParseURL(fileURL)
+	if !ok {
+		return apperr.NewAppError(400, "Invalid file URL")
+	}
+	return s.driver.Delete(ctx, bucket, key)
 }
 
-// DeleteProfilePicture deletes a profile picture from Supabase Storage
+// DeleteProfilePicture deletes a profile picture
 func (s *StorageService) DeleteProfilePicture(ctx context.Context, pictureURL string) error {
-	// Extract filename from URL
-	// URL format: https://xxx.supabase.co/storage/v1/object/public/bucket/filename
-	parts := strings.Split(pictureURL, "/"+s.config.BucketName+"/")
-	if len(parts) < 2 {
+	bucket, _, ok := s.driver.ParseURL(pictureURL)
+	if !ok || bucket != s.config.BucketName {
 		return apperr.NewAppError(400, "Invalid picture URL")
 	}
-	filename := parts[1]
-
-	deleteURL := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.supabaseURL, s.config.BucketName, filename)
+	return s.DeleteURL(ctx, pictureURL)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, deleteURL, nil)
-	if err != nil {
-		return apperr.ErrInternalServer
-	}
+// IsPrivateBucket reports whether a bucket is only reachable through signed URLs
+func (s *StorageService) IsPrivateBucket(bucketName string) bool {
+	return s.privateBuckets[bucketName]
+}
 
-	req.Header.Set("apikey", s.apiKey)
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+// SignedURL returns a URL granting read access to an object until it expires
+func (s *StorageService) SignedURL(ctx context.Context, bucketName, filePath string, expiresIn time.Duration) (string, error) {
+	return s.driver.SignedURL(ctx, bucketName, filePath, expiresIn)
+}
 
-	resp, err := s.http.Do(req)
-	if err != nil {
-		return apperr.ErrInternalServer
+// SignURL turns the permanent URL of an object in a private bucket into a signed, expiring URL
+// Other URLs (public buckets, external links) are returned unchanged
+func (s *StorageService) SignURL(ctx context.Context, fileURL string) string {
+	bucket, key, ok := s.driver.ParseURL(fileURL)
+	if !ok || !s.privateBuckets[bucket] {
+		return fileURL
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		// Ignore 404 errors (file might already be deleted)
-		if resp.StatusCode != 404 {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return apperr.NewAppError(resp.StatusCode, fmt.Sprintf("Failed to delete file: %s", string(bodyBytes)))
-		}
+	signedURL, err := s.driver.SignedURL(ctx, bucket, key, s.signedURLTTL)
+	if err != nil {
+		log.Printf("[Storage] Failed to sign URL for %s/%s: %v", bucket, key, err)
+		return fileURL
 	}
-
-	return nil
+	return signedURL
 }
 
 // isAllowedFileType checks if the file type is allowed
@@ -201,46 +203,3 @@ func (s *StorageService) isAllowedFileType(contentType string) bool {
 	}
 	return false
 }
-
-// CreateBucket creates a storage bucket in Supabase (one-time setup)
-func (s *StorageService) CreateBucket(ctx context.Context) error {
-	createURL := fmt.Sprintf("%s/storage/v1/bucket", s.supabaseURL)
-
-	bucketData := map[string]interface{}{
-		"id":                 s.config.BucketName,
-		"name":               s.config.BucketName,
-		"public":             true,
-		"file_size_limit":    s.config.MaxFileSize,
-		"allowed_mime_types": strings.Split(s.config.AllowedFileTypes, ","),
-	}
-
-	body, err := json.Marshal(bucketData)
-	if err != nil {
-		return apperr.ErrInternalServer
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, createURL, bytes.NewReader(body))
-	if err != nil {
-		return apperr.ErrInternalServer
-	}
-
-	req.Header.Set("apikey", s.apiKey)
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.http.Do(req)
-	if err != nil {
-		return apperr.ErrInternalServer
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		// Bucket might already exist, which is fine
-		if resp.StatusCode != 409 {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return apperr.NewAppError(resp.StatusCode, fmt.Sprintf("Failed to create bucket: %s", string(bodyBytes)))
-		}
-	}
-
-	return nil
-}
@@ -7,11 +7,21 @@ import (
 	"time"
 )
 
-// TokenBlacklist manages blacklisted JWT tokens in memory
+// sessionKeyPrefix namespaces revoked session IDs so they can't collide with token hashes
+const sessionKeyPrefix = "session:"
+
+// BlacklistStore persists blacklist entries until they expire
+// Keys are token hashes or namespaced session IDs, never raw tokens
+type BlacklistStore interface {
+	Set(key string, expiresAt time.Time)
+	Exists(key string) bool
+	Size() int
+}
+
+// TokenBlacklist manages blacklisted JWT tokens and revoked sessions
 // Tokens are stored by their SHA256 hash for security
 type TokenBlacklist struct {
-	entries     sync.Map // map[string]*BlacklistEntry
-	cleanupStop chan struct{}
+	store BlacklistStore
 }
 
 // BlacklistEntry represents a blacklisted token with expiry
@@ -19,36 +29,89 @@ type BlacklistEntry struct {
 	expiresAt time.Time
 }
 
-// NewTokenBlacklist creates a new token blacklist
+// NewTokenBlacklist creates a new in-memory token blacklist (single-node setups)
 func NewTokenBlacklist() *TokenBlacklist {
-	tb := &TokenBlacklist{
-		cleanupStop: make(chan struct{}),
-	}
-
-	// Start cleanup goroutine to remove expired entries
-	go tb.startCleanup()
+	return NewTokenBlacklistWithStore(NewMemoryBlacklistStore())
+}
 
-	return tb
+// NewTokenBlacklistWithStore creates a token blacklist backed by the given store
+// Use a shared store (e.g. Redis) when running more than one replica
+func NewTokenBlacklistWithStore(store BlacklistStore) *TokenBlacklist {
+	return &TokenBlacklist{
+		store: store,
+	}
 }
 
 // Add adds a token to the blacklist until it expires
 // tokenExpiry is when the token itself expires (we blacklist until then)
 func (tb *TokenBlacklist) Add(token string, tokenExpiry time.Time) {
 	// Hash the token for storage (don't store full token)
-	tokenHash := tb.hashToken(token)
+	tb.store.Set(tb.hashToken(token), tokenExpiry)
+}
 
-	entry := &BlacklistEntry{
-		expiresAt: tokenExpiry,
+// IsBlacklisted checks if a token is blacklisted
+func (tb *TokenBlacklist) IsBlacklisted(token string) bool {
+	return tb.store.Exists(tb.hashToken(token))
+}
+
+// RevokeSession revokes every access token issued for a session
+// until should be at least the access token lifetime; after that the tokens have expired on their own
+func (tb *TokenBlacklist) RevokeSession(sessionID string, until time.Time) {
+	tb.store.Set(sessionKeyPrefix+sessionID, until)
+}
+
+// IsSessionRevoked checks if a session has been revoked
+func (tb *TokenBlacklist) IsSessionRevoked(sessionID string) bool {
+	return tb.store.Exists(sessionKeyPrefix + sessionID)
+}
+
+// Stop stops background work of the underlying store (useful for testing or graceful shutdown)
+func (tb *TokenBlacklist) Stop() {
+	if stopper, ok := tb.store.(interface{ Stop() }); ok {
+		stopper.Stop()
 	}
+}
 
-	tb.entries.Store(tokenHash, entry)
+// Size returns the number of blacklist entries (for monitoring)
+func (tb *TokenBlacklist) Size() int {
+	return tb.store.Size()
 }
 
-// IsBlacklisted checks if a token is blacklisted
-func (tb *TokenBlacklist) IsBlacklisted(token string) bool {
-	tokenHash := tb.hashToken(token)
+// hashToken creates a SHA256 hash of the token
+// This ensures we don't store the actual token in memory
+func (tb *TokenBlacklist) hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// MemoryBlacklistStore keeps blacklist entries in process memory
+type MemoryBlacklistStore struct {
+	entries     sync.Map // map[string]*BlacklistEntry
+	cleanupStop chan struct{}
+}
+
+// NewMemoryBlacklistStore creates an in-memory blacklist store
+func NewMemoryBlacklistStore() *MemoryBlacklistStore {
+	ms := &MemoryBlacklistStore{
+		cleanupStop: make(chan struct{}),
+	}
+
+	// Start cleanup goroutine to remove expired entries
+	go ms.startCleanup()
+
+	return ms
+}
+
+// Set stores an entry until expiresAt
+func (ms *MemoryBlacklistStore) Set(key string, expiresAt time.Time) {
+	ms.entries.Store(key, &BlacklistEntry{
+		expiresAt: expiresAt,
+	})
+}
 
-	entryInterface, ok := tb.entries.Load(tokenHash)
+// Exists checks if an unexpired entry exists
+func (ms *MemoryBlacklistStore) Exists(key string) bool {
+	entryInterface, ok := ms.entries.Load(key)
 	if !ok {
 		return false
 	}
@@ -58,62 +121,55 @@ func (tb *TokenBlacklist) IsBlacklisted(token string) bool {
 	// Check if entry has expired (token itself expired)
 	if time.Now().After(entry.expiresAt) {
 		// Remove expired entry
-		tb.entries.Delete(tokenHash)
+		ms.entries.Delete(key)
 		return false
 	}
 
 	return true
 }
 
-// hashToken creates a SHA256 hash of the token
-// This ensures we don't store the actual token in memory
-func (tb *TokenBlacklist) hashToken(token string) string {
-	hash := sha256.Sum256([]byte(token))
-	return hex.EncodeToString(hash[:])
-}
-
 // startCleanup runs periodic cleanup to remove expired entries
-func (tb *TokenBlacklist) startCleanup() {
+func (ms *MemoryBlacklistStore) startCleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			tb.cleanup()
-		case <-tb.cleanupStop:
+			ms.cleanup()
+		case <-ms.cleanupStop:
 			return
 		}
 	}
 }
 
 // cleanup removes entries that have expired
-func (tb *TokenBlacklist) cleanup() {
+func (ms *MemoryBlacklistStore) cleanup() {
 	now := time.Now()
 
-	tb.entries.Range(func(key, value interface{}) bool {
+	ms.entries.Range(func(key, value interface{}) bool {
 		entry := value.(*BlacklistEntry)
 		if now.After(entry.expiresAt) {
-			tb.entries.Delete(key)
+			ms.entries.Delete(key)
 		}
 		return true
 	})
 }
 
-// Stop stops the cleanup goroutine (useful for testing or graceful shutdown)
-func (tb *TokenBlacklist) Stop() {
+// Stop stops the cleanup goroutine
+func (ms *MemoryBlacklistStore) Stop() {
 	select {
-	case <-tb.cleanupStop:
+	case <-ms.cleanupStop:
 		// Already closed
 	default:
-		close(tb.cleanupStop)
+		close(ms.cleanupStop)
 	}
 }
 
-// Size returns the number of blacklisted tokens (for monitoring)
-func (tb *TokenBlacklist) Size() int {
+// Size returns the number of stored entries
+func (ms *MemoryBlacklistStore) Size() int {
 	count := 0
-	tb.entries.Range(func(key, value interface{}) bool {
+	ms.entries.Range(func(key, value interface{}) bool {
 		count++
 		return true
 	})
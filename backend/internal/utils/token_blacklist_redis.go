@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// blacklistKeyPrefix namespaces blacklist entries in a shared Redis instance
+const blacklistKeyPrefix = "blacklist:"
+
+// redisStoreTimeout bounds every Redis call made from the request path
+const redisStoreTimeout = 2 * time.Second
+
+// RedisBlacklistStore keeps blacklist entries in Redis so every replica sees them
+// Entries are plain keys with a TTL, so Redis expires them on its own. They are also kept
+// in memory, so what this replica revoked stays revoked even if Redis lost the write.
+type RedisBlacklistStore struct {
+	redis *redis.Client
+	local *MemoryBlacklistStore
+}
+
+// NewRedisBlacklistStore creates a Redis-backed blacklist store
+func NewRedisBlacklistStore(redisClient *redis.Client) *RedisBlacklistStore {
+	return &RedisBlacklistStore{
+		redis: redisClient,
+		local: NewMemoryBlacklistStore(),
+	}
+}
+
+// Set stores an entry until expiresAt
+func (rs *RedisBlacklistStore) Set(key string, expiresAt time.Time) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return // Already expired, nothing to block
+	}
+
+	rs.local.Set(key, expiresAt)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisStoreTimeout)
+	defer cancel()
+
+	if err := rs.redis.Set(ctx, blacklistKeyPrefix+key, 1, ttl).Err(); err != nil {
+		log.Printf("[Blacklist] Failed to store entry in Redis: %v", err)
+	}
+}
+
+// Exists checks if an unexpired entry exists
+// Fails closed: while Redis can't be reached every entry is treated as blacklisted, since a
+// revocation made by another replica can't be ruled out
+func (rs *RedisBlacklistStore) Exists(key string) bool {
+	if rs.local.Exists(key) {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisStoreTimeout)
+	defer cancel()
+
+	count, err := rs.redis.Exists(ctx, blacklistKeyPrefix+key).Result()
+	if err != nil {
+		log.Printf("[Blacklist] Failed to check entry in Redis, treating it as blacklisted: %v", err)
+		return true
+	}
+
+	return count > 0
+}
+
+// Size returns the number of stored entries (scans the keyspace, monitoring only)
+func (rs *RedisBlacklistStore) Size() int {
+	ctx, cancel := context.WithTimeout(context.Background(), redisStoreTimeout)
+	defer cancel()
+
+	count := 0
+	iter := rs.redis.Scan(ctx, 0, blacklistKeyPrefix+"*", 500).Iterator()
+	for iter.Next(ctx) {
+		count++
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("[Blacklist] Failed to count entries in Redis: %v", err)
+	}
+
+	return count
+}
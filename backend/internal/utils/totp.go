@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTP parameters (RFC 6238 defaults, supported by all authenticator apps)
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+
+	// Number of time steps accepted before/after the current one to tolerate clock drift
+	totpSkew = 1
+
+	// Recovery code settings
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+// recoveryCodeAlphabet excludes characters that are easily confused (0/O, 1/I/L)
+const recoveryCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// GenerateTOTPSecret generates a new random base32-encoded TOTP secret (160 bits)
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI that authenticator apps scan as a QR code
+func TOTPProvisioningURI(secret, issuer, accountName string) string {
+	label := url.PathEscape(issuer + ":" + accountName)
+
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprintf("%d", totpDigits))
+	q.Set("period", fmt.Sprintf("%d", int(totpPeriod.Seconds())))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, q.Encode())
+}
+
+// GenerateTOTPCode generates the TOTP code for the given secret at a point in time
+func GenerateTOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpCodeAt(key, uint64(t.Unix())/uint64(totpPeriod.Seconds())), nil
+}
+
+// ValidateTOTPCode checks a user-supplied code against the secret, allowing for clock skew
+// Codes of time steps up to lastStep were already used and are rejected, so a code works once.
+// Returns the time step the code belongs to, to be recorded as the new lastStep.
+func ValidateTOTPCode(secret, code string, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	counter := time.Now().Unix() / int64(totpPeriod.Seconds())
+	for offset := -totpSkew; offset <= totpSkew; offset++ {
+		step := counter + int64(offset)
+		if step <= lastStep {
+			continue
+		}
+		expected := totpCodeAt(key, uint64(step))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// GenerateRecoveryCodes generates a set of one-time recovery codes formatted as XXXXX-XXXXX
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	alphabetLen := big.NewInt(int64(len(recoveryCodeAlphabet)))
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		var sb strings.Builder
+		for j := 0; j < recoveryCodeLength; j++ {
+			if j == recoveryCodeLength/2 {
+				sb.WriteByte('-')
+			}
+			// rand.Int is uniform, every character is equally likely
+			n, err := rand.Int(rand.Reader, alphabetLen)
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteByte(recoveryCodeAlphabet[n.Int64()])
+		}
+		codes = append(codes, sb.String())
+	}
+
+	return codes, nil
+}
+
+// NormalizeRecoveryCode normalizes user input so codes match regardless of case or dashes
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// HashRecoveryCode hashes a recovery code for storage (codes are never stored in plaintext)
+func HashRecoveryCode(code string) string {
+	return HashToken(NormalizeRecoveryCode(code))
+}
+
+// decodeTOTPSecret decodes a base32 secret, tolerating lowercase and missing padding
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+}
+
+// totpCodeAt computes the HOTP value (RFC 4226) for a counter
+func totpCodeAt(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
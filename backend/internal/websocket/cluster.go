@@ -0,0 +1,171 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// CLUSTER EXTENSION POINTS
+// =====================================================
+//
+// A single node keeps everything in memory. In cluster mode the manager publishes
+// broadcasts to a Bus so every node delivers them to its own sockets, and keeps
+// presence and ACK state in shared stores (see cluster_redis.go).
+
+// Bus fans broadcast messages out to every node in the cluster
+type Bus interface {
+	// Publish sends a broadcast to all nodes (including this one)
+	Publish(ctx context.Context, message *BroadcastMessage) error
+	// Subscribe delivers broadcasts published by any node until ctx is cancelled
+	Subscribe(ctx context.Context, deliver func(message *BroadcastMessage))
+}
+
+// PresenceStore tracks which users have an open connection on any node
+type PresenceStore interface {
+	// Connected records a connection for a user
+	Connected(userID, connectionID uuid.UUID)
+	// Disconnected removes a connection for a user
+	Disconnected(userID, connectionID uuid.UUID)
+	// Refresh keeps this node's connections alive (entries of crashed nodes expire)
+	Refresh(connections map[uuid.UUID][]uuid.UUID)
+	// IsOnline reports whether the user has at least one connection on any node
+	IsOnline(userID uuid.UUID) bool
+}
+
+// PendingStore holds messages awaiting acknowledgment
+type PendingStore interface {
+	// Add starts tracking a message
+	Add(pending *PendingMessage)
+	// Acknowledge stops tracking a message if it was sent to userID, reporting whether it was pending
+	Acknowledge(messageID string, userID uuid.UUID) bool
+	// Due claims messages whose ACK timed out so only one node retries each of them
+	Due(now time.Time, ackTimeout time.Duration) []*PendingMessage
+	// Update saves a message after a retry
+	Update(pending *PendingMessage)
+	// Remove stops tracking a message
+	Remove(messageID string)
+	// Count returns the number of pending messages
+	Count() int
+}
+
+// EnableCluster switches the manager to cluster mode
+// Must be called before Run
+func (m *Manager) EnableCluster(bus Bus, presence PresenceStore, pending PendingStore) {
+	m.bus = bus
+	m.presence = presence
+	m.pending = pending
+}
+
+// runBusSubscriber delivers broadcasts from the bus to local connections
+func (m *Manager) runBusSubscriber() {
+	m.bus.Subscribe(m.ctx, func(message *BroadcastMessage) {
+		select {
+		case m.broadcast <- message:
+		case <-m.ctx.Done():
+		}
+	})
+}
+
+// refreshPresence periodically re-announces this node's connections to the presence store
+func (m *Manager) refreshPresence() {
+	ticker := time.NewTicker(presenceRefreshPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.mu.RLock()
+			connections := make(map[uuid.UUID][]uuid.UUID, len(m.connections))
+			for userID, conns := range m.connections {
+				for _, conn := range conns {
+					connections[userID] = append(connections[userID], conn.ID)
+				}
+			}
+			m.mu.RUnlock()
+
+			m.presence.Refresh(connections)
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// =====================================================
+// IN-MEMORY PENDING STORE (single node)
+// =====================================================
+
+// memoryPendingStore keeps pending messages in process memory
+type memoryPendingStore struct {
+	messages map[string]*PendingMessage
+	mu       sync.RWMutex
+}
+
+func newMemoryPendingStore() *memoryPendingStore {
+	return &memoryPendingStore{
+		messages: make(map[string]*PendingMessage),
+	}
+}
+
+func (s *memoryPendingStore) Add(pending *PendingMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[pending.ID] = pending
+}
+
+func (s *memoryPendingStore) Acknowledge(messageID string, userID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, exists := s.messages[messageID]
+	if !exists || pending.RecipientID != userID {
+		return false
+	}
+
+	pending.Acknowledged = true
+	delete(s.messages, messageID)
+	return true
+}
+
+func (s *memoryPendingStore) Due(now time.Time, ackTimeout time.Duration) []*PendingMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*PendingMessage
+	for messageID, pending := range s.messages {
+		if pending.Acknowledged {
+			delete(s.messages, messageID)
+			continue
+		}
+		if now.Sub(pending.SentAt) > ackTimeout {
+			claimed := *pending
+			due = append(due, &claimed)
+		}
+	}
+	return due
+}
+
+func (s *memoryPendingStore) Update(pending *PendingMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Don't resurrect a message that was acknowledged while being retried
+	if _, exists := s.messages[pending.ID]; exists {
+		s.messages[pending.ID] = pending
+	}
+}
+
+func (s *memoryPendingStore) Remove(messageID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.messages, messageID)
+}
+
+func (s *memoryPendingStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.messages)
+}
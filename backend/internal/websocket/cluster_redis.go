@@ -0,0 +1,334 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Redis key patterns for cluster mode
+const (
+	// Broadcast fan-out channel shared by all nodes
+	keyBroadcastChannel = "ws:broadcast" // PUB/SUB
+
+	// Connections per user across nodes - member: connection ID, score: expiry (unix ms)
+	keyPresence = "ws:presence:%s" // ZSET
+
+	// Pending messages awaiting ACK - field: message ID, value: PendingMessage JSON
+	keyPending = "ws:pending" // HASH
+
+	// Retry schedule for pending messages - member: message ID, score: last send time (unix ms)
+	keyPendingDue = "ws:pending:due" // ZSET
+)
+
+const (
+	// Presence entries of a node that stops refreshing them expire after this long
+	presenceTTL = 90 * time.Second
+
+	// How often a node re-announces its connections (must be less than presenceTTL)
+	presenceRefreshPeriod = 30 * time.Second
+
+	// Timeout for a single Redis call made by the manager
+	redisCallTimeout = 2 * time.Second
+
+	// Maximum pending messages claimed per retry tick
+	pendingClaimBatch = 100
+)
+
+// =====================================================
+// REDIS BUS
+// =====================================================
+
+// RedisBus fans broadcasts out to every node through Redis pub/sub
+type RedisBus struct {
+	redis *redis.Client
+}
+
+// NewRedisBus creates a Redis pub/sub bus
+func NewRedisBus(redisClient *redis.Client) *RedisBus {
+	return &RedisBus{
+		redis: redisClient,
+	}
+}
+
+// Publish sends a broadcast to all nodes
+func (b *RedisBus) Publish(ctx context.Context, message *BroadcastMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redisCallTimeout)
+	defer cancel()
+
+	return b.redis.Publish(ctx, keyBroadcastChannel, data).Err()
+}
+
+// Subscribe delivers broadcasts until ctx is cancelled (go-redis reconnects automatically)
+func (b *RedisBus) Subscribe(ctx context.Context, deliver func(message *BroadcastMessage)) {
+	pubsub := b.redis.Subscribe(ctx, keyBroadcastChannel)
+	defer pubsub.Close()
+
+	log.Printf("[WebSocket] Subscribed to cluster channel %s", keyBroadcastChannel)
+
+	channel := pubsub.Channel()
+	for {
+		select {
+		case msg, ok := <-channel:
+			if !ok {
+				return
+			}
+
+			var message BroadcastMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				log.Printf("[WebSocket] Failed to decode cluster broadcast: %v", err)
+				continue
+			}
+			deliver(&message)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// =====================================================
+// REDIS PRESENCE STORE
+// =====================================================
+
+// RedisPresenceStore tracks connections of all nodes in Redis
+// Each connection is a sorted set member scored by its expiry, refreshed by the owning node
+type RedisPresenceStore struct {
+	redis *redis.Client
+}
+
+// NewRedisPresenceStore creates a Redis-backed presence store
+func NewRedisPresenceStore(redisClient *redis.Client) *RedisPresenceStore {
+	return &RedisPresenceStore{
+		redis: redisClient,
+	}
+}
+
+// Connected records a connection for a user
+func (s *RedisPresenceStore) Connected(userID, connectionID uuid.UUID) {
+	s.Refresh(map[uuid.UUID][]uuid.UUID{userID: {connectionID}})
+}
+
+// Disconnected removes a connection for a user
+func (s *RedisPresenceStore) Disconnected(userID, connectionID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	if err := s.redis.ZRem(ctx, fmt.Sprintf(keyPresence, userID), connectionID.String()).Err(); err != nil {
+		log.Printf("[WebSocket] Failed to remove presence for user %s: %v", userID, err)
+	}
+}
+
+// Refresh extends the expiry of the given connections
+func (s *RedisPresenceStore) Refresh(connections map[uuid.UUID][]uuid.UUID) {
+	if len(connections) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	expiresAt := float64(time.Now().Add(presenceTTL).UnixMilli())
+
+	pipe := s.redis.Pipeline()
+	for userID, connectionIDs := range connections {
+		key := fmt.Sprintf(keyPresence, userID)
+		for _, connectionID := range connectionIDs {
+			pipe.ZAdd(ctx, key, &redis.Z{Score: expiresAt, Member: connectionID.String()})
+		}
+		// Drop connections of nodes that stopped refreshing
+		pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("%d", time.Now().UnixMilli()))
+		pipe.Expire(ctx, key, presenceTTL)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[WebSocket] Failed to refresh presence: %v", err)
+	}
+}
+
+// IsOnline reports whether the user has an unexpired connection on any node
+func (s *RedisPresenceStore) IsOnline(userID uuid.UUID) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	count, err := s.redis.ZCount(ctx, fmt.Sprintf(keyPresence, userID), fmt.Sprintf("%d", time.Now().UnixMilli()), "+inf").Result()
+	if err != nil {
+		log.Printf("[WebSocket] Failed to check presence for user %s: %v", userID, err)
+		return false
+	}
+
+	return count > 0
+}
+
+// =====================================================
+// REDIS PENDING STORE
+// =====================================================
+
+// claimDueScript atomically picks pending messages whose ACK timed out and reschedules them,
+// so exactly one node retries each message per timeout
+// KEYS: due schedule, pending hash
+// ARGV: cutoff (unix ms), now (unix ms), batch size
+var claimDueScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[3]))
+local result = {}
+for _, id in ipairs(ids) do
+	local data = redis.call('HGET', KEYS[2], id)
+	if data then
+		redis.call('ZADD', KEYS[1], ARGV[2], id)
+		table.insert(result, data)
+	else
+		redis.call('ZREM', KEYS[1], id)
+	end
+end
+return result
+`)
+
+// acknowledgeScript removes a pending message if it was sent to the acknowledging user
+// KEYS: pending hash, due schedule
+// ARGV: message ID, user ID
+var acknowledgeScript = redis.NewScript(`
+local data = redis.call('HGET', KEYS[1], ARGV[1])
+if not data then
+	return 0
+end
+if cjson.decode(data)['RecipientID'] ~= ARGV[2] then
+	return 0
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+return 1
+`)
+
+// updateIfPendingScript saves a retried message unless it was acknowledged meanwhile
+// KEYS: pending hash
+// ARGV: message ID, message JSON
+var updateIfPendingScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+end
+return 1
+`)
+
+// RedisPendingStore keeps messages awaiting ACK in Redis so any node can accept the ACK or retry
+type RedisPendingStore struct {
+	redis *redis.Client
+}
+
+// NewRedisPendingStore creates a Redis-backed pending message store
+func NewRedisPendingStore(redisClient *redis.Client) *RedisPendingStore {
+	return &RedisPendingStore{
+		redis: redisClient,
+	}
+}
+
+// Add starts tracking a message
+func (s *RedisPendingStore) Add(pending *PendingMessage) {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		log.Printf("[WebSocket] Failed to marshal pending message %s: %v", pending.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, keyPending, pending.ID, data)
+	pipe.ZAdd(ctx, keyPendingDue, &redis.Z{Score: float64(pending.SentAt.UnixMilli()), Member: pending.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[WebSocket] Failed to store pending message %s: %v", pending.ID, err)
+	}
+}
+
+// Acknowledge stops tracking a message if it was sent to userID
+func (s *RedisPendingStore) Acknowledge(messageID string, userID uuid.UUID) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	removed, err := acknowledgeScript.Run(ctx, s.redis, []string{keyPending, keyPendingDue}, messageID, userID.String()).Int()
+	if err != nil {
+		log.Printf("[WebSocket] Failed to acknowledge message %s: %v", messageID, err)
+		return false
+	}
+
+	return removed == 1
+}
+
+// Due claims messages whose ACK timed out
+func (s *RedisPendingStore) Due(now time.Time, ackTimeout time.Duration) []*PendingMessage {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	cutoff := now.Add(-ackTimeout).UnixMilli()
+	rows, err := claimDueScript.Run(ctx, s.redis, []string{keyPendingDue, keyPending}, cutoff, now.UnixMilli(), pendingClaimBatch).StringSlice()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("[WebSocket] Failed to claim pending messages: %v", err)
+		}
+		return nil
+	}
+
+	due := make([]*PendingMessage, 0, len(rows))
+	for _, row := range rows {
+		var pending PendingMessage
+		if err := json.Unmarshal([]byte(row), &pending); err != nil {
+			log.Printf("[WebSocket] Failed to decode pending message: %v", err)
+			continue
+		}
+		due = append(due, &pending)
+	}
+
+	return due
+}
+
+// Update saves a message after a retry
+func (s *RedisPendingStore) Update(pending *PendingMessage) {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	if err := updateIfPendingScript.Run(ctx, s.redis, []string{keyPending}, pending.ID, data).Err(); err != nil {
+		log.Printf("[WebSocket] Failed to update pending message %s: %v", pending.ID, err)
+	}
+}
+
+// Remove stops tracking a message
+func (s *RedisPendingStore) Remove(messageID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	pipe := s.redis.TxPipeline()
+	pipe.HDel(ctx, keyPending, messageID)
+	pipe.ZRem(ctx, keyPendingDue, messageID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[WebSocket] Failed to remove pending message %s: %v", messageID, err)
+	}
+}
+
+// Count returns the number of pending messages across the cluster
+func (s *RedisPendingStore) Count() int {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	count, err := s.redis.HLen(ctx, keyPending).Result()
+	if err != nil {
+		log.Printf("[WebSocket] Failed to count pending messages: %v", err)
+		return 0
+	}
+
+	return int(count)
+}
@@ -0,0 +1,303 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+// Time allowed for a single inbound command to complete
+const commandTimeout = 10 * time.Second
+
+// Maximum topics a single connection can subscribe to
+const maxTopicsPerConnection = 100
+
+// Command error codes sent back to the client
+const (
+	errCodeInvalidRequest = "invalid_request"
+	errCodeUnknownCommand = "unknown_command"
+	errCodeForbidden      = "forbidden"
+	errCodeCommandFailed  = "command_failed"
+	errCodeUnavailable    = "unavailable"
+)
+
+// ChatService executes chat commands received over the socket (implemented by messaging.MessagingService)
+type ChatService interface {
+	SendMessage(ctx context.Context, conversationID, senderID uuid.UUID, req *models.MessageRequest) (*models.Message, error)
+	StartTyping(ctx context.Context, conversationID, userID uuid.UUID, isRecording bool) error
+	StopTyping(ctx context.Context, conversationID, userID uuid.UUID) error
+	MarkAsRead(ctx context.Context, conversationID, userID uuid.UUID) error
+	AddReaction(ctx context.Context, messageID, userID uuid.UUID, emoji string) (*models.MessageReaction, error)
+	GetConversation(ctx context.Context, conversationID, userID uuid.UUID) (*models.Conversation, error)
+}
+
+// TopicAuthorizer decides whether a user may subscribe to a topic; id is the part after "<kind>:"
+type TopicAuthorizer func(ctx context.Context, userID uuid.UUID, id string) error
+
+// commandError is returned by command handlers to pick the error code sent to the client
+type commandError struct {
+	code    string
+	message string
+}
+
+func (e *commandError) Error() string {
+	return e.message
+}
+
+// SetChatService enables chat commands over the socket and "conversation:<id>" topics
+// Must be called before the server starts accepting connections
+func (m *Manager) SetChatService(chat ChatService) {
+	m.chat = chat
+	m.RegisterTopic("conversation", func(ctx context.Context, userID uuid.UUID, id string) error {
+		conversationID, err := uuid.Parse(id)
+		if err != nil {
+			return &commandError{code: errCodeInvalidRequest, message: "Invalid conversation ID"}
+		}
+		// GetConversation verifies the user is a participant
+		_, err = chat.GetConversation(ctx, conversationID, userID)
+		return err
+	})
+}
+
+// RegisterTopic allows subscriptions to topics of the given kind ("<kind>:<id>")
+// Must be called before the server starts accepting connections
+func (m *Manager) RegisterTopic(kind string, authorize TopicAuthorizer) {
+	m.topicAuthorizers[kind] = authorize
+}
+
+// handleCommand decodes, validates and executes an inbound command, then replies on this connection
+func (conn *Connection) handleCommand(command *models.WSCommand) {
+	ctx, cancel := context.WithTimeout(conn.Manager.ctx, commandTimeout)
+	defer cancel()
+
+	data, err := conn.executeCommand(ctx, command)
+	if err != nil {
+		conn.replyError(command, err)
+		return
+	}
+
+	conn.reply(&models.WSCommandReply{
+		Type:    "reply",
+		ID:      command.ID,
+		Command: command.Type,
+		Success: true,
+		Data:    data,
+	})
+}
+
+// executeCommand routes a command to its handler
+func (conn *Connection) executeCommand(ctx context.Context, command *models.WSCommand) (interface{}, error) {
+	m := conn.Manager
+
+	switch command.Type {
+	case models.WSCommandAck:
+		var req models.WSAckCommand
+		if err := decodeCommand(command, &req); err != nil {
+			return nil, err
+		}
+		m.HandleACK(req.MessageID, conn.UserID)
+		return nil, nil
+
+	case models.WSCommandSubscribe:
+		var req models.WSSubscribeCommand
+		if err := decodeCommand(command, &req); err != nil {
+			return nil, err
+		}
+		if err := m.subscribe(ctx, conn, req.Topic); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"topic": req.Topic}, nil
+
+	case models.WSCommandUnsubscribe:
+		var req models.WSSubscribeCommand
+		if err := decodeCommand(command, &req); err != nil {
+			return nil, err
+		}
+		m.unsubscribe(conn, req.Topic)
+		return map[string]interface{}{"topic": req.Topic}, nil
+	}
+
+	// Everything else is a chat command
+	if m.chat == nil {
+		if isChatCommand(command.Type) {
+			return nil, &commandError{code: errCodeUnavailable, message: "Messaging is not available"}
+		}
+		return nil, &commandError{code: errCodeUnknownCommand, message: fmt.Sprintf("Unknown command: %s", command.Type)}
+	}
+
+	switch command.Type {
+	case models.WSCommandSendMessage:
+		var req models.WSSendMessageCommand
+		if err := decodeCommand(command, &req); err != nil {
+			return nil, err
+		}
+		// Set default message type if not provided
+		if req.MessageType == "" {
+			req.MessageType = models.MessageTypeText
+		}
+		message, err := m.chat.SendMessage(ctx, req.ConversationID, conn.UserID, &req.MessageRequest)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"message": message, "temp_id": req.TempID}, nil
+
+	case models.WSCommandTyping:
+		var req models.WSTypingCommand
+		if err := decodeCommand(command, &req); err != nil {
+			return nil, err
+		}
+		if _, err := m.chat.GetConversation(ctx, req.ConversationID, conn.UserID); err != nil {
+			return nil, &commandError{code: errCodeForbidden, message: err.Error()}
+		}
+		if req.IsTyping {
+			return nil, m.chat.StartTyping(ctx, req.ConversationID, conn.UserID, req.IsRecording)
+		}
+		return nil, m.chat.StopTyping(ctx, req.ConversationID, conn.UserID)
+
+	case models.WSCommandMarkRead:
+		var req models.WSMarkReadCommand
+		if err := decodeCommand(command, &req); err != nil {
+			return nil, err
+		}
+		if _, err := m.chat.GetConversation(ctx, req.ConversationID, conn.UserID); err != nil {
+			return nil, &commandError{code: errCodeForbidden, message: err.Error()}
+		}
+		return nil, m.chat.MarkAsRead(ctx, req.ConversationID, conn.UserID)
+
+	case models.WSCommandReact:
+		var req models.WSReactCommand
+		if err := decodeCommand(command, &req); err != nil {
+			return nil, err
+		}
+		reaction, err := m.chat.AddReaction(ctx, req.MessageID, conn.UserID, req.Emoji)
+		if err != nil {
+			return nil, err
+		}
+		// A nil reaction means it was toggled off
+		if reaction == nil {
+			return map[string]interface{}{"removed": true}, nil
+		}
+		return map[string]interface{}{"reaction": reaction}, nil
+	}
+
+	return nil, &commandError{code: errCodeUnknownCommand, message: fmt.Sprintf("Unknown command: %s", command.Type)}
+}
+
+// isChatCommand reports whether a command is routed to the chat service
+func isChatCommand(commandType models.WSCommandType) bool {
+	switch commandType {
+	case models.WSCommandSendMessage, models.WSCommandTyping, models.WSCommandMarkRead, models.WSCommandReact:
+		return true
+	}
+	return false
+}
+
+// decodeCommand unmarshals and validates command data using the same binding rules as the REST API
+func decodeCommand(command *models.WSCommand, req interface{}) error {
+	if len(command.Data) == 0 {
+		return &commandError{code: errCodeInvalidRequest, message: "Missing command data"}
+	}
+	if err := json.Unmarshal(command.Data, req); err != nil {
+		return &commandError{code: errCodeInvalidRequest, message: err.Error()}
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return &commandError{code: errCodeInvalidRequest, message: err.Error()}
+	}
+	return nil
+}
+
+// subscribe authorizes and records a topic subscription for a connection
+func (m *Manager) subscribe(ctx context.Context, conn *Connection, topic string) error {
+	kind, id, ok := strings.Cut(topic, ":")
+	if !ok || id == "" {
+		return &commandError{code: errCodeInvalidRequest, message: "Topic must look like <kind>:<id>"}
+	}
+
+	authorize, exists := m.topicAuthorizers[kind]
+	if !exists {
+		return &commandError{code: errCodeInvalidRequest, message: fmt.Sprintf("Unknown topic kind: %s", kind)}
+	}
+
+	if err := authorize(ctx, conn.UserID, id); err != nil {
+		if _, isCommandErr := err.(*commandError); isCommandErr {
+			return err
+		}
+		return &commandError{code: errCodeForbidden, message: "Not allowed to subscribe to this topic"}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, already := conn.topics[topic]; already {
+		return nil
+	}
+	if len(conn.topics) >= maxTopicsPerConnection {
+		return &commandError{code: errCodeInvalidRequest, message: fmt.Sprintf("Subscription limit reached (%d)", maxTopicsPerConnection)}
+	}
+
+	conn.topics[topic] = struct{}{}
+	if m.topics[topic] == nil {
+		m.topics[topic] = make(map[*Connection]struct{})
+	}
+	m.topics[topic][conn] = struct{}{}
+
+	return nil
+}
+
+// unsubscribe removes a topic subscription for a connection
+func (m *Manager) unsubscribe(conn *Connection, topic string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeSubscription(conn, topic)
+}
+
+// removeSubscription must be called with m.mu held
+func (m *Manager) removeSubscription(conn *Connection, topic string) {
+	delete(conn.topics, topic)
+	if subscribers, ok := m.topics[topic]; ok {
+		delete(subscribers, conn)
+		if len(subscribers) == 0 {
+			delete(m.topics, topic)
+		}
+	}
+}
+
+// reply sends a command reply to this connection
+func (conn *Connection) reply(reply *models.WSCommandReply) {
+	replyBytes, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("[WebSocket] Failed to marshal command reply: %v", err)
+		return
+	}
+
+	if err := conn.SendMessage(replyBytes); err != nil {
+		log.Printf("[WebSocket] Failed to send command reply to user %s: %v", conn.UserID, err)
+	}
+}
+
+// replyError sends a command failure to this connection
+func (conn *Connection) replyError(command *models.WSCommand, err error) {
+	code := errCodeCommandFailed
+	if cmdErr, ok := err.(*commandError); ok {
+		code = cmdErr.code
+	}
+
+	conn.reply(&models.WSCommandReply{
+		Type:    "error",
+		ID:      command.ID,
+		Command: command.Type,
+		Success: false,
+		Error: &models.WSCommandError{
+			Code:    code,
+			Message: err.Error(),
+		},
+	})
+}
@@ -0,0 +1,359 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"upvista-community-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// RESUMABLE EVENT STREAMS
+// =====================================================
+//
+// Every event sent to a user gets a per-user sequence number ("seq" in the frame)
+// and is kept in an EventLog for a retention window. A client that reconnects with
+// the last seq it saw gets the missed events replayed, followed by a "resumed" frame,
+// before live events are delivered on the new connection.
+
+const (
+	// Maximum events retained per user, older ones are dropped first
+	maxLoggedEventsPerUser = 1000
+
+	// Maximum live events held back on a connection while its missed events are replayed
+	maxHeldEvents = 1024
+
+	// Pause between attempts to queue a replayed event while the send buffer is full
+	replayBackoff = 50 * time.Millisecond
+)
+
+// Event types that only make sense live and are never sequenced or replayed
+var ephemeralEventTypes = map[string]bool{
+	string(models.WSMessageTypeTyping):     true,
+	string(models.WSMessageTypeStopTyping): true,
+	string(models.WSMessageTypeOnline):     true,
+	string(models.WSMessageTypeOffline):    true,
+	"count_update":                         true, // Clients refetch counts after resuming
+}
+
+// LoggedEvent is an event retained in a user's stream
+type LoggedEvent struct {
+	Seq     int64
+	Payload []byte // Frame as broadcast, without the seq field
+	At      time.Time
+}
+
+// EventLog stores each user's event stream for replay
+type EventLog interface {
+	// Append stores an event for a user and returns its sequence number (strictly increasing per user)
+	Append(userID uuid.UUID, payload []byte) (int64, error)
+	// Since returns retained events after afterSeq in order
+	// complete is false when some of those events are no longer retained and the client must resync
+	Since(userID uuid.UUID, afterSeq int64) (events []LoggedEvent, complete bool, err error)
+}
+
+// SetEventLog enables sequence numbers and replay on reconnect
+// Must be called before Run
+func (m *Manager) SetEventLog(events EventLog) {
+	m.events = events
+}
+
+// deliverToUsers sequences an event in each recipient's stream, then broadcasts it
+func (m *Manager) deliverToUsers(userIDs []uuid.UUID, message []byte) {
+	if m.events == nil || isEphemeral(message) {
+		m.dispatch(&BroadcastMessage{
+			UserIDs: userIDs,
+			Message: message,
+		})
+		return
+	}
+
+	// Sequence numbers are per user, so each recipient gets its own stamped copy
+	for _, userID := range userIDs {
+		m.dispatch(m.userBroadcast(userID, message))
+	}
+}
+
+// userBroadcast builds a broadcast to a single user, recording it in the user's stream when enabled
+func (m *Manager) userBroadcast(userID uuid.UUID, message []byte) *BroadcastMessage {
+	broadcast := &BroadcastMessage{
+		UserIDs: []uuid.UUID{userID},
+		Message: message,
+	}
+
+	if m.events == nil || isEphemeral(message) {
+		return broadcast
+	}
+
+	seq, err := m.events.Append(userID, message)
+	if err != nil {
+		// Still deliver live, the event just can't be replayed
+		log.Printf("[WebSocket] Failed to record event for user %s: %v", userID, err)
+		return broadcast
+	}
+
+	broadcast.Seq = seq
+	broadcast.Message = withSequence(message, seq)
+	return broadcast
+}
+
+// replay sends the events a resuming connection missed, then releases held live events
+func (conn *Connection) replay() {
+	m := conn.Manager
+	lastSeq := conn.resumeFrom
+
+	var events []LoggedEvent
+	complete := false
+	if m.events != nil {
+		var err error
+		events, complete, err = m.events.Since(conn.UserID, conn.resumeFrom)
+		if err != nil {
+			log.Printf("[WebSocket] Failed to load missed events for user %s: %v", conn.UserID, err)
+			events, complete = nil, false
+		}
+	}
+
+	for _, event := range events {
+		if !conn.enqueue(withSequence(event.Payload, event.Seq)) {
+			log.Printf("[WebSocket] Replay to user %s stalled, closing connection", conn.UserID)
+			conn.Close()
+			return
+		}
+		lastSeq = event.Seq
+	}
+
+	resumed := models.WSMessage{
+		Type: "resumed",
+		Data: map[string]interface{}{
+			"last_seq": lastSeq,
+			"replayed": len(events),
+			"complete": complete,
+		},
+	}
+	if resumedBytes, err := json.Marshal(resumed); err == nil {
+		conn.enqueue(resumedBytes)
+	}
+
+	if !conn.releaseHeld(lastSeq) {
+		log.Printf("[WebSocket] Replay to user %s stalled, closing connection", conn.UserID)
+		conn.Close()
+		return
+	}
+
+	log.Printf("[WebSocket] Replayed %d events to user %s (connection %s)", len(events), conn.UserID, conn.ID)
+}
+
+// deliver queues a broadcast on this connection without blocking
+// While the connection is resuming the broadcast is held back until missed events are replayed
+// Returns false when the connection can't keep up
+func (conn *Connection) deliver(message *BroadcastMessage) bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.sendClosed {
+		return true
+	}
+
+	if conn.resuming {
+		if len(conn.held) >= maxHeldEvents {
+			return false
+		}
+		conn.held = append(conn.held, message)
+		return true
+	}
+
+	select {
+	case conn.Send <- message.Message:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseHeld sends live events held back during replay, skipping those already replayed
+func (conn *Connection) releaseHeld(replayedSeq int64) bool {
+	for {
+		conn.mu.Lock()
+		held := conn.held
+		conn.held = nil
+		if len(held) == 0 {
+			conn.resuming = false
+			conn.mu.Unlock()
+			return true
+		}
+		conn.mu.Unlock()
+
+		for _, message := range held {
+			if message.Seq != 0 && message.Seq <= replayedSeq {
+				continue
+			}
+			if !conn.enqueue(message.Message) {
+				return false
+			}
+		}
+	}
+}
+
+// enqueue queues a frame, waiting up to writeWait for room in the send buffer
+func (conn *Connection) enqueue(message []byte) bool {
+	deadline := time.Now().Add(writeWait)
+
+	for {
+		conn.mu.Lock()
+		if conn.sendClosed {
+			conn.mu.Unlock()
+			return false
+		}
+		select {
+		case conn.Send <- message:
+			conn.mu.Unlock()
+			return true
+		default:
+		}
+		conn.mu.Unlock()
+
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(replayBackoff)
+	}
+}
+
+// isEphemeral reports whether a frame is live-only (or not a JSON object)
+func isEphemeral(message []byte) bool {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(message, &event); err != nil {
+		return true
+	}
+	return ephemeralEventTypes[event.Type]
+}
+
+// withSequence adds a "seq" field to a JSON object frame
+func withSequence(message []byte, seq int64) []byte {
+	trimmed := bytes.TrimSpace(message)
+	if len(trimmed) < 2 || trimmed[0] != '{' {
+		return message
+	}
+
+	rest := bytes.TrimSpace(trimmed[1:])
+	stamped := make([]byte, 0, len(trimmed)+24)
+	stamped = append(stamped, `{"seq":`...)
+	stamped = strconv.AppendInt(stamped, seq, 10)
+	if rest[0] != '}' {
+		stamped = append(stamped, ',')
+	}
+	return append(stamped, rest...)
+}
+
+// =====================================================
+// IN-MEMORY EVENT LOG (single node)
+// =====================================================
+
+// How often the memory event log drops streams that have fully expired
+const eventLogSweepPeriod = time.Minute
+
+// MemoryEventLog keeps event streams in process memory (lost on restart)
+type MemoryEventLog struct {
+	retention time.Duration
+	streams   map[uuid.UUID]*eventStream
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+type eventStream struct {
+	lastSeq int64
+	events  []LoggedEvent
+}
+
+// NewMemoryEventLog creates an in-memory event log retaining events for the given window
+func NewMemoryEventLog(retention time.Duration) *MemoryEventLog {
+	return &MemoryEventLog{
+		retention: retention,
+		streams:   make(map[uuid.UUID]*eventStream),
+		lastSweep: time.Now(),
+	}
+}
+
+// Append stores an event and returns its sequence number
+func (l *MemoryEventLog) Append(userID uuid.UUID, payload []byte) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > eventLogSweepPeriod {
+		l.sweep(now)
+	}
+
+	stream, exists := l.streams[userID]
+	if !exists {
+		stream = &eventStream{}
+		l.streams[userID] = stream
+	}
+
+	stream.lastSeq++
+	stream.events = append(stream.events, LoggedEvent{
+		Seq:     stream.lastSeq,
+		Payload: payload,
+		At:      now,
+	})
+	if len(stream.events) > maxLoggedEventsPerUser {
+		stream.events = stream.events[len(stream.events)-maxLoggedEventsPerUser:]
+	}
+
+	return stream.lastSeq, nil
+}
+
+// Since returns retained events after afterSeq
+func (l *MemoryEventLog) Since(userID uuid.UUID, afterSeq int64) ([]LoggedEvent, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stream, exists := l.streams[userID]
+	if !exists {
+		// Nothing recorded (or the stream expired), only a fresh client is up to date
+		return nil, afterSeq == 0, nil
+	}
+	if afterSeq > stream.lastSeq {
+		// The client saw a stream that no longer exists (e.g. after a restart)
+		return nil, false, nil
+	}
+
+	cutoff := time.Now().Add(-l.retention)
+	var events []LoggedEvent
+	for _, event := range stream.events {
+		if event.Seq > afterSeq && !event.At.Before(cutoff) {
+			events = append(events, event)
+		}
+	}
+
+	complete := afterSeq == stream.lastSeq || (len(events) > 0 && events[0].Seq == afterSeq+1)
+	return events, complete, nil
+}
+
+// sweep drops expired events and empty streams, must be called with l.mu held
+func (l *MemoryEventLog) sweep(now time.Time) {
+	l.lastSweep = now
+	cutoff := now.Add(-l.retention)
+
+	for userID, stream := range l.streams {
+		kept := stream.events[:0]
+		for _, event := range stream.events {
+			if !event.At.Before(cutoff) {
+				kept = append(kept, event)
+			}
+		}
+		stream.events = kept
+
+		if len(stream.events) == 0 {
+			delete(l.streams, userID)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Redis key patterns for event streams
+const (
+	// Last sequence number issued to a user (never expires so numbers are never reused)
+	keyEventSeq = "ws:events:%s:seq" // STRING
+
+	// Retained events - member: "<seq>:<unix ms>:<payload>", score: seq
+	keyEvents = "ws:events:%s" // ZSET
+)
+
+// appendEventScript issues the next sequence number and stores the event under it
+// KEYS: sequence counter, event set
+// ARGV: now (unix ms), payload, max events, retention (ms)
+var appendEventScript = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+redis.call('ZADD', KEYS[2], seq, seq .. ':' .. ARGV[1] .. ':' .. ARGV[2])
+redis.call('ZREMRANGEBYRANK', KEYS[2], 0, -(tonumber(ARGV[3]) + 1))
+redis.call('PEXPIRE', KEYS[2], ARGV[4])
+return seq
+`)
+
+// RedisEventLog keeps event streams in Redis so they survive restarts and any node can replay them
+type RedisEventLog struct {
+	redis     *redis.Client
+	retention time.Duration
+}
+
+// NewRedisEventLog creates a Redis-backed event log retaining events for the given window
+func NewRedisEventLog(redisClient *redis.Client, retention time.Duration) *RedisEventLog {
+	return &RedisEventLog{
+		redis:     redisClient,
+		retention: retention,
+	}
+}
+
+// Append stores an event and returns its sequence number
+func (l *RedisEventLog) Append(userID uuid.UUID, payload []byte) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	keys := []string{fmt.Sprintf(keyEventSeq, userID), fmt.Sprintf(keyEvents, userID)}
+	return appendEventScript.Run(ctx, l.redis, keys, time.Now().UnixMilli(), payload, maxLoggedEventsPerUser, l.retention.Milliseconds()).Int64()
+}
+
+// Since returns retained events after afterSeq
+func (l *RedisEventLog) Since(userID uuid.UUID, afterSeq int64) ([]LoggedEvent, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+
+	pipe := l.redis.Pipeline()
+	lastSeqCmd := pipe.Get(ctx, fmt.Sprintf(keyEventSeq, userID))
+	rowsCmd := pipe.ZRangeByScore(ctx, fmt.Sprintf(keyEvents, userID), &redis.ZRangeBy{
+		Min: fmt.Sprintf("(%d", afterSeq),
+		Max: "+inf",
+	})
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, false, err
+	}
+
+	lastSeq, err := lastSeqCmd.Int64()
+	if err == redis.Nil {
+		return nil, afterSeq == 0, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if afterSeq > lastSeq {
+		return nil, false, nil
+	}
+
+	cutoff := time.Now().Add(-l.retention)
+	var events []LoggedEvent
+	for _, row := range rowsCmd.Val() {
+		event, err := parseLoggedEvent(row)
+		if err != nil {
+			return nil, false, err
+		}
+		if !event.At.Before(cutoff) {
+			events = append(events, event)
+		}
+	}
+
+	complete := afterSeq == lastSeq || (len(events) > 0 && events[0].Seq == afterSeq+1)
+	return events, complete, nil
+}
+
+// parseLoggedEvent decodes a "<seq>:<unix ms>:<payload>" set member
+func parseLoggedEvent(row string) (LoggedEvent, error) {
+	parts := strings.SplitN(row, ":", 3)
+	if len(parts) != 3 {
+		return LoggedEvent{}, fmt.Errorf("malformed event entry")
+	}
+
+	seq, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return LoggedEvent{}, fmt.Errorf("malformed event sequence: %w", err)
+	}
+	at, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return LoggedEvent{}, fmt.Errorf("malformed event time: %w", err)
+	}
+
+	return LoggedEvent{
+		Seq:     seq,
+		Payload: []byte(parts[2]),
+		At:      time.UnixMilli(at),
+	}, nil
+}
@@ -3,6 +3,7 @@ package websocket
 import (
 	"log"
 	"net/http"
+	"strconv"
 
 	"upvista-community-backend/internal/utils"
 
@@ -37,6 +38,39 @@ func NewHandlers(manager *Manager, jwtSvc *utils.JWTService) *Handlers {
 
 // HandleWebSocket upgrades HTTP connection to WebSocket
 func (h *Handlers) HandleWebSocket(c *gin.Context) {
+	userID, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+
+	// A client resuming a session sends the last sequence number it received
+	lastSeq, ok := parseLastSeq(c, c.Query("last_seq"))
+	if !ok {
+		return
+	}
+
+	// Upgrade HTTP connection to WebSocket
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("[WebSocket] Failed to upgrade connection: %v", err)
+		return
+	}
+
+	// Register the connection, replaying missed events first when resuming
+	var connection *Connection
+	if lastSeq >= 0 {
+		connection = h.manager.Resume(userID, conn, lastSeq)
+	} else {
+		connection = h.manager.Register(userID, conn)
+	}
+
+	log.Printf("[WebSocket] User %s connected successfully (connection id: %s)", userID, connection.ID)
+}
+
+// authenticate validates the JWT from the query parameter or Authorization header
+// (browsers can't set headers on websocket or EventSource requests)
+// Writes the error response and returns false when the request is not authenticated
+func (h *Handlers) authenticate(c *gin.Context) (uuid.UUID, bool) {
 	// Extract JWT token from query parameter or header
 	token := c.Query("token")
 	if token == "" {
@@ -52,7 +86,7 @@ func (h *Handlers) HandleWebSocket(c *gin.Context) {
 			"success": false,
 			"message": "No authentication token provided",
 		})
-		return
+		return uuid.Nil, false
 	}
 
 	// Validate JWT token
@@ -63,7 +97,7 @@ func (h *Handlers) HandleWebSocket(c *gin.Context) {
 			"success": false,
 			"message": "Invalid authentication token",
 		})
-		return
+		return uuid.Nil, false
 	}
 
 	// Extract user ID from claims
@@ -74,25 +108,35 @@ func (h *Handlers) HandleWebSocket(c *gin.Context) {
 			"success": false,
 			"message": "Invalid user ID",
 		})
-		return
+		return uuid.Nil, false
 	}
 
-	// Upgrade HTTP connection to WebSocket
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		log.Printf("[WebSocket] Failed to upgrade connection: %v", err)
-		return
+	return userID, true
+}
+
+// parseLastSeq parses the sequence number a resuming client sent, -1 when it sent none
+// Writes the error response and returns false when the value is invalid
+func parseLastSeq(c *gin.Context, raw string) (int64, bool) {
+	if raw == "" {
+		return -1, true
 	}
 
-	// Register the connection
-	connection := h.manager.Register(userID, conn)
+	lastSeq, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || lastSeq < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid last event ID",
+		})
+		return -1, false
+	}
 
-	log.Printf("[WebSocket] User %s connected successfully (connection id: %s)", userID, connection.ID)
+	return lastSeq, true
 }
 
 // SetupRoutes registers WebSocket routes
 func (h *Handlers) SetupRoutes(router *gin.RouterGroup) {
 	router.GET("/ws", h.HandleWebSocket)
+	router.GET("/sse", h.HandleSSE) // Fallback for networks that block websocket upgrades
 }
 
 // GetStats returns WebSocket statistics (for monitoring/debugging)
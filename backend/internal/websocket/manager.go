@@ -23,22 +23,44 @@ const (
 	// Send pings to peer with this period (must be less than pongWait)
 	pingPeriod = (pongWait * 9) / 10
 
-	// Maximum message size allowed from peer
-	maxMessageSize = 512
+	// Maximum message size allowed from peer (commands carry message content)
+	maxMessageSize = 16 * 1024
 
 	// Maximum connections per user
 	maxConnectionsPerUser = 5
 )
 
-// Connection represents a single WebSocket connection
+// Transports a client can receive events over
+const (
+	TransportWebSocket = "websocket"
+	TransportSSE       = "sse" // Server-Sent Events fallback, receive only (see sse.go)
+)
+
+// Connection represents a single client connection (WebSocket, or SSE when Conn is nil)
 type Connection struct {
-	ID       uuid.UUID
-	UserID   uuid.UUID
-	Conn     *websocket.Conn
-	Send     chan []byte
-	Manager  *Manager
-	mu       sync.Mutex
-	isClosed bool
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Transport string
+	Conn      *websocket.Conn
+	Send      chan []byte
+	Manager   *Manager
+	mu        sync.Mutex
+	isClosed  bool
+
+	// Closed by Close, ends transports that don't own a socket
+	done chan struct{}
+
+	// Subscribed topics (guarded by Manager.mu)
+	topics map[string]struct{}
+
+	// Set once the manager closes Send (guarded by mu)
+	sendClosed bool
+
+	// Replay state (guarded by mu): while resuming, live events are held back
+	// until the events missed since resumeFrom have been sent
+	resuming   bool
+	resumeFrom int64
+	held       []*BroadcastMessage
 }
 
 // PendingMessage represents a message awaiting acknowledgment
@@ -75,17 +97,33 @@ type Manager struct {
 	// Cancel function
 	cancel context.CancelFunc
 
-	// Pending messages awaiting acknowledgment (messageID -> pending message)
-	pendingMessages map[string]*PendingMessage
-	pendingMu       sync.RWMutex
+	// Pending messages awaiting acknowledgment (in memory, or shared in cluster mode)
+	pending PendingStore
 
 	// ACK timeout duration
 	ackTimeout time.Duration
+
+	// Cluster mode (nil on a single node): broadcasts go through the bus,
+	// presence is shared between nodes
+	bus      Bus
+	presence PresenceStore
+
+	// Handles chat commands sent over the socket (optional)
+	chat ChatService
+
+	// Topic kind -> authorization check, and topic -> subscribed local connections (guarded by mu)
+	topicAuthorizers map[string]TopicAuthorizer
+	topics           map[string]map[*Connection]struct{}
+
+	// Per-user event streams for replay on reconnect (optional)
+	events EventLog
 }
 
-// BroadcastMessage represents a message to be broadcast to specific users
+// BroadcastMessage represents a message to be broadcast to specific users or topic subscribers
 type BroadcastMessage struct {
 	UserIDs []uuid.UUID
+	Topic   string `json:",omitempty"`
+	Seq     int64  `json:",omitempty"` // Sequence number in the recipient's stream (single-user events only)
 	Message []byte
 }
 
@@ -93,19 +131,19 @@ type BroadcastMessage struct {
 func NewManager() *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 	m := &Manager{
-		connections:     make(map[uuid.UUID][]*Connection),
-		register:        make(chan *Connection, 256),
-		unregister:      make(chan *Connection, 256),
-		broadcast:       make(chan *BroadcastMessage, 1024),
-		ctx:             ctx,
-		cancel:          cancel,
-		pendingMessages: make(map[string]*PendingMessage),
-		ackTimeout:      5 * time.Second,
+		connections: make(map[uuid.UUID][]*Connection),
+		register:    make(chan *Connection, 256),
+		unregister:  make(chan *Connection, 256),
+		broadcast:   make(chan *BroadcastMessage, 1024),
+		ctx:         ctx,
+		cancel:      cancel,
+		pending:     newMemoryPendingStore(),
+		ackTimeout:  5 * time.Second,
+
+		topicAuthorizers: make(map[string]TopicAuthorizer),
+		topics:           make(map[string]map[*Connection]struct{}),
 	}
 
-	// Start retry goroutine for pending messages
-	go m.retryPendingMessages()
-
 	return m
 }
 
@@ -114,6 +152,17 @@ func (m *Manager) Run() {
 	log.Println("[WebSocket] Manager started")
 	defer log.Println("[WebSocket] Manager stopped")
 
+	// Start retry goroutine for pending messages
+	go m.retryPendingMessages()
+
+	if m.bus != nil {
+		go m.runBusSubscriber()
+		log.Println("[WebSocket] Cluster mode enabled")
+	}
+	if m.presence != nil {
+		go m.refreshPresence()
+	}
+
 	for {
 		select {
 		case conn := <-m.register:
@@ -134,21 +183,43 @@ func (m *Manager) Run() {
 
 // Register adds a new connection
 func (m *Manager) Register(userID uuid.UUID, conn *websocket.Conn) *Connection {
-	connection := &Connection{
-		ID:      uuid.New(),
-		UserID:  userID,
-		Conn:    conn,
-		Send:    make(chan []byte, 256),
-		Manager: m,
+	connection := m.newConnection(userID, TransportWebSocket, conn)
+	m.start(connection)
+	return connection
+}
+
+// Resume adds a connection continuing an earlier session: events after lastSeq
+// are replayed before live events are delivered (see events.go)
+func (m *Manager) Resume(userID uuid.UUID, conn *websocket.Conn, lastSeq int64) *Connection {
+	connection := m.newConnection(userID, TransportWebSocket, conn)
+	connection.resuming = true
+	connection.resumeFrom = lastSeq
+
+	m.start(connection)
+	return connection
+}
+
+func (m *Manager) newConnection(userID uuid.UUID, transport string, conn *websocket.Conn) *Connection {
+	return &Connection{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Transport: transport,
+		Conn:      conn,
+		Send:      make(chan []byte, 256),
+		Manager:   m,
+		topics:    make(map[string]struct{}),
+		done:      make(chan struct{}),
 	}
+}
 
+func (m *Manager) start(connection *Connection) {
 	m.register <- connection
 
-	// Start goroutines for this connection
-	go connection.writePump()
-	go connection.readPump()
-
-	return connection
+	// Start goroutines for this connection (other transports drain Send themselves)
+	if connection.Conn != nil {
+		go connection.writePump()
+		go connection.readPump()
+	}
 }
 
 // Unregister removes a connection
@@ -158,18 +229,34 @@ func (m *Manager) Unregister(conn *Connection) {
 
 // BroadcastToUser sends a message to all connections of a specific user
 func (m *Manager) BroadcastToUser(userID uuid.UUID, message []byte) {
-	m.broadcast <- &BroadcastMessage{
-		UserIDs: []uuid.UUID{userID},
-		Message: message,
-	}
+	m.deliverToUsers([]uuid.UUID{userID}, message)
 }
 
 // BroadcastToUsers sends a message to all connections of multiple users
 func (m *Manager) BroadcastToUsers(userIDs []uuid.UUID, message []byte) {
-	m.broadcast <- &BroadcastMessage{
-		UserIDs: userIDs,
+	m.deliverToUsers(userIDs, message)
+}
+
+// BroadcastToTopic sends a message to every connection subscribed to a topic
+func (m *Manager) BroadcastToTopic(topic string, message []byte) {
+	m.dispatch(&BroadcastMessage{
+		Topic:   topic,
 		Message: message,
+	})
+}
+
+// dispatch routes a broadcast to the local hub, or to every node in cluster mode
+func (m *Manager) dispatch(message *BroadcastMessage) {
+	if m.bus != nil {
+		err := m.bus.Publish(m.ctx, message)
+		if err == nil {
+			return
+		}
+		// Deliver locally at least, users on other nodes miss this one
+		log.Printf("[WebSocket] Failed to publish cluster broadcast: %v", err)
 	}
+
+	m.broadcast <- message
 }
 
 // BroadcastNotification sends a notification to a user via WebSocket
@@ -188,6 +275,23 @@ func (m *Manager) BroadcastNotification(userID uuid.UUID, notification *models.N
 	m.BroadcastToUser(userID, messageBytes)
 }
 
+// BroadcastNotificationUpdate sends a grouped notification that changed in place
+// Clients replace the notification with the same ID instead of adding a new one
+func (m *Manager) BroadcastNotificationUpdate(userID uuid.UUID, notification *models.NotificationResponse) {
+	message := models.WSMessage{
+		Type: "notification_updated",
+		Data: notification,
+	}
+
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("[WebSocket] Failed to marshal notification update: %v", err)
+		return
+	}
+
+	m.BroadcastToUser(userID, messageBytes)
+}
+
 // BroadcastCountUpdate sends an unread count update to a user
 func (m *Manager) BroadcastCountUpdate(userID uuid.UUID, total int, categoryCounts map[string]int) {
 	message := models.WSMessage{
@@ -207,14 +311,14 @@ func (m *Manager) BroadcastCountUpdate(userID uuid.UUID, total int, categoryCoun
 	m.BroadcastToUser(userID, messageBytes)
 }
 
-// GetConnectionCount returns the number of active connections for a user
+// GetConnectionCount returns the number of active connections for a user on this node
 func (m *Manager) GetConnectionCount(userID uuid.UUID) int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	return len(m.connections[userID])
 }
 
-// GetTotalConnections returns the total number of active connections
+// GetTotalConnections returns the total number of active connections on this node
 func (m *Manager) GetTotalConnections() int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -226,7 +330,7 @@ func (m *Manager) GetTotalConnections() int {
 	return total
 }
 
-// GetConnectedUsers returns the number of unique connected users
+// GetConnectedUsers returns the number of unique users connected to this node
 func (m *Manager) GetConnectedUsers() int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -244,34 +348,61 @@ func (m *Manager) Shutdown() {
 
 func (m *Manager) handleRegister(conn *Connection) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+
+	var evicted *Connection
 
 	// Check connection limit per user
 	if len(m.connections[conn.UserID]) >= maxConnectionsPerUser {
 		log.Printf("[WebSocket] User %s reached max connections (%d), closing oldest", conn.UserID, maxConnectionsPerUser)
 		// Close the oldest connection
 		if len(m.connections[conn.UserID]) > 0 {
-			oldest := m.connections[conn.UserID][0]
-			oldest.Close()
+			evicted = m.connections[conn.UserID][0]
+			evicted.Close()
 			m.connections[conn.UserID] = m.connections[conn.UserID][1:]
+			for topic := range evicted.topics {
+				m.removeSubscription(evicted, topic)
+			}
 		}
 	}
 
 	// Add new connection
 	m.connections[conn.UserID] = append(m.connections[conn.UserID], conn)
-	log.Printf("[WebSocket] User %s connected (id: %s), total connections: %d", conn.UserID, conn.ID, len(m.connections[conn.UserID]))
+	log.Printf("[WebSocket] User %s connected over %s (id: %s), total connections: %d", conn.UserID, conn.Transport, conn.ID, len(m.connections[conn.UserID]))
+
+	m.mu.Unlock()
+
+	// Shared presence is updated outside the lock (network call)
+	if m.presence != nil {
+		if evicted != nil {
+			m.presence.Disconnected(evicted.UserID, evicted.ID)
+		}
+		m.presence.Connected(conn.UserID, conn.ID)
+	}
+
+	// Replay only once registered, so every later event is either replayed or held back
+	if conn.resuming {
+		go conn.replay()
+	}
 }
 
 func (m *Manager) handleUnregister(conn *Connection) {
+	if m.presence != nil {
+		m.presence.Disconnected(conn.UserID, conn.ID)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	for topic := range conn.topics {
+		m.removeSubscription(conn, topic)
+	}
+
 	if connections, ok := m.connections[conn.UserID]; ok {
 		// Find and remove the connection
 		for i, c := range connections {
 			if c.ID == conn.ID {
 				// Close the connection's send channel
-				close(c.Send)
+				c.closeSend()
 
 				// Remove from slice
 				m.connections[conn.UserID] = append(connections[:i], connections[i+1:]...)
@@ -293,13 +424,19 @@ func (m *Manager) handleBroadcast(message *BroadcastMessage) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	if message.Topic != "" {
+		for conn := range m.topics[message.Topic] {
+			if !conn.deliver(message) {
+				log.Printf("[WebSocket] Connection buffer full for user %s, closing", conn.UserID)
+				go m.Unregister(conn)
+			}
+		}
+	}
+
 	for _, userID := range message.UserIDs {
 		if connections, ok := m.connections[userID]; ok {
 			for _, conn := range connections {
-				select {
-				case conn.Send <- message.Message:
-					// Message sent successfully
-				default:
+				if !conn.deliver(message) {
 					// Buffer full, close connection
 					log.Printf("[WebSocket] Connection buffer full for user %s, closing", userID)
 					go m.Unregister(conn)
@@ -318,6 +455,9 @@ func (m *Manager) shutdown() {
 	for userID, connections := range m.connections {
 		for _, conn := range connections {
 			conn.Close()
+			if m.presence != nil {
+				m.presence.Disconnected(userID, conn.ID)
+			}
 		}
 		delete(m.connections, userID)
 	}
@@ -402,10 +542,12 @@ func (conn *Connection) writePump() {
 }
 
 // handleMessage processes incoming messages from the client
+// Besides ping/pong, every frame is a command (see commands.go) answered with a reply or error frame
 func (conn *Connection) handleMessage(message []byte) {
-	var msg models.WSMessage
+	var msg models.WSCommand
 	if err := json.Unmarshal(message, &msg); err != nil {
 		log.Printf("[WebSocket] Failed to unmarshal message: %v", err)
+		conn.replyError(&msg, &commandError{code: errCodeInvalidRequest, message: "Malformed JSON frame"})
 		return
 	}
 
@@ -422,7 +564,7 @@ func (conn *Connection) handleMessage(message []byte) {
 		conn.Conn.SetReadDeadline(time.Now().Add(pongWait))
 
 	default:
-		log.Printf("[WebSocket] Unknown message type: %s", msg.Type)
+		conn.handleCommand(&msg)
 	}
 }
 
@@ -433,7 +575,21 @@ func (conn *Connection) Close() {
 
 	if !conn.isClosed {
 		conn.isClosed = true
-		conn.Conn.Close()
+		if conn.Conn != nil {
+			conn.Conn.Close()
+		}
+		close(conn.done)
+	}
+}
+
+// closeSend closes the send channel once, so nothing is queued on it afterwards
+func (conn *Connection) closeSend() {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if !conn.sendClosed {
+		conn.sendClosed = true
+		close(conn.Send)
 	}
 }
 
@@ -442,7 +598,7 @@ func (conn *Connection) SendMessage(message []byte) error {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
 
-	if conn.isClosed {
+	if conn.isClosed || conn.sendClosed {
 		return websocket.ErrCloseSent
 	}
 
@@ -477,13 +633,15 @@ func (m *Manager) SendWithACK(userID uuid.UUID, messageID string, payload interf
 		Acknowledged: false,
 	}
 
+	// Sequence it once, retries resend the same frame
+	broadcast := m.userBroadcast(userID, payloadBytes)
+	pending.Payload = broadcast.Message
+
 	// Store in pending messages
-	m.pendingMu.Lock()
-	m.pendingMessages[messageID] = pending
-	m.pendingMu.Unlock()
+	m.pending.Add(pending)
 
 	// Send the message
-	m.BroadcastToUser(userID, payloadBytes)
+	m.dispatch(broadcast)
 
 	log.Printf("[WebSocket] Sent message %s to user %s with ACK tracking", messageID, userID)
 	return nil
@@ -491,15 +649,8 @@ func (m *Manager) SendWithACK(userID uuid.UUID, messageID string, payload interf
 
 // HandleACK processes an acknowledgment from a client
 func (m *Manager) HandleACK(messageID string, userID uuid.UUID) {
-	m.pendingMu.Lock()
-	defer m.pendingMu.Unlock()
-
-	if pending, exists := m.pendingMessages[messageID]; exists {
-		if pending.RecipientID == userID {
-			pending.Acknowledged = true
-			delete(m.pendingMessages, messageID)
-			log.Printf("[WebSocket] Message %s acknowledged by user %s", messageID, userID)
-		}
+	if m.pending.Acknowledge(messageID, userID) {
+		log.Printf("[WebSocket] Message %s acknowledged by user %s", messageID, userID)
 	}
 }
 
@@ -519,51 +670,44 @@ func (m *Manager) retryPendingMessages() {
 }
 
 // retryUnacknowledged retries sending unacknowledged messages
+// In cluster mode each timed-out message is claimed by exactly one node
 func (m *Manager) retryUnacknowledged() {
-	m.pendingMu.Lock()
-	defer m.pendingMu.Unlock()
-
 	now := time.Now()
-	toDelete := []string{}
 
-	for messageID, pending := range m.pendingMessages {
-		if pending.Acknowledged {
-			toDelete = append(toDelete, messageID)
+	for _, pending := range m.pending.Due(now, m.ackTimeout) {
+		if pending.RetryCount >= pending.MaxRetries {
+			// Max retries reached, give up
+			log.Printf("[WebSocket] Message %s failed after %d retries", pending.ID, pending.MaxRetries)
+			m.pending.Remove(pending.ID)
 			continue
 		}
 
-		// Check if message has timed out
-		if now.Sub(pending.SentAt) > m.ackTimeout {
-			if pending.RetryCount < pending.MaxRetries {
-				// Retry sending
-				pending.RetryCount++
-				pending.SentAt = now
-
-				// Check if user is still connected
-				if m.IsUserConnected(pending.RecipientID) {
-					m.BroadcastToUser(pending.RecipientID, pending.Payload)
-					log.Printf("[WebSocket] Retrying message %s to user %s (attempt %d/%d)",
-						messageID, pending.RecipientID, pending.RetryCount, pending.MaxRetries)
-				} else {
-					log.Printf("[WebSocket] User %s not connected, keeping message %s in queue",
-						pending.RecipientID, messageID)
-				}
-			} else {
-				// Max retries reached, give up
-				log.Printf("[WebSocket] Message %s failed after %d retries", messageID, pending.MaxRetries)
-				toDelete = append(toDelete, messageID)
-			}
+		// Retry sending
+		pending.RetryCount++
+		pending.SentAt = now
+		m.pending.Update(pending)
+
+		// Check if user is still connected
+		if m.IsUserConnected(pending.RecipientID) {
+			m.dispatch(&BroadcastMessage{
+				UserIDs: []uuid.UUID{pending.RecipientID},
+				Message: pending.Payload,
+			})
+			log.Printf("[WebSocket] Retrying message %s to user %s (attempt %d/%d)",
+				pending.ID, pending.RecipientID, pending.RetryCount, pending.MaxRetries)
+		} else {
+			log.Printf("[WebSocket] User %s not connected, keeping message %s in queue",
+				pending.RecipientID, pending.ID)
 		}
 	}
-
-	// Clean up acknowledged or failed messages
-	for _, messageID := range toDelete {
-		delete(m.pendingMessages, messageID)
-	}
 }
 
-// IsUserConnected checks if a user has any active connections
+// IsUserConnected checks if a user has any active connections (on any node in cluster mode)
 func (m *Manager) IsUserConnected(userID uuid.UUID) bool {
+	if m.presence != nil {
+		return m.presence.IsOnline(userID)
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -582,9 +726,18 @@ func (m *Manager) BroadcastToUserWithData(userID uuid.UUID, data interface{}) er
 	return nil
 }
 
+// BroadcastToTopicWithData sends structured data to a topic's subscribers (converts to JSON)
+func (m *Manager) BroadcastToTopicWithData(topic string, data interface{}) error {
+	messageBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	m.BroadcastToTopic(topic, messageBytes)
+	return nil
+}
+
 // GetPendingMessageCount returns the count of pending messages
 func (m *Manager) GetPendingMessageCount() int {
-	m.pendingMu.RLock()
-	defer m.pendingMu.RUnlock()
-	return len(m.pendingMessages)
+	return m.pending.Count()
 }
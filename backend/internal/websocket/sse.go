@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// SERVER-SENT EVENTS TRANSPORT
+// =====================================================
+//
+// For clients behind proxies that block websocket upgrades. An SSE connection is
+// registered with the manager like a socket, so everything sent to the user reaches
+// it too. Sequenced events carry their seq as the SSE id, which the browser sends
+// back in Last-Event-ID when it reconnects. Commands go through the REST API.
+
+const (
+	// Comment line sent on idle streams so proxies don't time them out
+	sseHeartbeatPeriod = 25 * time.Second
+
+	// Reconnect delay suggested to EventSource clients
+	sseRetryDelay = 3 * time.Second
+)
+
+// RegisterSSE adds a Server-Sent Events connection
+// The caller streams conn.Send to the client and calls Unregister when the request ends
+func (m *Manager) RegisterSSE(userID uuid.UUID) *Connection {
+	connection := m.newConnection(userID, TransportSSE, nil)
+	m.start(connection)
+	return connection
+}
+
+// ResumeSSE adds a Server-Sent Events connection replaying events after lastSeq first
+func (m *Manager) ResumeSSE(userID uuid.UUID, lastSeq int64) *Connection {
+	connection := m.newConnection(userID, TransportSSE, nil)
+	connection.resuming = true
+	connection.resumeFrom = lastSeq
+
+	m.start(connection)
+	return connection
+}
+
+// HandleSSE streams the user's realtime events as text/event-stream
+func (h *Handlers) HandleSSE(c *gin.Context) {
+	userID, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+
+	// Browsers send Last-Event-ID on reconnect, the query parameter covers the first connect
+	rawLastSeq := c.GetHeader("Last-Event-ID")
+	if rawLastSeq == "" {
+		rawLastSeq = c.Query("last_event_id")
+	}
+	lastSeq, ok := parseLastSeq(c, rawLastSeq)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	var connection *Connection
+	if lastSeq >= 0 {
+		connection = h.manager.ResumeSSE(userID, lastSeq)
+	} else {
+		connection = h.manager.RegisterSSE(userID)
+	}
+	defer h.manager.Unregister(connection)
+
+	log.Printf("[WebSocket] User %s connected over SSE (connection id: %s)", userID, connection.ID)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetryDelay.Milliseconds()); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatPeriod)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case message, ok := <-connection.Send:
+			if !ok {
+				return
+			}
+			if err := writeSSEEvent(c.Writer, message); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+		case <-connection.done:
+			return
+
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSEEvent writes a frame as an SSE message, using its seq (if any) as the event ID
+func writeSSEEvent(w io.Writer, message []byte) error {
+	var frame struct {
+		Seq int64 `json:"seq"`
+	}
+	var event bytes.Buffer
+
+	if json.Unmarshal(message, &frame) == nil && frame.Seq > 0 {
+		event.WriteString("id: ")
+		event.WriteString(strconv.FormatInt(frame.Seq, 10))
+		event.WriteByte('\n')
+	}
+	for _, line := range bytes.Split(message, []byte{'\n'}) {
+		event.WriteString("data: ")
+		event.Write(line)
+		event.WriteByte('\n')
+	}
+	event.WriteByte('\n')
+
+	_, err := w.Write(event.Bytes())
+	return err
+}
@@ -12,13 +12,20 @@ import (
 	"upvista-community-backend/internal/config"
 	"upvista-community-backend/internal/courses"
 	"upvista-community-backend/internal/events"
+	"upvista-community-backend/internal/inspect"
 	"upvista-community-backend/internal/jobs"
+	"upvista-community-backend/internal/mailer"
+	"upvista-community-backend/internal/media"
 	"upvista-community-backend/internal/messaging"
+	"upvista-community-backend/internal/models"
 	"upvista-community-backend/internal/notifications"
 	"upvista-community-backend/internal/posts"
 	"upvista-community-backend/internal/repository"
 	"upvista-community-backend/internal/search"
 	"upvista-community-backend/internal/social"
+	"upvista-community-backend/internal/storage"
+	"upvista-community-backend/internal/unfurl"
+	"upvista-community-backend/internal/uploads"
 	"upvista-community-backend/internal/utils"
 	"upvista-community-backend/internal/websocket"
 
@@ -49,6 +56,11 @@ func main() {
 		log.Fatalf("Failed to initialize session repository: %v", err)
 	}
 
+	passkeyRepo, err := repository.NewPasskeyRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize passkey repository: %v", err)
+	}
+
 	// Initialize experience and education repositories
 	expRepo := repository.NewSupabaseExperienceRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey)
 	eduRepo := repository.NewSupabaseEducationRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey)
@@ -63,36 +75,121 @@ func main() {
 	interestRepo := repository.NewSupabaseInterestRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey)
 	achievementRepo := repository.NewSupabaseAchievementRepository(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey)
 
+	// Load email templates and translations
+	emailRenderer, err := mailer.NewRenderer(cfg.Email.FrontendURL)
+	if err != nil {
+		log.Fatalf("Failed to load email templates: %v", err)
+	}
+
+	// Initialize the email outbox (emails are queued and sent by a background job)
+	emailOutboxRepo, err := repository.NewEmailOutboxRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize email outbox repository: %v", err)
+	}
+	emailTransport, err := mailer.NewTransport(&cfg.Email)
+	if err != nil {
+		log.Fatalf("Failed to initialize email transport: %v", err)
+	}
+	emailOutbox := mailer.NewOutbox(emailOutboxRepo, emailTransport, &cfg.Email)
+	log.Printf("[Email] Sending through the %s transport", emailTransport.Name())
+
 	// Initialize services
-	emailSvc := utils.NewEmailService(&cfg.Email)
-	// JWT tokens valid for 30 days with sliding window refresh
-	jwtSvc := utils.NewJWTService(cfg.JWT.Secret, 30*24*time.Hour)
+	emailSvc := utils.NewEmailService(&cfg.Email, emailRenderer, emailOutbox)
+	// Short-lived access tokens, renewed with rotating refresh tokens (see auth/session.go)
+	jwtSvc := utils.NewJWTService(cfg.JWT.Secret, cfg.GetAccessTokenExpiry())
 
-	// Initialize rate limiter with forgiveness
-	rateLimiter := utils.NewRateLimiter(cfg.RateLimit.Forgiveness)
+	// Initialize Redis client for messaging cache, OTP storage and shared auth state
+	redisClient, err := cache.InitializeRedis(cfg.Redis.Host, cfg.Redis.Port, cfg.Redis.Password, cfg.Redis.DB)
+	if err != nil {
+		log.Printf("[Warning] Failed to connect to Redis: %v (messaging cache and OTP storage disabled, rate limits and token blacklist are per-process)", err)
+		redisClient = nil
+	} else {
+		log.Println("[Redis] Connected successfully")
+	}
 
-	// Initialize token blacklist for logout functionality
-	tokenBlacklist := utils.NewTokenBlacklist()
+	// Initialize rate limiter and token blacklist (for logout functionality)
+	// Backed by Redis when available so every replica shares them, in memory otherwise
+	var rateLimiter *utils.RateLimiter
+	var tokenBlacklist *utils.TokenBlacklist
+	if redisClient != nil {
+		rateLimiter = utils.NewRateLimiterWithStore(utils.NewRedisRateLimitStore(redisClient), cfg.RateLimit.Forgiveness)
+		tokenBlacklist = utils.NewTokenBlacklistWithStore(utils.NewRedisBlacklistStore(redisClient))
+	} else {
+		rateLimiter = utils.NewRateLimiter(cfg.RateLimit.Forgiveness)
+		tokenBlacklist = utils.NewTokenBlacklist()
+	}
 
 	// Set blacklist on JWT service for token validation
 	jwtSvc.SetBlacklist(tokenBlacklist)
 
 	// Initialize authentication service
-	authSvc := auth.NewAuthService(userRepo, emailSvc, jwtSvc, tokenBlacklist)
+	authSvc := auth.NewAuthService(userRepo, sessionRepo, emailSvc, jwtSvc, tokenBlacklist, cfg.GetRefreshTokenExpiry())
 
 	// Set Redis client for OTP caching (if available)
-	// Redis is initialized later, so we'll set it after initialization
+	if redisClient != nil {
+		authSvc.SetRedis(redisClient)
+	}
 
 	// Initialize OAuth services
-	googleOAuth := auth.NewGoogleOAuthService(&cfg.Google, userRepo, jwtSvc)
-	githubOAuth := auth.NewGitHubOAuthService(&cfg.GitHub, userRepo, jwtSvc)
-	linkedinOAuth := auth.NewLinkedInOAuthService(&cfg.LinkedIn, userRepo, jwtSvc)
+	googleOAuth := auth.NewGoogleOAuthService(&cfg.Google, userRepo)
+	githubOAuth := auth.NewGitHubOAuthService(&cfg.GitHub, userRepo)
+	linkedinOAuth := auth.NewLinkedInOAuthService(&cfg.LinkedIn, userRepo)
+
+	// Initialize passkey (WebAuthn) service - ceremonies use the auth service's Redis client
+	passkeySvc, err := auth.NewPasskeyService(cfg, authSvc, passkeyRepo)
+	if err != nil {
+		log.Fatalf("Failed to initialize passkey service: %v", err)
+	}
+
+	// Initialize storage driver (supabase, local or s3) and service
+	storageDriver, err := storage.NewStorage(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	storageSvc := utils.NewStorageService(&cfg.Storage, storageDriver, cfg.GetPrivateBuckets(), cfg.GetSignedURLExpiry())
+	log.Printf("Storage driver: %s (private buckets: %v)", storageDriver.Name(), cfg.GetPrivateBuckets())
+
+	// Every upload is sniffed by its content, checked for hidden payloads and unsafe archives, and scanned
+	// by clamd when CLAMD_ADDRESS is set. Suspicious files go to the quarantine bucket, never to posts or messages
+	quarantineRepo, err := repository.NewQuarantineRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize quarantine repository: %v", err)
+	}
+	uploadInspector := inspect.NewInspector(storageDriver, quarantineRepo, cfg)
+	if cfg.Storage.ClamdAddress != "" {
+		scanner, err := inspect.NewClamdScanner(cfg.Storage.ClamdAddress, cfg.GetClamdTimeout())
+		if err != nil {
+			log.Fatalf("Failed to initialize malware scanner: %v", err)
+		}
+		if err := scanner.Ping(context.Background()); err != nil {
+			log.Printf("Warning: malware scanner unreachable: %v", err)
+		}
+		uploadInspector.SetScanner(scanner)
+		log.Printf("Malware scanning: clamd at %s", cfg.Storage.ClamdAddress)
+	}
+	storageSvc.SetInspector(uploadInspector) // Profile pictures are checked like every other upload
 
-	// Initialize storage service
-	storageSvc := utils.NewStorageService(&cfg.Storage, cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey)
+	// Uploaded images are stored as size variants (thumb, small, medium, large) with a blurhash placeholder,
+	// uploaded video and audio are probed, thumbnailed and transcoded in the background (needs ffmpeg).
+	// Files are stored once per bucket by content hash and deleted once nothing has used them for a while
+	mediaAssetRepo, err := repository.NewMediaAssetRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize media asset repository: %v", err)
+	}
+	mediaJobRepo, err := repository.NewMediaJobRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize media job repository: %v", err)
+	}
+	mediaReferenceRepo, err := repository.NewMediaReferenceRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize media reference repository: %v", err)
+	}
+	mediaSvc := media.NewService(mediaAssetRepo, mediaJobRepo, mediaReferenceRepo, storageSvc, uploadInspector, cfg)
 
 	// Initialize account service
 	accountSvc := account.NewAccountService(userRepo, sessionRepo, emailSvc, storageSvc)
+	accountSvc.SetTwoFactorVerifier(authSvc) // Enforce 2FA on password, email and deletion changes
+	accountSvc.SetSessionRevoker(authSvc)    // Revoke access tokens when sessions are deleted
 
 	// Initialize profile service
 	profileSvc := account.NewProfileService(userRepo)
@@ -127,6 +224,24 @@ func main() {
 
 	// Initialize WebSocket manager
 	wsManager := websocket.NewManager()
+	if cfg.Server.ClusterMode {
+		if redisClient != nil {
+			// Fan broadcasts out to every replica and share presence/ACK state
+			wsManager.EnableCluster(
+				websocket.NewRedisBus(redisClient),
+				websocket.NewRedisPresenceStore(redisClient),
+				websocket.NewRedisPendingStore(redisClient),
+			)
+		} else {
+			log.Println("[Warning] CLUSTER_MODE requires Redis, websocket broadcasts only reach this replica")
+		}
+	}
+	// Sequence each user's events so reconnecting clients can replay what they missed
+	if redisClient != nil {
+		wsManager.SetEventLog(websocket.NewRedisEventLog(redisClient, cfg.GetEventRetention()))
+	} else {
+		wsManager.SetEventLog(websocket.NewMemoryEventLog(cfg.GetEventRetention()))
+	}
 	go wsManager.Run() // Start WebSocket manager in background
 
 	// Initialize notification email service
@@ -136,6 +251,32 @@ func main() {
 	// Initialize notification service
 	notificationSvc := notifications.NewNotificationService(notificationRepo, userRepo, wsManager, notificationEmailSvc)
 
+	// Initialize Web Push (only when a VAPID key pair is configured)
+	pushSubscriptionRepo, err := repository.NewPushSubscriptionRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize push subscription repository: %v", err)
+	}
+	if cfg.PushEnabled() {
+		pushSvc, err := notifications.NewPushService(pushSubscriptionRepo, cfg.Push.VAPIDPublicKey, cfg.Push.VAPIDPrivateKey, cfg.GetVAPIDSubject())
+		if err != nil {
+			log.Fatalf("Failed to initialize push service: %v", err)
+		}
+		notificationSvc.SetPushService(pushSvc)
+		log.Println("[Notifications] Web Push enabled")
+	} else {
+		log.Println("[Warning] VAPID keys not configured, Web Push notifications disabled")
+	}
+
+	// Stop notification emails to addresses that hard bounce or report spam (they are also suppressed in the outbox)
+	emailOutbox.OnEvent(func(ctx context.Context, event *models.EmailDeliveryEvent, _ *models.OutboxEmail) {
+		if event.Type != models.EmailEventBounced && event.Type != models.EmailEventComplained {
+			return
+		}
+		if err := notificationSvc.DisableEmails(ctx, event.Email); err != nil {
+			log.Printf("[Email] Failed to disable emails for %s: %v", event.Email, err)
+		}
+	})
+
 	// Initialize relationship service with notification support
 	relationshipSvc := social.NewRelationshipService(relationshipRepo, userRepo)
 	relationshipSvc.SetNotificationService(notificationSvc) // Add notification support
@@ -143,21 +284,20 @@ func main() {
 	// Initialize search service
 	searchSvc := search.NewSearchService(userRepo)
 
+	// Link previews for posts and messages, cached in Redis when available so replicas share them
+	var linkPreviewSvc *unfurl.Service
+	if cfg.LinkPreview.Enabled {
+		var previewCache unfurl.Cache = unfurl.NewMemoryCache()
+		if redisClient != nil {
+			previewCache = unfurl.NewRedisCache(redisClient)
+		}
+		linkPreviewSvc = unfurl.NewService(cfg, previewCache)
+	}
+
 	// ============================================
 	// MESSAGING SYSTEM INITIALIZATION
 	// ============================================
 
-	// Initialize Redis client for messaging cache and OTP storage
-	redisClient, err := cache.InitializeRedis(cfg.Redis.Host, cfg.Redis.Port, cfg.Redis.Password, cfg.Redis.DB)
-	if err != nil {
-		log.Printf("[Warning] Failed to connect to Redis: %v (messaging cache and OTP storage disabled)", err)
-		redisClient = nil
-	} else {
-		log.Println("[Redis] Connected successfully")
-		// Set Redis client for auth service OTP caching
-		authSvc.SetRedis(redisClient)
-	}
-
 	// Initialize message cache service (only if Redis is available)
 	var messageCacheSvc *cache.MessageCacheService
 	if redisClient != nil {
@@ -172,12 +312,21 @@ func main() {
 
 	// Initialize media optimizer
 	mediaOptimizer := messaging.NewMediaOptimizer()
+	mediaOptimizer.SetAVTools(mediaSvc.AV()) // Audio conversion and duration through ffmpeg, when installed
 
 	// Initialize messaging service (with notification support)
 	messagingSvc := messaging.NewMessagingService(messageRepo, messageCacheSvc, wsManager, userRepo, notificationSvc)
+	wsManager.SetChatService(messagingSvc)       // Accept send_message, typing, mark_read and react over the socket
+	messagingSvc.SetAttachmentSigner(storageSvc) // Chat attachments are served through signed, expiring URLs
+	messagingSvc.SetMediaResolver(mediaSvc)      // Image variants, video and audio metadata on messages
+	messagingSvc.SetMediaReferences(mediaSvc)    // Attachments are kept while a message uses them
+	mediaSvc.AddListener(messagingSvc)           // Processed video and audio are saved to their messages
+	if linkPreviewSvc != nil {
+		messagingSvc.SetLinkPreviewer(linkPreviewSvc) // Open Graph, Twitter Card and oEmbed cards for links
+	}
 
 	// Initialize message handlers
-	messageHandlers := messaging.NewMessageHandlers(messagingSvc, storageSvc, mediaOptimizer)
+	messageHandlers := messaging.NewMessageHandlers(messagingSvc, storageSvc, mediaOptimizer, mediaSvc)
 
 	// ============================================
 	// POSTS & FEED SYSTEM INITIALIZATION
@@ -191,12 +340,24 @@ func main() {
 
 	// Initialize post service
 	postSvc := posts.NewService(postRepo, pollRepo, articleRepo, commentRepo, wsManager)
+	postSvc.SetRelationshipRepository(relationshipRepo) // Visibility checks for "connections" posts
+	postSvc.SetNotificationService(notificationSvc)     // Likes, comments, replies, mentions, shares, poll results
+	postSvc.SetMediaResolver(mediaSvc)                  // Image variants, video and audio metadata on posts
+	postSvc.SetMediaReferences(mediaSvc)                // Media is kept while a post or comment uses it
+	mediaSvc.AddListener(postSvc)                       // Processed video and audio are saved to their posts
+	if linkPreviewSvc != nil {
+		postSvc.SetLinkPreviewer(linkPreviewSvc) // Open Graph, Twitter Card and oEmbed cards for links
+	}
+
+	// Live post threads/counters and hashtag pages over the socket
+	wsManager.RegisterTopic("post", postSvc.AuthorizePostTopic)
+	wsManager.RegisterTopic("hashtag", postSvc.AuthorizeHashtagTopic)
 
 	// Initialize feed service
 	feedSvc := posts.NewFeedService(postRepo, relationshipRepo)
 
 	// Initialize post handlers
-	postHandlers := posts.NewHandlers(postSvc, feedSvc, storageSvc, mediaOptimizer)
+	postHandlers := posts.NewHandlers(postSvc, feedSvc, storageSvc, mediaOptimizer, mediaSvc)
 
 	log.Println("[Posts] Post & feed system initialized")
 
@@ -212,6 +373,10 @@ func main() {
 
 	// Initialize event service
 	eventSvc := events.NewService(eventRepo, userRepo, emailSvc)
+	eventSvc.SetWebSocketManager(wsManager)
+	eventSvc.SetNotificationService(notificationSvc) // Approval decisions
+	eventSvc.SetMediaReferences(mediaSvc)            // Cover images are kept while an event uses them
+	wsManager.RegisterTopic("event", eventSvc.AuthorizeEventTopic)
 
 	log.Println("[Events] Events system initialized")
 
@@ -227,43 +392,79 @@ func main() {
 
 	// Initialize course service
 	courseSvc := courses.NewService(courseRepo, userRepo)
+	courseSvc.SetNotificationService(notificationSvc) // Enrollments
 
 	log.Println("[Courses] Courses system initialized")
 
+	// ============================================
+	// RESUMABLE UPLOADS INITIALIZATION
+	// ============================================
+
+	// Large post videos, chat videos/files and event covers can be uploaded in chunks
+	uploadSessionRepo, err := repository.NewUploadSessionRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize upload session repository: %v", err)
+	}
+	uploadSvc := uploads.NewService(uploadSessionRepo, storageSvc, cfg)
+	uploadSvc.SetMediaProcessor(mediaSvc)   // Completed files are deduplicated, videos queued for processing
+	uploadSvc.SetInspector(uploadInspector) // Completed files are checked before they can be used
+	uploadHandlers := uploads.NewHandlers(uploadSvc)
+
 	// Initialize background jobs
 	cleanupJob := jobs.NewNotificationCleanupJob(notificationRepo)
+	cleanupJob.SetPushSubscriptionRepository(pushSubscriptionRepo)
 	digestJob := jobs.NewNotificationDigestJob(notificationRepo, userRepo, notificationEmailSvc)
 	hashtagTrendingJob := jobs.NewHashtagTrendingJob(cfg.Database.SupabaseURL, cfg.Database.SupabaseServiceKey)
+	pollResultsJob := jobs.NewPollResultsJob(postSvc)
+	scheduledPostsJob := jobs.NewScheduledPostsJob(postSvc)
+	deliveryJob := jobs.NewNotificationDeliveryJob(notificationSvc)
+	emailOutboxJob := jobs.NewEmailOutboxJob(emailOutbox)
+	uploadCleanupJob := jobs.NewUploadCleanupJob(uploadSvc)
+	mediaProcessingJob := jobs.NewMediaProcessingJob(mediaSvc)
+	mediaGCJob := jobs.NewMediaGCJob(mediaSvc)
 
 	// Start background jobs
 	jobCtx := context.Background()
 	go cleanupJob.Start(jobCtx)         // Runs daily at 2:00 AM
-	go digestJob.StartDaily(jobCtx)     // Runs daily at 9:00 AM
-	go digestJob.StartWeekly(jobCtx)    // Runs Monday at 9:00 AM
+	go digestJob.Start(jobCtx)          // Runs hourly, sends at 9:00 AM in each user's timezone
 	go hashtagTrendingJob.Start(jobCtx) // Runs daily at 3:00 AM
+	go pollResultsJob.Start(jobCtx)     // Runs every 5 minutes
+	go scheduledPostsJob.Start(jobCtx)  // Runs every minute
+	go deliveryJob.Start(jobCtx)        // Runs every minute
+	go emailOutboxJob.Start(jobCtx)     // Runs every 15 seconds and when emails are queued
+	go uploadCleanupJob.Start(jobCtx)   // Runs every hour
+	go mediaProcessingJob.Start(jobCtx) // Runs every 30 seconds and when video or audio is uploaded
+	go mediaGCJob.Start(jobCtx)         // Runs every hour
 
-	log.Println("[Jobs] Background jobs started: cleanup (2 AM), digest (9 AM), hashtag trending (3 AM)")
+	log.Println("[Jobs] Background jobs started: cleanup (2 AM), digest (hourly), hashtag trending (3 AM), poll results (every 5 min), scheduled posts (every min), held notifications (every min), email outbox (every 15s)")
 
 	// Initialize handlers
-	authHandlers := auth.NewAuthHandlers(authSvc, jwtSvc, rateLimiter, cfg, googleOAuth, githubOAuth, linkedinOAuth, sessionRepo)
+	authHandlers := auth.NewAuthHandlers(authSvc, jwtSvc, rateLimiter, cfg, googleOAuth, githubOAuth, linkedinOAuth, passkeySvc)
 	accountHandlers := account.NewAccountHandlers(accountSvc, profileSvc, advancedProfileSvc)
 	expEduHandlers := account.NewExperienceEducationHandlers(expEduSvc)
 	relationshipHandlers := social.NewRelationshipHandlers(relationshipSvc)
 	searchHandlers := search.NewSearchHandlers(searchSvc)
 	wsHandlers := websocket.NewHandlers(wsManager, jwtSvc)
 	notificationHandlers := notifications.NewHandlers(notificationSvc)
-	eventHandlers := events.NewHandlers(eventSvc, storageSvc)
+	eventHandlers := events.NewHandlers(eventSvc, storageSvc, mediaSvc)
 	courseHandlers := courses.NewHandlers(courseSvc)
 
+	// Rate limit policies per route group (auth routes apply their own in SetupRoutes)
+	rateLimits := auth.NewRateLimitPolicies(cfg)
+	apiRateLimit := auth.RateLimitMiddleware(rateLimits.API, rateLimiter)
+	writeRateLimit := auth.RateLimitMiddleware(rateLimits.Write, rateLimiter)
+	searchRateLimit := auth.RateLimitMiddleware(rateLimits.Search, rateLimiter)
+	uploadRateLimit := auth.RateLimitMiddleware(rateLimits.Upload, rateLimiter)
+
 	// Create Gin router with middleware
 	r := gin.Default()
 
 	// CORS middleware
 	corsConfig := cors.Config{
 		AllowOrigins:     cfg.GetCORSOrigins(),
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Upload-Offset", "Upload-Checksum"},
+		ExposeHeaders:    []string{"Content-Length", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "Location", "Upload-Offset", "Upload-Length", "Upload-Expires"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
@@ -287,6 +488,11 @@ func main() {
 		})
 	})
 
+	// Uploaded files, when stored on local disk
+	if localStorage, ok := storageDriver.(*storage.LocalStorage); ok {
+		storage.NewLocalHandlers(localStorage, cfg.GetPrivateBuckets()).SetupRoutes(r)
+	}
+
 	// API routes group
 	api := r.Group("/api/v1")
 	{
@@ -316,12 +522,31 @@ func main() {
 			})
 		})
 
+		// Email template previews and the outbox (bodies hold reset and verification links),
+		// only for admins and only when explicitly enabled
+		if cfg.Email.DevRoutes {
+			if adminIDs := cfg.GetAdminUserIDs(); len(adminIDs) > 0 {
+				emailDevHandlers := mailer.NewHandlers(emailRenderer)
+				emailDevHandlers.SetOutbox(emailOutbox)
+
+				emailAdmin := api.Group("")
+				emailAdmin.Use(auth.JWTAuthMiddleware(jwtSvc), auth.AdminMiddleware(adminIDs))
+				emailDevHandlers.SetupOutboxRoutes(emailAdmin)
+				emailDevHandlers.SetupRoutes(emailAdmin)
+			} else {
+				log.Println("[Email] EMAIL_DEV_ROUTES is set but ADMIN_USER_IDS is empty, email dev routes not mounted")
+			}
+		}
+
+		// Delivery, bounce and complaint webhooks from the email provider (public, token protected)
+		mailer.NewWebhookHandlers(emailOutbox, cfg.Email.WebhookSecret).SetupRoutes(api)
+
 		// Setup authentication routes
 		authHandlers.SetupRoutes(api)
 
-		// Setup account management routes (protected by JWT middleware)
+		// Setup account management routes (protected by JWT middleware, rate limited per user)
 		protected := api.Group("")
-		protected.Use(auth.JWTAuthMiddleware(jwtSvc))
+		protected.Use(auth.JWTAuthMiddleware(jwtSvc), apiRateLimit)
 		accountHandlers.SetupRoutes(protected)
 
 		// Experience and education routes (protected)
@@ -341,6 +566,14 @@ func main() {
 		// Notification routes (protected)
 		notificationHandlers.SetupRoutes(protected)
 
+		// Resumable upload routes (protected, starting an upload counts against the upload quota)
+		uploadHandlers.SetupRoutes(protected, uploadRateLimit)
+
+		// Link previews for composers (protected, every uncached link is a fetch)
+		if linkPreviewSvc != nil {
+			unfurl.NewHandlers(linkPreviewSvc).SetupRoutes(protected, searchRateLimit)
+		}
+
 		// Messaging routes (protected)
 		messagingGroup := protected.Group("/conversations")
 		{
@@ -350,12 +583,12 @@ func main() {
 
 			// Messages in conversation (specific routes with :id before catch-all)
 			messagingGroup.GET("/:id/messages", messageHandlers.GetMessages)
-			messagingGroup.POST("/:id/messages", messageHandlers.SendMessage)
+			messagingGroup.POST("/:id/messages", writeRateLimit, messageHandlers.SendMessage)
 			messagingGroup.PATCH("/:id/read", messageHandlers.MarkAsRead)
 
 			// Pinned messages and search
 			messagingGroup.GET("/:id/pinned", messageHandlers.GetPinnedMessages)
-			messagingGroup.GET("/:id/search", messageHandlers.SearchConversationMessages)
+			messagingGroup.GET("/:id/search", searchRateLimit, messageHandlers.SearchConversationMessages)
 
 			// Typing indicators
 			messagingGroup.POST("/:id/typing/start", messageHandlers.StartTyping)
@@ -369,7 +602,7 @@ func main() {
 		// Message-specific routes (protected)
 		messageGroup := protected.Group("/messages")
 		{
-			messageGroup.GET("/search", messageHandlers.SearchMessages)
+			messageGroup.GET("/search", searchRateLimit, messageHandlers.SearchMessages)
 			messageGroup.GET("/starred", messageHandlers.GetStarredMessages)
 			messageGroup.DELETE("/:id", messageHandlers.DeleteMessage)
 
@@ -390,13 +623,13 @@ func main() {
 			messageGroup.GET("/:id/edit-history", messageHandlers.GetMessageEditHistory)
 
 			// Forward
-			messageGroup.POST("/:id/forward", messageHandlers.ForwardMessage)
+			messageGroup.POST("/:id/forward", writeRateLimit, messageHandlers.ForwardMessage)
 
-			// Media uploads
-			messageGroup.POST("/upload-image", messageHandlers.UploadImage)
-			messageGroup.POST("/upload-audio", messageHandlers.UploadAudio)
-			messageGroup.POST("/upload-file", messageHandlers.UploadFile)
-			messageGroup.POST("/upload-video", messageHandlers.UploadVideo)
+			// Media uploads (separate upload quota)
+			messageGroup.POST("/upload-image", uploadRateLimit, messageHandlers.UploadImage)
+			messageGroup.POST("/upload-audio", uploadRateLimit, messageHandlers.UploadAudio)
+			messageGroup.POST("/upload-file", uploadRateLimit, messageHandlers.UploadFile)
+			messageGroup.POST("/upload-video", uploadRateLimit, messageHandlers.UploadVideo)
 		}
 
 		// Presence routes (protected)
@@ -406,30 +639,45 @@ func main() {
 			presenceGroup.GET("/presence/bulk", messageHandlers.GetBulkPresence)
 		}
 
-		// Search routes (public - no auth required)
-		searchHandlers.SetupRoutes(api)
+		// Search routes (public - no auth required, rate limited per IP)
+		searchHandlers.SetupRoutes(api.Group("", searchRateLimit))
 
 		// ============================================
 		// POSTS & FEED ROUTES
 		// ============================================
 
 		// Public article route (no auth required for SEO and sharing)
-		api.GET("/articles/:slug", postHandlers.GetArticleBySlug) // Get article by slug (PUBLIC)
+		api.GET("/articles/:slug", apiRateLimit, postHandlers.GetArticleBySlug) // Get article by slug (PUBLIC)
 
 		// Posts CRUD (protected)
 		postsGroup := protected.Group("/posts")
 		{
-			// Media uploads (must come before :id routes)
-			postsGroup.POST("/upload-image", postHandlers.UploadImage) // Upload image
-			postsGroup.POST("/upload-video", postHandlers.UploadVideo) // Upload video
-			postsGroup.POST("/upload-audio", postHandlers.UploadAudio) // Upload audio
+			// Media uploads (must come before :id routes, separate upload quota)
+			postsGroup.POST("/upload-image", uploadRateLimit, postHandlers.UploadImage) // Upload image
+			postsGroup.POST("/upload-video", uploadRateLimit, postHandlers.UploadVideo) // Upload video
+			postsGroup.POST("/upload-audio", uploadRateLimit, postHandlers.UploadAudio) // Upload audio
 
-			postsGroup.POST("", postHandlers.CreatePost)                 // Create post
+			postsGroup.POST("", writeRateLimit, postHandlers.CreatePost) // Create post
 			postsGroup.GET("/:id", postHandlers.GetPost)                 // Get single post
 			postsGroup.PUT("/:id", postHandlers.UpdatePost)              // Update post
 			postsGroup.DELETE("/:id", postHandlers.DeletePost)           // Delete post
 			postsGroup.GET("/user/:username", postHandlers.GetUserPosts) // User's posts
 
+			// Scheduled posts (published by the scheduled posts job)
+			postsGroup.GET("/scheduled", postHandlers.GetScheduledPosts)         // My scheduled posts
+			postsGroup.PATCH("/:id/schedule", postHandlers.ReschedulePost)       // Reschedule post
+			postsGroup.DELETE("/:id/schedule", postHandlers.CancelScheduledPost) // Cancel, kept as a draft
+
+			// Drafts (autosaved, only visible to their author until published)
+			postsGroup.GET("/drafts", postHandlers.ListDrafts)                                              // My drafts
+			postsGroup.POST("/drafts", writeRateLimit, postHandlers.CreateDraft)                            // Start draft
+			postsGroup.GET("/drafts/:id", postHandlers.GetDraft)                                            // Get draft
+			postsGroup.PUT("/drafts/:id", postHandlers.SaveDraft)                                           // Autosave draft
+			postsGroup.DELETE("/drafts/:id", postHandlers.DiscardDraft)                                     // Discard draft
+			postsGroup.GET("/drafts/:id/revisions", postHandlers.GetDraftRevisions)                         // Draft revisions
+			postsGroup.POST("/drafts/:id/revisions/:revisionId/restore", postHandlers.RestoreDraftRevision) // Restore revision
+			postsGroup.POST("/drafts/:id/publish", writeRateLimit, postHandlers.PublishDraft)               // Publish or schedule draft
+
 			// Engagement
 			postsGroup.POST("/:id/like", postHandlers.LikePost)     // Like post
 			postsGroup.DELETE("/:id/like", postHandlers.UnlikePost) // Unlike post
@@ -438,8 +686,8 @@ func main() {
 			postsGroup.DELETE("/:id/save", postHandlers.UnsavePost) // Unsave post
 
 			// Comments
-			postsGroup.POST("/:id/comments", postHandlers.CreateComment) // Create comment
-			postsGroup.GET("/:id/comments", postHandlers.GetComments)    // Get comments
+			postsGroup.POST("/:id/comments", writeRateLimit, postHandlers.CreateComment) // Create comment
+			postsGroup.GET("/:id/comments", postHandlers.GetComments)                    // Get comments
 
 			// Polls
 			postsGroup.POST("/:id/vote", postHandlers.VotePoll)         // Vote on poll
@@ -474,12 +722,12 @@ func main() {
 		// Hashtags (mixed public/protected)
 		hashtagGroup := api.Group("/hashtags")
 		{
-			hashtagGroup.GET("/:tag/posts", postHandlers.GetHashtagFeed)    // Hashtag feed (public)
-			hashtagGroup.GET("/trending", postHandlers.GetTrendingHashtags) // Trending (public)
+			hashtagGroup.GET("/:tag/posts", apiRateLimit, postHandlers.GetHashtagFeed)    // Hashtag feed (public)
+			hashtagGroup.GET("/trending", apiRateLimit, postHandlers.GetTrendingHashtags) // Trending (public)
 
 			// Protected hashtag actions
 			hashtagProtected := hashtagGroup.Group("")
-			hashtagProtected.Use(auth.JWTAuthMiddleware(jwtSvc))
+			hashtagProtected.Use(auth.JWTAuthMiddleware(jwtSvc), apiRateLimit)
 			{
 				hashtagProtected.POST("/:tag/follow", postHandlers.FollowHashtag)     // Follow hashtag
 				hashtagProtected.DELETE("/:tag/follow", postHandlers.UnfollowHashtag) // Unfollow hashtag
@@ -496,32 +744,32 @@ func main() {
 		eventsGroup := api.Group("/events")
 		{
 			// Public routes
-			eventsGroup.GET("", eventHandlers.ListEvents)               // List events (public)
-			eventsGroup.GET("/:id", eventHandlers.GetEvent)             // Get event details (public)
-			eventsGroup.GET("/categories", eventHandlers.GetCategories) // Get categories (public)
+			eventsGroup.GET("", apiRateLimit, eventHandlers.ListEvents)               // List events (public)
+			eventsGroup.GET("/:id", apiRateLimit, eventHandlers.GetEvent)             // Get event details (public)
+			eventsGroup.GET("/categories", apiRateLimit, eventHandlers.GetCategories) // Get categories (public)
 
 			// Protected routes
 			eventsProtected := eventsGroup.Group("")
-			eventsProtected.Use(auth.JWTAuthMiddleware(jwtSvc))
+			eventsProtected.Use(auth.JWTAuthMiddleware(jwtSvc), apiRateLimit)
 			{
-				eventsProtected.POST("", eventHandlers.CreateEvent)                              // Create event
-				eventsProtected.POST("/upload-cover-image", eventHandlers.UploadEventCoverImage) // Upload cover image
-				eventsProtected.PUT("/:id", eventHandlers.UpdateEvent)                           // Update event
-				eventsProtected.DELETE("/:id", eventHandlers.DeleteEvent)                        // Delete event
-				eventsProtected.POST("/:id/apply", eventHandlers.ApplyToEvent)                   // Apply to event
-				eventsProtected.GET("/:id/application", eventHandlers.GetApplication)            // Get user's application
-				eventsProtected.GET("/:id/ticket", eventHandlers.GetTicket)                      // Get ticket
+				eventsProtected.POST("", eventHandlers.CreateEvent)                                               // Create event
+				eventsProtected.POST("/upload-cover-image", uploadRateLimit, eventHandlers.UploadEventCoverImage) // Upload cover image
+				eventsProtected.PUT("/:id", eventHandlers.UpdateEvent)                                            // Update event
+				eventsProtected.DELETE("/:id", eventHandlers.DeleteEvent)                                         // Delete event
+				eventsProtected.POST("/:id/apply", eventHandlers.ApplyToEvent)                                    // Apply to event
+				eventsProtected.GET("/:id/application", eventHandlers.GetApplication)                             // Get user's application
+				eventsProtected.GET("/:id/ticket", eventHandlers.GetTicket)                                       // Get ticket
 			}
 
 			// Admin routes (should add admin middleware)
 			eventsAdmin := eventsGroup.Group("/approve")
-			eventsAdmin.Use(auth.JWTAuthMiddleware(jwtSvc))
+			eventsAdmin.Use(auth.JWTAuthMiddleware(jwtSvc), apiRateLimit)
 			{
 				eventsAdmin.POST("", eventHandlers.ApproveEvent) // Approve/reject event
 			}
 
 			eventsAdminGroup := eventsGroup.Group("/approvals")
-			eventsAdminGroup.Use(auth.JWTAuthMiddleware(jwtSvc))
+			eventsAdminGroup.Use(auth.JWTAuthMiddleware(jwtSvc), apiRateLimit)
 			{
 				eventsAdminGroup.GET("/pending", eventHandlers.GetPendingApprovals) // Get pending approvals
 			}
@@ -537,13 +785,13 @@ func main() {
 		coursesGroup := api.Group("/courses")
 		{
 			// Public routes
-			coursesGroup.GET("", courseHandlers.GetCourses)                 // List courses (public)
-			coursesGroup.GET("/:id", courseHandlers.GetCourse)              // Get course details (public)
-			coursesGroup.GET("/slug/:slug", courseHandlers.GetCourseBySlug) // Get course by slug (public)
+			coursesGroup.GET("", apiRateLimit, courseHandlers.GetCourses)                 // List courses (public)
+			coursesGroup.GET("/:id", apiRateLimit, courseHandlers.GetCourse)              // Get course details (public)
+			coursesGroup.GET("/slug/:slug", apiRateLimit, courseHandlers.GetCourseBySlug) // Get course by slug (public)
 
 			// Protected routes
 			coursesProtected := coursesGroup.Group("")
-			coursesProtected.Use(auth.JWTAuthMiddleware(jwtSvc))
+			coursesProtected.Use(auth.JWTAuthMiddleware(jwtSvc), apiRateLimit)
 			{
 				coursesProtected.POST("", courseHandlers.CreateCourse)              // Create course
 				coursesProtected.POST("/:id/enroll", courseHandlers.EnrollInCourse) // Enroll in course
@@ -554,13 +802,13 @@ func main() {
 		materialsGroup := api.Group("/learning-materials")
 		{
 			// Public routes
-			materialsGroup.GET("", courseHandlers.GetMaterials) // List materials (public)
+			materialsGroup.GET("", apiRateLimit, courseHandlers.GetMaterials) // List materials (public)
 		}
 
 		log.Println("[Routes] Courses routes registered")
 
 		// WebSocket route (protected - JWT in query param or header)
-		wsHandlers.SetupRoutes(api)
+		wsHandlers.SetupRoutes(api.Group("", apiRateLimit))
 	}
 
 	// Start server
@@ -44,6 +44,22 @@ var (
 	ErrInvalidToken       = NewAppError(http.StatusUnauthorized, "Invalid or expired token")
 	ErrTokenExpired       = NewAppError(http.StatusUnauthorized, "Token has expired")
 	ErrUnauthorized       = NewAppError(http.StatusUnauthorized, "Unauthorized access")
+	ErrRefreshTokenReused = NewAppError(http.StatusUnauthorized, "Session has been revoked, please log in again")
+
+	// Two-factor authentication errors
+	ErrTwoFactorRequired      = NewAppError(http.StatusForbidden, "Two-factor authentication code required")
+	ErrInvalidTwoFactorCode   = NewAppError(http.StatusUnauthorized, "Invalid two-factor authentication code")
+	ErrTwoFactorAlreadyOn     = NewAppError(http.StatusConflict, "Two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled    = NewAppError(http.StatusBadRequest, "Two-factor authentication is not enabled")
+	ErrTwoFactorSetupRequired = NewAppError(http.StatusBadRequest, "Two-factor setup has not been started")
+	ErrTooManyTwoFactorCodes  = NewAppError(http.StatusTooManyRequests, "Too many invalid codes, please log in again")
+
+	// Passkey (WebAuthn) errors
+	ErrPasskeyNotFound           = NewAppError(http.StatusNotFound, "Passkey not found")
+	ErrPasskeyCeremonyExpired    = NewAppError(http.StatusBadRequest, "Passkey request expired, please try again")
+	ErrPasskeyVerification       = NewAppError(http.StatusUnauthorized, "Passkey verification failed")
+	ErrPasskeyLimitReached       = NewAppError(http.StatusBadRequest, "Maximum number of passkeys reached")
+	ErrPasskeyServiceUnavailable = NewAppError(http.StatusServiceUnavailable, "Passkey service unavailable")
 
 	// Validation errors
 	ErrInvalidInput       = NewAppError(http.StatusBadRequest, "Invalid input data")
@@ -74,6 +90,18 @@ var (
 	// Request errors
 	ErrBadRequest = NewAppError(http.StatusBadRequest, "Bad request")
 
+	// Resumable upload errors
+	ErrUploadNotFound         = NewAppError(http.StatusNotFound, "Upload not found")
+	ErrUploadOffsetMismatch   = NewAppError(http.StatusConflict, "Upload offset does not match, fetch the current offset and resume from there")
+	ErrUploadNotUploading     = NewAppError(http.StatusConflict, "Upload is being completed or already completed")
+	ErrUploadIncomplete       = NewAppError(http.StatusConflict, "Upload is missing data, send the remaining chunks first")
+	ErrUploadChunkTooLarge    = NewAppError(http.StatusRequestEntityTooLarge, "Chunk is larger than allowed or goes past the end of the upload")
+	ErrUploadChecksumMismatch = NewAppError(460, "Checksum mismatch") // Status code of the tus checksum extension
+
+	// Upload inspection errors
+	ErrFileQuarantined     = NewAppError(http.StatusUnprocessableEntity, "File was flagged as unsafe and can't be uploaded")
+	ErrFileScanUnavailable = NewAppError(http.StatusServiceUnavailable, "File scanning is unavailable, please try again later")
+
 	// Rate limiting errors
 	ErrTooManyRequests = NewAppError(http.StatusTooManyRequests, "Too many requests")
 
@@ -0,0 +1,87 @@
+-- UpVista Community - Activity Notifications Migration
+-- Run this script in your Supabase SQL editor
+
+-- =====================================================
+-- NEW NOTIFICATION TYPES & CATEGORIES
+-- =====================================================
+
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'post_share';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'poll_ended';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'comment_reply';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'comment_mention';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'event_approved';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'event_rejected';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'course_enrollment';
+
+ALTER TYPE notification_category ADD VALUE IF NOT EXISTS 'events';
+ALTER TYPE notification_category ADD VALUE IF NOT EXISTS 'learning';
+
+-- =====================================================
+-- PREFERENCE DEFAULTS
+-- =====================================================
+
+-- Keep in sync with models.DefaultEmailTypes
+ALTER TABLE notification_preferences
+    ALTER COLUMN email_types SET DEFAULT '{
+        "follow": false,
+        "follow_back": false,
+        "connection_request": true,
+        "connection_accepted": true,
+        "collaboration_request": true,
+        "collaboration_accepted": true,
+        "message": false,
+        "post_like": false,
+        "post_comment": true,
+        "post_mention": true,
+        "post_share": false,
+        "poll_ended": false,
+        "comment_reply": true,
+        "comment_mention": true,
+        "event_approved": true,
+        "event_rejected": true,
+        "course_enrollment": false,
+        "project_invite": true,
+        "community_invite": true,
+        "payment_received": true
+    }',
+    ALTER COLUMN categories_enabled SET DEFAULT '{
+        "social": true,
+        "messages": true,
+        "projects": true,
+        "communities": true,
+        "payments": true,
+        "events": true,
+        "learning": true,
+        "system": true
+    }';
+
+-- Add the new keys to existing preferences without overriding the user's choices
+UPDATE notification_preferences
+SET email_types = '{
+        "post_mention": true,
+        "post_share": false,
+        "poll_ended": false,
+        "comment_reply": true,
+        "comment_mention": true,
+        "event_approved": true,
+        "event_rejected": true,
+        "course_enrollment": false
+    }'::jsonb || email_types,
+    categories_enabled = '{"events": true, "learning": true}'::jsonb || categories_enabled;
+
+-- =====================================================
+-- POLL RESULTS
+-- =====================================================
+
+-- Set once the results of an ended poll were sent to its author and voters
+ALTER TABLE polls
+    ADD COLUMN IF NOT EXISTS results_notified BOOLEAN NOT NULL DEFAULT false;
+
+-- Polls that ended before this migration aren't announced
+UPDATE polls SET results_notified = true WHERE ends_at < NOW();
+
+CREATE INDEX IF NOT EXISTS idx_polls_results_pending
+    ON polls(ends_at)
+    WHERE results_notified = false;
+
+COMMENT ON COLUMN polls.results_notified IS 'Whether the final results were sent to the author and voters';
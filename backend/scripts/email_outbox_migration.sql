@@ -0,0 +1,42 @@
+-- UpVista Community - Email Outbox Migration
+-- Run this script in your Supabase SQL editor
+
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    to_email VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    html_body TEXT NOT NULL DEFAULT '',
+    text_body TEXT NOT NULL DEFAULT '',
+    template VARCHAR(64),                        -- Email template name, NULL for raw emails
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'sent', 'delivered', 'failed', 'bounced', 'complained', 'suppressed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_at TIMESTAMPTZ,                       -- Set while a worker is sending
+    last_error TEXT,
+    transport VARCHAR(20),                       -- smtp, file, sendgrid, postmark or resend
+    provider_message_id TEXT,                    -- Matches provider webhooks to the email
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Workers poll for due pending emails
+CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_email_outbox_sending ON email_outbox(locked_at) WHERE status = 'sending';
+CREATE INDEX IF NOT EXISTS idx_email_outbox_provider_message_id ON email_outbox(provider_message_id) WHERE provider_message_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_email_outbox_to_email ON email_outbox(to_email, created_at DESC);
+
+-- Addresses that hard bounced or reported spam are never emailed again
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    email VARCHAR(255) PRIMARY KEY,              -- Lowercased
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('bounced', 'complained')),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Only the backend (service role) accesses the outbox
+ALTER TABLE email_outbox ENABLE ROW LEVEL SECURITY;
+ALTER TABLE email_suppressions ENABLE ROW LEVEL SECURITY;
+
+-- Bodies hold reset and verification links, they are cleared once an email is sent or given up on
+UPDATE email_outbox SET html_body = '', text_body = ''
+WHERE status NOT IN ('pending', 'sending') AND (html_body <> '' OR text_body <> '');
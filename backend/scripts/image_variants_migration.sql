@@ -0,0 +1,28 @@
+-- UpVista Community - Image Variants Migration
+-- Run this script in your Supabase SQL editor
+
+-- Every uploaded image is stored as size variants (thumb, small, medium, large) in one or more formats
+CREATE TABLE IF NOT EXISTS media_assets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    bucket VARCHAR(63) NOT NULL,
+    url TEXT NOT NULL UNIQUE,              -- Large variant in the most compatible format, what media_urls and attachment_url hold
+    width INTEGER NOT NULL,                -- Dimensions after orientation correction
+    height INTEGER NOT NULL,
+    blurhash VARCHAR(64),                  -- Placeholder shown while the image loads
+    variants JSONB NOT NULL DEFAULT '[]',  -- [{name, format, url, width, height, size}]
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_assets_user_id ON media_assets(user_id, created_at DESC);
+
+-- Only the backend (service role) accesses media assets
+ALTER TABLE media_assets ENABLE ROW LEVEL SECURITY;
+
+-- Variants and dimensions of the images of a post, in media_urls order
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS media JSONB;
+
+-- Variants and dimensions of an image attachment
+ALTER TABLE messages
+ADD COLUMN IF NOT EXISTS media JSONB;
@@ -0,0 +1,10 @@
+-- UpVista Community - Link Previews Migration
+-- Run this script in your Supabase SQL editor
+
+-- Preview cards of the links in a post or message (Open Graph, Twitter Card and oEmbed metadata),
+-- built when it is created and refreshed when its text is edited
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS link_previews JSONB;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS link_previews JSONB;
+
+COMMENT ON COLUMN posts.link_previews IS 'Previews of the links in the post content, in order';
+COMMENT ON COLUMN messages.link_previews IS 'Previews of the links in the message content, in order';
@@ -0,0 +1,142 @@
+-- UpVista Community - Media Deduplication Migration
+-- Run this script in your Supabase SQL editor
+
+-- Uploads are stored once per bucket, identified by the SHA-256 of their content.
+-- Images add the quality they were rendered at ("<hash>-standard", "<hash>-hd"), their variants differ.
+ALTER TABLE media_assets
+ADD COLUMN IF NOT EXISTS content_hash VARCHAR(80),               -- NULL for uploads recorded before this migration
+ADD COLUMN IF NOT EXISTS size BIGINT NOT NULL DEFAULT 0,          -- Bytes uploaded
+ADD COLUMN IF NOT EXISTS ref_count INTEGER NOT NULL DEFAULT 0,    -- Posts, comments, messages and events using the asset
+ADD COLUMN IF NOT EXISTS unreferenced_since TIMESTAMPTZ DEFAULT NOW(); -- Set while ref_count is 0, collected after the grace period
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_media_assets_content_hash ON media_assets(bucket, content_hash)
+    WHERE content_hash IS NOT NULL;
+
+-- The garbage collector looks for assets unreferenced the longest
+CREATE INDEX IF NOT EXISTS idx_media_assets_unreferenced ON media_assets(unreferenced_since)
+    WHERE ref_count = 0;
+
+-- A shared file outlives the account of whoever uploaded it first
+ALTER TABLE media_assets ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE media_assets DROP CONSTRAINT IF EXISTS media_assets_user_id_fkey;
+ALTER TABLE media_assets ADD CONSTRAINT media_assets_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Plain files: documents, client-made thumbnails and resumable uploads that aren't processed
+ALTER TABLE media_assets DROP CONSTRAINT IF EXISTS media_assets_kind_check;
+ALTER TABLE media_assets ADD CONSTRAINT media_assets_kind_check
+    CHECK (kind IN ('image', 'video', 'audio', 'file'));
+
+-- Which posts, comments, messages and events use an asset
+-- An asset can't be deleted while it has references
+CREATE TABLE IF NOT EXISTS media_references (
+    asset_id UUID NOT NULL REFERENCES media_assets(id),
+    owner_type VARCHAR(20) NOT NULL CHECK (owner_type IN ('post', 'comment', 'message', 'event')),
+    owner_id UUID NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (asset_id, owner_type, owner_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_references_owner ON media_references(owner_type, owner_id);
+
+-- Only the backend (service role) accesses references
+ALTER TABLE media_references ENABLE ROW LEVEL SECURITY;
+
+-- Keep ref_count and unreferenced_since in step with media_references
+CREATE OR REPLACE FUNCTION update_media_ref_count()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE media_assets
+        SET ref_count = ref_count + 1, unreferenced_since = NULL
+        WHERE id = NEW.asset_id;
+        RETURN NEW;
+    END IF;
+
+    UPDATE media_assets
+    SET ref_count = GREATEST(ref_count - 1, 0),
+        unreferenced_since = CASE WHEN ref_count <= 1 THEN NOW() ELSE unreferenced_since END
+    WHERE id = OLD.asset_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS media_references_count ON media_references;
+CREATE TRIGGER media_references_count
+    AFTER INSERT OR DELETE ON media_references
+    FOR EACH ROW EXECUTE FUNCTION update_media_ref_count();
+
+-- Rows deleted outside the backend (e.g. by cascades when an account is deleted) release their media too
+CREATE OR REPLACE FUNCTION release_media_references()
+RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM media_references WHERE owner_type = TG_ARGV[0] AND owner_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS posts_release_media ON posts;
+CREATE TRIGGER posts_release_media
+    AFTER DELETE ON posts
+    FOR EACH ROW EXECUTE FUNCTION release_media_references('post');
+
+DROP TRIGGER IF EXISTS post_comments_release_media ON post_comments;
+CREATE TRIGGER post_comments_release_media
+    AFTER DELETE ON post_comments
+    FOR EACH ROW EXECUTE FUNCTION release_media_references('comment');
+
+DROP TRIGGER IF EXISTS messages_release_media ON messages;
+CREATE TRIGGER messages_release_media
+    AFTER DELETE ON messages
+    FOR EACH ROW EXECUTE FUNCTION release_media_references('message');
+
+DROP TRIGGER IF EXISTS events_release_media ON events;
+CREATE TRIGGER events_release_media
+    AFTER DELETE ON events
+    FOR EACH ROW EXECUTE FUNCTION release_media_references('event');
+
+-- Reference the media of existing rows, so nothing in use is collected
+INSERT INTO media_references (asset_id, owner_type, owner_id)
+SELECT a.id, 'post', p.id
+FROM posts p
+CROSS JOIN LATERAL unnest(p.media_urls) AS u(url)
+JOIN media_assets a ON a.url = u.url
+WHERE p.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO media_references (asset_id, owner_type, owner_id)
+SELECT a.id, 'post', ar.post_id
+FROM articles ar
+JOIN posts p ON p.id = ar.post_id AND p.deleted_at IS NULL
+JOIN media_assets a ON a.url = ar.cover_image_url
+ON CONFLICT DO NOTHING;
+
+INSERT INTO media_references (asset_id, owner_type, owner_id)
+SELECT a.id, 'post', ar.post_id
+FROM articles ar
+JOIN posts p ON p.id = ar.post_id AND p.deleted_at IS NULL
+CROSS JOIN LATERAL regexp_matches(ar.content_html, '<img\s[^>]*?src=["'']([^"'']+)["'']', 'gi') AS m(src)
+JOIN media_assets a ON a.url = m.src[1]
+ON CONFLICT DO NOTHING;
+
+INSERT INTO media_references (asset_id, owner_type, owner_id)
+SELECT a.id, 'comment', c.id
+FROM post_comments c
+JOIN media_assets a ON a.url = c.media_url
+WHERE c.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO media_references (asset_id, owner_type, owner_id)
+SELECT a.id, 'message', m.id
+FROM messages m
+JOIN media_assets a ON a.url = m.attachment_url
+ON CONFLICT DO NOTHING;
+
+INSERT INTO media_references (asset_id, owner_type, owner_id)
+SELECT a.id, 'event', e.id
+FROM events e
+JOIN media_assets a ON a.url = e.cover_image_url
+ON CONFLICT DO NOTHING;
+
+-- Existing assets nothing uses start their grace period now
+UPDATE media_assets SET unreferenced_since = NOW() WHERE ref_count = 0;
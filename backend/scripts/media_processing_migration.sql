@@ -0,0 +1,53 @@
+-- UpVista Community - Media Processing Migration
+-- Run this script in your Supabase SQL editor
+
+-- Uploaded video and audio are recorded next to images and processed in the background
+ALTER TABLE media_assets
+ADD COLUMN IF NOT EXISTS kind VARCHAR(10) NOT NULL DEFAULT 'image',   -- image, video or audio
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'ready', -- processing, ready or failed
+ADD COLUMN IF NOT EXISTS duration DOUBLE PRECISION,                   -- Seconds
+ADD COLUMN IF NOT EXISTS thumbnail_url TEXT,
+ADD COLUMN IF NOT EXISTS waveform JSONB,                              -- Audio peaks scaled to 0-100
+ADD COLUMN IF NOT EXISTS video_codec VARCHAR(32),
+ADD COLUMN IF NOT EXISTS audio_codec VARCHAR(32),
+ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT NOW();
+
+-- Dimensions are only known once a video is probed
+ALTER TABLE media_assets ALTER COLUMN width SET DEFAULT 0;
+ALTER TABLE media_assets ALTER COLUMN height SET DEFAULT 0;
+
+ALTER TABLE media_assets DROP CONSTRAINT IF EXISTS media_assets_kind_check;
+ALTER TABLE media_assets ADD CONSTRAINT media_assets_kind_check
+    CHECK (kind IN ('image', 'video', 'audio'));
+
+ALTER TABLE media_assets DROP CONSTRAINT IF EXISTS media_assets_status_check;
+ALTER TABLE media_assets ADD CONSTRAINT media_assets_status_check
+    CHECK (status IN ('processing', 'ready', 'failed'));
+
+-- Queue of video and audio waiting to be probed, thumbnailed and transcoded
+CREATE TABLE IF NOT EXISTS media_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    asset_id UUID NOT NULL REFERENCES media_assets(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_at TIMESTAMPTZ,                 -- Set while a worker processes the job
+    last_error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Workers poll for due pending jobs and stale processing ones
+CREATE INDEX IF NOT EXISTS idx_media_jobs_due ON media_jobs(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_media_jobs_locked ON media_jobs(locked_at) WHERE status = 'processing';
+CREATE INDEX IF NOT EXISTS idx_media_jobs_asset_id ON media_jobs(asset_id);
+
+-- Only the backend (service role) accesses the queue
+ALTER TABLE media_jobs ENABLE ROW LEVEL SECURITY;
+
+-- Messages carrying a processed video or audio file are found by their attachment
+CREATE INDEX IF NOT EXISTS idx_messages_attachment_url ON messages(attachment_url) WHERE attachment_url IS NOT NULL;
+
+-- Posts carrying a processed video or audio file are found by their media_urls
+CREATE INDEX IF NOT EXISTS idx_posts_media_urls ON posts USING GIN (media_urls);
@@ -0,0 +1,24 @@
+-- UpVista Community - Notification Grouping Migration
+-- Run this script in your Supabase SQL editor
+
+-- Unread notifications with the same group key (e.g. "post_like:<post id>") collapse into one
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS group_key TEXT,
+    ADD COLUMN IF NOT EXISTS actor_ids UUID[] NOT NULL DEFAULT '{}',      -- Most recent actors, newest first (max 10)
+    ADD COLUMN IF NOT EXISTS actor_count INTEGER NOT NULL DEFAULT 1,     -- Distinct actors in the group
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+-- Backfill existing notifications as single-actor groups
+UPDATE notifications
+SET updated_at = created_at,
+    actor_ids = CASE WHEN actor_id IS NULL THEN '{}' ELSE ARRAY[actor_id] END
+WHERE actor_ids = '{}';
+
+-- At most one unread notification per group, concurrent inserts for the same group conflict
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_open_group
+    ON notifications(user_id, group_key)
+    WHERE is_read = false AND group_key IS NOT NULL;
+
+-- The inbox is ordered by the latest activity
+CREATE INDEX IF NOT EXISTS idx_notifications_user_updated
+    ON notifications(user_id, updated_at DESC);
@@ -0,0 +1,41 @@
+-- UpVista Community - Notification Scheduling Migration
+-- Run this script in your Supabase SQL editor
+
+-- =====================================================
+-- DEFERRED DELIVERY
+-- =====================================================
+
+-- in_app = false keeps email/push-only notifications out of the inbox
+-- pending_channels/deliver_at hold email and push during quiet hours or a snooze
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS in_app BOOLEAN NOT NULL DEFAULT true,
+    ADD COLUMN IF NOT EXISTS pending_channels TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS deliver_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_deliver_at
+    ON notifications(deliver_at)
+    WHERE deliver_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_inbox
+    ON notifications(user_id, created_at DESC)
+    WHERE in_app = true;
+
+COMMENT ON COLUMN notifications.in_app IS 'Whether the notification is shown in the inbox';
+COMMENT ON COLUMN notifications.pending_channels IS 'Channels held back until deliver_at';
+COMMENT ON COLUMN notifications.deliver_at IS 'When held email and push may be delivered';
+
+-- =====================================================
+-- PREFERENCES
+-- =====================================================
+
+ALTER TABLE notification_preferences
+    ADD COLUMN IF NOT EXISTS category_channels JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS last_digest_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_frequency
+    ON notification_preferences(email_frequency);
+
+COMMENT ON COLUMN notification_preferences.category_channels IS 'Per-category in_app/email/push overrides';
+COMMENT ON COLUMN notification_preferences.snoozed_until IS 'Email and push are held until this time';
+COMMENT ON COLUMN notification_preferences.last_digest_at IS 'When the last daily or weekly digest was sent';
@@ -0,0 +1,24 @@
+-- UpVista Community - Passkeys (WebAuthn) Migration
+-- Run this script in your Supabase SQL editor
+
+CREATE TABLE IF NOT EXISTS user_passkeys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential_id TEXT NOT NULL UNIQUE,          -- base64url raw credential ID
+    public_key TEXT NOT NULL,                    -- base64 COSE public key
+    attestation_type VARCHAR(32) DEFAULT '',
+    aaguid TEXT DEFAULT '',                      -- base64 authenticator model ID
+    sign_count BIGINT DEFAULT 0,
+    clone_warning BOOLEAN DEFAULT FALSE,
+    transports TEXT[] DEFAULT '{}',
+    backup_eligible BOOLEAN DEFAULT FALSE,
+    backup_state BOOLEAN DEFAULT FALSE,
+    name VARCHAR(64) NOT NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_passkeys_user_id ON user_passkeys(user_id);
+
+-- Only the backend (service role) accesses passkeys
+ALTER TABLE user_passkeys ENABLE ROW LEVEL SECURITY;
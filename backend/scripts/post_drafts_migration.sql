@@ -0,0 +1,31 @@
+-- UpVista Community - Post Drafts Migration
+-- Run this script in your Supabase SQL editor
+
+-- Everything saved in a draft, poll and article included, cleared once it is published
+-- Drafts have is_draft = TRUE and is_published = FALSE, their poll or article is created when they are published
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS draft JSONB,
+ADD COLUMN IF NOT EXISTS draft_revision INTEGER NOT NULL DEFAULT 0; -- Incremented by every save, a save made on an older revision is rejected
+
+COMMENT ON COLUMN posts.draft IS 'Content of a draft, NULL for published and scheduled posts';
+COMMENT ON COLUMN posts.draft_revision IS 'Revision of a draft, incremented by every save';
+
+-- Each user lists their own drafts, the last edited first
+CREATE INDEX IF NOT EXISTS idx_posts_user_drafts ON posts(user_id, updated_at DESC)
+    WHERE is_draft = TRUE AND is_published = FALSE AND deleted_at IS NULL;
+
+-- Earlier states of drafts, autosaves within a few minutes update the latest one
+-- The last 20 are kept, they are deleted when the draft is published or discarded
+CREATE TABLE IF NOT EXISTS post_draft_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    content JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_draft_revisions_post ON post_draft_revisions(post_id, revision DESC);
+
+-- Only the backend (service role) accesses revisions
+ALTER TABLE post_draft_revisions ENABLE ROW LEVEL SECURITY;
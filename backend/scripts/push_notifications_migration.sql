@@ -0,0 +1,26 @@
+-- UpVista Community - Web Push Notifications Migration
+-- Run this script in your Supabase SQL editor
+
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL UNIQUE,               -- Push service URL issued to the browser
+    p256dh TEXT NOT NULL,                        -- base64url browser ECDH public key
+    auth TEXT NOT NULL,                          -- base64url browser auth secret
+    user_agent TEXT DEFAULT '',
+    expires_at TIMESTAMP,                        -- NULL when the push service didn't set an expiry
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user_id ON push_subscriptions(user_id);
+
+-- Only the backend (service role) accesses push subscriptions
+ALTER TABLE push_subscriptions ENABLE ROW LEVEL SECURITY;
+
+-- Quiet hours (push is held back during them)
+ALTER TABLE notification_preferences
+    ADD COLUMN IF NOT EXISTS quiet_hours_enabled BOOLEAN DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS quiet_hours_start VARCHAR(5) DEFAULT '22:00' CHECK (quiet_hours_start ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    ADD COLUMN IF NOT EXISTS quiet_hours_end VARCHAR(5) DEFAULT '07:00' CHECK (quiet_hours_end ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) DEFAULT 'UTC';
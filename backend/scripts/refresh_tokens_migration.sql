@@ -0,0 +1,31 @@
+-- UpVista Community - Rotating Refresh Tokens Migration
+-- Run this script in your Supabase SQL editor
+
+-- Each row in user_sessions is one refresh token family.
+-- token_hash holds the current refresh token, previous_token_hash the last one it replaced
+-- (session_retired_tokens below keeps all of them for reuse detection).
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS previous_token_hash VARCHAR(64);
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_token_hash ON user_sessions(token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_previous_token_hash ON user_sessions(previous_token_hash);
+
+-- Existing sessions were keyed by access token hashes and can't be refreshed
+DELETE FROM user_sessions WHERE previous_token_hash IS NULL AND last_used_at IS NULL;
+
+-- Every refresh token a session was rotated away from, not only the last one.
+-- Presenting any of them revokes the session; they are deleted with it.
+CREATE TABLE IF NOT EXISTS session_retired_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES user_sessions(id) ON DELETE CASCADE,
+    retired_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_retired_tokens_session ON session_retired_tokens(session_id);
+
+INSERT INTO session_retired_tokens (token_hash, session_id)
+SELECT previous_token_hash, id FROM user_sessions WHERE previous_token_hash IS NOT NULL
+ON CONFLICT (token_hash) DO NOTHING;
+
+-- Only the backend (service role) accesses retired tokens
+ALTER TABLE session_retired_tokens ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,14 @@
+-- UpVista Community - Scheduled Posts Migration
+-- Run this script in your Supabase SQL editor
+
+-- When a post waiting to be published goes out, NULL for other posts
+-- Scheduled posts have is_published = FALSE until the scheduled posts job publishes them
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS scheduled_for TIMESTAMPTZ;
+
+COMMENT ON COLUMN posts.scheduled_for IS 'Publish time of a scheduled post, cleared once it is published';
+
+-- Due posts are looked up every minute, and each user lists their own
+CREATE INDEX IF NOT EXISTS idx_posts_scheduled_for ON posts(scheduled_for)
+    WHERE scheduled_for IS NOT NULL AND is_published = FALSE AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_user_scheduled ON posts(user_id, scheduled_for)
+    WHERE scheduled_for IS NOT NULL AND is_published = FALSE AND deleted_at IS NULL;
@@ -0,0 +1,16 @@
+-- UpVista Community - Two-Factor Authentication Migration
+-- Run this script in your Supabase SQL editor
+
+-- TOTP secret (base32). Stored as soon as setup starts; 2FA is only active once two_factor_enabled is true
+ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_enabled BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_enabled_at TIMESTAMP;
+
+-- SHA256 hashes of unused one-time recovery codes
+ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_recovery_codes TEXT[] DEFAULT '{}';
+
+-- Index for reporting on 2FA adoption
+CREATE INDEX IF NOT EXISTS idx_users_two_factor_enabled ON users(two_factor_enabled) WHERE two_factor_enabled = TRUE;
+
+-- TOTP time step of the last accepted code, codes of that step or earlier are rejected so each works once
+ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_last_step BIGINT;
@@ -0,0 +1,32 @@
+-- UpVista Community - Upload Quarantine Migration
+-- Run this script in your Supabase SQL editor
+
+-- Uploads flagged as unsafe (disguised programs, polyglots, dangerous archives, malware) are kept
+-- in a private bucket and recorded here for review instead of being stored where they were sent
+CREATE TABLE IF NOT EXISTS quarantined_files (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    bucket VARCHAR(63) NOT NULL,                 -- The quarantine bucket
+    object_key TEXT NOT NULL,
+    target_bucket VARCHAR(63),                   -- Where the upload was going
+    filename VARCHAR(255),                       -- As sent by the uploader
+    declared_type VARCHAR(255),                  -- Content type the uploader declared
+    detected_type VARCHAR(255) NOT NULL,         -- Content type sniffed from the file
+    size BIGINT NOT NULL DEFAULT 0,
+    sha256 CHAR(64) NOT NULL,
+    reason TEXT NOT NULL,                        -- Why the file was flagged
+    threat TEXT,                                 -- Signature the malware scanner matched, if any
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_quarantined_files_created_at ON quarantined_files(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_quarantined_files_user_id ON quarantined_files(user_id);
+CREATE INDEX IF NOT EXISTS idx_quarantined_files_sha256 ON quarantined_files(sha256);
+
+-- Quarantined files are never served (Supabase storage driver)
+INSERT INTO storage.buckets (id, name, public)
+VALUES ('quarantine', 'quarantine', false)
+ON CONFLICT (id) DO NOTHING;
+
+-- Only the backend (service role) accesses quarantined files
+ALTER TABLE quarantined_files ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,35 @@
+-- UpVista Community - Resumable Uploads Migration
+-- Run this script in your Supabase SQL editor
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(20) NOT NULL CHECK (purpose IN ('post_video', 'message_video', 'message_file', 'event_cover')),
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL CHECK (size > 0),
+    upload_offset BIGINT NOT NULL DEFAULT 0,     -- Bytes received so far
+    checksum CHAR(64),                           -- Expected SHA-256 of the whole file (hex), optional
+    parts TEXT[] NOT NULL DEFAULT '{}',          -- Keys of the chunks in the parts bucket, in order
+    bucket VARCHAR(63) NOT NULL,                 -- Destination of the completed file
+    object_key TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'uploading' CHECK (status IN ('uploading', 'finalizing', 'completed')),
+    url TEXT,                                    -- Set once completed
+    expires_at TIMESTAMPTZ NOT NULL,             -- Pushed back by every chunk, expired sessions are deleted
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (upload_offset >= 0 AND upload_offset <= size)
+);
+
+-- The cleanup job looks for expired sessions
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_user_id ON upload_sessions(user_id, created_at DESC);
+
+-- Chunks are kept in a private bucket until the upload is completed (Supabase storage driver)
+INSERT INTO storage.buckets (id, name, public)
+VALUES ('upload-parts', 'upload-parts', false)
+ON CONFLICT (id) DO NOTHING;
+
+-- Only the backend (service role) accesses upload sessions
+ALTER TABLE upload_sessions ENABLE ROW LEVEL SECURITY;
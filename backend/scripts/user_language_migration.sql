@@ -0,0 +1,13 @@
+-- UpVista Community - User Language Migration
+-- Run this script in your Supabase SQL editor
+
+-- =====================================================
+-- EMAIL LANGUAGE
+-- =====================================================
+
+-- Keep in sync with mailer.SupportedLanguages
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'en'
+    CHECK (language IN ('en', 'es', 'fr'));
+
+COMMENT ON COLUMN users.language IS 'Language of emails sent to the user';